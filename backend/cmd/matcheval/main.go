@@ -0,0 +1,162 @@
+// Command matcheval scores the matching engine offline. It ranks the mentors
+// of a dataset for each mentee, using the MATCH_WEIGHT_* settings the server
+// would use, and compares the rankings with the mentors a coordinator picked.
+// The bundled dataset holds the seed mentors and hand-labelled mentees.
+//
+//	go run ./cmd/matcheval [-dataset file.json] [-k 3] [-v]
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/config"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+//go:embed dataset.json
+var bundledDataset []byte
+
+type person struct {
+	Name         string   `json:"name"`
+	Expertise    []string `json:"expertise"`
+	Interests    []string `json:"interests"`
+	Location     string   `json:"location"`
+	Languages    []string `json:"languages"`
+	Availability []string `json:"availability"`
+	MaxMentees   int      `json:"max_mentees"`
+	Active       int      `json:"active"`
+	Expected     []string `json:"expected"` // Mentees only: suitable mentors, best first
+}
+
+type dataset struct {
+	Mentors []person `json:"mentors"`
+	Mentees []person `json:"mentees"`
+}
+
+// matchProfile builds the profile the server would score, so that locations
+// resolve to municipalities the same way
+func (p person) matchProfile() *services.MatchProfile {
+	view := &models.ProfileView{
+		UserID:       uuid.New(),
+		Expertise:    jsonList(p.Expertise),
+		Interests:    jsonList(p.Interests),
+		Location:     p.Location,
+		Languages:    jsonList(p.Languages),
+		Availability: jsonList(p.Availability),
+	}
+	return services.NewMatchProfile(view, p.MaxMentees, p.Active)
+}
+
+func jsonList(values []string) datatypes.JSON {
+	data, err := json.Marshal(values)
+	if err != nil {
+		log.Fatal("Failed to encode dataset values:", err)
+	}
+	return data
+}
+
+func main() {
+	path := flag.String("dataset", "", "dataset JSON file (default: the bundled dataset)")
+	k := flag.Int("k", 3, "cut-off for hit@k")
+	verbose := flag.Bool("v", false, "print the ranking for every mentee")
+	flag.Parse()
+
+	data := bundledDataset
+	if *path != "" {
+		var err error
+		if data, err = os.ReadFile(*path); err != nil {
+			log.Fatal("Failed to read dataset:", err)
+		}
+	}
+	var ds dataset
+	if err := json.Unmarshal(data, &ds); err != nil {
+		log.Fatal("Failed to parse dataset:", err)
+	}
+
+	cfg := config.Load()
+	weights := services.MatchWeights{
+		Expertise:    cfg.MatchWeightExpertise,
+		Interests:    cfg.MatchWeightInterests,
+		Location:     cfg.MatchWeightLocation,
+		Language:     cfg.MatchWeightLanguage,
+		Availability: cfg.MatchWeightAvailability,
+		Capacity:     cfg.MatchWeightCapacity,
+	}
+	// Only Score is used, which needs no repositories
+	matching := services.NewMatchingService(nil, nil, nil, nil, weights)
+
+	mentors := make([]*services.MatchProfile, len(ds.Mentors))
+	for i, m := range ds.Mentors {
+		mentors[i] = m.matchProfile()
+	}
+
+	type ranked struct {
+		name  string
+		score float64
+	}
+	var hits1, hitsK int
+	var reciprocalRanks float64
+	for _, mentee := range ds.Mentees {
+		profile := mentee.matchProfile()
+		var ranking []ranked
+		for i, mentor := range mentors {
+			// As in GET /matches, full mentors are not suggested
+			if mentor.OpenSlots() == 0 {
+				continue
+			}
+			ranking = append(ranking, ranked{ds.Mentors[i].Name, matching.Score(mentor, profile).Score})
+		}
+		sort.SliceStable(ranking, func(i, j int) bool { return ranking[i].score > ranking[j].score })
+
+		expected := make(map[string]bool, len(mentee.Expected))
+		for _, name := range mentee.Expected {
+			expected[name] = true
+		}
+		first := 0 // 1-based rank of the first expected mentor, 0 if none
+		for i, r := range ranking {
+			if expected[r.name] {
+				first = i + 1
+				break
+			}
+		}
+		if first == 1 {
+			hits1++
+		}
+		if first > 0 && first <= *k {
+			hitsK++
+		}
+		if first > 0 {
+			reciprocalRanks += 1 / float64(first)
+		}
+
+		if *verbose {
+			fmt.Printf("%s (expected %s)\n", mentee.Name, strings.Join(mentee.Expected, ", "))
+			for i, r := range ranking[:min(*k, len(ranking))] {
+				mark := " "
+				if expected[r.name] {
+					mark = "*"
+				}
+				fmt.Printf("  %s %d. %-16s %.3f\n", mark, i+1, r.name, r.score)
+			}
+		}
+	}
+
+	n := float64(len(ds.Mentees))
+	if n == 0 {
+		log.Fatal("Dataset has no mentees")
+	}
+	fmt.Printf("weights: expertise=%g interests=%g location=%g language=%g availability=%g capacity=%g\n",
+		weights.Expertise, weights.Interests, weights.Location, weights.Language, weights.Availability, weights.Capacity)
+	fmt.Printf("mentees: %d  hit@1: %.2f  hit@%d: %.2f  MRR: %.3f\n", len(ds.Mentees), float64(hits1)/n, *k, float64(hitsK)/n, reciprocalRanks/n)
+}
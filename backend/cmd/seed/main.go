@@ -9,6 +9,7 @@ import (
 	"mentori/internal/services"
 	"mentori/pkg/config"
 	"mentori/pkg/database"
+	"mentori/pkg/geo"
 
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
@@ -213,6 +214,7 @@ func main() {
 			// Convert expertise and interests to JSON
 			expertiseJSON, _ := json.Marshal(data.Expertise)
 			interestsJSON, _ := json.Marshal(data.Interests)
+			municipality, _ := geo.FindMunicipality(data.Location)
 
 			profile := models.Profile{
 				ID:        uuid.New(),
@@ -224,6 +226,8 @@ func main() {
 				Interests: datatypes.JSON(interestsJSON),
 				Location:  data.Location,
 				IsActive:  true,
+
+				MunicipalityCode: municipality.Code,
 			}
 
 			log.Printf("Creating mentor profile for %s %s...\n", data.FirstName, data.LastName)
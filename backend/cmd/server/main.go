@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -10,16 +11,15 @@ import (
 	"time"
 
 	_ "mentori/cmd/server/docs"
-	"mentori/internal/handlers"
-	"mentori/internal/middleware"
 	gormrepo "mentori/internal/repository/gorm"
+	"mentori/internal/server"
+	"mentori/internal/services"
 	"mentori/pkg/config"
 	"mentori/pkg/database"
+	"mentori/pkg/storage"
+	"mentori/pkg/utils"
 
-	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // @title           Mentori API
@@ -46,66 +46,94 @@ func main() {
 	// Initialize repositories
 	userRepo := gormrepo.NewUserRepository(database.GetDB())
 	profileRepo := gormrepo.NewProfileRepository(database.GetDB())
+	refreshTokenRepo := gormrepo.NewRefreshTokenRepository(database.GetDB())
+	emailVerificationRepo := gormrepo.NewEmailVerificationRepository(database.GetDB())
+	passwordResetRepo := gormrepo.NewPasswordResetRepository(database.GetDB())
+	identityRepo := gormrepo.NewUserIdentityRepository(database.GetDB())
+	mfaRecoveryRepo := gormrepo.NewMFARecoveryCodeRepository(database.GetDB())
+	mfaChallengeRepo := gormrepo.NewMFAChallengeRepository(database.GetDB())
+	oauthNonceRepo := gormrepo.NewOAuthNonceRepository(database.GetDB())
+	sessionRepo := gormrepo.NewAuthSessionRepository(database.GetDB())
+	loginThrottleRepo := gormrepo.NewLoginThrottleRepository(database.GetDB())
+	lockoutEventRepo := gormrepo.NewLockoutEventRepository(database.GetDB())
+	magicLinkRepo := gormrepo.NewMagicLinkRepository(database.GetDB())
+	impersonationLogRepo := gormrepo.NewImpersonationLogRepository(database.GetDB())
+	roleRepo := gormrepo.NewRoleRepository(database.GetDB())
+	apiKeyRepo := gormrepo.NewAPIKeyRepository(database.GetDB())
+	mentorApplicationRepo := gormrepo.NewMentorApplicationRepository(database.GetDB())
+	mentorshipRepo := gormrepo.NewMentorshipRepository(database.GetDB())
+	taxonomyRepo := gormrepo.NewTaxonomyRepository(database.GetDB())
+	cohortRepo := gormrepo.NewCohortRepository(database.GetDB())
+	availabilityRepo := gormrepo.NewAvailabilityRepository(database.GetDB())
+	mentoringSessionRepo := gormrepo.NewMentoringSessionRepository(database.GetDB())
+
+	// Background workers (e.g. JWKS key refresh) stop when the server shuts down
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+
+	// Initialize email sender (log-only when SMTP is not configured)
+	var emailSender utils.EmailSender
+	if cfg.SMTPHost != "" {
+		emailSender = utils.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	} else {
+		log.Println("SMTP_HOST not set, outgoing emails will be written to the log")
+		emailSender = utils.NewLogSender()
+	}
 
-	// Initialize handlers with repositories directly
-	authHandler := handlers.NewAuthHandler(userRepo, cfg.JWTSecret)
-	profileHandler := handlers.NewProfileHandler(profileRepo, userRepo) // Profile handler for swagger generation
-	adminHandler := handlers.NewAdminHandler(userRepo, profileRepo)
-
-	// Initialize Gin router
-	r := gin.New() // 🚀 OPTIMIZATION: Use gin.New() instead of gin.Default() for custom middleware
-
-	// 🚀 OPTIMIZATION: Add middleware in optimal order for performance
-	r.Use(middleware.SecurityHeaders())       // Security first
-	r.Use(middleware.CORS())                  // CORS
-	r.Use(gin.Recovery())                     // Recovery
-	r.Use(gzip.Gzip(gzip.DefaultCompression)) // Compression
-	r.Use(middleware.RateLimit())             // Rate limiting
-	r.Use(middleware.Logger())                // Logging last for performance
-
-	// 🚀 OPTIMIZATION: Enhanced health check endpoint
-	r.GET("/health", handlers.HealthCheck)
-	r.GET("/ready", handlers.ReadinessCheck)
-
-	// Swagger UI
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	// API v1 routes
-	v1 := r.Group("/api/v1")
-	{
-		// Auth routes
-		auth := v1.Group("/auth")
-		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
-			auth.POST("/logout", authHandler.Logout)
-			auth.GET("/profile", authHandler.GetProfile) // Get current user profile
-		}
-
-		// Profile routes (require authentication)
-		profiles := v1.Group("/profiles")
-		profiles.Use(middleware.JWTAuth())
-		{
-			profiles.POST("", profileHandler.CreateProfile)
-			profiles.GET("", profileHandler.GetMyProfile)
-			profiles.PUT("", profileHandler.UpdateProfile)
-			profiles.DELETE("", profileHandler.DeleteProfile)
-			profiles.GET("/public", profileHandler.GetPublicProfiles)
-		}
+	// Initialize avatar storage
+	avatarStore, err := newAvatarStore(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialize avatar storage:", err)
+	}
 
-		// Admin routes (require authentication and admin role)
-		admin := v1.Group("/admin")
-		admin.Use(middleware.JWTAuth())
-		admin.Use(middleware.AdminOnly())
-		{
-			admin.DELETE("/users/:userId", adminHandler.DeleteUser)
-		}
+	// Initialize the access token issuer
+	tokenIssuer, err := newTokenIssuer(cfg)
+	if err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
 	}
 
+	// Initialize the OAuth ID token verifiers, whose keys refresh in the background
+	oauthService := services.NewOAuthService(services.OAuthConfig{
+		GoogleClientIDs: cfg.GoogleClientIDs,
+		GoogleJWKSURL:   cfg.GoogleJWKSURL,
+		AppleClientIDs:  cfg.AppleClientIDs,
+		AppleJWKSURL:    cfg.AppleJWKSURL,
+	})
+	oauthService.Start(backgroundCtx)
+
+	api := server.New(cfg, server.Repositories{
+		Users:              userRepo,
+		Profiles:           profileRepo,
+		RefreshTokens:      refreshTokenRepo,
+		EmailVerifications: emailVerificationRepo,
+		PasswordResets:     passwordResetRepo,
+		Identities:         identityRepo,
+		MFARecoveryCodes:   mfaRecoveryRepo,
+		MFAChallenges:      mfaChallengeRepo,
+		OAuthNonces:        oauthNonceRepo,
+		Sessions:           sessionRepo,
+		LoginThrottles:     loginThrottleRepo,
+		LockoutEvents:      lockoutEventRepo,
+		MagicLinks:         magicLinkRepo,
+		ImpersonationLogs:  impersonationLogRepo,
+		Roles:              roleRepo,
+		APIKeys:            apiKeyRepo,
+		MentorApplications: mentorApplicationRepo,
+		Mentorships:        mentorshipRepo,
+		Taxonomy:           taxonomyRepo,
+		Cohorts:            cohortRepo,
+		Availability:       availabilityRepo,
+		MentoringSessions:  mentoringSessionRepo,
+	}, server.Dependencies{
+		Email:   emailSender,
+		Avatars: avatarStore,
+		Tokens:  tokenIssuer,
+		OAuth:   oauthService,
+	})
+
 	// 🚀 OPTIMIZATION: Graceful shutdown
 	srv := &http.Server{
 		Addr:    ":" + cfg.Port,
-		Handler: r,
+		Handler: api.Handler(),
 	}
 
 	// Start server in goroutine
@@ -121,6 +149,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopBackground()
 
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -130,6 +159,9 @@ func main() {
 		log.Fatal("Server forced to shutdown:", err)
 	}
 
+	// Let emails already being sent go out
+	api.Wait()
+
 	// Close database connection
 	if err := database.Close(); err != nil {
 		log.Printf("Error closing database: %v", err)
@@ -137,3 +169,55 @@ func main() {
 
 	log.Println("Server exited")
 }
+
+// newTokenIssuer loads the JWT signing key and any previous verification keys.
+// Without a signing key an ephemeral key is generated, so tokens do not survive a restart.
+func newTokenIssuer(cfg *config.Config) (*services.TokenIssuer, error) {
+	issuerCfg := services.TokenIssuerConfig{Issuer: cfg.JWTIssuer}
+
+	if cfg.JWTSigningKeyFile != "" {
+		key, err := services.LoadSigningKey(cfg.JWTSigningKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		issuerCfg.SigningKey = key
+	} else {
+		log.Println("JWT_SIGNING_KEY_FILE not set, using an ephemeral signing key (development only)")
+	}
+
+	for _, path := range cfg.JWTVerificationKeyFiles {
+		key, err := services.LoadVerificationKey(path)
+		if err != nil {
+			return nil, err
+		}
+		issuerCfg.VerificationKeys = append(issuerCfg.VerificationKeys, key)
+	}
+
+	tokenIssuer, err := services.NewTokenIssuer(issuerCfg)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Signing access tokens with key %s", tokenIssuer.KeyID())
+	return tokenIssuer, nil
+}
+
+// newAvatarStore creates the storage backend for uploaded avatars
+func newAvatarStore(cfg *config.Config) (storage.Store, error) {
+	switch cfg.AvatarStorage {
+	case "local":
+		log.Printf("Storing avatars in %s", cfg.UploadsDir)
+		return storage.NewLocalStore(cfg.UploadsDir, cfg.UploadsBaseURL)
+	case "s3":
+		log.Printf("Storing avatars in S3 bucket %s", cfg.S3Bucket)
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretKey,
+			PublicURL:       cfg.S3PublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown AVATAR_STORAGE %q, expected local or s3", cfg.AvatarStorage)
+	}
+}
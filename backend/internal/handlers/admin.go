@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"mentori/internal/models"
 	"mentori/internal/repository"
+	"mentori/internal/services"
 	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -13,43 +18,41 @@ import (
 
 // AdminHandler handles admin-only endpoints
 type AdminHandler struct {
-	userRepo    repository.UserRepository
-	profileRepo repository.ProfileRepository
+	userRepo      repository.UserRepository
+	profileRepo   repository.ProfileRepository
+	sessions      *services.SessionService
+	throttle      *services.LoginThrottleService
+	impersonation *services.ImpersonationService
+	authz         *services.AuthorizationService
 }
 
 // NewAdminHandler creates a new admin handler
-func NewAdminHandler(userRepo repository.UserRepository, profileRepo repository.ProfileRepository) *AdminHandler {
+func NewAdminHandler(userRepo repository.UserRepository, profileRepo repository.ProfileRepository, sessions *services.SessionService, throttle *services.LoginThrottleService, impersonation *services.ImpersonationService, authz *services.AuthorizationService) *AdminHandler {
 	return &AdminHandler{
-		userRepo:    userRepo,
-		profileRepo: profileRepo,
+		userRepo:      userRepo,
+		profileRepo:   profileRepo,
+		sessions:      sessions,
+		throttle:      throttle,
+		impersonation: impersonation,
+		authz:         authz,
 	}
 }
 
 // DeleteUser godoc
 //
 //	@Summary		Delete user and profile
-//	@Description	Delete a user and their associated profile from the system (Admin only)
+//	@Description	Delete a user and their associated profile from the system (requires users:delete)
 //	@Tags			admin
 //	@Security		BearerAuth
 //	@Produce		json
 //	@Param			userId	path		string					true	"User ID to delete"
 //	@Success		200		{object}	map[string]string		"User and profile deleted successfully"
 //	@Failure		400		{object}	models.ErrorResponse	"Invalid user ID"
-//	@Failure		403		{object}	models.ErrorResponse	"Forbidden - Admin access required"
+//	@Failure		403		{object}	models.ErrorResponse	"Forbidden - users:delete permission required"
 //	@Failure		404		{object}	models.ErrorResponse	"User not found"
 //	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/admin/users/{userId} [delete]
 func (h *AdminHandler) DeleteUser(c *gin.Context) {
-	// Check if user is admin
-	userRole, exists := c.Get(constants.ContextKeyUserRole)
-	if !exists || userRole.(string) != "admin" {
-		c.JSON(http.StatusForbidden, models.ErrorResponse{
-			Error:   "Forbidden",
-			Message: "Admin access required",
-		})
-		return
-	}
-
 	// Get user ID from path parameter
 	userIDStr := c.Param("userId")
 	userID, err := uuid.Parse(userIDStr)
@@ -104,3 +107,350 @@ func (h *AdminHandler) DeleteUser(c *gin.Context) {
 		"message": "User and profile deleted successfully",
 	})
 }
+
+// UnlockUser godoc
+//
+//	@Summary		Unlock user account
+//	@Description	Clear failed login attempts and any lockout on a user's account (requires users:unlock)
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			userId	path		string					true	"User ID to unlock"
+//	@Success		200		{object}	map[string]string		"Account unlocked successfully"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid user ID"
+//	@Failure		403		{object}	models.ErrorResponse	"Forbidden - users:unlock permission required"
+//	@Failure		404		{object}	models.ErrorResponse	"User not found"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/admin/users/{userId}/unlock [post]
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "User not found",
+				Message: "User does not exist",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.throttle.Unlock(ctx, user.Email, adminID); err != nil {
+		logger.Error("UnlockUser: failed to unlock account: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Unlock failed",
+			Message: "Failed to unlock account",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account unlocked successfully",
+	})
+}
+
+// ListLockouts godoc
+//
+//	@Summary		List login lockouts
+//	@Description	List the most recent account and IP lockouts caused by repeated failed logins (requires audit:read)
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			limit	query		int										false	"Page size (default 20, at most 100)"
+//	@Param			cursor	query		string									false	"next_cursor of the previous page"
+//	@Success		200		{object}	pagination.Page[models.LockoutEvent]	"Lockout events, newest first"
+//	@Failure		400		{object}	models.ErrorResponse					"Invalid limit or cursor"
+//	@Failure		403		{object}	models.ErrorResponse					"Forbidden - audit:read permission required"
+//	@Failure		500		{object}	models.ErrorResponse					"Internal server error"
+//	@Router			/admin/lockouts [get]
+func (h *AdminHandler) ListLockouts(c *gin.Context) {
+	page, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.throttle.ListLockouts(c.Request.Context(), page)
+	if err != nil {
+		logger.Error("ListLockouts: failed to list lockout events: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
+			Message: "Failed to list lockout events",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// ImpersonateUser godoc
+//
+//	@Summary		Impersonate user
+//	@Description	Issue a short-lived access token that acts as the user, for support staff to see what the user sees. The token carries an "act" claim naming the admin, cannot refresh, is refused by destructive endpoints and every request made with it is audited (requires users:impersonate).
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			userId	path		string						true	"User ID to impersonate"
+//	@Param			request	body		models.ImpersonationRequest	true	"Reason for the audit log"
+//	@Success		200		{object}	models.ImpersonationResponse	"Impersonation token issued"
+//	@Failure		400		{object}	models.ErrorResponse		"Invalid user ID or input"
+//	@Failure		403		{object}	models.ErrorResponse		"Forbidden - users:impersonate permission required, or user cannot be impersonated"
+//	@Failure		404		{object}	models.ErrorResponse		"User not found"
+//	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Router			/admin/users/{userId}/impersonate [post]
+func (h *AdminHandler) ImpersonateUser(c *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req models.ImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	currentID, _ := c.Get(constants.ContextKeySessionID)
+	sessionID, _ := currentID.(uuid.UUID)
+
+	token, user, err := h.impersonation.Start(c.Request.Context(), adminID, sessionID, userID, req.Reason, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrImpersonationTarget):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "User not found",
+				Message: "User does not exist",
+			})
+		case errors.Is(err, services.ErrImpersonationNotAllowed):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Forbidden",
+				Message: err.Error(),
+			})
+		default:
+			logger.Error("ImpersonateUser: failed to issue impersonation token: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Impersonation failed",
+				Message: "Failed to issue impersonation token",
+			})
+		}
+		return
+	}
+
+	logger.Info("Admin %s started impersonating user %s: %s", adminID, user.ID, req.Reason)
+	c.JSON(http.StatusOK, models.ImpersonationResponse{
+		User:      toUserResponse(user),
+		Token:     token,
+		ExpiresIn: int64(constants.ImpersonationTokenExpiry.Seconds()),
+		ActorID:   adminID,
+	})
+}
+
+// ListImpersonationLogs godoc
+//
+//	@Summary		List impersonation audit log
+//	@Description	List impersonation tokens issued and requests made with them, newest first (requires audit:read)
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			user_id	query		string										false	"Only entries for this impersonated user"
+//	@Param			limit	query		int											false	"Page size (default 20, at most 100)"
+//	@Param			cursor	query		string										false	"next_cursor of the previous page"
+//	@Success		200		{object}	pagination.Page[models.ImpersonationLog]	"Audit log entries"
+//	@Failure		400		{object}	models.ErrorResponse						"Invalid user ID, limit or cursor"
+//	@Failure		403		{object}	models.ErrorResponse						"Forbidden - audit:read permission required"
+//	@Failure		500		{object}	models.ErrorResponse						"Internal server error"
+//	@Router			/admin/impersonation-logs [get]
+func (h *AdminHandler) ListImpersonationLogs(c *gin.Context) {
+	page, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	var subjectID *uuid.UUID
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid user ID",
+				Message: "User ID must be a valid UUID",
+			})
+			return
+		}
+		subjectID = &id
+	}
+
+	entries, err := h.impersonation.List(c.Request.Context(), subjectID, page)
+	if err != nil {
+		logger.Error("ListImpersonationLogs: failed to list audit log: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
+			Message: "Failed to list impersonation audit log",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// UpdateUserRole godoc
+//
+//	@Summary		Change user role
+//	@Description	Assign a role, built-in or defined in the roles table such as a moderator, to a user. The user's sessions are ended, so the new permissions apply from their next sign-in (requires users:manage_roles). Callers cannot change their own role, nor give or take away a role with permissions they do not hold themselves.
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			userId	path		string						true	"User ID"
+//	@Param			request	body		models.UpdateRoleRequest	true	"New role"
+//	@Success		200		{object}	models.UserResponse			"Role updated"
+//	@Failure		400		{object}	models.ErrorResponse		"Invalid user ID or unknown role"
+//	@Failure		403		{object}	models.ErrorResponse		"Forbidden - users:manage_roles permission required, own role, or a role beyond the caller's"
+//	@Failure		404		{object}	models.ErrorResponse		"User not found"
+//	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Router			/admin/users/{userId}/role [put]
+func (h *AdminHandler) UpdateUserRole(c *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: "User ID must be a valid UUID",
+		})
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if userID == adminID {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Forbidden",
+			Message: "You cannot change your own role",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	exists, err := h.authz.RoleExists(ctx, req.Role)
+	if err != nil {
+		logger.Error("UpdateUserRole: failed to load roles: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
+			Message: "Failed to load roles",
+		})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "Unknown role " + req.Role,
+			Field:   "role",
+		})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "User not found",
+				Message: "User does not exist",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Neither the new nor the current role may carry permissions the caller
+	// lacks, so that role managers cannot raise anyone, themselves included,
+	// above their own level, nor demote those above it
+	callerRole, _ := utils.GetUserRoleFromContext(c)
+	for _, role := range []string{req.Role, user.Role} {
+		covered, err := h.authz.Covers(ctx, callerRole, role)
+		if err != nil {
+			logger.Error("UpdateUserRole: failed to load roles: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Database error",
+				Message: "Failed to load roles",
+			})
+			return
+		}
+		if !covered {
+			logger.Warn("UpdateUserRole: %s (%s) may not change user %s from %s to %s", adminID, callerRole, user.ID, user.Role, req.Role)
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Role " + role + " has permissions you do not hold",
+			})
+			return
+		}
+	}
+
+	previous := user.Role
+	user.Role = req.Role
+	user.UpdatedAt = time.Now()
+	if err := h.userRepo.Update(ctx, user); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Role update failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	// Tokens issued for the old role must not outlive the change
+	if err := h.sessions.RevokeAllForUser(ctx, user.ID); err != nil {
+		logger.Error("UpdateUserRole: failed to revoke sessions of user %s: %v", user.ID, err)
+	}
+
+	logger.Info("Admin %s changed role of user %s from %s to %s", adminID, user.ID, previous, user.Role)
+	c.JSON(http.StatusOK, toUserResponse(user))
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler handles the user's personal API keys
+type APIKeyHandler struct {
+	apiKeys *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(apiKeys *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeys: apiKeys,
+	}
+}
+
+// CreateAPIKey godoc
+//
+//	@Summary		Create API key
+//	@Description	Create a personal API key for scripts and integrations. Send it as "Authorization: ApiKey <key>". The "read" scope allows GET requests and "write" allows changes; account security and admin endpoints never accept API keys. The key is shown only in this response.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.CreateAPIKeyRequest		true	"Key name, scopes and expiry"
+//	@Success		201		{object}	models.CreateAPIKeyResponse		"API key created"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid input data"
+//	@Failure		401		{object}	models.ErrorResponse			"Unauthorized"
+//	@Failure		409		{object}	models.ErrorResponse			"Too many active API keys"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/auth/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	raw, key, err := h.apiKeys.Create(c.Request.Context(), userID, req.Name, req.Scopes, ttl)
+	if err != nil {
+		if errors.Is(err, services.ErrAPIKeyLimit) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Conflict",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Error("CreateAPIKey: failed to create API key: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to create API key",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(key),
+		Key:            raw,
+	})
+}
+
+// ListAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	List the authenticated user's active API keys with their prefix, scopes, expiry and last use. Key secrets are never returned. All keys come in one page.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page[models.APIKeyResponse]	"Active API keys"
+//	@Failure		401	{object}	models.ErrorResponse					"Unauthorized"
+//	@Failure		500	{object}	models.ErrorResponse					"Internal server error"
+//	@Router			/auth/api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	keys, err := h.apiKeys.List(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error("ListAPIKeys: failed to list API keys: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to list API keys",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	response := make([]models.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, toAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, pagination.All(response))
+}
+
+// RevokeAPIKey godoc
+//
+//	@Summary		Revoke API key
+//	@Description	Revoke one of the authenticated user's API keys. Requests using it are rejected immediately.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string					true	"API key ID"
+//	@Success		200	{object}	map[string]string		"API key revoked"
+//	@Failure		400	{object}	models.ErrorResponse	"Invalid API key ID"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	models.ErrorResponse	"API key not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "Invalid API key ID",
+		})
+		return
+	}
+
+	if err := h.apiKeys.Revoke(c.Request.Context(), userID, keyID); err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Not found",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Error("RevokeAPIKey: failed to revoke API key: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to revoke API key",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked successfully",
+	})
+}
+
+func toAPIKeyResponse(key *models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     services.APIKeyScopes(key),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
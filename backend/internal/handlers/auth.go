@@ -3,11 +3,18 @@ package handlers
 import (
 	"context"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"mentori/internal/models"
 	"mentori/internal/repository"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/utils"
+	"mentori/pkg/validators"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -18,27 +25,37 @@ import (
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
 	userRepo  repository.UserRepository
-	jwtSecret []byte
+	sessions  *services.SessionService
+	tokens    *services.TokenIssuer
+	throttle  *services.LoginThrottleService
+	mfa       *services.MFAService
+	passwords validators.PasswordPolicy
+	mentors   *services.MentorApplicationService
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(userRepo repository.UserRepository, jwtSecret string) *AuthHandler {
+func NewAuthHandler(userRepo repository.UserRepository, sessions *services.SessionService, tokens *services.TokenIssuer, throttle *services.LoginThrottleService, mfa *services.MFAService, passwords validators.PasswordPolicy, mentors *services.MentorApplicationService) *AuthHandler {
 	return &AuthHandler{
 		userRepo:  userRepo,
-		jwtSecret: []byte(jwtSecret),
+		sessions:  sessions,
+		tokens:    tokens,
+		throttle:  throttle,
+		mfa:       mfa,
+		passwords: passwords,
+		mentors:   mentors,
 	}
 }
 
 // Register godoc
 //
 //	@Summary		Register a new user
-//	@Description	Create a new user account with email, password, and role
+//	@Description	Create a new user account with email, password, and role. Admin accounts cannot be registered. Users registering as mentors start as mentees with a mentor application for an admin to approve.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		models.RegisterRequest	true	"User registration data"
 //	@Success		201		{object}	models.AuthResponse		"User created successfully"
-//	@Failure		400		{object}	models.ErrorResponse	"Invalid input data"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid input data or password rejected by the password policy"
 //	@Failure		409		{object}	models.ErrorResponse	"User already exists"
 //	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/auth/register [post]
@@ -52,6 +69,10 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
+	if respondPasswordPolicyError(c, "password", h.passwords.Validate(req.Password, req.Email)) {
+		return
+	}
+
 	ctx := c.Request.Context()
 
 	// Check if user already exists
@@ -81,12 +102,12 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Create user
+	// Create user. Mentors are approved by an admin before they get the role.
 	user := &models.User{
 		ID:           uuid.New(),
 		Email:        req.Email,
 		PasswordHash: string(hashedPassword),
-		Role:         req.Role,
+		Role:         constants.RoleMentee,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
@@ -99,8 +120,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate token
-	token, err := h.generateToken(user.ID, user.Email, user.Role)
+	response, err := h.newAuthResponse(c, user, false)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Token generation failed",
@@ -108,15 +128,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		})
 		return
 	}
-
-	response := &models.AuthResponse{
-		User: models.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Role:      user.Role,
-			CreatedAt: user.CreatedAt,
-		},
-		Token: token,
+	if req.Role == constants.RoleMentor {
+		response.MentorApplication = h.applyForMentor(ctx, user)
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -125,14 +138,16 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // Login godoc
 //
 //	@Summary		User login
-//	@Description	Authenticate user with email and password
+//	@Description	Authenticate user with email and password. Users with two-factor authentication get an MFA challenge to complete at /auth/mfa/verify.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		models.LoginRequest		true	"User login credentials"
 //	@Success		200		{object}	models.AuthResponse		"Login successful"
+//	@Success		202		{object}	models.MFAChallengeResponse	"Second factor required"
 //	@Failure		400		{object}	models.ErrorResponse	"Invalid input data"
 //	@Failure		401		{object}	models.ErrorResponse	"Invalid credentials"
+//	@Failure		429		{object}	models.ErrorResponse	"Too many failed attempts, see Retry-After"
 //	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -147,14 +162,19 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
+	ip := c.ClientIP()
+
+	// Refuse attempts while the account or client IP is backing off or locked out
+	if err := h.throttle.Check(ctx, req.Email, ip); err != nil {
+		respondThrottled(c, "Login", err)
+		return
+	}
+
 	// Get user by email
 	user, err := h.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, repository.ErrNotFound) {
-			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-				Error:   "Authentication failed",
-				Message: "Invalid email or password",
-			})
+			h.loginFailed(c, req.Email, ip, nil)
 			return
 		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
@@ -166,48 +186,123 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error:   "Authentication failed",
-			Message: "Invalid email or password",
+		h.loginFailed(c, req.Email, ip, &user.ID)
+		return
+	}
+
+	if err := h.throttle.RecordSuccess(ctx, req.Email); err != nil {
+		logger.Error("Login: failed to reset failed login counter: %v", err)
+	}
+
+	h.respondWithLogin(c, user, http.StatusOK)
+}
+
+// Refresh godoc
+//
+//	@Summary		Refresh access token
+//	@Description	Exchange a refresh token for a new access token and a rotated refresh token
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.RefreshRequest	true	"Refresh token"
+//	@Success		200		{object}	models.AuthResponse		"Tokens refreshed"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid input data"
+//	@Failure		401		{object}	models.ErrorResponse	"Invalid, expired or reused refresh token"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	// Generate token
-	token, err := h.generateToken(user.ID, user.Email, user.Role)
+	ctx := c.Request.Context()
+
+	rawToken, refreshToken, err := h.sessions.Refresh(ctx, req.RefreshToken)
 	if err != nil {
+		if errors.Is(err, services.ErrRefreshTokenInvalid) || errors.Is(err, services.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Unauthorized",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Error("Refresh: failed to rotate refresh token: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Token generation failed",
+			Error:   "Token refresh failed",
+			Message: "Failed to refresh token",
+		})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(ctx, refreshToken.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Unauthorized",
+				Message: "User no longer exists",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
 			Message: err.Error(),
 		})
 		return
 	}
 
-	response := &models.AuthResponse{
-		User: models.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Role:      user.Role,
-			CreatedAt: user.CreatedAt,
-		},
-		Token: token,
+	token, err := h.generateToken(user, refreshToken.FamilyID, refreshToken.MFAVerified)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Token generation failed",
+			Message: err.Error(),
+		})
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	c.JSON(http.StatusOK, &models.AuthResponse{
+		User:         toUserResponse(user),
+		Token:        token,
+		RefreshToken: rawToken,
+		ExpiresIn:    int64(constants.AccessTokenExpiry.Seconds()),
+	})
 }
 
 // Logout godoc
 //
 //	@Summary		User logout
-//	@Description	Invalidate user session (client-side token removal)
+//	@Description	Revoke the refresh token and every token rotated from the same login
 //	@Tags			auth
-//	@Security		BearerAuth
+//	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	map[string]string	"Logout successful"
+//	@Param			request	body		models.RefreshRequest	true	"Refresh token to revoke"
+//	@Success		200		{object}	map[string]string		"Logout successful"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid input data"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// In a stateless JWT system, logout is handled client-side
-	// by removing the token from storage
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.sessions.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		logger.Error("Logout: failed to revoke refresh token: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Logout failed",
+			Message: "Failed to revoke refresh token",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Logged out successfully",
 	})
@@ -223,89 +318,322 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 //	@Success		200	{object}	models.UserResponse		"User profile"
 //	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
 //	@Failure		404	{object}	models.ErrorResponse	"User not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
 //	@Router			/auth/profile [get]
 func (h *AuthHandler) GetProfile(c *gin.Context) {
-	// Extract token from Authorization header
-	token := c.GetHeader("Authorization")
-	if token == "" {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "Missing authorization token",
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Not found",
+				Message: "User not found",
+			})
+			return
+		}
+		logger.Error("GetProfile: failed to load user_id=%s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to retrieve user",
 		})
 		return
 	}
 
-	// Remove "Bearer " prefix if present
-	if len(token) > 7 && token[:7] == "Bearer " {
-		token = token[7:]
+	response := toUserResponse(user)
+	response.Profile = user.Profile
+	c.JSON(http.StatusOK, response)
+}
+
+// ChangePassword godoc
+//
+//	@Summary		Change password
+//	@Description	Change the authenticated user's password. Every other session is signed out.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.ChangePasswordRequest	true	"Current and new password"
+//	@Success		200		{object}	map[string]string				"Password changed"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid input data or password rejected by the password policy"
+//	@Failure		401		{object}	models.ErrorResponse			"Unauthorized or wrong current password"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/auth/password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
 	}
 
-	userResponse, err := h.validateToken(c.Request.Context(), token)
+	ctx := c.Request.Context()
+
+	user, err := h.userRepo.GetByID(ctx, userID)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Unauthorized",
+				Message: "User no longer exists",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)) != nil {
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error:   "Unauthorized",
-			Message: "Invalid token",
+			Error:   "Authentication failed",
+			Message: "Current password is incorrect",
+			Field:   "current_password",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, userResponse)
+	if respondPasswordPolicyError(c, "new_password", h.passwords.Validate(req.NewPassword, user.Email)) {
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Password hashing failed",
+			Message: err.Error(),
+		})
+		return
+	}
+	user.PasswordHash = string(hashedPassword)
+	user.UpdatedAt = time.Now()
+	if err := h.userRepo.Update(ctx, user); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Password change failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	currentID, _ := c.Get(constants.ContextKeySessionID)
+	sessionID, _ := currentID.(uuid.UUID)
+	if _, err := h.sessions.RevokeOthers(ctx, userID, sessionID); err != nil {
+		logger.Error("ChangePassword: failed to revoke other sessions: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully",
+	})
 }
 
-// generateToken creates a JWT token for the user
-func (h *AuthHandler) generateToken(userID uuid.UUID, email, role string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID.String(),
-		"email":   email,
-		"role":    role,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(), // 24 hours
-		"iat":     time.Now().Unix(),
+// JWKS godoc
+//
+//	@Summary		JSON Web Key Set
+//	@Description	Public keys for verifying Mentori access tokens. Keys are identified by the kid header and include previous keys during rotation.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}	"JWKS document"
+//	@Router			/.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "application/json", h.tokens.JWKS())
+}
+
+// loginFailed records a failed password login and responds with 401
+func (h *AuthHandler) loginFailed(c *gin.Context, email, ip string, userID *uuid.UUID) {
+	if err := h.throttle.RecordFailure(c.Request.Context(), email, ip, userID); err != nil {
+		logger.Error("Login: failed to record failed login: %v", err)
 	}
+	c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		Error:   "Authentication failed",
+		Message: "Invalid email or password",
+	})
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(h.jwtSecret)
+// respondThrottled responds with 429 and a Retry-After header for throttled logins
+func respondThrottled(c *gin.Context, action string, err error) {
+	var throttled *services.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		logger.Error("%s: failed to check login throttle: %v", action, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
+			Message: "Failed to check login attempts",
+		})
+		return
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+		Error:   "Too many requests",
+		Message: throttled.Error(),
+		Code:    http.StatusTooManyRequests,
+	})
+}
+
+// respondPasswordPolicyError responds with 400 naming the field when err is a
+// password policy violation, and reports whether it did
+func respondPasswordPolicyError(c *gin.Context, field string, err error) bool {
+	var weak *validators.PasswordError
+	if !errors.As(err, &weak) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error:   "Invalid password",
+		Message: weak.Error(),
+		Field:   field,
+	})
+	return true
 }
 
-// validateToken validates a JWT token and returns user info
-func (h *AuthHandler) validateToken(ctx context.Context, tokenString string) (*models.UserResponse, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+// respondWithLogin completes a first-factor login. Users with MFA enabled get
+// a challenge token instead of access tokens.
+func (h *AuthHandler) respondWithLogin(c *gin.Context, user *models.User, status int) {
+	if user.MFAEnabled {
+		challenge, err := h.generateMFAChallenge(c.Request.Context(), user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Token generation failed",
+				Message: err.Error(),
+			})
+			return
 		}
-		return h.jwtSecret, nil
+		c.JSON(http.StatusAccepted, models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    challenge,
+			ExpiresIn:   int64(constants.MFAChallengeExpiry.Seconds()),
+		})
+		return
+	}
+
+	response, err := h.newAuthResponse(c, user, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Token generation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(status, response)
+}
+
+// newAuthResponse starts a login session for the device making the request and
+// issues its access and refresh tokens. mfa records whether the login completed a second factor.
+func (h *AuthHandler) newAuthResponse(c *gin.Context, user *models.User, mfa bool) (*models.AuthResponse, error) {
+	refreshToken, session, err := h.sessions.Start(c.Request.Context(), user.ID, mfa, services.SessionInfo{
+		DeviceName: c.GetHeader(constants.HeaderDeviceName),
+		UserAgent:  c.Request.UserAgent(),
+		IPAddress:  c.ClientIP(),
 	})
+	if err != nil {
+		return nil, err
+	}
 
+	token, err := h.generateToken(user, session.ID, mfa)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userIDStr, ok := claims["user_id"].(string)
-		if !ok {
-			return nil, errors.New("invalid token claims")
-		}
+	return &models.AuthResponse{
+		User:         toUserResponse(user),
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(constants.AccessTokenExpiry.Seconds()),
+	}, nil
+}
 
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			return nil, errors.New("invalid user ID in token")
-		}
+// applyForMentor opens a mentor application for a new account. A failure is
+// logged but does not fail the sign-up; the user can apply again later.
+func (h *AuthHandler) applyForMentor(ctx context.Context, user *models.User) *models.MentorApplication {
+	application, err := h.mentors.Apply(ctx, user.ID, "")
+	if err != nil {
+		logger.Error("applyForMentor: failed to open mentor application for user_id=%s: %v", user.ID, err)
+		return nil
+	}
+	return application
+}
 
-		user, err := h.userRepo.GetByID(ctx, userID)
-		if err != nil {
-			return nil, err
-		}
+// toUserResponse converts a user to its client representation
+func toUserResponse(user *models.User) models.UserResponse {
+	return models.UserResponse{
+		ID:         user.ID,
+		Email:      user.Email,
+		Role:       user.Role,
+		IsVerified: user.IsVerified,
+		MFAEnabled: user.MFAEnabled,
+		CreatedAt:  user.CreatedAt,
+	}
+}
 
-		response := &models.UserResponse{
-			ID:        user.ID,
-			Email:     user.Email,
-			Role:      user.Role,
-			Profile:   user.Profile,
-			CreatedAt: user.CreatedAt,
-		}
-		return response, nil
+// generateToken creates a short-lived JWT access token bound to a login session
+func (h *AuthHandler) generateToken(user *models.User, sessionID uuid.UUID, mfa bool) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"email":   user.Email,
+		"role":    user.Role,
+		"sid":     sessionID.String(),
+		"typ":     constants.TokenTypeAccess,
+		"mfa":     mfa,
+		"exp":     time.Now().Add(constants.AccessTokenExpiry).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+
+	return h.tokens.Sign(claims)
+}
+
+// generateMFAChallenge starts a challenge and returns a short-lived token
+// proving the first factor succeeded. It is rejected by JWTAuth and can only
+// be exchanged once at /auth/mfa/verify.
+func (h *AuthHandler) generateMFAChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	challenge, err := h.mfa.StartChallenge(ctx, userID)
+	if err != nil {
+		return "", err
 	}
 
-	return nil, errors.New("invalid token")
+	claims := jwt.MapClaims{
+		"user_id": userID.String(),
+		"jti":     challenge.ID.String(),
+		"typ":     constants.TokenTypeMFAChallenge,
+		"exp":     challenge.ExpiresAt.Unix(),
+		"iat":     challenge.CreatedAt.Unix(),
+	}
+
+	return h.tokens.Sign(claims)
+}
+
+// parseMFAChallenge validates an MFA challenge token and returns its user and challenge IDs
+func (h *AuthHandler) parseMFAChallenge(tokenString string) (uuid.UUID, uuid.UUID, error) {
+	claims, err := h.tokens.Parse(tokenString)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	if claims["typ"] != constants.TokenTypeMFAChallenge {
+		return uuid.Nil, uuid.Nil, errors.New("not an MFA challenge token")
+	}
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	jti, _ := claims["jti"].(string)
+	challengeID, err := uuid.Parse(jti)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	return userID, challengeID, nil
 }
 
 //	@Summary		Create user profile
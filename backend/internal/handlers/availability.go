@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AvailabilityHandler lets mentors keep an availability calendar and others
+// find the times they can be booked
+type AvailabilityHandler struct {
+	availability *services.AvailabilityService
+}
+
+// NewAvailabilityHandler creates a new availability handler
+func NewAvailabilityHandler(availability *services.AvailabilityService) *AvailabilityHandler {
+	return &AvailabilityHandler{
+		availability: availability,
+	}
+}
+
+// GetAvailability godoc
+//
+//	@Summary		Get my availability
+//	@Description	Get the caller's availability calendar: the timezone, the weekly windows, and the overrides and blackouts that are not over yet. Mentors only.
+//	@Tags			availability
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	models.Availability		"Availability calendar"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Caller is not a mentor"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/availability [get]
+func (h *AvailabilityHandler) GetAvailability(c *gin.Context) {
+	mentorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	availability, err := h.availability.Get(c.Request.Context(), mentorID)
+	if err != nil {
+		h.respondWithAvailabilityError(c, "GetAvailability", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// UpdateAvailability godoc
+//
+//	@Summary		Update my weekly availability
+//	@Description	Set the caller's timezone (an IANA name, Europe/Helsinki by default) and replace their weekly windows. Weekdays run from 0 for Sunday to 6 for Saturday, times are HH:MM in the timezone and 24:00 ends a window at midnight. Mentors only.
+//	@Tags			availability
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.UpdateAvailabilityRequest	true	"Timezone and weekly windows"
+//	@Success		200		{object}	models.Availability					"Updated availability calendar"
+//	@Failure		400		{object}	models.ErrorResponse				"Invalid input data"
+//	@Failure		401		{object}	models.ErrorResponse				"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse				"Caller is not a mentor"
+//	@Failure		500		{object}	models.ErrorResponse				"Internal server error"
+//	@Router			/availability [put]
+func (h *AvailabilityHandler) UpdateAvailability(c *gin.Context) {
+	mentorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.UpdateAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	availability, err := h.availability.Update(c.Request.Context(), mentorID, &req)
+	if err != nil {
+		h.respondWithAvailabilityError(c, "UpdateAvailability", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// CreateOverride godoc
+//
+//	@Summary		Add an availability override
+//	@Description	Add a window on one date. The overrides of a date replace its weekly windows. Mentors only.
+//	@Tags			availability
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.AvailabilityOverrideRequest	true	"Date and window"
+//	@Success		201		{object}	models.AvailabilityOverride			"Created override"
+//	@Failure		400		{object}	models.ErrorResponse				"Invalid input data, or a date in the past"
+//	@Failure		401		{object}	models.ErrorResponse				"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse				"Caller is not a mentor"
+//	@Failure		500		{object}	models.ErrorResponse				"Internal server error"
+//	@Router			/availability/overrides [post]
+func (h *AvailabilityHandler) CreateOverride(c *gin.Context) {
+	mentorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.AvailabilityOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	override, err := h.availability.AddOverride(c.Request.Context(), mentorID, &req)
+	if err != nil {
+		h.respondWithAvailabilityError(c, "CreateOverride", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, override)
+}
+
+// DeleteOverride godoc
+//
+//	@Summary		Delete an availability override
+//	@Description	Delete one of the caller's overrides
+//	@Tags			availability
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string					true	"Override ID"
+//	@Success		200	{object}	map[string]string		"Override deleted"
+//	@Failure		400	{object}	models.ErrorResponse	"Invalid override ID"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	models.ErrorResponse	"Override not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/availability/overrides/{id} [delete]
+func (h *AvailabilityHandler) DeleteOverride(c *gin.Context) {
+	mentorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+	id, ok := availabilityIDParam(c, "override")
+	if !ok {
+		return
+	}
+
+	if err := h.availability.DeleteOverride(c.Request.Context(), mentorID, id); err != nil {
+		h.respondWithAvailabilityError(c, "DeleteOverride", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Override deleted successfully",
+	})
+}
+
+// CreateBlackout godoc
+//
+//	@Summary		Add a blackout
+//	@Description	Add a period the caller is away. No slots are open during it, whatever the windows say. Mentors only.
+//	@Tags			availability
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.AvailabilityBlackoutRequest	true	"Blackout period"
+//	@Success		201		{object}	models.AvailabilityBlackout			"Created blackout"
+//	@Failure		400		{object}	models.ErrorResponse				"Invalid input data, or a period already over"
+//	@Failure		401		{object}	models.ErrorResponse				"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse				"Caller is not a mentor"
+//	@Failure		500		{object}	models.ErrorResponse				"Internal server error"
+//	@Router			/availability/blackouts [post]
+func (h *AvailabilityHandler) CreateBlackout(c *gin.Context) {
+	mentorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.AvailabilityBlackoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	blackout, err := h.availability.AddBlackout(c.Request.Context(), mentorID, &req)
+	if err != nil {
+		h.respondWithAvailabilityError(c, "CreateBlackout", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, blackout)
+}
+
+// DeleteBlackout godoc
+//
+//	@Summary		Delete a blackout
+//	@Description	Delete one of the caller's blackouts
+//	@Tags			availability
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string					true	"Blackout ID"
+//	@Success		200	{object}	map[string]string		"Blackout deleted"
+//	@Failure		400	{object}	models.ErrorResponse	"Invalid blackout ID"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	models.ErrorResponse	"Blackout not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/availability/blackouts/{id} [delete]
+func (h *AvailabilityHandler) DeleteBlackout(c *gin.Context) {
+	mentorID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+	id, ok := availabilityIDParam(c, "blackout")
+	if !ok {
+		return
+	}
+
+	if err := h.availability.DeleteBlackout(c.Request.Context(), mentorID, id); err != nil {
+		h.respondWithAvailabilityError(c, "DeleteBlackout", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Blackout deleted successfully",
+	})
+}
+
+// GetOpenSlots godoc
+//
+//	@Summary		Open slots of a mentor
+//	@Description	List the times between from and to that the mentor can be booked, earliest first, in the mentor's timezone. Slots are cut from the weekly windows, or the overrides of a date, leaving out blackouts, booked sessions and times already started. All slots come in one page. Mentors whose profile the caller may not see are not found.
+//	@Tags			availability
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id				path		string								true	"Mentor user ID"
+//	@Param			from			query		string								false	"RFC 3339 start of the range (default now)"
+//	@Param			to				query		string								false	"RFC 3339 end of the range (default a week after from, at most 31 days)"
+//	@Param			slot_minutes	query		int									false	"Slot length in minutes (default 60, 15 to 240)"
+//	@Success		200				{object}	pagination.Page[models.OpenSlot]	"Open slots"
+//	@Failure		400				{object}	models.ErrorResponse				"Invalid mentor ID, range or slot length"
+//	@Failure		401				{object}	models.ErrorResponse				"Unauthorized"
+//	@Failure		404				{object}	models.ErrorResponse				"Mentor not found"
+//	@Failure		500				{object}	models.ErrorResponse				"Internal server error"
+//	@Router			/mentors/{id}/open-slots [get]
+func (h *AvailabilityHandler) GetOpenSlots(c *gin.Context) {
+	mentorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "Invalid mentor ID",
+		})
+		return
+	}
+
+	from := time.Now()
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			respondWithInvalidQuery(c, "from", "from must be an RFC 3339 time")
+			return
+		}
+	}
+	to := from.AddDate(0, 0, 7)
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			respondWithInvalidQuery(c, "to", "to must be an RFC 3339 time")
+			return
+		}
+	}
+	slotMinutes := constants.DefaultSlotMinutes
+	if value := c.Query("slot_minutes"); value != "" {
+		if slotMinutes, err = strconv.Atoi(value); err != nil {
+			respondWithInvalidQuery(c, "slot_minutes", "slot_minutes must be a whole number")
+			return
+		}
+	}
+
+	viewerID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+	viewerRole, _ := utils.GetUserRoleFromContext(c)
+
+	slots, err := h.availability.OpenSlots(c.Request.Context(), viewerID, viewerRole, mentorID, from, to, slotMinutes)
+	if err != nil {
+		h.respondWithAvailabilityError(c, "GetOpenSlots", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.All(slots))
+}
+
+func availabilityIDParam(c *gin.Context, entry string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "Invalid " + entry + " ID",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondWithInvalidQuery(c *gin.Context, field, message string) {
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error:   "Invalid input",
+		Message: message,
+		Code:    http.StatusBadRequest,
+		Field:   field,
+	})
+}
+
+func (h *AvailabilityHandler) respondWithAvailabilityError(c *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, services.ErrAvailabilityInvalid):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+	case errors.Is(err, services.ErrAvailabilityRole):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "forbidden",
+			Message: err.Error(),
+			Code:    http.StatusForbidden,
+		})
+	case errors.Is(err, services.ErrAvailabilityNotFound), errors.Is(err, services.ErrMentorNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not found",
+			Message: err.Error(),
+			Code:    http.StatusNotFound,
+		})
+	default:
+		logger.Error("%s: availability request failed: %v", op, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to process availability",
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
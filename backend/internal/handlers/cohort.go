@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/logger"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CohortHandler lets admins assign cohorts of mentees to mentors
+type CohortHandler struct {
+	cohorts *services.CohortService
+}
+
+// NewCohortHandler creates a new cohort handler
+func NewCohortHandler(cohorts *services.CohortService) *CohortHandler {
+	return &CohortHandler{
+		cohorts: cohorts,
+	}
+}
+
+// CreateCohort godoc
+//
+//	@Summary		Draft a cohort assignment
+//	@Description	Assign a cohort of mentees to a pool of mentors, maximising the total match score over the whole cohort within each mentor's capacity. Capacities default to each mentor's open slots. Pairs below min_score or already in a mentorship are never assigned. The result is a draft to review before publishing (requires mentorships:assign).
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.CreateCohortRequest	true	"Mentees and mentor pool"
+//	@Success		201		{object}	models.Cohort				"Draft assignment"
+//	@Failure		400		{object}	models.ErrorResponse		"Invalid input data, or a user with the wrong role"
+//	@Failure		403		{object}	models.ErrorResponse		"Forbidden - mentorships:assign permission required"
+//	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Router			/admin/cohorts [post]
+func (h *CohortHandler) CreateCohort(c *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.CreateCohortRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cohort, err := h.cohorts.Create(c.Request.Context(), adminID, &req)
+	if err != nil {
+		h.respondWithCohortError(c, "CreateCohort", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, cohort)
+}
+
+// ListCohorts godoc
+//
+//	@Summary		List cohorts
+//	@Description	List cohorts, newest first, without their pairs (requires mentorships:assign)
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			limit	query		int								false	"Page size (default 20, at most 100)"
+//	@Param			cursor	query		string							false	"next_cursor of the previous page"
+//	@Success		200		{object}	pagination.Page[models.Cohort]	"Cohorts"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid limit or cursor"
+//	@Failure		403		{object}	models.ErrorResponse	"Forbidden - mentorships:assign permission required"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/admin/cohorts [get]
+func (h *CohortHandler) ListCohorts(c *gin.Context) {
+	page, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	cohorts, err := h.cohorts.List(c.Request.Context(), page)
+	if err != nil {
+		logger.Error("ListCohorts: failed to list cohorts: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
+			Message: "Failed to list cohorts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, cohorts)
+}
+
+// GetCohort godoc
+//
+//	@Summary		Get cohort
+//	@Description	Get a cohort with its mentor pool and pairs, best scoring first. Each pair explains its score per factor (requires mentorships:assign).
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string					true	"Cohort ID"
+//	@Success		200	{object}	models.Cohort			"Cohort"
+//	@Failure		400	{object}	models.ErrorResponse	"Invalid cohort ID"
+//	@Failure		403	{object}	models.ErrorResponse	"Forbidden - mentorships:assign permission required"
+//	@Failure		404	{object}	models.ErrorResponse	"Cohort not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/admin/cohorts/{id} [get]
+func (h *CohortHandler) GetCohort(c *gin.Context) {
+	cohortID, ok := cohortIDParam(c)
+	if !ok {
+		return
+	}
+
+	cohort, err := h.cohorts.Get(c.Request.Context(), cohortID)
+	if err != nil {
+		h.respondWithCohortError(c, "GetCohort", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cohort)
+}
+
+// UpdateCohortPair godoc
+//
+//	@Summary		Reassign a cohort mentee
+//	@Description	Move a mentee of a draft cohort to another mentor of its pool, or leave them unassigned with a null mentor_id. The pair is scored again and marked as adjusted (requires mentorships:assign).
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		string							true	"Cohort ID"
+//	@Param			menteeId	path		string							true	"Mentee user ID"
+//	@Param			request		body		models.UpdateCohortPairRequest	true	"New mentor"
+//	@Success		200			{object}	models.Cohort					"Updated cohort"
+//	@Failure		400			{object}	models.ErrorResponse			"Invalid input data, or a user outside the cohort"
+//	@Failure		403			{object}	models.ErrorResponse			"Forbidden - mentorships:assign permission required"
+//	@Failure		404			{object}	models.ErrorResponse			"Cohort not found"
+//	@Failure		409			{object}	models.ErrorResponse			"Cohort already published, mentor at capacity or pair already in a mentorship"
+//	@Failure		500			{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/admin/cohorts/{id}/pairs/{menteeId} [put]
+func (h *CohortHandler) UpdateCohortPair(c *gin.Context) {
+	cohortID, ok := cohortIDParam(c)
+	if !ok {
+		return
+	}
+	menteeID, err := uuid.Parse(c.Param("menteeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "Invalid mentee ID",
+		})
+		return
+	}
+
+	var req models.UpdateCohortPairRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cohort, err := h.cohorts.Reassign(c.Request.Context(), cohortID, menteeID, req.MentorID)
+	if err != nil {
+		h.respondWithCohortError(c, "UpdateCohortPair", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cohort)
+}
+
+// PublishCohort godoc
+//
+//	@Summary		Publish a cohort
+//	@Description	Create an active mentorship for every assigned pair of a draft cohort, all in one transaction. Unassigned mentees are left out (requires mentorships:assign).
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string					true	"Cohort ID"
+//	@Success		200	{object}	models.Cohort			"Published cohort"
+//	@Failure		400	{object}	models.ErrorResponse	"Invalid cohort ID"
+//	@Failure		403	{object}	models.ErrorResponse	"Forbidden - mentorships:assign permission required"
+//	@Failure		404	{object}	models.ErrorResponse	"Cohort not found"
+//	@Failure		409	{object}	models.ErrorResponse	"Cohort already published, or a pair already in a mentorship"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/admin/cohorts/{id}/publish [post]
+func (h *CohortHandler) PublishCohort(c *gin.Context) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+	cohortID, ok := cohortIDParam(c)
+	if !ok {
+		return
+	}
+
+	cohort, err := h.cohorts.Publish(c.Request.Context(), cohortID, adminID)
+	if err != nil {
+		h.respondWithCohortError(c, "PublishCohort", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, cohort)
+}
+
+func cohortIDParam(c *gin.Context) (uuid.UUID, bool) {
+	cohortID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "Invalid cohort ID",
+		})
+		return uuid.Nil, false
+	}
+	return cohortID, true
+}
+
+func (h *CohortHandler) respondWithCohortError(c *gin.Context, op string, err error) {
+	switch {
+	case errors.Is(err, services.ErrCohortInvalid):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrCohortNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "Not found",
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrCohortPublished), errors.Is(err, services.ErrCohortCapacity), errors.Is(err, services.ErrCohortConflict):
+		c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error:   "Conflict",
+			Message: err.Error(),
+		})
+	default:
+		logger.Error("%s: cohort request failed: %v", op, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to process cohort",
+			Code:    http.StatusInternalServerError,
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerificationHandler handles email verification endpoints
+type EmailVerificationHandler struct {
+	verification *services.EmailVerificationService
+}
+
+// NewEmailVerificationHandler creates a new email verification handler
+func NewEmailVerificationHandler(verification *services.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{
+		verification: verification,
+	}
+}
+
+// RequestCode godoc
+//
+//	@Summary		Request email verification code
+//	@Description	Send a 6-digit verification code to the email address. The response and its timing are the same whether or not the email is registered. Requests too soon after the last code, or beyond 5 codes an hour, are ignored.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.VerificationRequest	true	"Email to verify"
+//	@Success		202		{object}	map[string]string			"Verification code sent if the account exists"
+//	@Failure		400		{object}	models.ErrorResponse		"Invalid input data"
+//	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Router			/auth/verify-email/request [post]
+func (h *EmailVerificationHandler) RequestCode(c *gin.Context) {
+	var req models.VerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.verification.RequestCode(c.Request.Context(), req.Email); err != nil {
+		logger.Error("RequestCode: failed to send verification code: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Verification failed",
+			Message: "Failed to send verification code",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If the account exists and is not verified, a verification code has been sent",
+	})
+}
+
+// ConfirmCode godoc
+//
+//	@Summary		Confirm email verification code
+//	@Description	Verify the email address with the 6-digit code sent by email
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.VerificationConfirmRequest	true	"Email and verification code"
+//	@Success		200		{object}	models.UserResponse					"Email verified"
+//	@Failure		400		{object}	models.ErrorResponse				"Invalid or expired code"
+//	@Failure		429		{object}	models.ErrorResponse				"Too many attempts"
+//	@Failure		500		{object}	models.ErrorResponse				"Internal server error"
+//	@Router			/auth/verify-email/confirm [post]
+func (h *EmailVerificationHandler) ConfirmCode(c *gin.Context) {
+	var req models.VerificationConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.verification.ConfirmCode(c.Request.Context(), req.Email, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrVerificationCodeInvalid):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid code",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrVerificationTooMany):
+			c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+				Error:   "Too many attempts",
+				Message: err.Error(),
+			})
+		default:
+			logger.Error("ConfirmCode: failed to verify email: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Verification failed",
+				Message: "Failed to verify email",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserResponse(user))
+}
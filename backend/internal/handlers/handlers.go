@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
+	"mentori/internal/models"
 	"mentori/pkg/database"
+	"mentori/pkg/pagination"
 
 	"github.com/gin-gonic/gin"
 )
@@ -55,3 +58,23 @@ func ReadinessCheck(c *gin.Context) {
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	})
 }
+
+// pageParams reads the limit and cursor query parameters shared by list
+// endpoints, responding with 400 if they are invalid
+func pageParams(c *gin.Context) (pagination.Params, bool) {
+	page, err := pagination.Parse(c.Query("limit"), c.Query("cursor"))
+	if err != nil {
+		field := "limit"
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			field = "cursor"
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+			Field:   field,
+		})
+		return page, false
+	}
+	return page, true
+}
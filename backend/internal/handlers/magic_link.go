@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MagicLinkHandler handles passwordless sign-in with emailed login links
+type MagicLinkHandler struct {
+	magicLinks *services.MagicLinkService
+	auth       *AuthHandler
+}
+
+// NewMagicLinkHandler creates a new magic link handler. Tokens are issued
+// through the auth handler once the link has been checked.
+func NewMagicLinkHandler(magicLinks *services.MagicLinkService, auth *AuthHandler) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinks: magicLinks,
+		auth:       auth,
+	}
+}
+
+// RequestLink godoc
+//
+//	@Summary		Request login link
+//	@Description	Email a single-use passwordless login link. The response is the same whether or not the email is registered, and repeated requests for one email are throttled.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.MagicLinkRequest	true	"Account email"
+//	@Success		202		{object}	map[string]string		"Login link sent if the account exists"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid input data"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/magic/request [post]
+func (h *MagicLinkHandler) RequestLink(c *gin.Context) {
+	var req models.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.magicLinks.RequestLink(c.Request.Context(), req.Email); err != nil {
+		logger.Error("RequestLink: failed to create login link: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Login link failed",
+			Message: "Failed to process login link request",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If an account exists for this email, a login link has been sent",
+	})
+}
+
+// Verify godoc
+//
+//	@Summary		Sign in with login link
+//	@Description	Exchange the token from an emailed login link for access and refresh tokens. Users with two-factor authentication get an MFA challenge instead.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.MagicLinkVerifyRequest	true	"Login link token"
+//	@Success		200		{object}	models.AuthResponse				"Login successful"
+//	@Success		202		{object}	models.MFAChallengeResponse		"Second factor required"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid input data"
+//	@Failure		401		{object}	models.ErrorResponse			"Invalid, used or expired link"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/auth/magic/verify [post]
+func (h *MagicLinkHandler) Verify(c *gin.Context) {
+	var req models.MagicLinkVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.magicLinks.Verify(c.Request.Context(), req.Token)
+	if err != nil {
+		if errors.Is(err, services.ErrMagicLinkInvalid) {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Unauthorized",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Error("Verify: failed to verify login link: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Login failed",
+			Message: "Failed to verify login link",
+		})
+		return
+	}
+
+	h.auth.respondWithLogin(c, user, http.StatusOK)
+}
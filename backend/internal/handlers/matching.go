@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MatchingHandler suggests mentors to mentees and mentees to mentors
+type MatchingHandler struct {
+	matching *services.MatchingService
+}
+
+// NewMatchingHandler creates a new matching handler
+func NewMatchingHandler(matching *services.MatchingService) *MatchingHandler {
+	return &MatchingHandler{
+		matching: matching,
+	}
+}
+
+// GetMatches godoc
+//
+//	@Summary		Suggested matches
+//	@Description	List the candidates of the opposite role that best fit the caller, best first. Each match has a score from 0 to 1 and explains it per factor: expertise, interests, location, language, availability and mentor capacity. Existing mentorship partners and mentors without open slots are left out. The best matches come in one page.
+//	@Tags			matches
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			limit	query		int								false	"Number of matches (default 20, at most 100)"
+//	@Success		200		{object}	pagination.Page[models.Match]	"Ranked matches"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid limit"
+//	@Failure		401		{object}	models.ErrorResponse			"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse			"Caller is neither a mentor nor a mentee"
+//	@Failure		404		{object}	models.ErrorResponse			"Caller has no profile"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/matches [get]
+func (h *MatchingHandler) GetMatches(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+	role, _ := utils.GetUserRoleFromContext(c)
+
+	// Matches are ranked in memory, so there is no cursor to follow
+	page, err := pagination.Parse(c.Query("limit"), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+			Field:   "limit",
+		})
+		return
+	}
+
+	matches, err := h.matching.Matches(c.Request.Context(), userID, role, page.Limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMatchRole):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "forbidden",
+				Message: err.Error(),
+				Code:    http.StatusForbidden,
+			})
+		case errors.Is(err, services.ErrMatchNoProfile):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "profile_not_found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+		default:
+			logger.Error("GetMatches: failed to match user %s: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to find matches",
+				Code:    http.StatusInternalServerError,
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.All(matches))
+}
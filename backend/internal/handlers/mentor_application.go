@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MentorApplicationHandler handles applying to become a mentor and the admin review of applications
+type MentorApplicationHandler struct {
+	mentors *services.MentorApplicationService
+}
+
+// NewMentorApplicationHandler creates a new mentor application handler
+func NewMentorApplicationHandler(mentors *services.MentorApplicationService) *MentorApplicationHandler {
+	return &MentorApplicationHandler{
+		mentors: mentors,
+	}
+}
+
+// Apply godoc
+//
+//	@Summary		Apply to become a mentor
+//	@Description	Open a mentor application for the authenticated mentee. The user keeps the mentee role until an admin approves the application.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.MentorApplicationRequest	true	"Why the user wants to mentor"
+//	@Success		201		{object}	models.MentorApplication		"Application opened"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid input data"
+//	@Failure		401		{object}	models.ErrorResponse			"Unauthorized"
+//	@Failure		403		{object}	models.ErrorResponse			"Only mentees can apply"
+//	@Failure		409		{object}	models.ErrorResponse			"An application is already waiting for review"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/auth/mentor-application [post]
+func (h *MentorApplicationHandler) Apply(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.MentorApplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	application, err := h.mentors.Apply(c.Request.Context(), userID, req.Motivation)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMentorApplicationRole):
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Forbidden",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrMentorApplicationPending):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Conflict",
+				Message: err.Error(),
+			})
+		default:
+			logger.Error("Apply: failed to open mentor application: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to open mentor application",
+				Code:    http.StatusInternalServerError,
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, application)
+}
+
+// GetMine godoc
+//
+//	@Summary		Get my mentor application
+//	@Description	Get the authenticated user's most recent mentor application and its review status
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	models.MentorApplication	"Latest application"
+//	@Failure		401	{object}	models.ErrorResponse		"Unauthorized"
+//	@Failure		404	{object}	models.ErrorResponse		"No application"
+//	@Failure		500	{object}	models.ErrorResponse		"Internal server error"
+//	@Router			/auth/mentor-application [get]
+func (h *MentorApplicationHandler) GetMine(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	application, err := h.mentors.Latest(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, services.ErrMentorApplicationNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Not found",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Error("GetMine: failed to load mentor application: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to load mentor application",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
+
+// ListApplications godoc
+//
+//	@Summary		List mentor applications
+//	@Description	List mentor applications, oldest first so the review queue is worked in order (requires mentors:approve)
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			status	query		string										false	"Filter by status (pending, approved, rejected)"	default(pending)
+//	@Param			limit	query		int											false	"Page size (default 20, at most 100)"
+//	@Param			cursor	query		string										false	"next_cursor of the previous page"
+//	@Success		200		{object}	pagination.Page[models.MentorApplication]	"Mentor applications"
+//	@Failure		400		{object}	models.ErrorResponse						"Invalid status, limit or cursor"
+//	@Failure		403		{object}	models.ErrorResponse						"Forbidden - mentors:approve permission required"
+//	@Failure		500		{object}	models.ErrorResponse						"Internal server error"
+//	@Router			/admin/mentor-applications [get]
+func (h *MentorApplicationHandler) ListApplications(c *gin.Context) {
+	status := c.DefaultQuery("status", constants.MentorApplicationPending)
+	switch status {
+	case constants.MentorApplicationPending, constants.MentorApplicationApproved, constants.MentorApplicationRejected:
+	default:
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "status must be pending, approved or rejected",
+			Field:   "status",
+		})
+		return
+	}
+	page, ok := pageParams(c)
+	if !ok {
+		return
+	}
+
+	applications, err := h.mentors.List(c.Request.Context(), status, page)
+	if err != nil {
+		logger.Error("ListApplications: failed to list mentor applications: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Database error",
+			Message: "Failed to list mentor applications",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, applications)
+}
+
+// Approve godoc
+//
+//	@Summary		Approve mentor application
+//	@Description	Make the applicant a mentor and email them the decision with the optional reason (requires mentors:approve)
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string									true	"Application ID"
+//	@Param			request	body		models.MentorApplicationDecisionRequest	false	"Message to the applicant"
+//	@Success		200		{object}	models.MentorApplication				"Application approved"
+//	@Failure		400		{object}	models.ErrorResponse					"Invalid input data"
+//	@Failure		403		{object}	models.ErrorResponse					"Forbidden - mentors:approve permission required"
+//	@Failure		404		{object}	models.ErrorResponse					"Application not found"
+//	@Failure		409		{object}	models.ErrorResponse					"Application already reviewed"
+//	@Failure		500		{object}	models.ErrorResponse					"Internal server error"
+//	@Router			/admin/mentor-applications/{id}/approve [post]
+func (h *MentorApplicationHandler) Approve(c *gin.Context) {
+	h.decide(c, constants.MentorApplicationApproved)
+}
+
+// Reject godoc
+//
+//	@Summary		Reject mentor application
+//	@Description	Turn down a mentor application and email the reason to the applicant, who stays a mentee (requires mentors:approve)
+//	@Tags			admin
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		string									true	"Application ID"
+//	@Param			request	body		models.MentorApplicationDecisionRequest	true	"Reason sent to the applicant"
+//	@Success		200		{object}	models.MentorApplication				"Application rejected"
+//	@Failure		400		{object}	models.ErrorResponse					"Invalid input data or missing reason"
+//	@Failure		403		{object}	models.ErrorResponse					"Forbidden - mentors:approve permission required"
+//	@Failure		404		{object}	models.ErrorResponse					"Application not found"
+//	@Failure		409		{object}	models.ErrorResponse					"Application already reviewed"
+//	@Failure		500		{object}	models.ErrorResponse					"Internal server error"
+//	@Router			/admin/mentor-applications/{id}/reject [post]
+func (h *MentorApplicationHandler) Reject(c *gin.Context) {
+	h.decide(c, constants.MentorApplicationRejected)
+}
+
+func (h *MentorApplicationHandler) decide(c *gin.Context, status string) {
+	adminID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	applicationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "Invalid application ID",
+		})
+		return
+	}
+
+	var req models.MentorApplicationDecisionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid input",
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+	if status == constants.MentorApplicationRejected && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "A reason is required when rejecting an application",
+			Field:   "reason",
+		})
+		return
+	}
+
+	var application *models.MentorApplication
+	if status == constants.MentorApplicationApproved {
+		application, err = h.mentors.Approve(c.Request.Context(), applicationID, adminID, req.Reason)
+	} else {
+		application, err = h.mentors.Reject(c.Request.Context(), applicationID, adminID, req.Reason)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrMentorApplicationNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Not found",
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrMentorApplicationDecided):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Conflict",
+				Message: err.Error(),
+			})
+		default:
+			logger.Error("decide: failed to review mentor application %s: %v", applicationID, err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to review mentor application",
+				Code:    http.StatusInternalServerError,
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/internal/services"
+	"mentori/pkg/logger"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MFAHandler handles two-factor enrollment and the second login step
+type MFAHandler struct {
+	mfa  *services.MFAService
+	auth *AuthHandler
+}
+
+// NewMFAHandler creates a new MFA handler. Tokens are issued through the auth
+// handler once the second factor has been checked.
+func NewMFAHandler(mfa *services.MFAService, auth *AuthHandler) *MFAHandler {
+	return &MFAHandler{
+		mfa:  mfa,
+		auth: auth,
+	}
+}
+
+// Setup godoc
+//
+//	@Summary		Start two-factor setup
+//	@Description	Generate a TOTP secret and otpauth:// provisioning URI for an authenticator app. Available to mentors and admins.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	models.MFASetupResponse	"TOTP secret generated"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	models.ErrorResponse	"Role cannot use two-factor authentication"
+//	@Failure		409	{object}	models.ErrorResponse	"Two-factor authentication already enabled"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/mfa/setup [post]
+func (h *MFAHandler) Setup(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	setup, err := h.mfa.BeginSetup(c.Request.Context(), userID)
+	if err != nil {
+		respondMFAError(c, "Setup", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+// Enable godoc
+//
+//	@Summary		Enable two-factor authentication
+//	@Description	Confirm setup with a code from the authenticator app. Returns recovery codes that are shown only once. Wrong codes count towards the login throttle.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.MFACodeRequest			true	"TOTP code"
+//	@Success		200		{object}	models.MFARecoveryCodesResponse	"Two-factor authentication enabled"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid input data or setup not started"
+//	@Failure		401		{object}	models.ErrorResponse			"Unauthorized or invalid code"
+//	@Failure		409		{object}	models.ErrorResponse			"Two-factor authentication already enabled"
+//	@Failure		429		{object}	models.ErrorResponse			"Too many failed attempts, see Retry-After"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/auth/mfa/enable [post]
+func (h *MFAHandler) Enable(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var codes []string
+	if !h.throttled(c, "Enable", userID, func(ctx context.Context) (err error) {
+		codes, err = h.mfa.Enable(ctx, userID, req.Code)
+		return err
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable godoc
+//
+//	@Summary		Disable two-factor authentication
+//	@Description	Turn off two-factor authentication with the password and a TOTP or recovery code. Needs a session that passed two-factor login. Wrong passwords and codes count towards the login throttle.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.MFAChangeRequest	true	"Password and TOTP or recovery code"
+//	@Success		200		{object}	map[string]string		"Two-factor authentication disabled"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid input data or not enabled"
+//	@Failure		401		{object}	models.ErrorResponse	"Unauthorized, or invalid password or code"
+//	@Failure		403		{object}	models.ErrorResponse	"Session did not pass two-factor login"
+//	@Failure		429		{object}	models.ErrorResponse	"Too many failed attempts, see Retry-After"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/mfa/disable [post]
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.MFAChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if !h.throttled(c, "Disable", userID, func(ctx context.Context) error {
+		return h.mfa.Disable(ctx, userID, req.Password, req.Code)
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes godoc
+//
+//	@Summary		Regenerate recovery codes
+//	@Description	Replace all recovery codes after checking the password and a TOTP code. Previous codes stop working. Needs a session that passed two-factor login. Wrong passwords and codes count towards the login throttle.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.MFAChangeRequest			true	"Password and TOTP code"
+//	@Success		200		{object}	models.MFARecoveryCodesResponse	"New recovery codes"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid input data or not enabled"
+//	@Failure		401		{object}	models.ErrorResponse			"Unauthorized, or invalid password or code"
+//	@Failure		403		{object}	models.ErrorResponse			"Session did not pass two-factor login"
+//	@Failure		429		{object}	models.ErrorResponse			"Too many failed attempts, see Retry-After"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/auth/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.MFAChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	var codes []string
+	if !h.throttled(c, "RegenerateRecoveryCodes", userID, func(ctx context.Context) (err error) {
+		codes, err = h.mfa.RegenerateRecoveryCodes(ctx, userID, req.Password, req.Code)
+		return err
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MFARecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// throttled runs a password or code check for the signed-in user under the
+// login throttle, so that an access token cannot be used to keep guessing.
+// Wrong passwords and codes count as failed logins of the account. It reports
+// whether check succeeded, having written the error response otherwise.
+func (h *MFAHandler) throttled(c *gin.Context, action string, userID uuid.UUID, check func(ctx context.Context) error) bool {
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	user, err := h.auth.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		respondMFAError(c, action, err)
+		return false
+	}
+	if err := h.auth.throttle.Check(ctx, user.Email, ip); err != nil {
+		respondThrottled(c, action, err)
+		return false
+	}
+
+	if err := check(ctx); err != nil {
+		if errors.Is(err, services.ErrMFAInvalidCode) || errors.Is(err, services.ErrMFAInvalidPassword) {
+			if err := h.auth.throttle.RecordFailure(ctx, user.Email, ip, &userID); err != nil {
+				logger.Error("%s: failed to record failed attempt: %v", action, err)
+			}
+		}
+		respondMFAError(c, action, err)
+		return false
+	}
+
+	if err := h.auth.throttle.RecordSuccess(ctx, user.Email); err != nil {
+		logger.Error("%s: failed to reset failed login counter: %v", action, err)
+	}
+	return true
+}
+
+// Verify godoc
+//
+//	@Summary		Complete two-factor login
+//	@Description	Exchange the MFA challenge token from login and a TOTP or recovery code for access and refresh tokens. A challenge can be completed once and allows a few attempts; failed codes count towards the login throttle.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.MFAVerifyRequest	true	"Challenge token and code"
+//	@Success		200		{object}	models.AuthResponse		"Login successful"
+//	@Failure		400		{object}	models.ErrorResponse	"Invalid input data"
+//	@Failure		401		{object}	models.ErrorResponse	"Invalid or expired challenge, or invalid code"
+//	@Failure		429		{object}	models.ErrorResponse	"Too many failed attempts, see Retry-After"
+//	@Failure		500		{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/mfa/verify [post]
+func (h *MFAHandler) Verify(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	userID, challengeID, err := h.auth.parseMFAChallenge(req.MFAToken)
+	if err != nil {
+		respondMFAError(c, "Verify", services.ErrMFAChallengeInvalid)
+		return
+	}
+
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	user, err := h.auth.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		respondMFAError(c, "Verify", err)
+		return
+	}
+
+	// Second-factor guesses share the password login's backoff and lockout
+	if err := h.auth.throttle.Check(ctx, user.Email, ip); err != nil {
+		respondThrottled(c, "Verify", err)
+		return
+	}
+
+	verified, err := h.mfa.CompleteChallenge(ctx, challengeID, userID, req.Code)
+	if err != nil {
+		if errors.Is(err, services.ErrMFAInvalidCode) {
+			if err := h.auth.throttle.RecordFailure(ctx, user.Email, ip, &userID); err != nil {
+				logger.Error("Verify: failed to record failed two-factor code: %v", err)
+			}
+		}
+		respondMFAError(c, "Verify", err)
+		return
+	}
+
+	if err := h.auth.throttle.RecordSuccess(ctx, user.Email); err != nil {
+		logger.Error("Verify: failed to reset failed login counter: %v", err)
+	}
+
+	response, err := h.auth.newAuthResponse(c, verified, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Token generation failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// respondMFAError maps MFA service errors to HTTP responses
+func respondMFAError(c *gin.Context, action string, err error) {
+	var status int
+	switch {
+	case errors.Is(err, services.ErrMFAInvalidCode):
+		logger.Warn("%s: invalid two-factor code", action)
+		status = http.StatusUnauthorized
+	case errors.Is(err, services.ErrMFAInvalidPassword):
+		logger.Warn("%s: invalid password", action)
+		status = http.StatusUnauthorized
+	case errors.Is(err, services.ErrMFAChallengeInvalid):
+		status = http.StatusUnauthorized
+	case errors.Is(err, services.ErrMFANotAllowed):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrMFAAlreadyEnabled):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrMFANotEnabled),
+		errors.Is(err, services.ErrMFASetupRequired):
+		status = http.StatusBadRequest
+	case errors.Is(err, repository.ErrNotFound):
+		status = http.StatusUnauthorized
+		err = errors.New("user no longer exists")
+	default:
+		logger.Error("%s: two-factor request failed: %v", action, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Two-factor request failed",
+			Message: "Failed to process two-factor request",
+		})
+		return
+	}
+
+	c.JSON(status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: err.Error(),
+	})
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// OAuthHandler handles OAuth sign-in and provider linking endpoints
+type OAuthHandler struct {
+	accounts *services.OAuthAccountService
+	auth     *AuthHandler
+}
+
+// NewOAuthHandler creates a new OAuth handler. Tokens are issued through the
+// auth handler so OAuth sign-ins get the same access/refresh tokens as password logins.
+func NewOAuthHandler(accounts *services.OAuthAccountService, auth *AuthHandler) *OAuthHandler {
+	return &OAuthHandler{
+		accounts: accounts,
+		auth:     auth,
+	}
+}
+
+// IssueNonce godoc
+//
+//	@Summary		Issue an OAuth nonce
+//	@Description	Issue a nonce to pass to Google or Apple for one sign-in, or for one link on /auth/oauth/link/nonce. The nonce expires after 10 minutes and is deleted once a provider token carrying it is accepted, so an ID token cannot be replayed. Link nonces are only accepted from the login session that asked for them.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		201	{object}	models.OAuthNonceResponse	"Nonce issued"
+//	@Failure		500	{object}	models.ErrorResponse		"Internal server error"
+//	@Router			/auth/oauth/nonce [post]
+//	@Router			/auth/oauth/link/nonce [post]
+func (h *OAuthHandler) IssueNonce(c *gin.Context) {
+	// Set by JWTAuth on the link route only
+	value, _ := c.Get(constants.ContextKeySessionID)
+	sessionID, _ := value.(uuid.UUID)
+
+	nonce, err := h.accounts.IssueNonce(c.Request.Context(), sessionID)
+	if err != nil {
+		logger.Error("IssueNonce: failed to issue nonce: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to issue nonce",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, nonce)
+}
+
+// Login godoc
+//
+//	@Summary		Sign in with OAuth
+//	@Description	Sign in or sign up with a Google or Apple ID token. A verified local account with the same email is linked automatically. New accounts are mentees; choosing the mentor role opens a mentor application.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			provider	path		string					true	"OAuth provider (google, apple)"
+//	@Param			request		body		models.OAuthLoginRequest	true	"Provider ID token and the nonce from /auth/oauth/nonce"
+//	@Success		200			{object}	models.AuthResponse		"Signed in"
+//	@Success		201			{object}	models.AuthResponse		"Account created"
+//	@Success		202			{object}	models.MFAChallengeResponse	"Second factor required"
+//	@Failure		400			{object}	models.ErrorResponse	"Invalid input data or provider"
+//	@Failure		401			{object}	models.ErrorResponse	"Invalid ID token, or a nonce that is unknown, expired or already used"
+//	@Failure		409			{object}	models.ErrorResponse	"Email belongs to an account that must link the provider first"
+//	@Failure		500			{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/oauth/{provider} [post]
+func (h *OAuthHandler) Login(c *gin.Context) {
+	var req models.OAuthLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, created, err := h.accounts.SignIn(c.Request.Context(), c.Param("provider"), req.IDToken, req.Nonce)
+	if err != nil {
+		respondOAuthError(c, "Login", err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		if req.Role == constants.RoleMentor {
+			h.auth.applyForMentor(c.Request.Context(), user)
+		}
+	}
+	h.auth.respondWithLogin(c, user, status)
+}
+
+// ListIdentities godoc
+//
+//	@Summary		List linked providers
+//	@Description	List the OAuth providers linked to the authenticated user, all in one page
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page[models.UserIdentity]	"Linked identities"
+//	@Failure		401	{object}	models.ErrorResponse					"Unauthorized"
+//	@Failure		500	{object}	models.ErrorResponse					"Internal server error"
+//	@Router			/auth/oauth/identities [get]
+func (h *OAuthHandler) ListIdentities(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	identities, err := h.accounts.ListIdentities(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error("ListIdentities: failed to list identities: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to list linked providers",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.All(identities))
+}
+
+// Link godoc
+//
+//	@Summary		Link OAuth provider
+//	@Description	Link a Google or Apple identity to the authenticated user
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Accept			json
+//	@Produce		json
+//	@Param			provider	path		string					true	"OAuth provider (google, apple)"
+//	@Param			request		body		models.OAuthLinkRequest	true	"Provider ID token and the nonce from /auth/oauth/link/nonce"
+//	@Success		200			{object}	models.UserIdentity		"Provider linked"
+//	@Failure		400			{object}	models.ErrorResponse	"Invalid input data or provider"
+//	@Failure		401			{object}	models.ErrorResponse	"Unauthorized or invalid ID token"
+//	@Failure		409			{object}	models.ErrorResponse	"Identity already linked elsewhere"
+//	@Failure		500			{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/oauth/{provider}/link [post]
+func (h *OAuthHandler) Link(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	var req models.OAuthLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	value, _ := c.Get(constants.ContextKeySessionID)
+	sessionID, _ := value.(uuid.UUID)
+
+	identity, err := h.accounts.Link(c.Request.Context(), userID, sessionID, c.Param("provider"), req.IDToken, req.Nonce)
+	if err != nil {
+		respondOAuthError(c, "Link", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, identity)
+}
+
+// Unlink godoc
+//
+//	@Summary		Unlink OAuth provider
+//	@Description	Remove a linked provider. The last remaining login method cannot be removed.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			provider	path		string					true	"OAuth provider (google, apple)"
+//	@Success		200			{object}	map[string]string		"Provider unlinked"
+//	@Failure		401			{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404			{object}	models.ErrorResponse	"Provider not linked"
+//	@Failure		409			{object}	models.ErrorResponse	"Last remaining login method"
+//	@Failure		500			{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/oauth/{provider}/link [delete]
+func (h *OAuthHandler) Unlink(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	if err := h.accounts.Unlink(c.Request.Context(), userID, c.Param("provider")); err != nil {
+		respondOAuthError(c, "Unlink", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Provider unlinked successfully",
+	})
+}
+
+// respondOAuthError maps OAuth account errors to HTTP responses
+func respondOAuthError(c *gin.Context, action string, err error) {
+	var status int
+	switch {
+	case errors.Is(err, services.ErrOAuthProviderInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrOAuthTokenVerification):
+		logger.Warn("%s: OAuth token rejected: %v", action, err)
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Message: services.ErrOAuthTokenVerification.Error(),
+		})
+		return
+	case errors.Is(err, services.ErrOAuthIdentityNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrOAuthAccountExists),
+		errors.Is(err, services.ErrOAuthIdentityInUse),
+		errors.Is(err, services.ErrOAuthProviderLinked),
+		errors.Is(err, services.ErrOAuthLastLoginMethod):
+		status = http.StatusConflict
+	default:
+		logger.Error("%s: OAuth request failed: %v", action, err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "OAuth request failed",
+			Message: "Failed to process OAuth request",
+		})
+		return
+	}
+
+	c.JSON(status, models.ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: err.Error(),
+	})
+}
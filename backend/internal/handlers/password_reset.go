@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordResetHandler handles forgotten password endpoints
+type PasswordResetHandler struct {
+	passwordReset *services.PasswordResetService
+}
+
+// NewPasswordResetHandler creates a new password reset handler
+func NewPasswordResetHandler(passwordReset *services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		passwordReset: passwordReset,
+	}
+}
+
+// ForgotPassword godoc
+//
+//	@Summary		Request password reset
+//	@Description	Email a single-use password reset link. The response is the same whether or not the email is registered.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.ForgotPasswordRequest	true	"Account email"
+//	@Success		202		{object}	map[string]string				"Reset email sent if the account exists"
+//	@Failure		400		{object}	models.ErrorResponse			"Invalid input data"
+//	@Failure		500		{object}	models.ErrorResponse			"Internal server error"
+//	@Router			/auth/forgot-password [post]
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.passwordReset.RequestReset(c.Request.Context(), req.Email); err != nil {
+		logger.Error("ForgotPassword: failed to create reset token: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Password reset failed",
+			Message: "Failed to process password reset request",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword godoc
+//
+//	@Summary		Reset password
+//	@Description	Set a new password with a reset token and sign out all existing sessions
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		models.ResetPasswordRequest	true	"Reset token and new password"
+//	@Success		200		{object}	map[string]string			"Password reset successful"
+//	@Failure		400		{object}	models.ErrorResponse		"Invalid input, token or password"
+//	@Failure		500		{object}	models.ErrorResponse		"Internal server error"
+//	@Router			/auth/reset-password [post]
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.passwordReset.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		if respondPasswordPolicyError(c, "new_password", err) {
+			return
+		}
+		if errors.Is(err, services.ErrResetTokenInvalid) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid token",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Error("ResetPassword: failed to reset password: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Password reset failed",
+			Message: "Failed to reset password",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successful",
+	})
+}
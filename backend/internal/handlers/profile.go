@@ -1,14 +1,26 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"mentori/internal/models"
 	"mentori/internal/repository"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/geo"
 	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+	"mentori/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -19,18 +31,24 @@ import (
 type ProfileHandler struct {
 	profileRepo repository.ProfileRepository
 	userRepo    repository.UserRepository
+	privacy     *services.ProfilePrivacyService
+	avatars     *services.AvatarService
+	taxonomy    *services.TaxonomyService
 }
 
-func NewProfileHandler(profileRepo repository.ProfileRepository, userRepo repository.UserRepository) *ProfileHandler {
+func NewProfileHandler(profileRepo repository.ProfileRepository, userRepo repository.UserRepository, privacy *services.ProfilePrivacyService, avatars *services.AvatarService, taxonomy *services.TaxonomyService) *ProfileHandler {
 	return &ProfileHandler{
 		profileRepo: profileRepo,
 		userRepo:    userRepo,
+		privacy:     privacy,
+		avatars:     avatars,
+		taxonomy:    taxonomy,
 	}
 }
 
 // CreateProfile godoc
 // @Summary Create user profile
-// @Description Create a new profile for the authenticated user
+// @Description Create a new profile for the authenticated user. A location in Finland is spelled as its municipality and given its municipality_code.
 // @Tags profiles
 // @Security BearerAuth
 // @Accept json
@@ -111,9 +129,17 @@ func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 		return
 	}
 
-	// Marshal arrays to JSON
-	expJSON, _ := json.Marshal(req.Expertise)
-	intJSON, _ := json.Marshal(req.Interests)
+	// Store expertise and interests as taxonomy slugs
+	expJSON, ok := h.normalizeTerms(c, constants.TaxonomyKindExpertise, "expertise", req.Expertise, nil)
+	if !ok {
+		return
+	}
+	intJSON, ok := h.normalizeTerms(c, constants.TaxonomyKindInterest, "interests", req.Interests, nil)
+	if !ok {
+		return
+	}
+
+	location, municipalityCode := normalizeLocation(req.Location)
 
 	profile := &models.Profile{
 		ID:        uuid.New(),
@@ -122,12 +148,30 @@ func (h *ProfileHandler) CreateProfile(c *gin.Context) {
 		LastName:  req.LastName,
 		Bio:       req.Bio,
 		AvatarURL: req.AvatarURL,
-		Expertise: datatypes.JSON(expJSON),
-		Interests: datatypes.JSON(intJSON),
-		Location:  req.Location,
+		Expertise: expJSON,
+		Interests: intJSON,
+		Location:  location,
 		IsActive:  true,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
+
+		MunicipalityCode: municipalityCode,
+
+		ShowLastName:      req.ShowLastName,
+		ShowExactLocation: req.ShowExactLocation,
+		HiddenFields:      stringListJSON(req.HiddenFields),
+		Visibility:        req.Visibility,
+		HiddenFromSearch:  req.HiddenFromSearch,
+
+		Languages:    stringListJSON(req.Languages),
+		Availability: stringListJSON(req.Availability),
+		MaxMentees:   constants.DefaultMaxMentees,
+	}
+	if profile.Visibility == "" {
+		profile.Visibility = constants.ProfileVisibilityEveryone
+	}
+	if req.MaxMentees != nil {
+		profile.MaxMentees = *req.MaxMentees
 	}
 
 	if err := h.profileRepo.Create(c.Request.Context(), profile); err != nil {
@@ -200,7 +244,7 @@ func (h *ProfileHandler) GetMyProfile(c *gin.Context) {
 
 // UpdateProfile godoc
 // @Summary Update user profile
-// @Description Update the authenticated user's profile
+// @Description Update the authenticated user's profile. A location in Finland is spelled as its municipality and given its municipality_code.
 // @Tags profiles
 // @Security BearerAuth
 // @Accept json
@@ -281,6 +325,12 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 				IsActive:  true,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
+
+				HiddenFields: datatypes.JSON([]byte("[]")),
+				Visibility:   constants.ProfileVisibilityEveryone,
+				Languages:    datatypes.JSON([]byte("[]")),
+				Availability: datatypes.JSON([]byte("[]")),
+				MaxMentees:   constants.DefaultMaxMentees,
 			}
 			// Apply provided fields
 			if req.FirstName != nil {
@@ -296,19 +346,49 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 				newProfile.AvatarURL = *req.AvatarURL
 			}
 			if req.Expertise != nil {
-				expJSON, _ := json.Marshal(req.Expertise)
-				newProfile.Expertise = datatypes.JSON(expJSON)
+				expJSON, ok := h.normalizeTerms(c, constants.TaxonomyKindExpertise, "expertise", *req.Expertise, nil)
+				if !ok {
+					return
+				}
+				newProfile.Expertise = expJSON
 			}
 			if req.Interests != nil {
-				intJSON, _ := json.Marshal(req.Interests)
-				newProfile.Interests = datatypes.JSON(intJSON)
+				intJSON, ok := h.normalizeTerms(c, constants.TaxonomyKindInterest, "interests", *req.Interests, nil)
+				if !ok {
+					return
+				}
+				newProfile.Interests = intJSON
 			}
 			if req.Location != nil {
-				newProfile.Location = *req.Location
+				newProfile.Location, newProfile.MunicipalityCode = normalizeLocation(*req.Location)
 			}
 			if req.IsActive != nil {
 				newProfile.IsActive = *req.IsActive
 			}
+			if req.ShowLastName != nil {
+				newProfile.ShowLastName = *req.ShowLastName
+			}
+			if req.ShowExactLocation != nil {
+				newProfile.ShowExactLocation = *req.ShowExactLocation
+			}
+			if req.HiddenFields != nil {
+				newProfile.HiddenFields = stringListJSON(*req.HiddenFields)
+			}
+			if req.Visibility != nil {
+				newProfile.Visibility = *req.Visibility
+			}
+			if req.HiddenFromSearch != nil {
+				newProfile.HiddenFromSearch = *req.HiddenFromSearch
+			}
+			if req.Languages != nil {
+				newProfile.Languages = stringListJSON(*req.Languages)
+			}
+			if req.Availability != nil {
+				newProfile.Availability = stringListJSON(*req.Availability)
+			}
+			if req.MaxMentees != nil {
+				newProfile.MaxMentees = *req.MaxMentees
+			}
 
 			if err := h.profileRepo.Create(c.Request.Context(), newProfile); err != nil {
 				logger.Error("UpdateProfile upsert: failed to create profile: %v", err)
@@ -340,25 +420,60 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 	if req.Bio != nil {
 		profile.Bio = *req.Bio
 	}
+	// Pointing the avatar elsewhere replaces an uploaded one, whose files are deleted below
+	var replacedAvatar *models.Profile
 	if req.AvatarURL != nil {
+		if *req.AvatarURL != profile.AvatarURL && profile.AvatarKey != "" {
+			replaced := *profile
+			replacedAvatar = &replaced
+			profile.AvatarKey = ""
+		}
 		profile.AvatarURL = *req.AvatarURL
 	}
 	if req.Expertise != nil {
-		if b, err := json.Marshal(*req.Expertise); err == nil {
-			profile.Expertise = datatypes.JSON(b)
+		expJSON, ok := h.normalizeTerms(c, constants.TaxonomyKindExpertise, "expertise", *req.Expertise, profile.Expertise)
+		if !ok {
+			return
 		}
+		profile.Expertise = expJSON
 	}
 	if req.Interests != nil {
-		if b, err := json.Marshal(*req.Interests); err == nil {
-			profile.Interests = datatypes.JSON(b)
+		intJSON, ok := h.normalizeTerms(c, constants.TaxonomyKindInterest, "interests", *req.Interests, profile.Interests)
+		if !ok {
+			return
 		}
+		profile.Interests = intJSON
 	}
 	if req.Location != nil {
-		profile.Location = *req.Location
+		profile.Location, profile.MunicipalityCode = normalizeLocation(*req.Location)
 	}
 	if req.IsActive != nil {
 		profile.IsActive = *req.IsActive
 	}
+	if req.ShowLastName != nil {
+		profile.ShowLastName = *req.ShowLastName
+	}
+	if req.ShowExactLocation != nil {
+		profile.ShowExactLocation = *req.ShowExactLocation
+	}
+	if req.HiddenFields != nil {
+		profile.HiddenFields = stringListJSON(*req.HiddenFields)
+	}
+	if req.Visibility != nil {
+		profile.Visibility = *req.Visibility
+	}
+	if req.HiddenFromSearch != nil {
+		profile.HiddenFromSearch = *req.HiddenFromSearch
+	}
+	if req.Languages != nil {
+		profile.Languages = stringListJSON(*req.Languages)
+	}
+	if req.Availability != nil {
+		profile.Availability = stringListJSON(*req.Availability)
+	}
+	if req.MaxMentees != nil {
+		profile.MaxMentees = *req.MaxMentees
+	}
 
 	profile.UpdatedAt = time.Now()
 
@@ -371,10 +486,100 @@ func (h *ProfileHandler) UpdateProfile(c *gin.Context) {
 		})
 		return
 	}
+	if replacedAvatar != nil {
+		h.avatars.Discard(c.Request.Context(), replacedAvatar)
+	}
 
 	c.JSON(http.StatusOK, profile)
 }
 
+// GetProfileByID godoc
+// @Summary Get profile by ID
+// @Description Get another user's profile. Public viewers see only the last name's initial and the broadest part of the location unless the owner shows them; users in an active mentorship with the owner also see the full name, exact location, hidden fields and email, and the owner and staff with profiles:read_private see everything. Inactive profiles are not found except by the owner and staff, and profiles whose visibility setting excludes the caller are not found except by mentorship partners too.
+// @Tags profiles
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Profile ID"
+// @Success 200 {object} models.ProfileView "Profile as visible to the caller"
+// @Failure 400 {object} models.ErrorResponse "Invalid profile ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Profile not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /profiles/{id} [get]
+func (h *ProfileHandler) GetProfileByID(c *gin.Context) {
+	viewerID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+	viewerRole, _ := utils.GetUserRoleFromContext(c)
+
+	profileID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Profile ID must be a valid UUID",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	view, err := h.view(c.Request.Context(), viewerID, viewerRole, profileID)
+	if err != nil {
+		logger.Error("GetProfileByID: failed to retrieve profile: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to retrieve profile",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	if view == nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error:   "profile_not_found",
+			Message: "Profile not found",
+			Code:    http.StatusNotFound,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, view)
+}
+
+// view loads a profile as seen by the viewer. It returns nil when the profile
+// does not exist or the viewer may not see it.
+func (h *ProfileHandler) view(ctx context.Context, viewerID uuid.UUID, viewerRole string, profileID uuid.UUID) (*models.ProfileView, error) {
+	profile, err := h.profileRepo.GetByID(ctx, profileID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return h.project(ctx, viewerID, viewerRole, profile)
+}
+
+// project returns the profile as seen by the viewer, or nil if the viewer may not see it
+func (h *ProfileHandler) project(ctx context.Context, viewerID uuid.UUID, viewerRole string, profile *models.Profile) (*models.ProfileView, error) {
+	owner, err := h.userRepo.GetByID(ctx, profile.UserID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	audience, err := h.privacy.Audience(ctx, viewerID, viewerRole, owner.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !h.privacy.Visible(profile, owner, viewerRole, audience) {
+		return nil, nil
+	}
+	view := h.privacy.Project(profile, owner, audience)
+	return &view, nil
+}
+
 // DeleteProfile godoc
 // @Summary Delete user profile
 // @Description Delete the authenticated user's profile
@@ -457,21 +662,147 @@ func (h *ProfileHandler) DeleteProfile(c *gin.Context) {
 		return
 	}
 
+	h.avatars.Discard(c.Request.Context(), profile)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Profile deleted successfully"})
 }
 
+// UploadAvatar godoc
+// @Summary Upload profile image
+// @Description Upload a JPEG or PNG of at most 5 MB as the authenticated user's avatar. The image is cropped to a square, stored as 512, 256, 128 and 64 pixel JPEG thumbnails without EXIF data, and the previous upload is deleted. The profile's avatar_url is set to the 256 pixel thumbnail.
+// @Tags profiles
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param image formData file true "JPEG or PNG image"
+// @Success 200 {object} models.AvatarResponse "Avatar uploaded"
+// @Failure 400 {object} models.ErrorResponse "No image in the request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Profile not found"
+// @Failure 409 {object} models.ErrorResponse "Another upload replaced the image meanwhile"
+// @Failure 413 {object} models.ErrorResponse "Image too large"
+// @Failure 415 {object} models.ErrorResponse "Not a JPEG or PNG image"
+// @Failure 422 {object} models.ErrorResponse "Image dimensions too large"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /profiles/me/image [post]
+func (h *ProfileHandler) UploadAvatar(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	tooLarge := models.ErrorResponse{
+		Error:   "image_too_large",
+		Message: services.ErrAvatarTooLarge.Error(),
+		Code:    http.StatusRequestEntityTooLarge,
+	}
+
+	// Leave room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, constants.AvatarMaxBytes+64<<10)
+	file, err := c.FormFile(constants.AvatarFormField)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "An image file is required in the \"image\" form field",
+			Code:    http.StatusBadRequest,
+			Field:   constants.AvatarFormField,
+		})
+		return
+	}
+	if file.Size > constants.AvatarMaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		logger.Error("UploadAvatar: failed to open upload: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to read image",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, constants.AvatarMaxBytes+1))
+	if err != nil {
+		logger.Error("UploadAvatar: failed to read upload: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to read image",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	avatar, err := h.avatars.Upload(c.Request.Context(), userID, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAvatarTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+		case errors.Is(err, services.ErrAvatarUnsupported):
+			c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{
+				Error:   "unsupported_image",
+				Message: err.Error(),
+				Code:    http.StatusUnsupportedMediaType,
+			})
+		case errors.Is(err, services.ErrAvatarDimensions):
+			c.JSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+				Error:   "invalid_image",
+				Message: err.Error(),
+				Code:    http.StatusUnprocessableEntity,
+			})
+		case errors.Is(err, services.ErrAvatarNoProfile):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "profile_not_found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+		case errors.Is(err, services.ErrAvatarConflict):
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "conflict",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+		default:
+			logger.Error("UploadAvatar: failed to store avatar: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to store image",
+				Code:    http.StatusInternalServerError,
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, avatar)
+}
+
 // GetPublicProfiles godoc
 // @Summary Get public profiles
-// @Description Search and retrieve public user profiles with optional filters
+// @Description Search and retrieve public user profiles with optional filters. Signing in is optional: anonymous callers only find profiles visible to everyone, signed-in users also find profiles visible to signed-in users and to their role. Profiles hidden from search are left out, and each profile is shown as in GET /profiles/{id}.
 // @Tags profiles
+// @Security BearerAuth
 // @Produce json
 // @Param expertise query []string false "Filter by expertise areas"
 // @Param interests query []string false "Filter by interests"
 // @Param location query string false "Filter by location"
+// @Param near query string false "Only profiles in municipalities within radius_km of this municipality, by Finnish or Swedish name or code. Results carry distance_km between municipality centres."
+// @Param radius_km query number false "Radius around near in kilometres (default 30)"
+// @Param region query string false "Only profiles in this region, by Finnish, Swedish or English name or code, e.g. Uusimaa"
 // @Param role query string false "Filter by user role"
-// @Param limit query int false "Limit number of results (default 20)"
-// @Param offset query int false "Offset for pagination (default 0)"
-// @Success 200 {array} models.Profile "List of public profiles"
+// @Param q query string false "Free text matched against name, bio, expertise and interests in Finnish and English, tolerating typos. Results are ordered by relevance and carry rank and highlight."
+// @Param limit query int false "Page size (default 20, at most 100)"
+// @Param cursor query string false "next_cursor of the previous page, used with the same filters"
+// @Success 200 {object} pagination.Page[models.ProfileView] "Page of public profiles, oldest first or by relevance. The first page carries estimated_total."
+// @Failure 400 {object} models.ErrorResponse "Query too long, unknown municipality or region, or invalid radius, limit or cursor"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /profiles/public [get]
 func (h *ProfileHandler) GetPublicProfiles(c *gin.Context) {
@@ -489,20 +820,36 @@ func (h *ProfileHandler) GetPublicProfiles(c *gin.Context) {
 	if role := c.Query("role"); role != "" {
 		filters.Role = role
 	}
+	filters.Query = strings.TrimSpace(c.Query("q"))
+	if len(filters.Query) > constants.ProfileSearchMaxQueryLength {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: fmt.Sprintf("q must be at most %d characters", constants.ProfileSearchMaxQueryLength),
+			Code:    http.StatusBadRequest,
+			Field:   "q",
+		})
+		return
+	}
 
-	limit := 20
-	offset := 0
-
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-		}
+	near, municipalities, ok := geoFilters(c)
+	if !ok {
+		return
 	}
+	filters.Municipalities = municipalities
 
-	if offsetStr := c.Query("offset"); offsetStr != "" {
-		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
-			offset = parsedOffset
-		}
+	page, ok := pageParams(c)
+	if !ok {
+		return
+	}
+	// A search cursor carries the rank of its result, a listing cursor does not
+	if page.After != nil && (page.After.Rank != nil) != (filters.Query != "") {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: pagination.ErrInvalidCursor.Error(),
+			Code:    http.StatusBadRequest,
+			Field:   "cursor",
+		})
+		return
 	}
 
 	repoFilters := &models.ProfileFilters{}
@@ -513,16 +860,212 @@ func (h *ProfileHandler) GetPublicProfiles(c *gin.Context) {
 		repoFilters.Interests = filters.Interests
 	}
 	repoFilters.Location = filters.Location
+	repoFilters.Municipalities = filters.Municipalities
 	repoFilters.Role = filters.Role
+	repoFilters.Query = filters.Query
+
+	// Signing in is optional here; anonymous callers only find profiles visible to everyone
+	var viewerID uuid.UUID
+	var viewerRole string
+	if id, err := utils.GetUserIDFromContext(c); err == nil {
+		viewerID = id
+		viewerRole, _ = utils.GetUserRoleFromContext(c)
+	}
+	ctx := c.Request.Context()
 
-	profiles, err := h.profileRepo.Search(c.Request.Context(), repoFilters, limit, offset)
+	scope, err := h.privacy.SearchScope(ctx, viewerID, viewerRole)
 	if err != nil {
+		logger.Error("GetPublicProfiles: failed to check search access: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "internal_error",
 			Message: "Failed to search profiles",
 		})
 		return
 	}
+	repoFilters.Scope = scope
 
-	c.JSON(http.StatusOK, profiles)
+	profiles, err := h.profileRepo.Search(ctx, repoFilters, page.Fetch(), page.After)
+	if err != nil {
+		logger.Error("GetPublicProfiles: failed to search profiles: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to search profiles",
+		})
+		return
+	}
+
+	// The cursor is taken before projecting, which may leave profiles out
+	found := pagination.NewPage(profiles, page, func(r *models.ProfileSearchResult) pagination.Cursor {
+		cursor := pagination.Cursor{CreatedAt: r.Profile.CreatedAt, ID: r.Profile.ID}
+		if repoFilters.Query != "" {
+			rank := r.Rank
+			cursor.Rank = &rank
+		}
+		return cursor
+	})
+	if page.After == nil {
+		total, err := h.profileRepo.Count(ctx, repoFilters)
+		if err != nil {
+			logger.Error("GetPublicProfiles: failed to count profiles: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to search profiles",
+			})
+			return
+		}
+		found.EstimatedTotal = &total
+	}
+
+	views := make([]*models.ProfileView, 0, len(found.Items))
+	for _, result := range found.Items {
+		view, err := h.project(ctx, viewerID, viewerRole, result.Profile)
+		if err != nil {
+			logger.Error("GetPublicProfiles: failed to project profile %s: %v", result.Profile.ID, err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to search profiles",
+			})
+			return
+		}
+		if view == nil {
+			continue
+		}
+		// Distances are from municipality centres, so they reveal no more than the location
+		if near != nil && view.Location != "" {
+			if municipality, ok := geo.LookupMunicipality(result.Profile.MunicipalityCode); ok {
+				distance := math.Round(geo.DistanceKm(*near, municipality)*10) / 10
+				view.DistanceKm = &distance
+			}
+		}
+		if repoFilters.Query != "" {
+			rank := result.Rank
+			view.Rank = &rank
+			// The excerpt is from the bio, so it is dropped where the bio is hidden
+			if view.Bio != "" {
+				view.Highlight = result.Highlight
+			}
+		}
+		views = append(views, view)
+	}
+
+	c.JSON(http.StatusOK, pagination.Page[*models.ProfileView]{
+		Items:          views,
+		NextCursor:     found.NextCursor,
+		EstimatedTotal: found.EstimatedTotal,
+	})
+}
+
+// geoFilters reads the near, radius_km and region query parameters into the
+// municipality codes to search and the municipality distances are measured
+// from. On failure the error response has been written.
+func geoFilters(c *gin.Context) (*geo.Municipality, *[]string, bool) {
+	invalid := func(field, message string) (*geo.Municipality, *[]string, bool) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: message,
+			Code:    http.StatusBadRequest,
+			Field:   field,
+		})
+		return nil, nil, false
+	}
+
+	var near *geo.Municipality
+	var codes []string
+	if name := c.Query("near"); name != "" {
+		municipality, ok := geo.FindMunicipality(name)
+		if !ok {
+			return invalid("near", "near must be a Finnish municipality")
+		}
+		radius := constants.DefaultSearchRadiusKm
+		if value := c.Query("radius_km"); value != "" {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil || parsed < 0 || parsed > constants.MaxSearchRadiusKm {
+				return invalid("radius_km", fmt.Sprintf("radius_km must be between 0 and %g", constants.MaxSearchRadiusKm))
+			}
+			radius = parsed
+		}
+		near = &municipality
+		for _, m := range geo.Within(municipality, radius) {
+			codes = append(codes, m.Code)
+		}
+	} else if c.Query("radius_km") != "" {
+		return invalid("radius_km", "radius_km needs near")
+	}
+
+	if name := c.Query("region"); name != "" {
+		region, ok := geo.FindRegion(name)
+		if !ok {
+			return invalid("region", "region must be a Finnish region")
+		}
+		var inRegion []string
+		for _, m := range geo.InRegion(region.Code) {
+			if near == nil || slices.Contains(codes, m.Code) {
+				inRegion = append(inRegion, m.Code)
+			}
+		}
+		codes = inRegion
+	}
+
+	if near == nil && c.Query("region") == "" {
+		return nil, nil, true
+	}
+	if codes == nil {
+		codes = []string{} // Nothing is both near and in the region
+	}
+	return near, &codes, true
+}
+
+// normalizeLocation spells the municipality of a location as the gazetteer
+// does and returns its code. Locations outside Finland are kept as written,
+// without a code.
+func normalizeLocation(location string) (string, string) {
+	municipality, normalized, ok := geo.ResolveLocation(location)
+	if !ok {
+		return strings.TrimSpace(location), ""
+	}
+	return normalized, municipality.Code
+}
+
+// normalizeTerms validates expertise or interests against the taxonomy and
+// returns their slugs as JSON. current is what the profile lists today, which
+// may include deprecated terms. On failure the error response has been written.
+func (h *ProfileHandler) normalizeTerms(c *gin.Context, kind, field string, values []string, current datatypes.JSON) (datatypes.JSON, bool) {
+	var existing []string
+	if len(current) > 0 {
+		if err := json.Unmarshal(current, &existing); err != nil {
+			logger.Warn("normalizeTerms: unreadable %s on profile: %v", field, err)
+		}
+	}
+
+	slugs, err := h.taxonomy.Normalize(c.Request.Context(), kind, values, existing)
+	if err != nil {
+		if errors.Is(err, services.ErrTaxonomyUnknown) || errors.Is(err, services.ErrTaxonomyDeprecated) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "invalid_request",
+				Message: fmt.Sprintf("%s: %v", field, err),
+				Code:    http.StatusBadRequest,
+				Field:   field,
+			})
+		} else {
+			logger.Error("normalizeTerms: failed to load taxonomy: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to validate " + field,
+				Code:    http.StatusInternalServerError,
+			})
+		}
+		return nil, false
+	}
+
+	b, _ := json.Marshal(slugs)
+	return datatypes.JSON(b), true
+}
+
+// stringListJSON stores a list such as hidden field names as a JSON array
+func stringListJSON(values []string) datatypes.JSON {
+	if values == nil {
+		values = []string{}
+	}
+	b, _ := json.Marshal(values)
+	return datatypes.JSON(b)
 }
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SessionHandler handles listing and revoking the user's login sessions
+type SessionHandler struct {
+	sessions *services.SessionService
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(sessions *services.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessions: sessions,
+	}
+}
+
+// ListSessions godoc
+//
+//	@Summary		List login sessions
+//	@Description	List the authenticated user's active sessions with device, IP address and last use. The session making the request is marked as current. All sessions come in one page.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	pagination.Page[models.SessionResponse]	"Active sessions"
+//	@Failure		401	{object}	models.ErrorResponse					"Unauthorized"
+//	@Failure		500	{object}	models.ErrorResponse					"Internal server error"
+//	@Router			/auth/sessions [get]
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	sessions, err := h.sessions.List(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error("ListSessions: failed to list sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to list sessions",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	currentID, _ := c.Get(constants.ContextKeySessionID)
+	sessionID, _ := currentID.(uuid.UUID)
+	response := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, models.SessionResponse{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.ID == sessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, pagination.All(response))
+}
+
+// RevokeSession godoc
+//
+//	@Summary		Revoke a session
+//	@Description	Sign out one of the authenticated user's sessions. Its refresh token stops working and its access tokens are rejected.
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Param			id	path		string					true	"Session ID"
+//	@Success		200	{object}	map[string]string		"Session revoked"
+//	@Failure		400	{object}	models.ErrorResponse	"Invalid session ID"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		404	{object}	models.ErrorResponse	"Session not found"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/sessions/{id} [delete]
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid input",
+			Message: "Invalid session ID",
+		})
+		return
+	}
+
+	if err := h.sessions.Revoke(c.Request.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, services.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Not found",
+				Message: err.Error(),
+			})
+			return
+		}
+		logger.Error("RevokeSession: failed to revoke session: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to revoke session",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Session revoked successfully",
+	})
+}
+
+// RevokeOtherSessions godoc
+//
+//	@Summary		Revoke all other sessions
+//	@Description	Sign out every session of the authenticated user except the one making the request
+//	@Tags			auth
+//	@Security		BearerAuth
+//	@Produce		json
+//	@Success		200	{object}	map[string]interface{}	"Sessions revoked"
+//	@Failure		401	{object}	models.ErrorResponse	"Unauthorized"
+//	@Failure		500	{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/auth/sessions/revoke-others [post]
+func (h *SessionHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, err, "Authentication required")
+		return
+	}
+
+	currentID, _ := c.Get(constants.ContextKeySessionID)
+	sessionID, _ := currentID.(uuid.UUID)
+
+	revoked, err := h.sessions.RevokeOthers(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		logger.Error("RevokeOtherSessions: failed to revoke sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to revoke sessions",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Other sessions revoked successfully",
+		"revoked": revoked,
+	})
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaxonomyHandler serves the expertise and interest terms profiles can list
+type TaxonomyHandler struct {
+	taxonomy *services.TaxonomyService
+}
+
+// NewTaxonomyHandler creates a new taxonomy handler
+func NewTaxonomyHandler(taxonomy *services.TaxonomyService) *TaxonomyHandler {
+	return &TaxonomyHandler{
+		taxonomy: taxonomy,
+	}
+}
+
+// GetTaxonomy godoc
+//
+//	@Summary		List expertise and interests
+//	@Description	List the expertise areas and interests profiles can choose from, in the language picked from Accept-Language (en or fi, English by default). Profiles store the slugs; deprecated and merged terms are not offered.
+//	@Tags			taxonomy
+//	@Produce		json
+//	@Param			Accept-Language	header		string					false	"Preferred languages, e.g. fi-FI,fi;q=0.9,en;q=0.8"
+//	@Success		200				{object}	models.TaxonomyResponse	"Localised terms"
+//	@Failure		500				{object}	models.ErrorResponse	"Internal server error"
+//	@Router			/taxonomy [get]
+func (h *TaxonomyHandler) GetTaxonomy(c *gin.Context) {
+	language := utils.PreferredLanguage(c.GetHeader("Accept-Language"), constants.SupportedLanguages)
+
+	taxonomy, err := h.taxonomy.Localized(c.Request.Context(), language)
+	if err != nil {
+		logger.Error("GetTaxonomy: failed to load taxonomy: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "internal_error",
+			Message: "Failed to load taxonomy",
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.Header("Content-Language", language)
+	c.Header("Vary", "Accept-Language")
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, taxonomy)
+}
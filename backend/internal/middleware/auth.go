@@ -3,51 +3,47 @@ package middleware
 import (
 	"errors"
 	"net/http"
-	"os"
 	"strings"
 
 	"mentori/internal/models"
+	"mentori/internal/services"
 	"mentori/pkg/constants"
 	"mentori/pkg/logger"
 	"mentori/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// JWTAuth middleware validates JWT tokens
-func JWTAuth() gin.HandlerFunc {
-	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
-	if len(jwtSecret) == 0 {
-		// Check environment mode
-		goEnv := os.Getenv("GO_ENV")
-		if goEnv == "production" {
-			panic("❌ FATAL: JWT_SECRET must be set in production environment!")
-		}
-		// Warn in development
-		logger.Warn("Using default JWT secret (development only)")
-		jwtSecret = []byte("dev-only-secret-not-for-production")
-	}
-
+// JWTAuth middleware validates access tokens signed by the token issuer and
+// rejects tokens whose login session has been revoked. Personal API keys sent
+// as "Authorization: ApiKey <key>" are accepted too when apiKeys is not nil.
+func JWTAuth(tokens *services.TokenIssuer, sessions *services.SessionService, apiKeys *services.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(constants.HeaderAuthorization)
 		if authHeader == "" {
 			logger.Warn("Authorization header missing")
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error:   utils.ErrUserNotAuthenticated.Error(),
-				Message: "Please provide a Bearer token",
+				Message: "Please provide a Bearer token or API key",
 				Code:    http.StatusUnauthorized,
 			})
 			c.Abort()
 			return
 		}
 
+		if rawKey, ok := strings.CutPrefix(authHeader, constants.APIKeyAuthScheme+" "); ok && apiKeys != nil {
+			authenticateAPIKey(c, apiKeys, rawKey)
+			return
+		}
+
 		// Check if it starts with "Bearer "
 		if !strings.HasPrefix(authHeader, "Bearer ") {
 			logger.Warn("Invalid authorization format")
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error:   utils.ErrInvalidToken.Error(),
-				Message: "Authorization header must start with 'Bearer '",
+				Message: "Authorization header must start with 'Bearer ' or 'ApiKey '",
 				Code:    http.StatusUnauthorized,
 			})
 			c.Abort()
@@ -58,14 +54,8 @@ func JWTAuth() gin.HandlerFunc {
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
 		// Validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := tokens.Parse(tokenString)
+		if err != nil {
 			logger.Warn("Token validation failed: %v", err)
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error:   utils.ErrInvalidToken.Error(),
@@ -76,26 +66,239 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			logger.Warn("Failed to extract claims")
+		// MFA challenge tokens are signed with the same key but only grant
+		// access to the verify endpoint
+		if typ, ok := claims["typ"]; ok && typ != constants.TokenTypeAccess {
+			logger.Warn("Rejected non-access token of type %v", typ)
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   utils.ErrInvalidToken.Error(),
+				Message: "Token is not an access token",
+				Code:    http.StatusUnauthorized,
+			})
+			c.Abort()
+			return
+		}
+
+		sid, _ := claims["sid"].(string)
+		sessionID, err := uuid.Parse(sid)
+		if err != nil {
+			logger.Warn("Access token without a valid session ID")
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error:   utils.ErrInvalidToken.Error(),
-				Message: "Token claims are invalid",
+				Message: "Token is invalid or expired",
 				Code:    http.StatusUnauthorized,
 			})
 			c.Abort()
 			return
 		}
+		if err := sessions.Validate(c.Request.Context(), sessionID); err != nil {
+			if errors.Is(err, services.ErrSessionRevoked) {
+				c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+					Error:   utils.ErrInvalidToken.Error(),
+					Message: "Session has been revoked",
+					Code:    http.StatusUnauthorized,
+				})
+			} else {
+				logger.Error("JWTAuth: failed to check session: %v", err)
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error:   "internal_error",
+					Message: "Failed to check session",
+					Code:    http.StatusInternalServerError,
+				})
+			}
+			c.Abort()
+			return
+		}
+
+		// Impersonation tokens name the admin acting as the user in an RFC 8693
+		// "act" claim; handlers see the user as the subject and the admin as the actor
+		if act, ok := claims["act"]; ok {
+			actor, _ := act.(map[string]interface{})
+			actorSub, _ := actor["sub"].(string)
+			actorID, err := uuid.Parse(actorSub)
+			if err != nil {
+				logger.Warn("Impersonation token without a valid actor")
+				c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+					Error:   utils.ErrInvalidToken.Error(),
+					Message: "Token is invalid or expired",
+					Code:    http.StatusUnauthorized,
+				})
+				c.Abort()
+				return
+			}
+			actorEmail, _ := actor["email"].(string)
+			c.Set(constants.ContextKeyActorID, actorID)
+			c.Set(constants.ContextKeyActorEmail, actorEmail)
+		}
+
+		userID, _ := claims["user_id"].(string)
+		email, _ := claims["email"].(string)
+		role, _ := claims["role"].(string)
+		mfa, _ := claims["mfa"].(bool)
 
 		// Set user information in context using constants
-		c.Set(constants.ContextKeyUserID, claims["user_id"].(string))
-		c.Set(constants.ContextKeyUserEmail, claims["email"].(string))
-		c.Set(constants.ContextKeyUserRole, claims["role"].(string))
+		c.Set(constants.ContextKeyUserID, userID)
+		c.Set(constants.ContextKeyUserEmail, email)
+		c.Set(constants.ContextKeyUserRole, role)
+		c.Set(constants.ContextKeyMFA, mfa)
+		c.Set(constants.ContextKeySessionID, sessionID)
 		c.Set("user", claims) // Also set full claims for handlers
 
 		logger.Debug("User authenticated: %s", claims["email"])
 		c.Next()
 	}
 }
+
+// OptionalJWTAuth authenticates the caller like JWTAuth when an Authorization
+// header is sent and lets anonymous requests through otherwise. Handlers tell
+// the two apart by whether a user ID is in the context.
+func OptionalJWTAuth(tokens *services.TokenIssuer, sessions *services.SessionService, apiKeys *services.APIKeyService) gin.HandlerFunc {
+	auth := JWTAuth(tokens, sessions, apiKeys)
+	return func(c *gin.Context) {
+		if c.GetHeader(constants.HeaderAuthorization) == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// authenticateAPIKey completes JWTAuth for a personal API key. The request
+// acts as the key's owner, is never MFA-verified and has no login session.
+func authenticateAPIKey(c *gin.Context, apiKeys *services.APIKeyService, rawKey string) {
+	key, user, err := apiKeys.Authenticate(c.Request.Context(), strings.TrimSpace(rawKey))
+	if err != nil {
+		if errors.Is(err, services.ErrAPIKeyInvalid) {
+			logger.Warn("API key authentication failed: %v", err)
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   utils.ErrInvalidToken.Error(),
+				Message: err.Error(),
+				Code:    http.StatusUnauthorized,
+			})
+		} else {
+			logger.Error("JWTAuth: failed to check API key: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "internal_error",
+				Message: "Failed to check API key",
+				Code:    http.StatusInternalServerError,
+			})
+		}
+		c.Abort()
+		return
+	}
+
+	if !services.APIKeyAllows(key, c.Request.Method) {
+		logger.Warn("API key %s lacks scope for %s %s", key.Prefix, c.Request.Method, c.Request.URL.Path)
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "insufficient_scope",
+			Message: "API key does not have the scope for this request",
+			Code:    http.StatusForbidden,
+		})
+		c.Abort()
+		return
+	}
+
+	c.Set(constants.ContextKeyUserID, user.ID.String())
+	c.Set(constants.ContextKeyUserEmail, user.Email)
+	c.Set(constants.ContextKeyUserRole, user.Role)
+	c.Set(constants.ContextKeyMFA, false)
+	c.Set(constants.ContextKeyAPIKeyID, key.ID)
+	c.Set("user", jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"email":   user.Email,
+		"role":    user.Role,
+	})
+
+	logger.Debug("User authenticated with API key %s: %s", key.Prefix, user.Email)
+	c.Next()
+}
+
+// RejectAPIKey middleware refuses requests authenticated with a personal API
+// key. Use it on account security and admin endpoints. Must run after JWTAuth.
+func RejectAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if keyID, ok := c.Get(constants.ContextKeyAPIKeyID); ok {
+			logger.Warn("API key %s request to %s %s refused", keyID, c.Request.Method, c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "api_key_forbidden",
+				Message: "This action requires signing in, API keys are not accepted",
+				Code:    http.StatusForbidden,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireMFA middleware rejects access tokens from logins that did not complete
+// a second factor. Must run after JWTAuth.
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(constants.ContextKeyMFA) {
+			logger.Warn("Access without two-factor authentication denied for user %s", c.GetString(constants.ContextKeyUserID))
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "mfa_required",
+				Message: "Two-factor authentication is required for this endpoint",
+				Code:    http.StatusForbidden,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RejectImpersonation middleware refuses requests made with an impersonation
+// token. Use it on destructive and account security endpoints. Must run after JWTAuth.
+func RejectImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actorID, impersonating := c.Get(constants.ContextKeyActorID); impersonating {
+			logger.Warn("Impersonated request by admin %s to %s %s refused", actorID, c.Request.Method, c.Request.URL.Path)
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "impersonation_forbidden",
+				Message: "This action is not available while impersonating a user",
+				Code:    http.StatusForbidden,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuditImpersonation middleware logs and records every request made with an
+// impersonation token once it has been handled. Mount it before JWTAuth so that
+// refused requests are recorded too.
+func AuditImpersonation(audit *services.ImpersonationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		value, impersonating := c.Get(constants.ContextKeyActorID)
+		if !impersonating {
+			return
+		}
+		actorID, _ := value.(uuid.UUID)
+		subjectID, _ := utils.GetUserIDFromContext(c)
+		sessionValue, _ := c.Get(constants.ContextKeySessionID)
+		sessionID, _ := sessionValue.(uuid.UUID)
+
+		logger.Info("Impersonated request: actor=%s (%s) subject=%s %s %s status=%d",
+			actorID, c.GetString(constants.ContextKeyActorEmail), subjectID, c.Request.Method, c.Request.URL.Path, c.Writer.Status())
+
+		if err := audit.RecordRequest(c.Request.Context(), &models.ImpersonationLog{
+			ActorID:   actorID,
+			SubjectID: subjectID,
+			SessionID: sessionID,
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			IPAddress: c.ClientIP(),
+		}); err != nil {
+			logger.Error("AuditImpersonation: failed to record impersonated request: %v", err)
+		}
+	}
+}
@@ -6,8 +6,9 @@ import (
 	"time"
 
 	"mentori/internal/models"
-	"mentori/pkg/constants"
+	"mentori/internal/services"
 	"mentori/pkg/logger"
+	"mentori/pkg/utils"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -18,7 +19,7 @@ func CORS() gin.HandlerFunc {
 	config := cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000", "http://127.0.0.1:3000", "https://mentori.com"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "X-Requested-With"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "X-Requested-With", "X-Device-Name"},
 		ExposeHeaders:    []string{"Content-Length", "Authorization"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -92,11 +93,23 @@ func SecurityHeaders() gin.HandlerFunc {
 	}
 }
 
-// AdminOnly middleware ensures only admin users can access the endpoint
-func AdminOnly() gin.HandlerFunc {
+// Authorizer builds middleware that checks the authenticated user's role
+// against the permission registry
+type Authorizer struct {
+	authz *services.AuthorizationService
+}
+
+// NewAuthorizer creates a new authorizer
+func NewAuthorizer(authz *services.AuthorizationService) *Authorizer {
+	return &Authorizer{authz: authz}
+}
+
+// Require middleware allows the request only if the user's role has every
+// given permission, e.g. Require(constants.PermissionUsersDelete). Must run after JWTAuth.
+func (a *Authorizer) Require(permissions ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userRole, exists := c.Get(constants.ContextKeyUserRole)
-		if !exists {
+		role, err := utils.GetUserRoleFromContext(c)
+		if err != nil {
 			logger.Warn("User role not found in context")
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error:   "Unauthorized",
@@ -106,14 +119,27 @@ func AdminOnly() gin.HandlerFunc {
 			return
 		}
 
-		if userRole.(string) != "admin" {
-			logger.Warn("Non-admin user attempted to access admin endpoint: %s", userRole)
-			c.JSON(http.StatusForbidden, models.ErrorResponse{
-				Error:   "Forbidden",
-				Message: "Admin access required",
-			})
-			c.Abort()
-			return
+		for _, permission := range permissions {
+			allowed, err := a.authz.Can(c.Request.Context(), role, permission)
+			if err != nil {
+				logger.Error("Require: failed to check permission %s: %v", permission, err)
+				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error:   "internal_error",
+					Message: "Failed to check permissions",
+					Code:    http.StatusInternalServerError,
+				})
+				c.Abort()
+				return
+			}
+			if !allowed {
+				logger.Warn("Role %s lacks permission %s for %s %s", role, permission, c.Request.Method, c.Request.URL.Path)
+				c.JSON(http.StatusForbidden, models.ErrorResponse{
+					Error:   "Forbidden",
+					Message: "Missing permission " + permission,
+				})
+				c.Abort()
+				return
+			}
 		}
 
 		c.Next()
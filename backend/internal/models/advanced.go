@@ -0,0 +1,350 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// EmailVerification stores a one-time code sent to an email address
+type EmailVerification struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Email     string    `json:"email" gorm:"not null;index"`
+	Code      string    `json:"-" gorm:"type:varchar(6);not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	Attempts  int       `json:"attempts" gorm:"default:0"`
+	IsUsed    bool      `json:"is_used" gorm:"default:false"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OAuthUser is the identity returned by an OAuth provider after token verification
+type OAuthUser struct {
+	Email      string `json:"email"`
+	ProviderID string `json:"provider_id"`
+	IsVerified bool   `json:"is_verified"`
+	Name       string `json:"name"`
+}
+
+// UserIdentity links an external OAuth identity to a user.
+// A user may have several identities and can sign in with any of them.
+type UserIdentity struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Provider   string    `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_user_identities_provider_id"`
+	ProviderID string    `json:"-" gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_id"`
+	Email      string    `json:"email"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RefreshToken is a single-use, server-side refresh token.
+// Tokens issued from the same login share a FamilyID so that reuse of an
+// already-rotated token can revoke the whole chain.
+type RefreshToken struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	FamilyID     uuid.UUID  `json:"family_id" gorm:"type:uuid;not null;index"`
+	TokenHash    string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID *uuid.UUID `json:"replaced_by_id,omitempty" gorm:"type:uuid"`
+	MFAVerified  bool       `json:"mfa_verified" gorm:"default:false"` // Login completed a second factor
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// APIKey is a personal API key for scripting against the API. Only a SHA-256
+// hash of the key is stored; Prefix is shown in listings so that users can
+// tell their keys apart.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(32);uniqueIndex;not null"`
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);not null"`
+	Scopes     string     `json:"scopes" gorm:"type:varchar(100);not null"` // Space-separated, e.g. "read write"
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" gorm:"index"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// MentorApplication is a request to become a mentor. Users who sign up as
+// mentors start as mentees until an admin approves their application.
+type MentorApplication struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Email      string     `json:"email,omitempty" gorm:"->;-:migration"` // Read from users in admin listings
+	Motivation string     `json:"motivation,omitempty" gorm:"type:text"`
+	Status     string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Reason     string     `json:"reason,omitempty" gorm:"type:text"` // Reviewer's reason, sent to the applicant
+	ReviewedBy *uuid.UUID `json:"reviewed_by,omitempty" gorm:"type:uuid"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Cohort pairs a batch of mentees with a pool of mentors in one go. The
+// computed pairs stay a draft that admins can adjust until it is published,
+// which creates the mentorships.
+type Cohort struct {
+	ID          uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name        string         `json:"name" gorm:"type:varchar(100);not null"`
+	Status      string         `json:"status" gorm:"type:varchar(20);not null;default:'draft';index"`
+	MinScore    float64        `json:"min_score" gorm:"not null;default:0"` // Pairs scoring lower are never assigned
+	CreatedBy   uuid.UUID      `json:"created_by" gorm:"type:uuid;not null"`
+	PublishedBy *uuid.UUID     `json:"published_by,omitempty" gorm:"type:uuid"`
+	PublishedAt *time.Time     `json:"published_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Mentors     []CohortMentor `json:"mentors,omitempty" gorm:"foreignKey:CohortID"`
+	Pairs       []CohortPair   `json:"pairs,omitempty" gorm:"foreignKey:CohortID"`
+	// Summary of the pairs
+	Assigned   int     `json:"assigned" gorm:"-"`
+	Unassigned int     `json:"unassigned" gorm:"-"`
+	TotalScore float64 `json:"total_score" gorm:"-"`
+}
+
+// CohortMentor is a mentor in a cohort's pool and how many of its mentees they take
+type CohortMentor struct {
+	CohortID uuid.UUID `json:"-" gorm:"type:uuid;primary_key"`
+	MentorID uuid.UUID `json:"mentor_id" gorm:"type:uuid;primary_key"`
+	Capacity int       `json:"capacity" gorm:"not null"`
+}
+
+// CohortPair is a mentee of a cohort and the mentor assigned to them, if any
+type CohortPair struct {
+	CohortID uuid.UUID      `json:"-" gorm:"type:uuid;primary_key"`
+	MenteeID uuid.UUID      `json:"mentee_id" gorm:"type:uuid;primary_key"`
+	MentorID *uuid.UUID     `json:"mentor_id" gorm:"type:uuid"` // Nil when no mentor could be assigned
+	Score    float64        `json:"score"`
+	Factors  datatypes.JSON `json:"factors" gorm:"type:jsonb"`              // []MatchFactor explaining the score
+	Adjusted bool           `json:"adjusted" gorm:"not null;default:false"` // Changed by an admin after computing
+}
+
+// MentorCalendar holds the settings of a mentor's availability calendar
+type MentorCalendar struct {
+	MentorID  uuid.UUID `json:"-" gorm:"type:uuid;primary_key"`
+	Timezone  string    `json:"timezone" gorm:"type:varchar(64);not null;default:'Europe/Helsinki'"` // IANA name the calendar's times are in
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AvailabilityRule is a window a mentor is free every week, in the
+// calendar's timezone
+type AvailabilityRule struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MentorID  uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	Weekday   int       `json:"weekday" gorm:"not null"`                    // 0 is Sunday, 6 Saturday
+	StartTime string    `json:"start_time" gorm:"type:varchar(5);not null"` // HH:MM
+	EndTime   string    `json:"end_time" gorm:"type:varchar(5);not null"`   // HH:MM, 24:00 for midnight
+}
+
+// AvailabilityOverride is a window on one date. The overrides of a date
+// replace its weekly windows.
+type AvailabilityOverride struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MentorID  uuid.UUID `json:"-" gorm:"type:uuid;not null;index:idx_availability_overrides_mentor_date"`
+	Date      string    `json:"date" gorm:"type:varchar(10);not null;index:idx_availability_overrides_mentor_date"` // YYYY-MM-DD in the calendar's timezone
+	StartTime string    `json:"start_time" gorm:"type:varchar(5);not null"`
+	EndTime   string    `json:"end_time" gorm:"type:varchar(5);not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AvailabilityBlackout is a period a mentor is away, whatever their windows say
+type AvailabilityBlackout struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MentorID  uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	StartsAt  time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt    time.Time `json:"ends_at" gorm:"not null"`
+	Reason    string    `json:"reason,omitempty" gorm:"type:varchar(200)"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MentoringSession is a session with a mentor, stored in the sessions table of
+// docs/database-schema.md. Pending, accepted and scheduled sessions take the
+// mentor's time, see constants.BookedSessionStatuses.
+type MentoringSession struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MentorID    uuid.UUID  `json:"mentor_id" gorm:"type:uuid;not null;index"`
+	MenteeID    uuid.UUID  `json:"mentee_id" gorm:"type:uuid;not null;index"`
+	Status      string     `json:"status" gorm:"type:varchar(20);not null;default:'pending'"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	Duration    int        `json:"duration" gorm:"not null;default:60"` // Minutes
+	MeetingLink string     `json:"meeting_link,omitempty" gorm:"type:varchar(500)"`
+	Notes       string     `json:"notes,omitempty" gorm:"type:text"`
+	MenteeNotes string     `json:"mentee_notes,omitempty" gorm:"type:text"`
+	MentorNotes string     `json:"mentor_notes,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName keeps the documented table name; "sessions" alone would be
+// ambiguous next to AuthSession in Go
+func (MentoringSession) TableName() string {
+	return "sessions"
+}
+
+// EndsAt returns when a scheduled session is over
+func (s *MentoringSession) EndsAt() time.Time {
+	return s.ScheduledAt.Add(time.Duration(s.Duration) * time.Minute)
+}
+
+// Availability is a mentor's calendar: the timezone, the weekly windows, and
+// the overrides and blackouts that are not over yet
+type Availability struct {
+	Timezone  string                  `json:"timezone"`
+	Weekly    []*AvailabilityRule     `json:"weekly"`
+	Overrides []*AvailabilityOverride `json:"overrides"`
+	Blackouts []*AvailabilityBlackout `json:"blackouts"`
+}
+
+// OpenSlot is a time a mentor can be booked, in the mentor's timezone
+type OpenSlot struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// TaxonomyTerm is an expertise area or interest a profile can list. Profiles
+// store the slug, which never changes; labels are shown in the caller's language.
+type TaxonomyTerm struct {
+	Kind         string     `json:"kind" gorm:"type:varchar(20);primary_key"`
+	Slug         string     `json:"slug" gorm:"type:varchar(64);primary_key"`
+	LabelEN      string     `json:"label_en" gorm:"column:label_en;not null"`
+	LabelFI      string     `json:"label_fi" gorm:"column:label_fi;not null"`
+	SortOrder    int        `json:"sort_order" gorm:"not null;default:0"`
+	DeprecatedAt *time.Time `json:"deprecated_at,omitempty"`                       // No longer offered; profiles listing it keep it
+	MergedInto   *string    `json:"merged_into,omitempty" gorm:"type:varchar(64)"` // Slug of the same kind that replaces this one
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// TaxonomyOption is a term as offered to clients
+type TaxonomyOption struct {
+	Slug  string `json:"slug"`
+	Label string `json:"label"`
+}
+
+// TaxonomyResponse lists the terms profiles can choose from, in one language
+type TaxonomyResponse struct {
+	Language  string           `json:"language"`
+	Expertise []TaxonomyOption `json:"expertise"`
+	Interests []TaxonomyOption `json:"interests"`
+}
+
+// Role is a named set of permissions assigned to users
+type Role struct {
+	Name        string           `json:"name" gorm:"type:varchar(50);primary_key"`
+	Description string           `json:"description"`
+	Permissions []RolePermission `json:"permissions" gorm:"foreignKey:Role;references:Name;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// RolePermission grants one permission from the registry to a role
+type RolePermission struct {
+	Role       string `json:"-" gorm:"type:varchar(50);primary_key"`
+	Permission string `json:"permission" gorm:"type:varchar(100);primary_key"`
+}
+
+// PasswordResetToken is a single-use token emailed to reset a forgotten password.
+// Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MagicLinkToken tracks a signed passwordless login link so it can be used
+// once. The ID is the link token's "jti" claim.
+type MagicLinkToken struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Email     string     `json:"email" gorm:"type:varchar(255);not null;index"` // Lowercased, for per-email throttling
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MFARecoveryCode is a hashed single-use code for signing in without the authenticator app
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MFAChallenge is a pending second login step. Its ID is the challenge token's
+// jti, so each challenge can be completed once and guessed a limited number of times.
+type MFAChallenge struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Attempts  int        `json:"attempts" gorm:"not null;default:0"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// OAuthNonce is a nonce the server issued for one OAuth sign-in or link. It is
+// deleted when a provider token carrying it is accepted, so a captured token
+// cannot be replayed. Link nonces belong to the login session that asked for them.
+type OAuthNonce struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	NonceHash string     `json:"-" gorm:"type:varchar(64);uniqueIndex;not null"`
+	SessionID *uuid.UUID `json:"-" gorm:"type:uuid;index"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName avoids GORM's o_auth_nonces
+func (OAuthNonce) TableName() string {
+	return "oauth_nonces"
+}
+
+// AuthSession is one login on a device. Its ID is the refresh token family ID
+// and is carried in access tokens as the "sid" claim.
+type AuthSession struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	DeviceName string     `json:"device_name" gorm:"type:varchar(100)"`
+	UserAgent  string     `json:"user_agent" gorm:"type:varchar(512)"`
+	IPAddress  string     `json:"ip_address" gorm:"type:varchar(45)"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at" gorm:"not null"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ImpersonationLog is an audit record of an admin impersonating a user: one
+// entry when the token is issued and one for every request made with it
+type ImpersonationLog struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ActorID   uuid.UUID `json:"actor_id" gorm:"type:uuid;not null;index"`   // Admin
+	SubjectID uuid.UUID `json:"subject_id" gorm:"type:uuid;not null;index"` // Impersonated user
+	SessionID uuid.UUID `json:"session_id" gorm:"type:uuid;not null"`       // Admin's login session
+	Action    string    `json:"action" gorm:"type:varchar(20);not null"`    // token_issued or request
+	Method    string    `json:"method,omitempty" gorm:"type:varchar(10)"`
+	Path      string    `json:"path,omitempty" gorm:"type:varchar(512)"`
+	Status    int       `json:"status,omitempty"`
+	Reason    string    `json:"reason,omitempty" gorm:"type:text"`
+	IPAddress string    `json:"ip_address" gorm:"type:varchar(45)"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoginThrottle counts recent failed logins for one account or client IP
+type LoginThrottle struct {
+	Key           string     `json:"key" gorm:"type:varchar(320);primary_key"` // "account:<email>" or "ip:<address>"
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// LockoutEvent records an account or IP address being locked out after repeated failed logins
+type LockoutEvent struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Scope       string     `json:"scope" gorm:"type:varchar(20);not null"` // account or ip
+	Subject     string     `json:"subject" gorm:"type:varchar(320);not null;index"`
+	UserID      *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	IPAddress   string     `json:"ip_address" gorm:"type:varchar(45)"`
+	Failures    int        `json:"failures"`
+	LockedUntil time.Time  `json:"locked_until"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
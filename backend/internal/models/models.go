@@ -4,14 +4,21 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
 // User represents a user in the system (mentor or mentee)
 type User struct {
 	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Email        string    `json:"email" gorm:"uniqueIndex;not null"`
-	PasswordHash string    `json:"-" gorm:"not null"` // Never return password in JSON
-	Role         string    `json:"role" gorm:"not null;check:role IN ('mentor','mentee','admin')"`
+	PasswordHash string    `json:"-" gorm:"not null"`                     // Never return password in JSON
+	Role         string    `json:"role" gorm:"type:varchar(50);not null"` // References roles.name
+	IsVerified   bool      `json:"is_verified" gorm:"default:false"`
+	Provider     string    `json:"provider" gorm:"type:varchar(50);default:'local'"` // Sign-up method: local, google or apple
+	ProviderID   *string   `json:"-"`
+	MFAEnabled   bool      `json:"mfa_enabled" gorm:"default:false"`
+	MFASecret    string    `json:"-"` // Base32 TOTP secret, set during enrollment
+	MFALastStep  int64     `json:"-"` // Last accepted TOTP time step, prevents code replay
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 
@@ -21,25 +28,143 @@ type User struct {
 
 // Profile contains additional user information
 type Profile struct {
-	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Bio       string    `json:"bio"`
-	AvatarURL string    `json:"avatar_url"`
-	Expertise []string  `json:"expertise" gorm:"type:text[]"` // Array of skills/expertise
-	Interests []string  `json:"interests" gorm:"type:text[]"` // Array of interests
-	Location  string    `json:"location"`
-	IsActive  bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID      `json:"user_id" gorm:"type:uuid;not null"`
+	FirstName string         `json:"first_name"`
+	LastName  string         `json:"last_name"`
+	Bio       string         `json:"bio"`
+	AvatarURL string         `json:"avatar_url"`
+	AvatarKey string         `json:"-"`                           // Storage prefix of the uploaded avatar, empty for external URLs
+	Expertise datatypes.JSON `json:"expertise" gorm:"type:jsonb"` // JSON array of skills/expertise
+	Interests datatypes.JSON `json:"interests" gorm:"type:jsonb"` // JSON array of interests
+	Location  string         `json:"location"`
+	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	// Statistics Finland code of the municipality in Location, set on write;
+	// empty for locations outside the gazetteer in pkg/geo
+	MunicipalityCode string `json:"municipality_code" gorm:"type:varchar(3);not null;default:''"`
+	// Privacy settings for viewers without a mentorship with the owner
+	ShowLastName      bool           `json:"show_last_name" gorm:"default:false"`      // Otherwise only the initial is shown
+	ShowExactLocation bool           `json:"show_exact_location" gorm:"default:false"` // Otherwise only the broadest part is shown
+	HiddenFields      datatypes.JSON `json:"hidden_fields" gorm:"type:jsonb"`          // JSON array of field names, see CreateProfileRequest
+	Visibility        string         `json:"visibility" gorm:"type:varchar(20);not null;default:'everyone'"`
+	HiddenFromSearch  bool           `json:"hidden_from_search" gorm:"default:false"` // Still reachable by ID, e.g. by existing mentorship partners
+	// Matching
+	Languages    datatypes.JSON `json:"languages" gorm:"type:jsonb"`           // JSON array of ISO 639-1 codes, e.g. ["fi","en"]
+	Availability datatypes.JSON `json:"availability" gorm:"type:jsonb"`        // JSON array of constants.AvailabilitySlots
+	MaxMentees   int            `json:"max_mentees" gorm:"not null;default:3"` // Mentors only; 0 pauses new matches
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// Mentorship pairs a mentor with a mentee
+type Mentorship struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	MentorID  uuid.UUID  `json:"mentor_id" gorm:"type:uuid;not null;index"`
+	MenteeID  uuid.UUID  `json:"mentee_id" gorm:"type:uuid;not null;index"`
+	Status    string     `json:"status" gorm:"type:varchar(20);not null;default:'active'"`
+	CreatedAt time.Time  `json:"created_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+}
+
+// ProfileView is a profile as shown to another user. How much is shown
+// depends on View: public, connected (mentorship) or private (owner or staff).
+type ProfileView struct {
+	ID           uuid.UUID      `json:"id"`
+	UserID       uuid.UUID      `json:"user_id"`
+	Role         string         `json:"role"`
+	FirstName    string         `json:"first_name"`
+	LastName     string         `json:"last_name"`
+	Bio          string         `json:"bio"`
+	AvatarURL    string         `json:"avatar_url"`
+	Expertise    datatypes.JSON `json:"expertise"`
+	Interests    datatypes.JSON `json:"interests"`
+	Location     string         `json:"location"`
+	Languages    datatypes.JSON `json:"languages"`
+	Availability datatypes.JSON `json:"availability"`
+	Email        string         `json:"email,omitempty"`     // Connected and private views
+	IsActive     *bool          `json:"is_active,omitempty"` // Private view
+	View         string         `json:"view"`
+	CreatedAt    time.Time      `json:"created_at"`
+	// Search results for a free-text query
+	Rank      *float64 `json:"rank,omitempty"`      // Relevance, higher is better
+	Highlight string   `json:"highlight,omitempty"` // Bio excerpt, HTML-escaped with matches in <mark>
+	// Search results near a municipality, unless the location is hidden
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+}
+
+// AvatarResponse is returned after an avatar upload
+type AvatarResponse struct {
+	AvatarURL string            `json:"avatar_url"`
+	Sizes     map[string]string `json:"sizes"` // Thumbnail URL by size in pixels
+}
+
+// Match is a candidate for a mentorship with the viewer, with the reasons
+type Match struct {
+	Profile *ProfileView  `json:"profile"`
+	Score   float64       `json:"score"` // Weighted average of the factors, 0-1
+	Factors []MatchFactor `json:"factors"`
+}
+
+// MatchFactor explains one part of a match score
+type MatchFactor struct {
+	Name   string   `json:"name"`   // expertise, interests, location, language, availability or capacity
+	Score  float64  `json:"score"`  // 0-1, before weighting
+	Weight float64  `json:"weight"` // Share of the match score, weights sum to 1
+	Shared []string `json:"shared,omitempty"`
+	Detail string   `json:"detail"`
+}
+
+// CreateCohortRequest asks for an assignment of mentees to mentors
+type CreateCohortRequest struct {
+	Name      string                `json:"name" binding:"required,max=100"`
+	MenteeIDs []uuid.UUID           `json:"mentee_ids" binding:"required,min=1,max=300"`
+	Mentors   []CohortMentorRequest `json:"mentors" binding:"required,min=1,max=300,dive"`
+	MinScore  float64               `json:"min_score" binding:"min=0,max=1"`
+}
+
+// CohortMentorRequest adds a mentor to a cohort's pool
+type CohortMentorRequest struct {
+	MentorID uuid.UUID `json:"mentor_id" binding:"required"`
+	Capacity *int      `json:"capacity,omitempty" binding:"omitempty,min=0,max=50"` // Defaults to the mentor's open slots
+}
+
+// UpdateCohortPairRequest assigns a cohort mentee to another mentor of the pool
+type UpdateCohortPairRequest struct {
+	MentorID *uuid.UUID `json:"mentor_id"` // Null leaves the mentee unassigned
+}
+
+// UpdateAvailabilityRequest sets a mentor's timezone and replaces their weekly windows
+type UpdateAvailabilityRequest struct {
+	Timezone string                      `json:"timezone"` // IANA name, defaults to Europe/Helsinki
+	Weekly   []AvailabilityWindowRequest `json:"weekly" binding:"max=100,dive"`
+}
+
+// AvailabilityWindowRequest is a weekly window
+type AvailabilityWindowRequest struct {
+	Weekday   int    `json:"weekday" binding:"min=0,max=6"` // 0 is Sunday, 6 Saturday
+	StartTime string `json:"start_time" binding:"required"` // HH:MM
+	EndTime   string `json:"end_time" binding:"required"`   // HH:MM, 24:00 for midnight
+}
+
+// AvailabilityOverrideRequest adds a window on one date
+type AvailabilityOverrideRequest struct {
+	Date      string `json:"date" binding:"required"` // YYYY-MM-DD
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+}
+
+// AvailabilityBlackoutRequest adds a period the mentor is away
+type AvailabilityBlackoutRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason" binding:"max=200"`
 }
 
 // RegisterRequest represents user registration data
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
-	Role     string `json:"role" binding:"required,oneof=mentor mentee admin"`
+	Password string `json:"password" binding:"required"`                 // Checked against the password policy
+	Role     string `json:"role" binding:"required,oneof=mentor mentee"` // Mentors start as mentees with a pending application
 }
 
 // LoginRequest represents user login data
@@ -48,19 +173,185 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshRequest carries a refresh token for rotation or revocation
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 // AuthResponse represents authentication response
 type AuthResponse struct {
-	User  UserResponse `json:"user"`
-	Token string       `json:"token"`
+	User              UserResponse       `json:"user"`
+	Token             string             `json:"token"`
+	RefreshToken      string             `json:"refresh_token,omitempty"`
+	ExpiresIn         int64              `json:"expires_in,omitempty"`         // Access token lifetime in seconds
+	MentorApplication *MentorApplication `json:"mentor_application,omitempty"` // Set when registering as a mentor
 }
 
 // UserResponse represents user data returned to client
 type UserResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	Profile   *Profile  `json:"profile,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         uuid.UUID `json:"id"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	IsVerified bool      `json:"is_verified"`
+	MFAEnabled bool      `json:"mfa_enabled"`
+	Profile    *Profile  `json:"profile,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// VerificationRequest requests a new email verification code
+type VerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// VerificationConfirmRequest confirms an email address with a verification code
+type VerificationConfirmRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Code  string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// ForgotPasswordRequest requests a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password using a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"` // Checked against the password policy
+}
+
+// ChangePasswordRequest changes the password of the authenticated user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"` // Checked against the password policy
+}
+
+// MagicLinkRequest requests a passwordless login link by email
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// MagicLinkVerifyRequest exchanges a login link token for tokens
+type MagicLinkVerifyRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// OAuthNonceResponse is a nonce to pass to the provider for one sign-in or link
+type OAuthNonceResponse struct {
+	Nonce     string    `json:"nonce"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// OAuthLoginRequest signs in with a provider ID token
+type OAuthLoginRequest struct {
+	IDToken string `json:"id_token" binding:"required"`
+	Nonce   string `json:"nonce" binding:"required"`                     // From POST /auth/oauth/nonce, as passed to the provider
+	Role    string `json:"role" binding:"omitempty,oneof=mentor mentee"` // Used only when a new account is created; mentor opens an application
+}
+
+// OAuthLinkRequest links a provider identity to the signed-in user
+type OAuthLinkRequest struct {
+	IDToken string `json:"id_token" binding:"required"`
+	Nonce   string `json:"nonce" binding:"required"` // From POST /auth/oauth/link/nonce, as passed to the provider
+}
+
+// MFACodeRequest carries a TOTP or recovery code for the signed-in user
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFAChangeRequest confirms turning off two-factor authentication or
+// replacing the recovery codes
+type MFAChangeRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// MFAVerifyRequest completes a two-step login
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP code or recovery code
+}
+
+// MFAChallengeResponse is returned by login when a second factor is required
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"` // Challenge lifetime in seconds
+}
+
+// MFASetupResponse contains the TOTP secret for enrollment
+type MFASetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI to render as a QR code
+}
+
+// MFARecoveryCodesResponse lists one-time recovery codes. They are shown only once.
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SessionResponse describes a login session to its owner
+type SessionResponse struct {
+	ID         uuid.UUID `json:"id"`
+	DeviceName string    `json:"device_name"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	Current    bool      `json:"current"` // Session of the access token making the request
+}
+
+// CreateAPIKeyRequest creates a personal API key
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1,dive,oneof=read write"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"` // Defaults to 90
+}
+
+// APIKeyResponse describes a personal API key to its owner
+type APIKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateAPIKeyResponse returns a new API key. Key is shown only once.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// MentorApplicationRequest applies to become a mentor
+type MentorApplicationRequest struct {
+	Motivation string `json:"motivation" binding:"max=2000"`
+}
+
+// MentorApplicationDecisionRequest approves or rejects a mentor application
+type MentorApplicationDecisionRequest struct {
+	Reason string `json:"reason" binding:"max=1000"` // Required when rejecting
+}
+
+// UpdateRoleRequest assigns a role to a user
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required,max=50"`
+}
+
+// ImpersonationRequest starts an admin impersonation of a user
+type ImpersonationRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"` // Recorded in the audit log, e.g. a support ticket
+}
+
+// ImpersonationResponse carries a short-lived access token acting as the user
+type ImpersonationResponse struct {
+	User      UserResponse `json:"user"`
+	Token     string       `json:"token"`
+	ExpiresIn int64        `json:"expires_in"` // Token lifetime in seconds, there is no refresh token
+	ActorID   uuid.UUID    `json:"actor_id"`
 }
 
 // ErrorResponse represents error response
@@ -68,6 +359,7 @@ type ErrorResponse struct {
 	Error   string `json:"error"`
 	Message string `json:"message,omitempty"`
 	Code    int    `json:"code,omitempty"`
+	Field   string `json:"field,omitempty"` // Request field the error refers to, for validation errors
 }
 
 // CreateProfileRequest represents profile creation data
@@ -79,6 +371,16 @@ type CreateProfileRequest struct {
 	Expertise []string `json:"expertise"`
 	Interests []string `json:"interests"`
 	Location  string   `json:"location"`
+	// Privacy settings, all off by default and visible to everyone
+	ShowLastName      bool     `json:"show_last_name"`
+	ShowExactLocation bool     `json:"show_exact_location"`
+	HiddenFields      []string `json:"hidden_fields" binding:"omitempty,dive,oneof=first_name last_name bio avatar_url expertise interests location languages availability"`
+	Visibility        string   `json:"visibility" binding:"omitempty,oneof=everyone signed_in opposite_role"` // Defaults to everyone
+	HiddenFromSearch  bool     `json:"hidden_from_search"`
+	// Matching
+	Languages    []string `json:"languages" binding:"omitempty,dive,len=2,lowercase"`
+	Availability []string `json:"availability" binding:"omitempty,dive,oneof=weekday_morning weekday_afternoon weekday_evening weekend_morning weekend_afternoon weekend_evening"`
+	MaxMentees   *int     `json:"max_mentees" binding:"omitempty,min=0,max=20"` // Defaults to 3
 }
 
 // UpdateProfileRequest represents profile update data (all fields optional)
@@ -91,6 +393,16 @@ type UpdateProfileRequest struct {
 	Interests *[]string `json:"interests,omitempty"`
 	Location  *string   `json:"location,omitempty"`
 	IsActive  *bool     `json:"is_active,omitempty"`
+	// Privacy settings
+	ShowLastName      *bool     `json:"show_last_name,omitempty"`
+	ShowExactLocation *bool     `json:"show_exact_location,omitempty"`
+	HiddenFields      *[]string `json:"hidden_fields,omitempty" binding:"omitempty,dive,oneof=first_name last_name bio avatar_url expertise interests location languages availability"`
+	Visibility        *string   `json:"visibility,omitempty" binding:"omitnil,oneof=everyone signed_in opposite_role"` // An empty string is rejected, not stored
+	HiddenFromSearch  *bool     `json:"hidden_from_search,omitempty"`
+	// Matching
+	Languages    *[]string `json:"languages,omitempty" binding:"omitempty,dive,len=2,lowercase"`
+	Availability *[]string `json:"availability,omitempty" binding:"omitempty,dive,oneof=weekday_morning weekday_afternoon weekday_evening weekend_morning weekend_afternoon weekend_evening"`
+	MaxMentees   *int      `json:"max_mentees,omitempty" binding:"omitempty,min=0,max=20"`
 }
 
 // ProfileFilters represents filters for profile search
@@ -98,5 +410,24 @@ type ProfileFilters struct {
 	Expertise *[]string `json:"expertise,omitempty"`
 	Interests *[]string `json:"interests,omitempty"`
 	Location  string    `json:"location,omitempty"`
-	Role      string    `json:"role,omitempty"` // mentor, mentee, admin
+	// Municipality codes, from the near and region filters
+	Municipalities *[]string `json:"municipalities,omitempty"`
+	Role           string    `json:"role,omitempty"` // mentor, mentee, admin
+	Query          string    `json:"q,omitempty"`    // Free text matched against name, bio, expertise and interests
+	// Scope is what the viewer may see; nil searches as an anonymous caller
+	Scope *ProfileSearchScope `json:"-"`
+}
+
+// ProfileSearchResult is a profile found by a search
+type ProfileSearchResult struct {
+	Profile   *Profile
+	Rank      float64 // Relevance to ProfileFilters.Query, 0 without a query
+	Highlight string  // Bio excerpt, HTML-escaped with matches in <mark>; empty without a query
+}
+
+// ProfileSearchScope limits a profile search to the visibility levels open to the viewer
+type ProfileSearchScope struct {
+	All          bool   // Staff see every visibility level and hidden field
+	SignedIn     bool   // Profiles visible to signed-in users
+	OppositeRole string // Owners with this role also show profiles visible to the opposite role
 }
@@ -4,11 +4,19 @@ package gormrepo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
 	"mentori/internal/models"
 	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // userRepository implements UserRepository using GORM
@@ -33,6 +41,15 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 	return &user, err
 }
 
+func (r *userRepository) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var users []*models.User
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	err := r.db.WithContext(ctx).Preload("Profile").Where("email = ?", email).First(&user).Error
@@ -42,10 +59,26 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 	return &user, err
 }
 
+func (r *userRepository) GetByProvider(ctx context.Context, provider, providerID string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Preload("Profile").Where("provider = ? AND provider_id = ?", provider, providerID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &user, err
+}
+
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
 }
 
+func (r *userRepository) AdvanceMFAStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND mfa_last_step < ?", id, step).
+		Update("mfa_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
 }
@@ -85,28 +118,1022 @@ func (r *profileRepository) Update(ctx context.Context, profile *models.Profile)
 	return r.db.WithContext(ctx).Save(profile).Error
 }
 
+func (r *profileRepository) SetAvatar(ctx context.Context, id uuid.UUID, previousKey, avatarURL, avatarKey string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Profile{}).
+		Where("id = ? AND avatar_key = ?", id, previousKey).
+		Updates(map[string]interface{}{
+			"avatar_url": avatarURL,
+			"avatar_key": avatarKey,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
 func (r *profileRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&models.Profile{}, id).Error
 }
 
-func (r *profileRepository) Search(ctx context.Context, filters *models.ProfileFilters, limit, offset int) ([]*models.Profile, error) {
-	query := r.db.WithContext(ctx).Where("is_active = ?", true)
+func (r *profileRepository) Search(ctx context.Context, filters *models.ProfileFilters, limit int, after *pagination.Cursor) ([]*models.ProfileSearchResult, error) {
+	query := r.searchQuery(ctx, filters)
+	if filters.Query != "" {
+		query = query.Select(`profiles.*,
+				(ts_rank(profiles.search_vector, search.fi || search.en || search.simple) + ? * word_similarity(search.q, profiles.search_text))::float8 AS search_rank,
+				CASE
+					WHEN COALESCE(profiles.bio, '') = '' THEN ''
+					WHEN to_tsvector('finnish', profiles.bio) @@ search.fi THEN ts_headline('finnish', profiles.bio, search.fi, ?)
+					ELSE ts_headline('english', profiles.bio, search.en || search.simple, ?)
+				END AS search_highlight`,
+			constants.ProfileSearchTrigramWeight, headlineOptions, headlineOptions)
+		// The rank is computed, so the keyset applies to the ranked rows
+		query = r.db.WithContext(ctx).Table("(?) AS profiles", query)
+		if after != nil {
+			if after.Rank == nil {
+				return nil, pagination.ErrInvalidCursor
+			}
+			query = query.Where("(profiles.search_rank < ? OR (profiles.search_rank = ? AND (profiles.created_at, profiles.id) > (?, ?)))",
+				*after.Rank, *after.Rank, after.CreatedAt, after.ID)
+		}
+		query = query.Order("profiles.search_rank DESC, profiles.created_at, profiles.id")
+	} else {
+		if after != nil {
+			if after.Rank != nil {
+				return nil, pagination.ErrInvalidCursor
+			}
+			query = query.Where("(profiles.created_at, profiles.id) > (?, ?)", after.CreatedAt, after.ID)
+		}
+		query = query.Select("profiles.*").Order("profiles.created_at, profiles.id")
+	}
+
+	var rows []profileSearchRow
+	if err := query.Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	results := make([]*models.ProfileSearchResult, 0, len(rows))
+	for i := range rows {
+		results = append(results, &models.ProfileSearchResult{
+			Profile:   &rows[i].Profile,
+			Rank:      rows[i].SearchRank,
+			Highlight: highlightHTML(rows[i].SearchHighlight),
+		})
+	}
+	return results, nil
+}
+
+func (r *profileRepository) Count(ctx context.Context, filters *models.ProfileFilters) (int64, error) {
+	var count int64
+	err := r.searchQuery(ctx, filters).Count(&count).Error
+	return count, err
+}
+
+// searchQuery selects the profiles matching the filters that the viewer may find
+func (r *profileRepository) searchQuery(ctx context.Context, filters *models.ProfileFilters) *gorm.DB {
+	query := r.db.WithContext(ctx).Table("profiles").
+		Joins("JOIN users ON profiles.user_id = users.id").
+		Where("profiles.is_active = ? AND NOT profiles.hidden_from_search", true)
+
+	// Only profiles the viewer may see, filtered on fields the owner has not hidden
+	scope := filters.Scope
+	if scope == nil {
+		scope = &models.ProfileSearchScope{}
+	}
+	if !scope.All {
+		visibilities := []string{constants.ProfileVisibilityEveryone}
+		if scope.SignedIn {
+			visibilities = append(visibilities, constants.ProfileVisibilitySignedIn)
+		}
+		if scope.OppositeRole != "" {
+			query = query.Where("(profiles.visibility IN ? OR (profiles.visibility = ? AND users.role = ?))",
+				visibilities, constants.ProfileVisibilityOppositeRole, scope.OppositeRole)
+		} else {
+			query = query.Where("profiles.visibility IN ?", visibilities)
+		}
+	}
+	searchable := func(field string) {
+		if !scope.All {
+			query = query.Where("NOT COALESCE(profiles.hidden_fields, '[]'::jsonb) @> ?::jsonb", `["`+field+`"]`)
+		}
+	}
 
 	// Apply filters
+	// The terms are jsonb arrays of slugs. jsonb_exists_any is the ?| operator,
+	// which GORM would take for a placeholder.
 	if filters.Expertise != nil && len(*filters.Expertise) > 0 {
-		query = query.Where("expertise && ?", *filters.Expertise)
+		query = query.Where(anyTerm("profiles.expertise", *filters.Expertise))
+		searchable("expertise")
 	}
 	if filters.Interests != nil && len(*filters.Interests) > 0 {
-		query = query.Where("interests && ?", *filters.Interests)
+		query = query.Where(anyTerm("profiles.interests", *filters.Interests))
+		searchable("interests")
 	}
 	if filters.Location != "" {
-		query = query.Where("location ILIKE ?", "%"+filters.Location+"%")
+		// Viewers only see the broadest part of a location the owner keeps
+		// coarse, see ProfilePrivacyService, so only that part is matched
+		location := "profiles.location"
+		if !scope.All {
+			location = `CASE WHEN profiles.show_exact_location THEN profiles.location
+				ELSE btrim(regexp_replace(profiles.location, '^.*,', '')) END`
+		}
+		query = query.Where(location+" ILIKE ?", "%"+filters.Location+"%")
+		searchable("location")
+	}
+	if filters.Municipalities != nil {
+		query = query.Where("profiles.municipality_code IN ?", *filters.Municipalities)
+		searchable("location")
 	}
 	if filters.Role != "" {
-		query = query.Joins("JOIN users ON profiles.user_id = users.id").Where("users.role = ?", filters.Role)
+		query = query.Where("users.role = ?", filters.Role)
+	}
+
+	// Free text. search_vector and search_text are kept up to date by a
+	// trigger and leave out hidden fields, see migration 025.
+	if filters.Query != "" {
+		query = query.
+			Joins("CROSS JOIN (SELECT websearch_to_tsquery('finnish', ?) AS fi, websearch_to_tsquery('english', ?) AS en, websearch_to_tsquery('simple', ?) AS simple, ?::text AS q) AS search",
+				filters.Query, filters.Query, filters.Query, filters.Query).
+			Where("(profiles.search_vector @@ (search.fi || search.en || search.simple) OR search.q <% profiles.search_text)")
+	}
+	return query
+}
+
+// anyTerm matches rows whose jsonb array column holds any of the terms
+func anyTerm(column string, terms []string) clause.Expr {
+	return clause.Expr{
+		SQL:                "jsonb_exists_any(" + column + ", ARRAY[?]::text[])",
+		Vars:               []interface{}{terms},
+		WithoutParentheses: true,
+	}
+}
+
+// keyset sorts a query on the table's creation time and ID, newest or oldest
+// first, and keeps the rows after the cursor
+func keyset(query *gorm.DB, table string, newestFirst bool, after *pagination.Cursor) *gorm.DB {
+	direction, comparison := "ASC", ">"
+	if newestFirst {
+		direction, comparison = "DESC", "<"
+	}
+	if after != nil {
+		query = query.Where(fmt.Sprintf("(%[1]s.created_at, %[1]s.id) %[2]s (?, ?)", table, comparison), after.CreatedAt, after.ID)
+	}
+	return query.Order(fmt.Sprintf("%[1]s.created_at %[2]s, %[1]s.id %[2]s", table, direction))
+}
+
+// profileSearchRow is a profile with the columns Search computes for a query
+type profileSearchRow struct {
+	models.Profile
+	SearchRank      float64
+	SearchHighlight string
+}
+
+// ts_headline marks matches with private-use characters, which highlightHTML
+// turns into <mark> tags once the rest of the excerpt has been escaped
+const (
+	headlineStart = "\ue000"
+	headlineStop  = "\ue001"
+)
+
+var headlineOptions = fmt.Sprintf(`StartSel="%s", StopSel="%s", MaxWords=%d, MinWords=%d, MaxFragments=2`,
+	headlineStart, headlineStop, constants.ProfileSearchHighlightWords, constants.ProfileSearchHighlightWords/2)
+
+func highlightHTML(excerpt string) string {
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(html.EscapeString(excerpt))
+}
+
+// mentorshipRepository implements MentorshipRepository using GORM
+type mentorshipRepository struct {
+	db *gorm.DB
+}
+
+func NewMentorshipRepository(db *gorm.DB) repository.MentorshipRepository {
+	return &mentorshipRepository{db: db}
+}
+
+func (r *mentorshipRepository) ActiveBetween(ctx context.Context, userA, userB uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Mentorship{}).
+		Where("status = ?", constants.MentorshipActive).
+		Where("((mentor_id = ? AND mentee_id = ?) OR (mentor_id = ? AND mentee_id = ?))", userA, userB, userB, userA).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *mentorshipRepository) ActivePartners(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	var partners []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.Mentorship{}).
+		Select("CASE WHEN mentor_id = ? THEN mentee_id ELSE mentor_id END", userID).
+		Where("status = ? AND (mentor_id = ? OR mentee_id = ?)", constants.MentorshipActive, userID, userID).
+		Scan(&partners).Error
+	return partners, err
+}
+
+func (r *mentorshipRepository) ActiveMenteeCounts(ctx context.Context, mentorIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	counts := make(map[uuid.UUID]int, len(mentorIDs))
+	if len(mentorIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		MentorID uuid.UUID
+		Count    int
+	}
+	err := r.db.WithContext(ctx).Model(&models.Mentorship{}).
+		Select("mentor_id, COUNT(*) AS count").
+		Where("status = ? AND mentor_id IN ?", constants.MentorshipActive, mentorIDs).
+		Group("mentor_id").
+		Scan(&rows).Error
+	for _, row := range rows {
+		counts[row.MentorID] = row.Count
+	}
+	return counts, err
+}
+
+func (r *mentorshipRepository) ActiveMentors(ctx context.Context, menteeIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	mentors := make(map[uuid.UUID][]uuid.UUID, len(menteeIDs))
+	if len(menteeIDs) == 0 {
+		return mentors, nil
+	}
+	var rows []struct {
+		MentorID uuid.UUID
+		MenteeID uuid.UUID
+	}
+	err := r.db.WithContext(ctx).Model(&models.Mentorship{}).
+		Select("mentor_id, mentee_id").
+		Where("status = ? AND mentee_id IN ?", constants.MentorshipActive, menteeIDs).
+		Scan(&rows).Error
+	for _, row := range rows {
+		mentors[row.MenteeID] = append(mentors[row.MenteeID], row.MentorID)
+	}
+	return mentors, err
+}
+
+// refreshTokenRepository implements RefreshTokenRepository using GORM
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) repository.RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &token, err
+}
+
+func (r *refreshTokenRepository) MarkReplaced(ctx context.Context, id, replacedByID uuid.UUID) (bool, error) {
+	// Conditional update so that two concurrent refreshes cannot both succeed
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"replaced_by_id": replacedByID,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// emailVerificationRepository implements EmailVerificationRepository using GORM
+type emailVerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationRepository(db *gorm.DB) repository.EmailVerificationRepository {
+	return &emailVerificationRepository{db: db}
+}
+
+func (r *emailVerificationRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
+	return r.db.WithContext(ctx).Create(verification).Error
+}
+
+func (r *emailVerificationRepository) GetLatest(ctx context.Context, email string) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	err := r.db.WithContext(ctx).Where("email = ?", email).Order("created_at DESC").First(&verification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &verification, err
+}
+
+func (r *emailVerificationRepository) CountSince(ctx context.Context, email string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.EmailVerification{}).
+		Where("email = ? AND created_at > ?", email, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *emailVerificationRepository) ConsumeAttempt(ctx context.Context, id uuid.UUID, max int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.EmailVerification{}).
+		Where("id = ? AND attempts < ?", id, max).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *emailVerificationRepository) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.EmailVerification{}).
+		Where("id = ?", id).
+		Update("is_used", true).Error
+}
+
+func (r *emailVerificationRepository) InvalidateAll(ctx context.Context, email string) error {
+	return r.db.WithContext(ctx).Model(&models.EmailVerification{}).
+		Where("email = ? AND is_used = ?", email, false).
+		Update("is_used", true).Error
+}
+
+// passwordResetRepository implements PasswordResetRepository using GORM
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) repository.PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *passwordResetRepository) GetByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &token, err
+}
+
+func (r *passwordResetRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *passwordResetRepository) InvalidateAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *passwordResetRepository) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
+
+// mfaChallengeRepository implements MFAChallengeRepository using GORM
+type mfaChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewMFAChallengeRepository(db *gorm.DB) repository.MFAChallengeRepository {
+	return &mfaChallengeRepository{db: db}
+}
+
+func (r *mfaChallengeRepository) Create(ctx context.Context, challenge *models.MFAChallenge) error {
+	return r.db.WithContext(ctx).Create(challenge).Error
+}
+
+func (r *mfaChallengeRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.MFAChallenge, error) {
+	var challenge models.MFAChallenge
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&challenge).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &challenge, err
+}
+
+func (r *mfaChallengeRepository) ConsumeAttempt(ctx context.Context, id uuid.UUID, max int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.MFAChallenge{}).
+		Where("id = ? AND attempts < ?", id, max).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *mfaChallengeRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.MFAChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// oauthNonceRepository implements OAuthNonceRepository using GORM
+type oauthNonceRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthNonceRepository(db *gorm.DB) repository.OAuthNonceRepository {
+	return &oauthNonceRepository{db: db}
+}
+
+func (r *oauthNonceRepository) Create(ctx context.Context, nonce *models.OAuthNonce) error {
+	return r.db.WithContext(ctx).Create(nonce).Error
+}
+
+func (r *oauthNonceRepository) Consume(ctx context.Context, nonceHash string, sessionID uuid.UUID) (bool, error) {
+	query := r.db.WithContext(ctx).Where("nonce_hash = ? AND expires_at > ?", nonceHash, time.Now())
+	if sessionID == uuid.Nil {
+		query = query.Where("session_id IS NULL")
+	} else {
+		query = query.Where("session_id = ?", sessionID)
+	}
+	result := query.Delete(&models.OAuthNonce{})
+	return result.RowsAffected == 1, result.Error
+}
+
+// magicLinkRepository implements MagicLinkRepository using GORM
+type magicLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewMagicLinkRepository(db *gorm.DB) repository.MagicLinkRepository {
+	return &magicLinkRepository{db: db}
+}
+
+func (r *magicLinkRepository) Create(ctx context.Context, token *models.MagicLinkToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *magicLinkRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.MagicLinkToken, error) {
+	var token models.MagicLinkToken
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &token, err
+}
+
+func (r *magicLinkRepository) GetLatest(ctx context.Context, email string) (*models.MagicLinkToken, error) {
+	var token models.MagicLinkToken
+	err := r.db.WithContext(ctx).Where("email = ?", email).Order("created_at DESC").First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &token, err
+}
+
+func (r *magicLinkRepository) CountSince(ctx context.Context, email string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.MagicLinkToken{}).
+		Where("email = ? AND created_at > ?", email, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *magicLinkRepository) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.MagicLinkToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *magicLinkRepository) InvalidateAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.MagicLinkToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+// userIdentityRepository implements UserIdentityRepository using GORM
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) repository.UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+func (r *userIdentityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *userIdentityRepository) GetByProvider(ctx context.Context, provider, providerID string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND provider_id = ?", provider, providerID).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &identity, err
+}
+
+func (r *userIdentityRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error) {
+	var identities []*models.UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	return identities, err
+}
+
+func (r *userIdentityRepository) Delete(ctx context.Context, userID uuid.UUID, provider string) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.UserIdentity{}).Error
+}
+
+// mfaRecoveryCodeRepository implements MFARecoveryCodeRepository using GORM
+type mfaRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARecoveryCodeRepository(db *gorm.DB) repository.MFARecoveryCodeRepository {
+	return &mfaRecoveryCodeRepository{db: db}
+}
+
+func (r *mfaRecoveryCodeRepository) ReplaceAll(ctx context.Context, userID uuid.UUID, codes []*models.MFARecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *mfaRecoveryCodeRepository) Consume(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *mfaRecoveryCodeRepository) DeleteAll(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+}
+
+// authSessionRepository implements AuthSessionRepository using GORM
+type authSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewAuthSessionRepository(db *gorm.DB) repository.AuthSessionRepository {
+	return &authSessionRepository{db: db}
+}
+
+func (r *authSessionRepository) Create(ctx context.Context, session *models.AuthSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *authSessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error) {
+	var session models.AuthSession
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &session, err
+}
+
+func (r *authSessionRepository) ListActiveByUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.AuthSession, error) {
+	var sessions []*models.AuthSession
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND last_used_at > ?", userID, since).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *authSessionRepository) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
+
+func (r *authSessionRepository) Revoke(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *authSessionRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// apiKeyRepository implements APIKeyRepository using GORM
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) repository.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
 	}
+	return &key, err
+}
+
+func (r *apiKeyRepository) ListActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", at).Error
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// loginThrottleRepository implements LoginThrottleRepository using GORM
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) repository.LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) Get(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.WithContext(ctx).Where("key = ?", key).First(&throttle).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &throttle, err
+}
+
+func (r *loginThrottleRepository) RecordFailure(ctx context.Context, key string, at, windowStart time.Time) (int, error) {
+	var failures int
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < ? THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures`, key, at, windowStart).Scan(&failures).Error
+	return failures, err
+}
+
+func (r *loginThrottleRepository) SetLockedUntil(ctx context.Context, key string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.LoginThrottle{}).
+		Where("key = ?", key).
+		Update("locked_until", until).Error
+}
+
+func (r *loginThrottleRepository) Delete(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&models.LoginThrottle{}).Error
+}
+
+// lockoutEventRepository implements LockoutEventRepository using GORM
+type lockoutEventRepository struct {
+	db *gorm.DB
+}
+
+func NewLockoutEventRepository(db *gorm.DB) repository.LockoutEventRepository {
+	return &lockoutEventRepository{db: db}
+}
+
+func (r *lockoutEventRepository) Create(ctx context.Context, event *models.LockoutEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *lockoutEventRepository) ListRecent(ctx context.Context, limit int, after *pagination.Cursor) ([]*models.LockoutEvent, error) {
+	var events []*models.LockoutEvent
+	err := keyset(r.db.WithContext(ctx), "lockout_events", true, after).Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// impersonationLogRepository implements ImpersonationLogRepository using GORM
+type impersonationLogRepository struct {
+	db *gorm.DB
+}
+
+func NewImpersonationLogRepository(db *gorm.DB) repository.ImpersonationLogRepository {
+	return &impersonationLogRepository{db: db}
+}
+
+func (r *impersonationLogRepository) Create(ctx context.Context, entry *models.ImpersonationLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *impersonationLogRepository) List(ctx context.Context, subjectID *uuid.UUID, limit int, after *pagination.Cursor) ([]*models.ImpersonationLog, error) {
+	query := keyset(r.db.WithContext(ctx), "impersonation_logs", true, after).Limit(limit)
+	if subjectID != nil {
+		query = query.Where("subject_id = ?", *subjectID)
+	}
+	var entries []*models.ImpersonationLog
+	err := query.Find(&entries).Error
+	return entries, err
+}
+
+// mentorApplicationRepository implements MentorApplicationRepository using GORM
+type mentorApplicationRepository struct {
+	db *gorm.DB
+}
+
+func NewMentorApplicationRepository(db *gorm.DB) repository.MentorApplicationRepository {
+	return &mentorApplicationRepository{db: db}
+}
+
+func (r *mentorApplicationRepository) Create(ctx context.Context, application *models.MentorApplication) error {
+	return r.db.WithContext(ctx).Create(application).Error
+}
+
+func (r *mentorApplicationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.MentorApplication, error) {
+	var application models.MentorApplication
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&application).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &application, err
+}
+
+func (r *mentorApplicationRepository) GetLatestByUser(ctx context.Context, userID uuid.UUID) (*models.MentorApplication, error) {
+	var application models.MentorApplication
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&application).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &application, err
+}
+
+func (r *mentorApplicationRepository) List(ctx context.Context, status string, limit int, after *pagination.Cursor) ([]*models.MentorApplication, error) {
+	query := r.db.WithContext(ctx).
+		Select("mentor_applications.*, users.email").
+		Joins("JOIN users ON users.id = mentor_applications.user_id")
+	query = keyset(query, "mentor_applications", false, after).Limit(limit)
+	if status != "" {
+		query = query.Where("mentor_applications.status = ?", status)
+	}
+	var applications []*models.MentorApplication
+	err := query.Find(&applications).Error
+	return applications, err
+}
+
+func (r *mentorApplicationRepository) Decide(ctx context.Context, id uuid.UUID, status, reason string, reviewerID uuid.UUID, at time.Time) (bool, error) {
+	decided := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.MentorApplication{}).
+			Where("id = ? AND status = ?", id, constants.MentorApplicationPending).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"reason":      reason,
+				"reviewed_by": reviewerID,
+				"reviewed_at": at,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if status == constants.MentorApplicationApproved {
+			applicant := tx.Model(&models.MentorApplication{}).Select("user_id").Where("id = ?", id)
+			if err := tx.Model(&models.User{}).
+				Where("id = (?) AND role = ?", applicant, constants.RoleMentee).
+				Updates(map[string]interface{}{
+					"role":       constants.RoleMentor,
+					"updated_at": at,
+				}).Error; err != nil {
+				return err
+			}
+		}
+		decided = true
+		return nil
+	})
+	return decided, err
+}
+
+// cohortRepository implements CohortRepository using GORM
+type cohortRepository struct {
+	db *gorm.DB
+}
+
+func NewCohortRepository(db *gorm.DB) repository.CohortRepository {
+	return &cohortRepository{db: db}
+}
+
+func (r *cohortRepository) Create(ctx context.Context, cohort *models.Cohort) error {
+	return r.db.WithContext(ctx).Create(cohort).Error
+}
+
+func (r *cohortRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Cohort, error) {
+	var cohort models.Cohort
+	err := r.db.WithContext(ctx).
+		Preload("Mentors").
+		Preload("Pairs", func(db *gorm.DB) *gorm.DB { return db.Order("score DESC") }).
+		Where("id = ?", id).First(&cohort).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &cohort, err
+}
+
+func (r *cohortRepository) List(ctx context.Context, limit int, after *pagination.Cursor) ([]*models.Cohort, error) {
+	var cohorts []*models.Cohort
+	err := keyset(r.db.WithContext(ctx), "cohorts", true, after).Limit(limit).Find(&cohorts).Error
+	return cohorts, err
+}
+
+func (r *cohortRepository) UpdatePair(ctx context.Context, pair *models.CohortPair) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.CohortPair{}).
+		Where("cohort_id = ? AND mentee_id = ?", pair.CohortID, pair.MenteeID).
+		Where("EXISTS (SELECT 1 FROM cohorts WHERE cohorts.id = cohort_pairs.cohort_id AND cohorts.status = ?)", constants.CohortDraft).
+		Updates(map[string]interface{}{
+			"mentor_id": pair.MentorID,
+			"score":     pair.Score,
+			"factors":   pair.Factors,
+			"adjusted":  pair.Adjusted,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *cohortRepository) Publish(ctx context.Context, id, publisherID uuid.UUID, at time.Time) (bool, error) {
+	published := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Cohort{}).
+			Where("id = ? AND status = ?", id, constants.CohortDraft).
+			Updates(map[string]interface{}{
+				"status":       constants.CohortPublished,
+				"published_by": publisherID,
+				"published_at": at,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		var pairs []models.CohortPair
+		if err := tx.Where("cohort_id = ? AND mentor_id IS NOT NULL", id).Find(&pairs).Error; err != nil {
+			return err
+		}
+		if len(pairs) > 0 {
+			mentorships := make([]models.Mentorship, len(pairs))
+			for i, pair := range pairs {
+				mentorships[i] = models.Mentorship{
+					MentorID:  *pair.MentorID,
+					MenteeID:  pair.MenteeID,
+					Status:    constants.MentorshipActive,
+					CreatedAt: at,
+				}
+			}
+			// idx_mentorships_active_pair rejects pairs that became active meanwhile
+			if err := tx.Create(&mentorships).Error; err != nil {
+				return err
+			}
+		}
+		published = true
+		return nil
+	})
+	return published, err
+}
+
+// roleRepository implements RoleRepository using GORM
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) repository.RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) List(ctx context.Context) ([]*models.Role, error) {
+	var roles []*models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
+// taxonomyRepository implements TaxonomyRepository using GORM
+type taxonomyRepository struct {
+	db *gorm.DB
+}
+
+func NewTaxonomyRepository(db *gorm.DB) repository.TaxonomyRepository {
+	return &taxonomyRepository{db: db}
+}
+
+func (r *taxonomyRepository) List(ctx context.Context) ([]*models.TaxonomyTerm, error) {
+	var terms []*models.TaxonomyTerm
+	err := r.db.WithContext(ctx).Order("kind, sort_order, slug").Find(&terms).Error
+	return terms, err
+}
+
+// availabilityRepository implements AvailabilityRepository using GORM
+type availabilityRepository struct {
+	db *gorm.DB
+}
+
+func NewAvailabilityRepository(db *gorm.DB) repository.AvailabilityRepository {
+	return &availabilityRepository{db: db}
+}
+
+func (r *availabilityRepository) GetCalendar(ctx context.Context, mentorID uuid.UUID) (*models.MentorCalendar, error) {
+	var calendar models.MentorCalendar
+	err := r.db.WithContext(ctx).Where("mentor_id = ?", mentorID).First(&calendar).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, repository.ErrNotFound
+	}
+	return &calendar, err
+}
+
+func (r *availabilityRepository) ReplaceWeekly(ctx context.Context, calendar *models.MentorCalendar, rules []*models.AvailabilityRule) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(calendar).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("mentor_id = ?", calendar.MentorID).Delete(&models.AvailabilityRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(&rules).Error
+	})
+}
+
+func (r *availabilityRepository) ListWeekly(ctx context.Context, mentorID uuid.UUID) ([]*models.AvailabilityRule, error) {
+	var rules []*models.AvailabilityRule
+	err := r.db.WithContext(ctx).Where("mentor_id = ?", mentorID).Order("weekday, start_time").Find(&rules).Error
+	return rules, err
+}
+
+func (r *availabilityRepository) CreateOverride(ctx context.Context, override *models.AvailabilityOverride) error {
+	return r.db.WithContext(ctx).Create(override).Error
+}
+
+func (r *availabilityRepository) ListOverrides(ctx context.Context, mentorID uuid.UUID, from, to string) ([]*models.AvailabilityOverride, error) {
+	// YYYY-MM-DD dates sort as text
+	query := r.db.WithContext(ctx).Where("mentor_id = ? AND date >= ?", mentorID, from)
+	if to != "" {
+		query = query.Where("date <= ?", to)
+	}
+	var overrides []*models.AvailabilityOverride
+	err := query.Order("date, start_time").Find(&overrides).Error
+	return overrides, err
+}
+
+func (r *availabilityRepository) DeleteOverride(ctx context.Context, mentorID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND mentor_id = ?", id, mentorID).Delete(&models.AvailabilityOverride{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *availabilityRepository) CreateBlackout(ctx context.Context, blackout *models.AvailabilityBlackout) error {
+	return r.db.WithContext(ctx).Create(blackout).Error
+}
+
+func (r *availabilityRepository) ListBlackouts(ctx context.Context, mentorID uuid.UUID, from, to time.Time) ([]*models.AvailabilityBlackout, error) {
+	query := r.db.WithContext(ctx).Where("mentor_id = ? AND ends_at > ?", mentorID, from)
+	if !to.IsZero() {
+		query = query.Where("starts_at < ?", to)
+	}
+	var blackouts []*models.AvailabilityBlackout
+	err := query.Order("starts_at").Find(&blackouts).Error
+	return blackouts, err
+}
+
+func (r *availabilityRepository) DeleteBlackout(ctx context.Context, mentorID, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND mentor_id = ?", id, mentorID).Delete(&models.AvailabilityBlackout{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// mentoringSessionRepository implements MentoringSessionRepository using GORM
+type mentoringSessionRepository struct {
+	db *gorm.DB
+}
+
+func NewMentoringSessionRepository(db *gorm.DB) repository.MentoringSessionRepository {
+	return &mentoringSessionRepository{db: db}
+}
 
-	var profiles []*models.Profile
-	err := query.Limit(limit).Offset(offset).Find(&profiles).Error
-	return profiles, err
+func (r *mentoringSessionRepository) ListBooked(ctx context.Context, mentorID uuid.UUID, from, to time.Time) ([]*models.MentoringSession, error) {
+	var sessions []*models.MentoringSession
+	err := r.db.WithContext(ctx).
+		Where("mentor_id = ? AND status IN ?", mentorID, constants.BookedSessionStatuses).
+		Where("scheduled_at < ? AND scheduled_at + duration * interval '1 minute' > ?", to, from).
+		Order("scheduled_at").
+		Find(&sessions).Error
+	return sessions, err
 }
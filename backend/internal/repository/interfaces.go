@@ -5,6 +5,8 @@ import (
 	"context"
 	"errors"
 	"mentori/internal/models"
+	"mentori/pkg/pagination"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -18,8 +20,15 @@ var (
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	// GetByIDs returns the users that exist of the given IDs, in no particular order
+	GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// GetByProvider finds a user by the OAuth identity they signed up with
+	GetByProvider(ctx context.Context, provider, providerID string) (*models.User, error)
 	Update(ctx context.Context, user *models.User) error
+	// AdvanceMFAStep records step as the last accepted TOTP step. It returns
+	// false if the user already accepted this step or a later one.
+	AdvanceMFAStep(ctx context.Context, id uuid.UUID, step int64) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -29,6 +38,224 @@ type ProfileRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*models.Profile, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Profile, error)
 	Update(ctx context.Context, profile *models.Profile) error
+	// SetAvatar points the profile at a new avatar, leaving its other fields
+	// alone. It returns false if the avatar key is no longer previousKey.
+	SetAvatar(ctx context.Context, id uuid.UUID, previousKey, avatarURL, avatarKey string) (bool, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	Search(ctx context.Context, filters *models.ProfileFilters, limit, offset int) ([]*models.Profile, error)
+	// Search returns up to limit profiles after the cursor, by relevance when
+	// filters has a query and oldest first otherwise
+	Search(ctx context.Context, filters *models.ProfileFilters, limit int, after *pagination.Cursor) ([]*models.ProfileSearchResult, error)
+	// Count returns how many profiles Search would find in total
+	Count(ctx context.Context, filters *models.ProfileFilters) (int64, error)
+}
+
+// MentorshipRepository defines the interface for mentorships
+type MentorshipRepository interface {
+	// ActiveBetween reports whether two users are in an active mentorship, in either direction
+	ActiveBetween(ctx context.Context, userA, userB uuid.UUID) (bool, error)
+	// ActivePartners returns everyone in an active mentorship with the user, in either role
+	ActivePartners(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	// ActiveMenteeCounts returns how many active mentees each of the mentors has
+	ActiveMenteeCounts(ctx context.Context, mentorIDs []uuid.UUID) (map[uuid.UUID]int, error)
+	// ActiveMentors returns the current mentors of each of the mentees
+	ActiveMentors(ctx context.Context, menteeIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
+}
+
+// RefreshTokenRepository defines the interface for refresh token storage
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// MarkReplaced revokes an active token and records its successor.
+	// It returns false if the token had already been revoked.
+	MarkReplaced(ctx context.Context, id, replacedByID uuid.UUID) (bool, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// EmailVerificationRepository defines the interface for email verification codes
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, verification *models.EmailVerification) error
+	// GetLatest returns the most recently created code for an email, used or not
+	GetLatest(ctx context.Context, email string) (*models.EmailVerification, error)
+	// CountSince counts the codes created for an email after since
+	CountSince(ctx context.Context, email string, since time.Time) (int64, error)
+	// ConsumeAttempt counts an attempt at the code unless max attempts were
+	// already made, in one conditional update; false if none were left
+	ConsumeAttempt(ctx context.Context, id uuid.UUID, max int) (bool, error)
+	MarkUsed(ctx context.Context, id uuid.UUID) error
+	// InvalidateAll marks every unused code for an email as used
+	InvalidateAll(ctx context.Context, email string) error
+}
+
+// PasswordResetRepository defines the interface for password reset tokens
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	// MarkUsed consumes a token. It returns false if the token was already used.
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	// InvalidateAllForUser consumes every outstanding token of a user
+	InvalidateAllForUser(ctx context.Context, userID uuid.UUID) error
+	// CountSince returns how many tokens were issued to the user after since
+	CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error)
+}
+
+// MagicLinkRepository defines the interface for passwordless login links
+type MagicLinkRepository interface {
+	Create(ctx context.Context, token *models.MagicLinkToken) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.MagicLinkToken, error)
+	// GetLatest returns the most recently created link for an email
+	GetLatest(ctx context.Context, email string) (*models.MagicLinkToken, error)
+	// CountSince counts the links created for an email after since
+	CountSince(ctx context.Context, email string, since time.Time) (int64, error)
+	// MarkUsed consumes a link. It returns false if the link was already used.
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	// InvalidateAllForUser consumes every outstanding link of a user
+	InvalidateAllForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// UserIdentityRepository defines the interface for linked OAuth identities
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	GetByProvider(ctx context.Context, provider, providerID string) (*models.UserIdentity, error)
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error)
+	Delete(ctx context.Context, userID uuid.UUID, provider string) error
+}
+
+// MFARecoveryCodeRepository defines the interface for MFA recovery codes
+type MFARecoveryCodeRepository interface {
+	// ReplaceAll deletes a user's existing codes and stores the new set
+	ReplaceAll(ctx context.Context, userID uuid.UUID, codes []*models.MFARecoveryCode) error
+	// Consume marks a matching unused code as used. It returns false if none matched.
+	Consume(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	DeleteAll(ctx context.Context, userID uuid.UUID) error
+}
+
+// MFAChallengeRepository defines the interface for pending second login steps
+type MFAChallengeRepository interface {
+	Create(ctx context.Context, challenge *models.MFAChallenge) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.MFAChallenge, error)
+	// ConsumeAttempt counts a code attempt. It returns false once max attempts were made.
+	ConsumeAttempt(ctx context.Context, id uuid.UUID, max int) (bool, error)
+	// MarkUsed completes a challenge. It returns false if it was already completed.
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// OAuthNonceRepository defines the interface for server-issued OAuth nonces
+type OAuthNonceRepository interface {
+	Create(ctx context.Context, nonce *models.OAuthNonce) error
+	// Consume deletes an unexpired nonce issued to the session, or to no
+	// session for uuid.Nil. It returns false if none matched.
+	Consume(ctx context.Context, nonceHash string, sessionID uuid.UUID) (bool, error)
+}
+
+// APIKeyRepository defines the interface for personal API keys
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	ListActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.APIKey, error)
+	Touch(ctx context.Context, id uuid.UUID, at time.Time) error
+	Revoke(ctx context.Context, userID, id uuid.UUID) (bool, error)
+}
+
+// AuthSessionRepository defines the interface for login sessions
+type AuthSessionRepository interface {
+	Create(ctx context.Context, session *models.AuthSession) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error)
+	// ListActiveByUser returns unrevoked sessions used after since, most recent first
+	ListActiveByUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.AuthSession, error)
+	Touch(ctx context.Context, id uuid.UUID, at time.Time) error
+	Revoke(ctx context.Context, id uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// LoginThrottleRepository defines the interface for failed login counters
+type LoginThrottleRepository interface {
+	Get(ctx context.Context, key string) (*models.LoginThrottle, error)
+	// RecordFailure increments the counter, restarting it when the last failure
+	// is older than windowStart, and returns the new count
+	RecordFailure(ctx context.Context, key string, at, windowStart time.Time) (int, error)
+	SetLockedUntil(ctx context.Context, key string, until time.Time) error
+	Delete(ctx context.Context, key string) error
+}
+
+// RoleRepository defines the interface for roles and their permissions
+type RoleRepository interface {
+	// List returns every role with its permissions
+	List(ctx context.Context) ([]*models.Role, error)
+}
+
+// TaxonomyRepository defines the interface for expertise and interest terms
+type TaxonomyRepository interface {
+	// List returns every term, including deprecated and merged ones
+	List(ctx context.Context) ([]*models.TaxonomyTerm, error)
+}
+
+// ImpersonationLogRepository defines the interface for the impersonation audit log
+type ImpersonationLogRepository interface {
+	Create(ctx context.Context, entry *models.ImpersonationLog) error
+	// List returns the newest entries, optionally only those for one impersonated user
+	List(ctx context.Context, subjectID *uuid.UUID, limit int, after *pagination.Cursor) ([]*models.ImpersonationLog, error)
+}
+
+// MentorApplicationRepository defines the interface for mentor applications
+type MentorApplicationRepository interface {
+	Create(ctx context.Context, application *models.MentorApplication) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.MentorApplication, error)
+	GetLatestByUser(ctx context.Context, userID uuid.UUID) (*models.MentorApplication, error)
+	// List returns the oldest applications first, optionally only those with one status
+	List(ctx context.Context, status string, limit int, after *pagination.Cursor) ([]*models.MentorApplication, error)
+	// Decide records a decision on a pending application; false if it was already decided.
+	// Approving also makes a mentee applicant a mentor, in the same transaction.
+	Decide(ctx context.Context, id uuid.UUID, status, reason string, reviewerID uuid.UUID, at time.Time) (bool, error)
+}
+
+// CohortRepository defines the interface for cohort assignments
+type CohortRepository interface {
+	// Create stores a cohort with its mentor pool and pairs
+	Create(ctx context.Context, cohort *models.Cohort) error
+	// GetByID returns a cohort with its mentor pool and pairs
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Cohort, error)
+	// List returns the newest cohorts first, without their pools and pairs
+	List(ctx context.Context, limit int, after *pagination.Cursor) ([]*models.Cohort, error)
+	// UpdatePair replaces a pair of a draft cohort; false if the cohort is no longer a draft
+	UpdatePair(ctx context.Context, pair *models.CohortPair) (bool, error)
+	// Publish marks a draft cohort published and creates an active mentorship
+	// for each assigned pair, in one transaction; false if it was already published
+	Publish(ctx context.Context, id, publisherID uuid.UUID, at time.Time) (bool, error)
+}
+
+// AvailabilityRepository defines the interface for mentor availability calendars
+type AvailabilityRepository interface {
+	// GetCalendar returns the mentor's calendar settings, ErrNotFound if never set
+	GetCalendar(ctx context.Context, mentorID uuid.UUID) (*models.MentorCalendar, error)
+	// ReplaceWeekly saves the calendar settings and replaces the mentor's weekly windows, in one transaction
+	ReplaceWeekly(ctx context.Context, calendar *models.MentorCalendar, rules []*models.AvailabilityRule) error
+	// ListWeekly returns the mentor's weekly windows by weekday and start time
+	ListWeekly(ctx context.Context, mentorID uuid.UUID) ([]*models.AvailabilityRule, error)
+	CreateOverride(ctx context.Context, override *models.AvailabilityOverride) error
+	// ListOverrides returns the mentor's overrides dated from from to to, both
+	// inclusive, by date and start time; an empty to has no upper bound
+	ListOverrides(ctx context.Context, mentorID uuid.UUID, from, to string) ([]*models.AvailabilityOverride, error)
+	// DeleteOverride deletes one of the mentor's overrides; false if there is none with the ID
+	DeleteOverride(ctx context.Context, mentorID, id uuid.UUID) (bool, error)
+	CreateBlackout(ctx context.Context, blackout *models.AvailabilityBlackout) error
+	// ListBlackouts returns the mentor's blackouts that end after from and,
+	// unless to is zero, start before to, earliest first
+	ListBlackouts(ctx context.Context, mentorID uuid.UUID, from, to time.Time) ([]*models.AvailabilityBlackout, error)
+	// DeleteBlackout deletes one of the mentor's blackouts; false if there is none with the ID
+	DeleteBlackout(ctx context.Context, mentorID, id uuid.UUID) (bool, error)
+}
+
+// MentoringSessionRepository defines the interface for booked mentoring sessions
+type MentoringSessionRepository interface {
+	// ListBooked returns the mentor's sessions in constants.BookedSessionStatuses
+	// that overlap the period from from to to, earliest first
+	ListBooked(ctx context.Context, mentorID uuid.UUID, from, to time.Time) ([]*models.MentoringSession, error)
+}
+
+// LockoutEventRepository defines the interface for lockout events
+type LockoutEventRepository interface {
+	Create(ctx context.Context, event *models.LockoutEvent) error
+	// ListRecent returns the newest events first
+	ListRecent(ctx context.Context, limit int, after *pagination.Cursor) ([]*models.LockoutEvent, error)
 }
@@ -0,0 +1,314 @@
+// Package server wires the services and handlers of the API and registers its
+// routes. cmd/server runs it over the database; tests run the same routes
+// over in-memory repositories.
+package server
+
+import (
+	"net/http"
+
+	"mentori/internal/handlers"
+	"mentori/internal/middleware"
+	"mentori/internal/repository"
+	"mentori/internal/services"
+	"mentori/pkg/config"
+	"mentori/pkg/constants"
+	"mentori/pkg/storage"
+	"mentori/pkg/utils"
+	"mentori/pkg/validators"
+
+	"github.com/gin-contrib/gzip"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// Repositories holds the storage the services work on
+type Repositories struct {
+	Users              repository.UserRepository
+	Profiles           repository.ProfileRepository
+	RefreshTokens      repository.RefreshTokenRepository
+	EmailVerifications repository.EmailVerificationRepository
+	PasswordResets     repository.PasswordResetRepository
+	Identities         repository.UserIdentityRepository
+	MFARecoveryCodes   repository.MFARecoveryCodeRepository
+	MFAChallenges      repository.MFAChallengeRepository
+	OAuthNonces        repository.OAuthNonceRepository
+	Sessions           repository.AuthSessionRepository
+	LoginThrottles     repository.LoginThrottleRepository
+	LockoutEvents      repository.LockoutEventRepository
+	MagicLinks         repository.MagicLinkRepository
+	ImpersonationLogs  repository.ImpersonationLogRepository
+	Roles              repository.RoleRepository
+	APIKeys            repository.APIKeyRepository
+	MentorApplications repository.MentorApplicationRepository
+	Mentorships        repository.MentorshipRepository
+	Taxonomy           repository.TaxonomyRepository
+	Cohorts            repository.CohortRepository
+	Availability       repository.AvailabilityRepository
+	MentoringSessions  repository.MentoringSessionRepository
+}
+
+// Dependencies are the outside services the server talks to, created by the
+// caller from the configuration
+type Dependencies struct {
+	Email   utils.EmailSender
+	Avatars storage.Store
+	Tokens  *services.TokenIssuer
+	OAuth   *services.OAuthService // Started by the caller to refresh its keys
+}
+
+// Server serves the API
+type Server struct {
+	router            *gin.Engine
+	emailVerification *services.EmailVerificationService
+	passwordReset     *services.PasswordResetService
+	magicLinks        *services.MagicLinkService
+}
+
+// New creates the services and handlers and registers the routes
+func New(cfg *config.Config, repos Repositories, deps Dependencies) *Server {
+	passwordPolicy := validators.PasswordPolicy{
+		MinLength:  cfg.PasswordMinLength,
+		MinClasses: cfg.PasswordMinClasses,
+	}
+
+	// Initialize services
+	refreshTokenService := services.NewRefreshTokenService(repos.RefreshTokens)
+	sessionService := services.NewSessionService(repos.Sessions, refreshTokenService)
+	emailVerificationService := services.NewEmailVerificationService(repos.EmailVerifications, repos.Users, deps.Email)
+	passwordResetService := services.NewPasswordResetService(repos.PasswordResets, repos.Users, sessionService, deps.Email, cfg.FrontendURL, passwordPolicy)
+	oauthAccountService := services.NewOAuthAccountService(deps.OAuth, repos.Users, repos.Identities, repos.OAuthNonces)
+	authorizationService := services.NewAuthorizationService(repos.Roles)
+	apiKeyService := services.NewAPIKeyService(repos.APIKeys, repos.Users)
+	mentorApplicationService := services.NewMentorApplicationService(repos.MentorApplications, repos.Users, deps.Email)
+	profilePrivacyService := services.NewProfilePrivacyService(authorizationService, repos.Mentorships)
+	avatarService := services.NewAvatarService(repos.Profiles, deps.Avatars)
+	taxonomyService := services.NewTaxonomyService(repos.Taxonomy)
+	matchingService := services.NewMatchingService(repos.Profiles, repos.Users, repos.Mentorships, profilePrivacyService, services.MatchWeights{
+		Expertise:    cfg.MatchWeightExpertise,
+		Interests:    cfg.MatchWeightInterests,
+		Location:     cfg.MatchWeightLocation,
+		Language:     cfg.MatchWeightLanguage,
+		Availability: cfg.MatchWeightAvailability,
+		Capacity:     cfg.MatchWeightCapacity,
+	})
+	cohortService := services.NewCohortService(repos.Cohorts, repos.Profiles, repos.Users, repos.Mentorships, matchingService)
+	availabilityService := services.NewAvailabilityService(repos.Availability, repos.MentoringSessions, repos.Users, repos.Profiles, profilePrivacyService)
+	mfaService := services.NewMFAService(repos.Users, repos.MFARecoveryCodes, repos.MFAChallenges, authorizationService)
+	loginThrottleService := services.NewLoginThrottleService(repos.LoginThrottles, repos.LockoutEvents)
+	magicLinkService := services.NewMagicLinkService(repos.MagicLinks, repos.Users, deps.Tokens, deps.Email, cfg.FrontendURL)
+	impersonationService := services.NewImpersonationService(repos.ImpersonationLogs, repos.Users, deps.Tokens, authorizationService)
+
+	// Initialize handlers with repositories directly
+	authHandler := handlers.NewAuthHandler(repos.Users, sessionService, deps.Tokens, loginThrottleService, mfaService, passwordPolicy, mentorApplicationService)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(emailVerificationService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
+	oauthHandler := handlers.NewOAuthHandler(oauthAccountService, authHandler)
+	mfaHandler := handlers.NewMFAHandler(mfaService, authHandler)
+	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkService, authHandler)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	mentorApplicationHandler := handlers.NewMentorApplicationHandler(mentorApplicationService)
+	taxonomyHandler := handlers.NewTaxonomyHandler(taxonomyService)
+	matchingHandler := handlers.NewMatchingHandler(matchingService)
+	cohortHandler := handlers.NewCohortHandler(cohortService)
+	availabilityHandler := handlers.NewAvailabilityHandler(availabilityService)
+	profileHandler := handlers.NewProfileHandler(repos.Profiles, repos.Users, profilePrivacyService, avatarService, taxonomyService) // Profile handler for swagger generation
+	adminHandler := handlers.NewAdminHandler(repos.Users, repos.Profiles, sessionService, loginThrottleService, impersonationService, authorizationService)
+
+	// Initialize Gin router
+	r := gin.New() // 🚀 OPTIMIZATION: Use gin.New() instead of gin.Default() for custom middleware
+
+	// 🚀 OPTIMIZATION: Add middleware in optimal order for performance
+	r.Use(middleware.SecurityHeaders())       // Security first
+	r.Use(middleware.CORS())                  // CORS
+	r.Use(gin.Recovery())                     // Recovery
+	r.Use(gzip.Gzip(gzip.DefaultCompression)) // Compression
+	r.Use(middleware.RateLimit())             // Rate limiting
+	r.Use(middleware.Logger())                // Logging last for performance
+
+	// 🚀 OPTIMIZATION: Enhanced health check endpoint
+	r.GET("/health", handlers.HealthCheck)
+	r.GET("/ready", handlers.ReadinessCheck)
+
+	// Public keys for verifying access tokens
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Uploaded avatars when they are stored on the local filesystem
+	if cfg.AvatarStorage == "local" {
+		r.Static("/uploads", cfg.UploadsDir)
+	}
+
+	// Swagger UI
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// API v1 routes
+	v1 := r.Group("/api/v1")
+	v1.Use(middleware.AuditImpersonation(impersonationService))
+	{
+		// Auth routes
+		// Credential endpoints share one strict per-IP limiter
+		authLimit := middleware.StrictRateLimitMiddleware()
+		auth := v1.Group("/auth")
+		{
+			auth.POST("/register", authLimit, authHandler.Register)
+			auth.POST("/login", authLimit, authHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
+			auth.POST("/logout", authHandler.Logout)
+			auth.GET("/profile", middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService), authHandler.GetProfile) // Get current user profile
+			auth.POST("/verify-email/request", authLimit, emailVerificationHandler.RequestCode)
+			auth.POST("/verify-email/confirm", authLimit, emailVerificationHandler.ConfirmCode)
+			auth.POST("/forgot-password", authLimit, passwordResetHandler.ForgotPassword)
+			auth.POST("/reset-password", authLimit, passwordResetHandler.ResetPassword)
+			auth.POST("/oauth/nonce", authLimit, oauthHandler.IssueNonce)
+			auth.POST("/oauth/:provider", authLimit, oauthHandler.Login)
+			auth.POST("/mfa/verify", authLimit, mfaHandler.Verify)
+			auth.POST("/magic/request", authLimit, magicLinkHandler.RequestLink)
+			auth.POST("/magic/verify", authLimit, magicLinkHandler.Verify)
+		}
+
+		// Two-factor enrollment routes (require authentication)
+		mfa := v1.Group("/auth/mfa")
+		mfa.Use(middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService))
+		mfa.Use(middleware.RejectAPIKey())
+		mfa.Use(middleware.RejectImpersonation())
+		{
+			mfa.POST("/setup", mfaHandler.Setup)
+			mfa.POST("/enable", authLimit, mfaHandler.Enable)
+			mfa.POST("/disable", authLimit, middleware.RequireMFA(), mfaHandler.Disable)
+			mfa.POST("/recovery-codes", authLimit, middleware.RequireMFA(), mfaHandler.RegenerateRecoveryCodes)
+		}
+
+		// Password change (requires authentication)
+		password := v1.Group("/auth/password")
+		password.Use(middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService))
+		password.Use(middleware.RejectAPIKey())
+		{
+			password.POST("", authLimit, middleware.RejectImpersonation(), authHandler.ChangePassword)
+		}
+
+		// Login session routes (require authentication)
+		sessions := v1.Group("/auth/sessions")
+		sessions.Use(middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService))
+		sessions.Use(middleware.RejectAPIKey())
+		{
+			sessions.GET("", sessionHandler.ListSessions)
+			sessions.POST("/revoke-others", middleware.RejectImpersonation(), sessionHandler.RevokeOtherSessions)
+			sessions.DELETE("/:id", middleware.RejectImpersonation(), sessionHandler.RevokeSession)
+		}
+
+		// Personal API key routes (require a signed-in user, not another API key)
+		apiKeys := v1.Group("/auth/api-keys")
+		apiKeys.Use(middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService))
+		apiKeys.Use(middleware.RejectAPIKey())
+		apiKeys.Use(middleware.RejectImpersonation())
+		{
+			apiKeys.POST("", apiKeyHandler.CreateAPIKey)
+			apiKeys.GET("", apiKeyHandler.ListAPIKeys)
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+		}
+
+		// Mentor application routes (require authentication)
+		mentorApplication := v1.Group("/auth/mentor-application")
+		mentorApplication.Use(middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService))
+		mentorApplication.Use(middleware.RejectAPIKey())
+		mentorApplication.Use(middleware.RejectImpersonation())
+		{
+			mentorApplication.POST("", mentorApplicationHandler.Apply)
+			mentorApplication.GET("", mentorApplicationHandler.GetMine)
+		}
+
+		// Account linking routes (require authentication)
+		oauthLinks := v1.Group("/auth/oauth")
+		oauthLinks.Use(middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService))
+		oauthLinks.Use(middleware.RejectAPIKey())
+		{
+			oauthLinks.GET("/identities", oauthHandler.ListIdentities)
+			oauthLinks.POST("/link/nonce", middleware.RejectImpersonation(), oauthHandler.IssueNonce)
+			oauthLinks.POST("/:provider/link", middleware.RejectImpersonation(), oauthHandler.Link)
+			oauthLinks.DELETE("/:provider/link", middleware.RejectImpersonation(), oauthHandler.Unlink)
+		}
+
+		// Expertise and interest terms for profile forms
+		v1.GET("/taxonomy", taxonomyHandler.GetTaxonomy)
+
+		// Profile search is open to anonymous callers, who only find profiles
+		// visible to everyone
+		v1.GET("/profiles/public", middleware.OptionalJWTAuth(deps.Tokens, sessionService, apiKeyService), profileHandler.GetPublicProfiles)
+
+		// Profile routes (require authentication)
+		profiles := v1.Group("/profiles")
+		profiles.Use(middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService))
+		{
+			profiles.POST("", middleware.RejectImpersonation(), profileHandler.CreateProfile)
+			profiles.GET("", profileHandler.GetMyProfile)
+			profiles.PUT("", middleware.RejectImpersonation(), profileHandler.UpdateProfile)
+			profiles.DELETE("", middleware.RejectAPIKey(), middleware.RejectImpersonation(), profileHandler.DeleteProfile)
+			profiles.POST("/me/image", middleware.RejectImpersonation(), profileHandler.UploadAvatar)
+			profiles.GET("/:id", profileHandler.GetProfileByID)
+		}
+
+		// Suggested mentors for mentees and mentees for mentors
+		v1.GET("/matches", middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService), matchingHandler.GetMatches)
+
+		// Mentors' availability calendars (require authentication)
+		availability := v1.Group("/availability")
+		availability.Use(middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService))
+		{
+			availability.GET("", availabilityHandler.GetAvailability)
+			availability.PUT("", middleware.RejectImpersonation(), availabilityHandler.UpdateAvailability)
+			availability.POST("/overrides", middleware.RejectImpersonation(), availabilityHandler.CreateOverride)
+			availability.DELETE("/overrides/:id", middleware.RejectImpersonation(), availabilityHandler.DeleteOverride)
+			availability.POST("/blackouts", middleware.RejectImpersonation(), availabilityHandler.CreateBlackout)
+			availability.DELETE("/blackouts/:id", middleware.RejectImpersonation(), availabilityHandler.DeleteBlackout)
+		}
+
+		// Times a mentor can be booked
+		v1.GET("/mentors/:id/open-slots", middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService), availabilityHandler.GetOpenSlots)
+
+		// Admin routes (require authentication and a permission per route)
+		perms := middleware.NewAuthorizer(authorizationService)
+		admin := v1.Group("/admin")
+		admin.Use(middleware.JWTAuth(deps.Tokens, sessionService, apiKeyService))
+		admin.Use(middleware.RejectAPIKey())
+		admin.Use(middleware.RejectImpersonation())
+		if cfg.AdminMFARequired {
+			admin.Use(middleware.RequireMFA())
+		}
+		{
+			admin.DELETE("/users/:userId", perms.Require(constants.PermissionUsersDelete), adminHandler.DeleteUser)
+			admin.PUT("/users/:userId/role", perms.Require(constants.PermissionUsersManageRoles), adminHandler.UpdateUserRole)
+			admin.POST("/users/:userId/unlock", perms.Require(constants.PermissionUsersUnlock), adminHandler.UnlockUser)
+			admin.GET("/lockouts", perms.Require(constants.PermissionAuditRead), adminHandler.ListLockouts)
+			admin.POST("/users/:userId/impersonate", perms.Require(constants.PermissionUsersImpersonate), adminHandler.ImpersonateUser)
+			admin.GET("/impersonation-logs", perms.Require(constants.PermissionAuditRead), adminHandler.ListImpersonationLogs)
+			admin.GET("/mentor-applications", perms.Require(constants.PermissionMentorsApprove), mentorApplicationHandler.ListApplications)
+			admin.POST("/mentor-applications/:id/approve", perms.Require(constants.PermissionMentorsApprove), mentorApplicationHandler.Approve)
+			admin.POST("/mentor-applications/:id/reject", perms.Require(constants.PermissionMentorsApprove), mentorApplicationHandler.Reject)
+			admin.POST("/cohorts", perms.Require(constants.PermissionMentorshipsAssign), cohortHandler.CreateCohort)
+			admin.GET("/cohorts", perms.Require(constants.PermissionMentorshipsAssign), cohortHandler.ListCohorts)
+			admin.GET("/cohorts/:id", perms.Require(constants.PermissionMentorshipsAssign), cohortHandler.GetCohort)
+			admin.PUT("/cohorts/:id/pairs/:menteeId", perms.Require(constants.PermissionMentorshipsAssign), cohortHandler.UpdateCohortPair)
+			admin.POST("/cohorts/:id/publish", perms.Require(constants.PermissionMentorshipsAssign), cohortHandler.PublishCohort)
+		}
+	}
+
+	return &Server{
+		router:            r,
+		emailVerification: emailVerificationService,
+		passwordReset:     passwordResetService,
+		magicLinks:        magicLinkService,
+	}
+}
+
+// Handler returns the HTTP handler serving every route
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Wait blocks until emails already being sent have gone out
+func (s *Server) Wait() {
+	s.emailVerification.Wait()
+	s.passwordReset.Wait()
+	s.magicLinks.Wait()
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// API key errors
+var (
+	ErrAPIKeyInvalid  = errors.New("API key is invalid, expired or revoked")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrAPIKeyLimit    = errors.New("too many active API keys, revoke one first")
+)
+
+// APIKeyService issues and checks personal API keys. A key authenticates as
+// its owner with the owner's current role, limited to the key's scopes.
+type APIKeyService struct {
+	repo     repository.APIKeyRepository
+	userRepo repository.UserRepository
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(repo repository.APIKeyRepository, userRepo repository.UserRepository) *APIKeyService {
+	return &APIKeyService{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+// Create issues a key for the user. The raw key is returned once and never stored.
+// A ttl of zero uses APIKeyDefaultExpiry.
+func (s *APIKeyService) Create(ctx context.Context, userID uuid.UUID, name string, scopes []string, ttl time.Duration) (string, *models.APIKey, error) {
+	now := time.Now()
+	active, err := s.repo.ListActiveByUser(ctx, userID, now)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(active) >= constants.APIKeyMaxPerUser {
+		return "", nil, ErrAPIKeyLimit
+	}
+	if ttl == 0 {
+		ttl = constants.APIKeyDefaultExpiry
+	}
+
+	idBytes := make([]byte, 6)
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", nil, err
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", nil, err
+	}
+	prefix := constants.APIKeyPrefix + hex.EncodeToString(idBytes)
+	raw := prefix + "_" + base64.RawURLEncoding.EncodeToString(secretBytes)
+
+	key := &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   HashToken(raw),
+		Scopes:    strings.Join(normalizeScopes(scopes), " "),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return "", nil, err
+	}
+	logger.Info("API key %s created for user %s with scopes %q", key.Prefix, userID, key.Scopes)
+	return raw, key, nil
+}
+
+// List returns the user's active keys, newest first
+func (s *APIKeyService) List(ctx context.Context, userID uuid.UUID) ([]*models.APIKey, error) {
+	return s.repo.ListActiveByUser(ctx, userID, time.Now())
+}
+
+// Revoke disables one of the user's keys
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID uuid.UUID) error {
+	ok, err := s.repo.Revoke(ctx, userID, keyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	logger.Info("API key %s revoked by user %s", keyID, userID)
+	return nil
+}
+
+// Authenticate checks a raw key and returns it with its owner
+func (s *APIKeyService) Authenticate(ctx context.Context, raw string) (*models.APIKey, *models.User, error) {
+	prefix, ok := keyPrefix(raw)
+	if !ok {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+	key, err := s.repo.GetByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrAPIKeyInvalid
+		}
+		return nil, nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(HashToken(raw))) != 1 {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || now.After(key.ExpiresAt) {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrAPIKeyInvalid
+		}
+		return nil, nil, err
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > constants.APIKeyTouchInterval {
+		if err := s.repo.Touch(ctx, key.ID, now); err != nil {
+			return nil, nil, err
+		}
+		key.LastUsedAt = &now
+	}
+	return key, user, nil
+}
+
+// APIKeyScopes splits a key's stored scopes
+func APIKeyScopes(key *models.APIKey) []string {
+	return strings.Fields(key.Scopes)
+}
+
+// APIKeyAllows reports whether the key may make a request with the given
+// HTTP method: reads need the read scope, anything else the write scope
+func APIKeyAllows(key *models.APIKey, method string) bool {
+	scope := constants.APIKeyScopeWrite
+	if method == http.MethodGet || method == http.MethodHead {
+		scope = constants.APIKeyScopeRead
+	}
+	return slices.Contains(APIKeyScopes(key), scope)
+}
+
+// keyPrefix extracts the lookup prefix from "mnt_<prefix>_<secret>"
+func keyPrefix(raw string) (string, bool) {
+	rest, ok := strings.CutPrefix(raw, constants.APIKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	id, secret, ok := strings.Cut(rest, "_")
+	if !ok || id == "" || secret == "" {
+		return "", false
+	}
+	return constants.APIKeyPrefix + id, true
+}
+
+func normalizeScopes(scopes []string) []string {
+	result := slices.Clone(scopes)
+	slices.Sort(result)
+	return slices.Compact(result)
+}
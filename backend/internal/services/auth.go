@@ -3,38 +3,16 @@ package services
 import (
 	"context"
 	"errors"
-	"fmt"
-	"os"
 	"time"
 
 	"mentori/internal/models"
 	"mentori/pkg/database"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-var jwtSecret = []byte(getJWTSecret())
-
-// getJWTSecret retrieves JWT secret from environment variable
-func getJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		// Check environment mode
-		goEnv := os.Getenv("GO_ENV")
-		if goEnv == "production" {
-			panic("❌ FATAL: JWT_SECRET must be set in production environment!")
-		}
-		// Warn in development
-		fmt.Println("⚠️  WARNING: Using default JWT secret (development only)")
-		fmt.Println("   Set JWT_SECRET environment variable for production!")
-		secret = "dev-only-secret-not-for-production"
-	}
-	return secret
-}
-
 // AuthService handles authentication operations
 type AuthService struct{}
 
@@ -55,41 +33,6 @@ func (s *AuthService) CheckPassword(password, hash string) bool {
 	return err == nil
 }
 
-// GenerateToken generates a JWT token for a user
-func (s *AuthService) GenerateToken(user *models.User) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"role":    user.Role,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(), // 24 hours
-		"iat":     time.Now().Unix(),
-		"iss":     "mentori-api", // 🚀 OPTIMIZATION: Add issuer
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
-}
-
-// ValidateToken validates a JWT token and returns the claims
-func (s *AuthService) ValidateToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return jwtSecret, nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
-	}
-
-	return nil, errors.New("invalid token")
-}
-
 // RegisterUser creates a new user account
 func (s *AuthService) RegisterUser(req *models.RegisterRequest) (*models.User, error) {
 	// 🚀 OPTIMIZATION: Add timeout context for database operations
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+)
+
+// AuthorizationService answers whether a role holds a permission. Roles and
+// their permissions are stored in the database, so new roles such as a
+// moderator need no code change; permissions must be in constants.Permissions.
+type AuthorizationService struct {
+	repo  repository.RoleRepository
+	roles *reloadingCache[map[string]map[string]bool]
+}
+
+// NewAuthorizationService creates a new authorization service. Roles are
+// loaded on first use and reloaded every RolePermissionsCacheTTL.
+func NewAuthorizationService(repo repository.RoleRepository) *AuthorizationService {
+	s := &AuthorizationService{repo: repo}
+	s.roles = newReloadingCache("AuthorizationService", constants.RolePermissionsCacheTTL, s.load)
+	return s
+}
+
+// Can reports whether the role has been granted the permission
+func (s *AuthorizationService) Can(ctx context.Context, role, permission string) (bool, error) {
+	roles, err := s.roles.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	return roles[role][permission], nil
+}
+
+// RoleExists reports whether the role is defined
+func (s *AuthorizationService) RoleExists(ctx context.Context, role string) (bool, error) {
+	roles, err := s.roles.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, ok := roles[role]
+	return ok, nil
+}
+
+// Covers reports whether the role holds every permission of the other role,
+// so that granting other cannot give anyone more than role already has
+func (s *AuthorizationService) Covers(ctx context.Context, role, other string) (bool, error) {
+	roles, err := s.roles.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	for permission := range roles[other] {
+		if !roles[role][permission] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Reload reads roles and permissions from the database, skipping permissions
+// that are not in the registry
+func (s *AuthorizationService) Reload(ctx context.Context) error {
+	_, err := s.roles.reload(ctx)
+	return err
+}
+
+func (s *AuthorizationService) load(ctx context.Context) (map[string]map[string]bool, error) {
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(constants.Permissions))
+	for _, permission := range constants.Permissions {
+		known[permission] = true
+	}
+
+	roles := make(map[string]map[string]bool, len(list))
+	for _, role := range list {
+		granted := make(map[string]bool, len(role.Permissions))
+		for _, p := range role.Permissions {
+			if !known[p.Permission] {
+				logger.Warn("Role %s has unknown permission %q, ignoring it", role.Name, p.Permission)
+				continue
+			}
+			granted[p.Permission] = true
+		}
+		roles[role.Name] = granted
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,490 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	_ "time/tzdata" // Calendars name their timezone, which must load without system zoneinfo
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+
+	"github.com/google/uuid"
+)
+
+// Availability errors
+var (
+	ErrAvailabilityInvalid  = errors.New("invalid availability")
+	ErrAvailabilityRole     = errors.New("only mentors have an availability calendar")
+	ErrAvailabilityNotFound = errors.New("availability entry not found")
+	ErrMentorNotFound       = errors.New("mentor not found")
+)
+
+// dateLayout is how override dates are written
+const dateLayout = "2006-01-02"
+
+// AvailabilityService keeps mentors' availability calendars and works out
+// the times mentees can book. A calendar has weekly windows in the mentor's
+// timezone, overrides that replace the windows of a date, and blackouts.
+type AvailabilityService struct {
+	repo     repository.AvailabilityRepository
+	sessions repository.MentoringSessionRepository
+	userRepo repository.UserRepository
+	profiles repository.ProfileRepository
+	privacy  *ProfilePrivacyService
+}
+
+// NewAvailabilityService creates a new availability service
+func NewAvailabilityService(repo repository.AvailabilityRepository, sessions repository.MentoringSessionRepository, userRepo repository.UserRepository, profiles repository.ProfileRepository, privacy *ProfilePrivacyService) *AvailabilityService {
+	return &AvailabilityService{
+		repo:     repo,
+		sessions: sessions,
+		userRepo: userRepo,
+		profiles: profiles,
+		privacy:  privacy,
+	}
+}
+
+// Get returns the mentor's calendar with the overrides and blackouts that are
+// not over yet
+func (s *AvailabilityService) Get(ctx context.Context, mentorID uuid.UUID) (*models.Availability, error) {
+	if err := s.requireMentor(ctx, mentorID, ErrAvailabilityRole); err != nil {
+		return nil, err
+	}
+	calendar, loc, err := s.calendar(ctx, mentorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	weekly, err := s.repo.ListWeekly(ctx, mentorID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.repo.ListOverrides(ctx, mentorID, now.In(loc).Format(dateLayout), "")
+	if err != nil {
+		return nil, err
+	}
+	blackouts, err := s.repo.ListBlackouts(ctx, mentorID, now, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return &models.Availability{
+		Timezone:  calendar.Timezone,
+		Weekly:    nonNil(weekly),
+		Overrides: nonNil(overrides),
+		Blackouts: nonNil(blackouts),
+	}, nil
+}
+
+// Update sets the mentor's timezone and replaces their weekly windows
+func (s *AvailabilityService) Update(ctx context.Context, mentorID uuid.UUID, req *models.UpdateAvailabilityRequest) (*models.Availability, error) {
+	if err := s.requireMentor(ctx, mentorID, ErrAvailabilityRole); err != nil {
+		return nil, err
+	}
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = constants.DefaultTimezone
+	}
+	if _, err := loadTimezone(timezone); err != nil {
+		return nil, err
+	}
+
+	rules := make([]*models.AvailabilityRule, len(req.Weekly))
+	for i, window := range req.Weekly {
+		start, end, err := parseWindow(window.StartTime, window.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		rules[i] = &models.AvailabilityRule{
+			ID:        uuid.New(),
+			MentorID:  mentorID,
+			Weekday:   window.Weekday,
+			StartTime: start,
+			EndTime:   end,
+		}
+	}
+
+	calendar := &models.MentorCalendar{MentorID: mentorID, Timezone: timezone, UpdatedAt: time.Now()}
+	if err := s.repo.ReplaceWeekly(ctx, calendar, rules); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, mentorID)
+}
+
+// AddOverride adds a window on one date, which from then on replaces the
+// weekly windows of that date
+func (s *AvailabilityService) AddOverride(ctx context.Context, mentorID uuid.UUID, req *models.AvailabilityOverrideRequest) (*models.AvailabilityOverride, error) {
+	if err := s.requireMentor(ctx, mentorID, ErrAvailabilityRole); err != nil {
+		return nil, err
+	}
+	_, loc, err := s.calendar(ctx, mentorID)
+	if err != nil {
+		return nil, err
+	}
+	date, err := time.Parse(dateLayout, req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrAvailabilityInvalid)
+	}
+	if req.Date < time.Now().In(loc).Format(dateLayout) {
+		return nil, fmt.Errorf("%w: date %s is in the past", ErrAvailabilityInvalid, req.Date)
+	}
+	start, end, err := parseWindow(req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	override := &models.AvailabilityOverride{
+		ID:        uuid.New(),
+		MentorID:  mentorID,
+		Date:      date.Format(dateLayout),
+		StartTime: start,
+		EndTime:   end,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateOverride(ctx, override); err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// DeleteOverride deletes one of the mentor's overrides
+func (s *AvailabilityService) DeleteOverride(ctx context.Context, mentorID, id uuid.UUID) error {
+	deleted, err := s.repo.DeleteOverride(ctx, mentorID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAvailabilityNotFound
+	}
+	return nil
+}
+
+// AddBlackout adds a period the mentor is away
+func (s *AvailabilityService) AddBlackout(ctx context.Context, mentorID uuid.UUID, req *models.AvailabilityBlackoutRequest) (*models.AvailabilityBlackout, error) {
+	if err := s.requireMentor(ctx, mentorID, ErrAvailabilityRole); err != nil {
+		return nil, err
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrAvailabilityInvalid)
+	}
+	if !req.EndsAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: the blackout is already over", ErrAvailabilityInvalid)
+	}
+
+	blackout := &models.AvailabilityBlackout{
+		ID:        uuid.New(),
+		MentorID:  mentorID,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Reason:    req.Reason,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateBlackout(ctx, blackout); err != nil {
+		return nil, err
+	}
+	return blackout, nil
+}
+
+// DeleteBlackout deletes one of the mentor's blackouts
+func (s *AvailabilityService) DeleteBlackout(ctx context.Context, mentorID, id uuid.UUID) error {
+	deleted, err := s.repo.DeleteBlackout(ctx, mentorID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAvailabilityNotFound
+	}
+	return nil
+}
+
+// OpenSlots returns the times from from to to that the mentor can be booked
+// for slotMinutes, earliest first. Slots follow each other from the start of
+// a window, and those overlapping a blackout or a booked session are left
+// out, as are those already started. Mentors whose profile the viewer may not
+// see are not found. A viewerID of uuid.Nil is an anonymous caller.
+func (s *AvailabilityService) OpenSlots(ctx context.Context, viewerID uuid.UUID, viewerRole string, mentorID uuid.UUID, from, to time.Time, slotMinutes int) ([]models.OpenSlot, error) {
+	if slotMinutes < constants.MinSlotMinutes || slotMinutes > constants.MaxSlotMinutes {
+		return nil, fmt.Errorf("%w: slot_minutes must be between %d and %d", ErrAvailabilityInvalid, constants.MinSlotMinutes, constants.MaxSlotMinutes)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrAvailabilityInvalid)
+	}
+	if to.Sub(from) > constants.MaxOpenSlotsDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: at most %d days at a time", ErrAvailabilityInvalid, constants.MaxOpenSlotsDays)
+	}
+	if err := s.requireVisibleMentor(ctx, viewerID, viewerRole, mentorID); err != nil {
+		return nil, err
+	}
+
+	slots := []models.OpenSlot{}
+	if now := time.Now(); from.Before(now) {
+		from = now
+	}
+	if !to.After(from) {
+		return slots, nil
+	}
+
+	_, loc, err := s.calendar(ctx, mentorID)
+	if err != nil {
+		return nil, err
+	}
+	windows, err := s.windows(ctx, mentorID, loc, from, to)
+	if err != nil {
+		return nil, err
+	}
+	busy, err := s.busy(ctx, mentorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	length := time.Duration(slotMinutes) * time.Minute
+	for _, w := range windows {
+		for start := w.start; !start.Add(length).After(w.end); start = start.Add(length) {
+			end := start.Add(length)
+			if end.After(to) {
+				break
+			}
+			if start.Before(from) || overlapsAny(busy, start, end) {
+				continue
+			}
+			slots = append(slots, models.OpenSlot{StartsAt: start.In(loc), EndsAt: end.In(loc)})
+		}
+	}
+	return slots, nil
+}
+
+// interval is a period from start to end
+type interval struct {
+	start, end time.Time
+}
+
+// windows returns the mentor's windows on the calendar days from from to to,
+// merged where they overlap, earliest first. A date with overrides uses them
+// instead of the weekly windows.
+func (s *AvailabilityService) windows(ctx context.Context, mentorID uuid.UUID, loc *time.Location, from, to time.Time) ([]interval, error) {
+	first, last := from.In(loc), to.In(loc)
+	rules, err := s.repo.ListWeekly(ctx, mentorID)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.repo.ListOverrides(ctx, mentorID, first.Format(dateLayout), last.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	weekly := make(map[time.Weekday][]clockWindow)
+	for _, rule := range rules {
+		weekday := time.Weekday(rule.Weekday)
+		weekly[weekday] = append(weekly[weekday], newClockWindow(rule.StartTime, rule.EndTime))
+	}
+	dated := make(map[string][]clockWindow)
+	for _, override := range overrides {
+		dated[override.Date] = append(dated[override.Date], newClockWindow(override.StartTime, override.EndTime))
+	}
+
+	var result []interval
+	day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+	for !day.After(last) {
+		daily, ok := dated[day.Format(dateLayout)]
+		if !ok {
+			daily = weekly[day.Weekday()]
+		}
+		for _, w := range daily {
+			// time.Date rather than an offset from midnight, so that on days
+			// when clocks change windows still open at the time on the clock
+			result = append(result, interval{
+				start: time.Date(day.Year(), day.Month(), day.Day(), 0, w.start, 0, 0, loc),
+				end:   time.Date(day.Year(), day.Month(), day.Day(), 0, w.end, 0, 0, loc),
+			})
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return mergeIntervals(result), nil
+}
+
+// busy returns the blackouts and booked sessions overlapping from to to
+func (s *AvailabilityService) busy(ctx context.Context, mentorID uuid.UUID, from, to time.Time) ([]interval, error) {
+	blackouts, err := s.repo.ListBlackouts(ctx, mentorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.sessions.ListBooked(ctx, mentorID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interval, 0, len(blackouts)+len(sessions))
+	for _, b := range blackouts {
+		result = append(result, interval{b.StartsAt, b.EndsAt})
+	}
+	for _, session := range sessions {
+		result = append(result, interval{*session.ScheduledAt, session.EndsAt()})
+	}
+	return result, nil
+}
+
+// calendar returns the mentor's calendar settings and its timezone, with the
+// defaults for mentors who never saved them
+func (s *AvailabilityService) calendar(ctx context.Context, mentorID uuid.UUID) (*models.MentorCalendar, *time.Location, error) {
+	calendar, err := s.repo.GetCalendar(ctx, mentorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		calendar, err = &models.MentorCalendar{MentorID: mentorID, Timezone: constants.DefaultTimezone}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := loadTimezone(calendar.Timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+	return calendar, loc, nil
+}
+
+// requireMentor returns notMentor unless the user exists and is a mentor
+func (s *AvailabilityService) requireMentor(ctx context.Context, userID uuid.UUID, notMentor error) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return notMentor
+	}
+	if err != nil {
+		return err
+	}
+	if user.Role != constants.RoleMentor {
+		return notMentor
+	}
+	return nil
+}
+
+// requireVisibleMentor returns ErrMentorNotFound unless the user is a mentor
+// whose profile the viewer may see, as on GET /profiles/:id
+func (s *AvailabilityService) requireVisibleMentor(ctx context.Context, viewerID uuid.UUID, viewerRole string, mentorID uuid.UUID) error {
+	mentor, err := s.userRepo.GetByID(ctx, mentorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrMentorNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if mentor.Role != constants.RoleMentor {
+		return ErrMentorNotFound
+	}
+
+	audience, err := s.privacy.Audience(ctx, viewerID, viewerRole, mentorID)
+	if err != nil {
+		return err
+	}
+	if audience == constants.ProfileViewPrivate {
+		return nil
+	}
+	profile, err := s.profiles.GetByUserID(ctx, mentorID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrMentorNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if !s.privacy.Visible(profile, mentor, viewerRole, audience) {
+		return ErrMentorNotFound
+	}
+	return nil
+}
+
+func loadTimezone(name string) (*time.Location, error) {
+	// "Local" would be the server's zone, not the mentor's
+	if name == "Local" {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrAvailabilityInvalid, name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrAvailabilityInvalid, name)
+	}
+	return loc, nil
+}
+
+// parseWindow checks the start and end of a window on one day and returns
+// them as HH:MM
+func parseWindow(start, end string) (string, string, error) {
+	from, err := parseClock(start)
+	if err != nil {
+		return "", "", err
+	}
+	until, err := parseClock(end)
+	if err != nil {
+		return "", "", err
+	}
+	if until <= from {
+		return "", "", fmt.Errorf("%w: window %s-%s must end after it starts, on the same day", ErrAvailabilityInvalid, start, end)
+	}
+	return formatClock(from), formatClock(until), nil
+}
+
+// parseClock reads an HH:MM time of day as minutes since midnight; 24:00 is
+// the end of the day
+func parseClock(clock string) (int, error) {
+	invalid := fmt.Errorf("%w: time %q must be HH:MM", ErrAvailabilityInvalid, clock)
+	if len(clock) != 5 || clock[2] != ':' {
+		return 0, invalid
+	}
+	hours, err := strconv.Atoi(clock[:2])
+	if err != nil {
+		return 0, invalid
+	}
+	minutes, err := strconv.Atoi(clock[3:])
+	if err != nil || hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes > 0) {
+		return 0, invalid
+	}
+	return hours*60 + minutes, nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// clockWindow is a window on any day, in minutes since midnight
+type clockWindow struct {
+	start, end int
+}
+
+// newClockWindow reads a stored window, which was checked on write
+func newClockWindow(start, end string) clockWindow {
+	from, _ := parseClock(start)
+	until, _ := parseClock(end)
+	return clockWindow{from, until}
+}
+
+// mergeIntervals sorts intervals and joins those that overlap or touch
+func mergeIntervals(intervals []interval) []interval {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+	var merged []interval
+	for _, next := range intervals {
+		if n := len(merged); n > 0 && !next.start.After(merged[n-1].end) {
+			if next.end.After(merged[n-1].end) {
+				merged[n-1].end = next.end
+			}
+			continue
+		}
+		merged = append(merged, next)
+	}
+	return merged
+}
+
+func overlapsAny(intervals []interval, start, end time.Time) bool {
+	for _, other := range intervals {
+		if other.start.Before(end) && other.end.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonNil returns an empty slice for nil, so that lists encode as []
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/imaging"
+	"mentori/pkg/logger"
+	"mentori/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+// Avatar upload errors
+var (
+	ErrAvatarTooLarge    = errors.New("image must be at most 5 MB")
+	ErrAvatarUnsupported = errors.New("image must be a JPEG or PNG")
+	ErrAvatarDimensions  = errors.New("image dimensions are too large")
+	ErrAvatarNoProfile   = errors.New("create a profile before uploading an image")
+	ErrAvatarConflict    = errors.New("the image was replaced by another upload, try again")
+)
+
+// AvatarService turns uploaded photos into square thumbnails, stores them and
+// points the profile at them. The previous upload is deleted once the profile
+// no longer references it.
+type AvatarService struct {
+	profileRepo repository.ProfileRepository
+	store       storage.Store
+	decodes     chan struct{} // Semaphore bounding the images decoded at once
+}
+
+// NewAvatarService creates a new avatar service
+func NewAvatarService(profileRepo repository.ProfileRepository, store storage.Store) *AvatarService {
+	return &AvatarService{
+		profileRepo: profileRepo,
+		store:       store,
+		decodes:     make(chan struct{}, constants.AvatarMaxConcurrentDecodes),
+	}
+}
+
+// Upload replaces the user's avatar with the image. The content type is
+// sniffed from the data rather than trusted from the client, and the image is
+// re-encoded so EXIF data such as GPS coordinates never reaches storage.
+func (s *AvatarService) Upload(ctx context.Context, userID uuid.UUID, data []byte) (*models.AvatarResponse, error) {
+	if len(data) > constants.AvatarMaxBytes {
+		return nil, ErrAvatarTooLarge
+	}
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png":
+	default:
+		return nil, ErrAvatarUnsupported
+	}
+
+	profile, err := s.profileRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrAvatarNoProfile
+		}
+		return nil, err
+	}
+
+	thumbnails, err := s.render(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("avatars/%s/%s", userID, uuid.New())
+	resp := &models.AvatarResponse{Sizes: make(map[string]string, len(constants.AvatarSizes))}
+	for i, size := range constants.AvatarSizes {
+		key := avatarKey(prefix, size)
+		if err := s.store.Put(ctx, key, thumbnails[i], "image/jpeg"); err != nil {
+			s.remove(ctx, prefix)
+			return nil, err
+		}
+		resp.Sizes[strconv.Itoa(size)] = s.store.URL(key)
+	}
+	resp.AvatarURL = s.store.URL(avatarKey(prefix, constants.AvatarDisplaySize))
+
+	// Only the avatar columns are written, so profile edits made while the
+	// image was processed survive. If another upload got there first, its
+	// avatar is kept and this one is dropped.
+	previous := profile.AvatarKey
+	updated, err := s.profileRepo.SetAvatar(ctx, profile.ID, previous, resp.AvatarURL, prefix)
+	if err != nil || !updated {
+		s.remove(ctx, prefix)
+		if err == nil {
+			err = ErrAvatarConflict
+		}
+		return nil, err
+	}
+	logger.Info("Avatar %s uploaded for user %s", prefix, userID)
+
+	if previous != "" {
+		s.remove(ctx, previous)
+	}
+	return resp, nil
+}
+
+// render decodes the image and encodes its thumbnails as JPEGs, largest
+// first. Uploads wait for one of AvatarMaxConcurrentDecodes slots, so a burst
+// of large images cannot exhaust memory.
+func (s *AvatarService) render(ctx context.Context, data []byte) ([][]byte, error) {
+	select {
+	case s.decodes <- struct{}{}:
+		defer func() { <-s.decodes }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	img, err := imaging.Decode(data, constants.AvatarMaxPixels)
+	if err != nil {
+		if errors.Is(err, imaging.ErrTooManyPixels) {
+			return nil, ErrAvatarDimensions
+		}
+		return nil, ErrAvatarUnsupported
+	}
+
+	thumbs := imaging.SquareThumbnails(img, constants.AvatarSizes)
+	encoded := make([][]byte, len(thumbs))
+	for i, thumb := range thumbs {
+		if encoded[i], err = imaging.EncodeJPEG(thumb, constants.AvatarJPEGQuality); err != nil {
+			return nil, err
+		}
+	}
+	return encoded, nil
+}
+
+// Discard deletes the stored thumbnails of a profile's uploaded avatar, e.g.
+// after the profile is deleted
+func (s *AvatarService) Discard(ctx context.Context, profile *models.Profile) {
+	if profile.AvatarKey != "" {
+		s.remove(ctx, profile.AvatarKey)
+	}
+}
+
+// remove deletes every thumbnail of an upload. Failures are logged only; an
+// orphaned thumbnail is harmless and the upload itself has already succeeded
+// or failed by the time this runs.
+func (s *AvatarService) remove(ctx context.Context, prefix string) {
+	for _, size := range constants.AvatarSizes {
+		if err := s.store.Delete(ctx, avatarKey(prefix, size)); err != nil {
+			logger.Error("AvatarService: failed to delete %s: %v", avatarKey(prefix, size), err)
+		}
+	}
+}
+
+func avatarKey(prefix string, size int) string {
+	return fmt.Sprintf("%s/%d.jpg", prefix, size)
+}
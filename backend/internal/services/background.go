@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"mentori/pkg/logger"
+)
+
+// backgroundTasks runs work after the request that started it is answered.
+// Requests that must not reveal whether an email is registered hand over
+// everything they only do for registered users, so that both kinds of
+// request take the same time.
+type backgroundTasks struct {
+	wg sync.WaitGroup
+}
+
+// run starts the task with a context that outlives the request; failures
+// are logged under op
+func (b *backgroundTasks) run(ctx context.Context, op string, task func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		if err := task(ctx); err != nil {
+			logger.Error("%s: %v", op, err)
+		}
+	}()
+}
+
+// wait blocks until every started task is done
+func (b *backgroundTasks) wait() {
+	b.wg.Wait()
+}
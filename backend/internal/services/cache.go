@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mentori/pkg/logger"
+)
+
+// reloadingCache holds data loaded from the database and reloads it once it
+// is older than ttl. If a reload fails after an earlier success, the stale
+// data is kept so a database hiccup does not fail every request.
+type reloadingCache[T any] struct {
+	name string // Owner named in log messages
+	ttl  time.Duration
+	load func(ctx context.Context) (T, error)
+
+	mu       sync.RWMutex
+	value    T
+	loaded   bool
+	loadedAt time.Time
+}
+
+func newReloadingCache[T any](name string, ttl time.Duration, load func(ctx context.Context) (T, error)) *reloadingCache[T] {
+	return &reloadingCache[T]{name: name, ttl: ttl, load: load}
+}
+
+// get returns the cached data, reloading it when stale
+func (c *reloadingCache[T]) get(ctx context.Context) (T, error) {
+	c.mu.RLock()
+	value, loaded, loadedAt := c.value, c.loaded, c.loadedAt
+	c.mu.RUnlock()
+	if loaded && time.Since(loadedAt) < c.ttl {
+		return value, nil
+	}
+
+	fresh, err := c.reload(ctx)
+	if err != nil {
+		if loaded {
+			logger.Error("%s: failed to reload, using cached data: %v", c.name, err)
+			return value, nil
+		}
+		return fresh, err
+	}
+	return fresh, nil
+}
+
+// reload loads the data and caches it
+func (c *reloadingCache[T]) reload(ctx context.Context) (T, error) {
+	value, err := c.load(ctx)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	c.mu.Lock()
+	c.value = value
+	c.loaded = true
+	c.loadedAt = time.Now()
+	c.mu.Unlock()
+	return value, nil
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/assignment"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// Cohort errors
+var (
+	ErrCohortNotFound  = errors.New("cohort not found")
+	ErrCohortPublished = errors.New("cohort has already been published")
+	ErrCohortInvalid   = errors.New("invalid cohort")
+	ErrCohortCapacity  = errors.New("the mentor has no capacity left in this cohort")
+	ErrCohortConflict  = errors.New("the pair is already in an active mentorship")
+)
+
+// CohortService assigns a cohort of mentees to a pool of mentors. The
+// assignment maximises the total match score over the whole cohort within
+// each mentor's capacity, and stays a draft until an admin publishes it.
+type CohortService struct {
+	repo        repository.CohortRepository
+	profiles    repository.ProfileRepository
+	users       repository.UserRepository
+	mentorships repository.MentorshipRepository
+	matching    *MatchingService
+}
+
+// NewCohortService creates a new cohort service
+func NewCohortService(repo repository.CohortRepository, profiles repository.ProfileRepository, users repository.UserRepository, mentorships repository.MentorshipRepository, matching *MatchingService) *CohortService {
+	return &CohortService{
+		repo:        repo,
+		profiles:    profiles,
+		users:       users,
+		mentorships: mentorships,
+		matching:    matching,
+	}
+}
+
+// Create computes and stores a draft assignment. Mentor capacities default to
+// each mentor's open slots. Pairs scoring below the minimum score, and pairs
+// already in an active mentorship, are never assigned; mentees who cannot be
+// placed are left unassigned.
+func (s *CohortService) Create(ctx context.Context, adminID uuid.UUID, req *models.CreateCohortRequest) (*models.Cohort, error) {
+	menteeIDs := make([]uuid.UUID, 0, len(req.MenteeIDs))
+	seen := make(map[uuid.UUID]bool, len(req.MenteeIDs)+len(req.Mentors))
+	for _, id := range req.MenteeIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("%w: mentee %s is listed twice", ErrCohortInvalid, id)
+		}
+		seen[id] = true
+		menteeIDs = append(menteeIDs, id)
+	}
+	mentorIDs := make([]uuid.UUID, 0, len(req.Mentors))
+	for _, m := range req.Mentors {
+		if seen[m.MentorID] {
+			return nil, fmt.Errorf("%w: mentor %s is listed twice", ErrCohortInvalid, m.MentorID)
+		}
+		seen[m.MentorID] = true
+		mentorIDs = append(mentorIDs, m.MentorID)
+	}
+
+	active, err := s.mentorships.ActiveMenteeCounts(ctx, mentorIDs)
+	if err != nil {
+		return nil, err
+	}
+	partners, err := s.mentorships.ActiveMentors(ctx, menteeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	mentors := make([]*MatchProfile, len(req.Mentors))
+	capacity := make([]int, len(req.Mentors))
+	for j, m := range req.Mentors {
+		if mentors[j], err = s.cohortMember(ctx, m.MentorID, constants.RoleMentor, active[m.MentorID]); err != nil {
+			return nil, err
+		}
+		capacity[j] = mentors[j].OpenSlots()
+		if m.Capacity != nil {
+			capacity[j] = *m.Capacity
+		}
+	}
+	mentees := make([]*MatchProfile, len(menteeIDs))
+	for i, id := range menteeIDs {
+		if mentees[i], err = s.cohortMember(ctx, id, constants.RoleMentee, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	matches := make([][]*models.Match, len(mentees))
+	scores := make([][]float64, len(mentees))
+	for i, mentee := range mentees {
+		matches[i] = make([]*models.Match, len(mentors))
+		scores[i] = make([]float64, len(mentors))
+		excluded := make(map[uuid.UUID]bool, len(partners[mentee.UserID]))
+		for _, id := range partners[mentee.UserID] {
+			excluded[id] = true
+		}
+		for j, mentor := range mentors {
+			matches[i][j] = s.matching.Score(mentor, mentee)
+			scores[i][j] = matches[i][j].Score
+			if excluded[mentor.UserID] || scores[i][j] < req.MinScore {
+				scores[i][j] = -1
+			}
+		}
+	}
+	assigned := assignment.Solve(scores, capacity)
+
+	now := time.Now()
+	cohort := &models.Cohort{
+		ID:        uuid.New(),
+		Name:      req.Name,
+		Status:    constants.CohortDraft,
+		MinScore:  req.MinScore,
+		CreatedBy: adminID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Mentors:   make([]models.CohortMentor, len(mentors)),
+		Pairs:     make([]models.CohortPair, len(mentees)),
+	}
+	for j, mentor := range mentors {
+		cohort.Mentors[j] = models.CohortMentor{CohortID: cohort.ID, MentorID: mentor.UserID, Capacity: capacity[j]}
+	}
+	for i, mentee := range mentees {
+		pair := models.CohortPair{CohortID: cohort.ID, MenteeID: mentee.UserID}
+		if j := assigned[i]; j >= 0 {
+			if err := setPairMatch(&pair, mentors[j].UserID, matches[i][j]); err != nil {
+				return nil, err
+			}
+		}
+		cohort.Pairs[i] = pair
+	}
+
+	if err := s.repo.Create(ctx, cohort); err != nil {
+		return nil, err
+	}
+	summarizeCohort(cohort)
+	logger.Info("Cohort %s drafted by admin %s: %d of %d mentees assigned, total score %.3f",
+		cohort.ID, adminID, cohort.Assigned, len(mentees), cohort.TotalScore)
+	return cohort, nil
+}
+
+// Get returns a cohort with its mentor pool and pairs
+func (s *CohortService) Get(ctx context.Context, id uuid.UUID) (*models.Cohort, error) {
+	cohort, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCohortNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	summarizeCohort(cohort)
+	return cohort, nil
+}
+
+// List returns a page of cohorts, newest first
+func (s *CohortService) List(ctx context.Context, page pagination.Params) (pagination.Page[*models.Cohort], error) {
+	cohorts, err := s.repo.List(ctx, page.Fetch(), page.After)
+	return pagination.NewPage(cohorts, page, func(c *models.Cohort) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}
+	}), err
+}
+
+// Reassign moves a mentee of a draft cohort to another mentor of its pool, or
+// leaves them unassigned when mentorID is nil. The pair is scored again and
+// marked as adjusted. Admin choices may go below the cohort's minimum score,
+// but not over a mentor's capacity.
+func (s *CohortService) Reassign(ctx context.Context, cohortID, menteeID uuid.UUID, mentorID *uuid.UUID) (*models.Cohort, error) {
+	cohort, err := s.Get(ctx, cohortID)
+	if err != nil {
+		return nil, err
+	}
+	if cohort.Status != constants.CohortDraft {
+		return nil, ErrCohortPublished
+	}
+
+	var pair *models.CohortPair
+	for i := range cohort.Pairs {
+		if cohort.Pairs[i].MenteeID == menteeID {
+			pair = &cohort.Pairs[i]
+		}
+	}
+	if pair == nil {
+		return nil, fmt.Errorf("%w: user %s is not a mentee of this cohort", ErrCohortInvalid, menteeID)
+	}
+
+	updated := models.CohortPair{CohortID: cohortID, MenteeID: menteeID, Adjusted: true}
+	if mentorID != nil {
+		capacity := -1
+		for _, m := range cohort.Mentors {
+			if m.MentorID == *mentorID {
+				capacity = m.Capacity
+			}
+		}
+		if capacity < 0 {
+			return nil, fmt.Errorf("%w: user %s is not a mentor of this cohort", ErrCohortInvalid, *mentorID)
+		}
+		taken := 0
+		for _, p := range cohort.Pairs {
+			if p.MentorID != nil && *p.MentorID == *mentorID && p.MenteeID != menteeID {
+				taken++
+			}
+		}
+		if taken >= capacity {
+			return nil, ErrCohortCapacity
+		}
+		partnered, err := s.mentorships.ActiveBetween(ctx, *mentorID, menteeID)
+		if err != nil {
+			return nil, err
+		}
+		if partnered {
+			return nil, ErrCohortConflict
+		}
+
+		active, err := s.mentorships.ActiveMenteeCounts(ctx, []uuid.UUID{*mentorID})
+		if err != nil {
+			return nil, err
+		}
+		mentor, err := s.cohortMember(ctx, *mentorID, constants.RoleMentor, active[*mentorID])
+		if err != nil {
+			return nil, err
+		}
+		mentee, err := s.cohortMember(ctx, menteeID, constants.RoleMentee, 0)
+		if err != nil {
+			return nil, err
+		}
+		if err := setPairMatch(&updated, *mentorID, s.matching.Score(mentor, mentee)); err != nil {
+			return nil, err
+		}
+	}
+
+	ok, err := s.repo.UpdatePair(ctx, &updated)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCohortPublished
+	}
+	logger.Info("Cohort %s: mentee %s reassigned", cohortID, menteeID)
+	return s.Get(ctx, cohortID)
+}
+
+// Publish turns the draft pairs into active mentorships, all or none
+func (s *CohortService) Publish(ctx context.Context, cohortID, adminID uuid.UUID) (*models.Cohort, error) {
+	cohort, err := s.Get(ctx, cohortID)
+	if err != nil {
+		return nil, err
+	}
+	if cohort.Status != constants.CohortDraft {
+		return nil, ErrCohortPublished
+	}
+	// Mentorships may have started since the draft was computed
+	for _, pair := range cohort.Pairs {
+		if pair.MentorID == nil {
+			continue
+		}
+		partnered, err := s.mentorships.ActiveBetween(ctx, *pair.MentorID, pair.MenteeID)
+		if err != nil {
+			return nil, err
+		}
+		if partnered {
+			return nil, fmt.Errorf("%w: mentor %s and mentee %s", ErrCohortConflict, *pair.MentorID, pair.MenteeID)
+		}
+	}
+
+	ok, err := s.repo.Publish(ctx, cohortID, adminID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCohortPublished
+	}
+	logger.Info("Cohort %s published by admin %s: %d mentorships created", cohortID, adminID, cohort.Assigned)
+	return s.Get(ctx, cohortID)
+}
+
+// cohortMember reads the full matching profile of a cohort member. Admins
+// run cohorts, so privacy settings do not apply. Members without a profile
+// are matched on nothing and, for mentors, take no mentees by default.
+func (s *CohortService) cohortMember(ctx context.Context, userID uuid.UUID, role string, activeCount int) (*MatchProfile, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: user %s not found", ErrCohortInvalid, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != role {
+		return nil, fmt.Errorf("%w: user %s is not a %s", ErrCohortInvalid, userID, role)
+	}
+
+	profile, err := s.profiles.GetByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &MatchProfile{UserID: userID, ActiveCount: activeCount}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &MatchProfile{
+		UserID:           userID,
+		Expertise:        jsonList(profile.Expertise),
+		Interests:        jsonList(profile.Interests),
+		Location:         profile.Location,
+		MunicipalityCode: profile.MunicipalityCode,
+		Languages:        jsonList(profile.Languages),
+		Availability:     jsonList(profile.Availability),
+		MaxMentees:       profile.MaxMentees,
+		ActiveCount:      activeCount,
+	}, nil
+}
+
+// setPairMatch assigns the mentor to the pair with the match explaining it
+func setPairMatch(pair *models.CohortPair, mentorID uuid.UUID, match *models.Match) error {
+	factors, err := json.Marshal(match.Factors)
+	if err != nil {
+		return err
+	}
+	pair.MentorID = &mentorID
+	pair.Score = match.Score
+	pair.Factors = factors
+	return nil
+}
+
+// summarizeCohort fills in the counts and total score of the pairs
+func summarizeCohort(cohort *models.Cohort) {
+	cohort.Assigned, cohort.Unassigned, cohort.TotalScore = 0, 0, 0
+	for _, pair := range cohort.Pairs {
+		if pair.MentorID == nil {
+			cohort.Unassigned++
+			continue
+		}
+		cohort.Assigned++
+		cohort.TotalScore += pair.Score
+	}
+	cohort.TotalScore = round3(cohort.TotalScore)
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// Email verification errors
+var (
+	ErrVerificationCodeInvalid = errors.New("verification code is invalid or expired")
+	ErrVerificationTooMany     = errors.New("too many verification attempts, request a new code")
+)
+
+// EmailVerificationService issues and checks 6-digit email verification codes
+type EmailVerificationService struct {
+	repo       repository.EmailVerificationRepository
+	userRepo   repository.UserRepository
+	sender     utils.EmailSender
+	background backgroundTasks
+}
+
+// NewEmailVerificationService creates a new email verification service
+func NewEmailVerificationService(repo repository.EmailVerificationRepository, userRepo repository.UserRepository, sender utils.EmailSender) *EmailVerificationService {
+	return &EmailVerificationService{
+		repo:     repo,
+		userRepo: userRepo,
+		sender:   sender,
+	}
+}
+
+// RequestCode sends a new verification code to a registered, unverified user.
+// It returns nil for unknown and already verified addresses and issues the
+// code in the background, swallowing throttled requests and delivery
+// failures, so that callers can learn neither from the response nor from its
+// timing whether an email is registered.
+func (s *EmailVerificationService) RequestCode(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.IsVerified {
+		return nil
+	}
+
+	s.background.run(ctx, "RequestCode", func(ctx context.Context) error {
+		return s.sendCode(ctx, user, email)
+	})
+	return nil
+}
+
+// Wait blocks until the codes being issued in the background are sent
+func (s *EmailVerificationService) Wait() {
+	s.background.wait()
+}
+
+func (s *EmailVerificationService) sendCode(ctx context.Context, user *models.User, email string) error {
+	throttled, err := s.throttled(ctx, email)
+	if err != nil {
+		return err
+	}
+	if throttled {
+		logger.Warn("RequestCode: verification code requests throttled for user_id=%s", user.ID)
+		return nil
+	}
+
+	// Only the newest code is ever valid
+	if err := s.repo.InvalidateAll(ctx, email); err != nil {
+		return err
+	}
+
+	code, err := generateNumericCode(6)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	verification := &models.EmailVerification{
+		ID:        uuid.New(),
+		Email:     email,
+		Code:      code,
+		ExpiresAt: now.Add(constants.VerificationCodeExpiry),
+		CreatedAt: now,
+	}
+	if err := s.repo.Create(ctx, verification); err != nil {
+		return err
+	}
+
+	if err := s.sender.Send(ctx, utils.EmailMessage{
+		To:      email,
+		Subject: "Your Mentori verification code",
+		Body: fmt.Sprintf("Your Mentori verification code is %s.\n\nThe code expires in %d minutes. If you did not create a Mentori account, you can ignore this email.",
+			code, int(constants.VerificationCodeExpiry.Minutes())),
+	}); err != nil {
+		return fmt.Errorf("failed to send verification code to user_id=%s: %w", user.ID, err)
+	}
+	return nil
+}
+
+// throttled reports whether the email had a code too recently, or as many
+// codes as allowed in the request window
+func (s *EmailVerificationService) throttled(ctx context.Context, email string) (bool, error) {
+	latest, err := s.repo.GetLatest(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if time.Since(latest.CreatedAt) < constants.VerificationResendInterval {
+		return true, nil
+	}
+
+	count, err := s.repo.CountSince(ctx, email, time.Now().Add(-constants.VerificationRequestWindow))
+	if err != nil {
+		return false, err
+	}
+	return count >= constants.VerificationMaxRequests, nil
+}
+
+// ConfirmCode checks a verification code and marks the user's email as verified
+func (s *EmailVerificationService) ConfirmCode(ctx context.Context, email, code string) (*models.User, error) {
+	verification, err := s.repo.GetLatest(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrVerificationCodeInvalid
+		}
+		return nil, err
+	}
+	if verification.IsUsed || time.Now().After(verification.ExpiresAt) {
+		return nil, ErrVerificationCodeInvalid
+	}
+
+	// Every guess uses up an attempt before the code is compared, so that
+	// concurrent guesses cannot get past the limit
+	ok, err := s.repo.ConsumeAttempt(ctx, verification.ID, constants.VerificationMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrVerificationTooMany
+	}
+
+	if subtle.ConstantTimeCompare([]byte(verification.Code), []byte(code)) != 1 {
+		return nil, ErrVerificationCodeInvalid
+	}
+
+	if err := s.repo.MarkUsed(ctx, verification.ID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrVerificationCodeInvalid
+		}
+		return nil, err
+	}
+	if !user.IsVerified {
+		user.IsVerified = true
+		user.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// generateNumericCode returns a cryptographically random decimal code of the given length
+func generateNumericCode(digits int) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < digits; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(byte('0' + n.Int64()))
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Impersonation errors
+var (
+	ErrImpersonationNotAllowed = errors.New("admins and your own account cannot be impersonated")
+	ErrImpersonationTarget     = errors.New("user to impersonate does not exist")
+)
+
+// ImpersonationService lets support staff act as a user. Impersonation tokens
+// are short-lived access tokens for the user with an RFC 8693 "act" claim
+// naming the admin. They are bound to the admin's login session and every use
+// is written to the audit log.
+type ImpersonationService struct {
+	repo     repository.ImpersonationLogRepository
+	userRepo repository.UserRepository
+	tokens   *TokenIssuer
+	authz    *AuthorizationService
+}
+
+// NewImpersonationService creates a new impersonation service
+func NewImpersonationService(repo repository.ImpersonationLogRepository, userRepo repository.UserRepository, tokens *TokenIssuer, authz *AuthorizationService) *ImpersonationService {
+	return &ImpersonationService{
+		repo:     repo,
+		userRepo: userRepo,
+		tokens:   tokens,
+		authz:    authz,
+	}
+}
+
+// Start issues an impersonation token for subjectID and records who asked and why
+func (s *ImpersonationService) Start(ctx context.Context, actorID, sessionID, subjectID uuid.UUID, reason, ip string) (string, *models.User, error) {
+	if actorID == subjectID {
+		return "", nil, ErrImpersonationNotAllowed
+	}
+
+	actor, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		return "", nil, err
+	}
+	subject, err := s.userRepo.GetByID(ctx, subjectID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", nil, ErrImpersonationTarget
+		}
+		return "", nil, err
+	}
+	// Impersonating another admin would be a privilege escalation path
+	privileged, err := s.authz.Can(ctx, subject.Role, constants.PermissionUsersImpersonate)
+	if err != nil {
+		return "", nil, err
+	}
+	if privileged {
+		return "", nil, ErrImpersonationNotAllowed
+	}
+
+	now := time.Now()
+	token, err := s.tokens.Sign(jwt.MapClaims{
+		"user_id": subject.ID.String(),
+		"email":   subject.Email,
+		"role":    subject.Role,
+		"sid":     sessionID.String(),
+		"typ":     constants.TokenTypeAccess,
+		"mfa":     false,
+		"act": map[string]interface{}{
+			"sub":   actor.ID.String(),
+			"email": actor.Email,
+		},
+		"exp": now.Add(constants.ImpersonationTokenExpiry).Unix(),
+		"iat": now.Unix(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := s.repo.Create(ctx, &models.ImpersonationLog{
+		ID:        uuid.New(),
+		ActorID:   actor.ID,
+		SubjectID: subject.ID,
+		SessionID: sessionID,
+		Action:    constants.ImpersonationActionIssued,
+		Reason:    reason,
+		IPAddress: ip,
+		CreatedAt: now,
+	}); err != nil {
+		return "", nil, err
+	}
+	return token, subject, nil
+}
+
+// RecordRequest writes a request made with an impersonation token to the audit log
+func (s *ImpersonationService) RecordRequest(ctx context.Context, entry *models.ImpersonationLog) error {
+	entry.ID = uuid.New()
+	entry.Action = constants.ImpersonationActionRequest
+	entry.CreatedAt = time.Now()
+	return s.repo.Create(ctx, entry)
+}
+
+// List returns a page of audit log entries, newest first, optionally for one
+// impersonated user
+func (s *ImpersonationService) List(ctx context.Context, subjectID *uuid.UUID, page pagination.Params) (pagination.Page[*models.ImpersonationLog], error) {
+	entries, err := s.repo.List(ctx, subjectID, page.Fetch(), page.After)
+	return pagination.NewPage(entries, page, func(e *models.ImpersonationLog) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
+	}), err
+}
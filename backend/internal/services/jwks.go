@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"mentori/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWKS verification errors
+var (
+	ErrUnknownKeyID  = errors.New("token signed with unknown key")
+	ErrInvalidIssuer = errors.New("token issuer is not trusted")
+	ErrInvalidAud    = errors.New("token audience does not match")
+	ErrInvalidNonce  = errors.New("token nonce does not match")
+)
+
+const (
+	defaultJWKSRefreshInterval = time.Hour
+	minJWKSRefreshInterval     = time.Minute
+	jwksClockSkew              = 30 * time.Second
+)
+
+// JWKSVerifierConfig configures a JWKSVerifier
+type JWKSVerifierConfig struct {
+	JWKSURL         string
+	Issuers         []string // Accepted "iss" values
+	Audiences       []string // Accepted "aud" values (OAuth client IDs)
+	RefreshInterval time.Duration
+	HTTPClient      *http.Client
+}
+
+// JWKSVerifier verifies RS256/ES256/EdDSA signed tokens against a remote JSON Web Key Set.
+// Keys are cached by "kid" and refreshed in the background and on cache misses.
+type JWKSVerifier struct {
+	cfg JWKSVerifierConfig
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	lastFetch time.Time
+
+	fetchMu sync.Mutex // serialises fetches so a burst of misses triggers one request
+}
+
+// NewJWKSVerifier creates a new JWKS verifier. Keys are fetched lazily on first use.
+func NewJWKSVerifier(cfg JWKSVerifierConfig) *JWKSVerifier {
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaultJWKSRefreshInterval
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &JWKSVerifier{
+		cfg:  cfg,
+		keys: make(map[string]crypto.PublicKey),
+	}
+}
+
+// Start refreshes the key set periodically until ctx is cancelled
+func (v *JWKSVerifier) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(v.cfg.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			if err := v.Refresh(ctx); err != nil && ctx.Err() == nil {
+				logger.Warn("JWKS refresh failed for %s: %v", v.cfg.JWKSURL, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Verify checks the signature and the iss, aud, exp and (when non-empty) nonce claims
+func (v *JWKSVerifier) Verify(ctx context.Context, rawToken, nonce string) (jwt.MapClaims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(jwksClockSkew),
+	)
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, ErrUnknownKeyID
+		}
+		return v.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	iss, _ := claims.GetIssuer()
+	if !slices.Contains(v.cfg.Issuers, iss) {
+		return nil, ErrInvalidIssuer
+	}
+
+	aud, _ := claims.GetAudience()
+	if !slices.ContainsFunc(aud, func(a string) bool { return slices.Contains(v.cfg.Audiences, a) }) {
+		return nil, ErrInvalidAud
+	}
+
+	if nonce != "" {
+		if got, _ := claims["nonce"].(string); got != nonce {
+			return nil, ErrInvalidNonce
+		}
+	}
+
+	return claims, nil
+}
+
+// Refresh fetches the key set and replaces the cache
+func (v *JWKSVerifier) Refresh(ctx context.Context) error {
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+	return v.fetch(ctx)
+}
+
+// key returns the cached key for kid, refetching the key set once on a miss
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if k, ok := v.cachedKey(kid); ok {
+		return k, nil
+	}
+
+	v.fetchMu.Lock()
+	defer v.fetchMu.Unlock()
+
+	// Another request may have refreshed while we waited
+	if k, ok := v.cachedKey(kid); ok {
+		return k, nil
+	}
+
+	v.mu.RLock()
+	recentlyFetched := time.Since(v.lastFetch) < minJWKSRefreshInterval
+	v.mu.RUnlock()
+	if recentlyFetched {
+		return nil, ErrUnknownKeyID
+	}
+
+	if err := v.fetch(ctx); err != nil {
+		return nil, err
+	}
+	if k, ok := v.cachedKey(kid); ok {
+		return k, nil
+	}
+	return nil, ErrUnknownKeyID
+}
+
+func (v *JWKSVerifier) cachedKey(kid string) (crypto.PublicKey, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	k, ok := v.keys[kid]
+	return k, ok
+}
+
+// fetch must be called with fetchMu held
+func (v *JWKSVerifier) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := v.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var set jsonWebKeySet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			logger.Warn("Skipping JWKS key %q from %s: %v", jwk.Kid, v.cfg.JWKSURL, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.lastFetch = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+// jsonWebKeySet is the RFC 7517 key set document
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// jsonWebKey is a single RSA, EC or OKP (Ed25519) public key
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key encoding: %w", err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
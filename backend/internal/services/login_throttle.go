@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// LoginThrottledError is returned while an account or client IP must wait before trying again
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool // True for a lockout, false for a backoff delay
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("please wait %s before trying again", e.RetryAfter.Round(time.Second))
+}
+
+// throttlePolicy sets the backoff and lockout limits for one scope
+type throttlePolicy struct {
+	FreeAttempts     int // Failures allowed before backoff starts
+	LockoutThreshold int // Failures that lock the scope out
+	LockoutDuration  time.Duration
+}
+
+// LoginThrottleService slows down password guessing. Failed logins are counted
+// both per account, against targeted guessing, and per client IP, against
+// credential stuffing across many accounts.
+type LoginThrottleService struct {
+	repo    repository.LoginThrottleRepository
+	events  repository.LockoutEventRepository
+	account throttlePolicy
+	ip      throttlePolicy
+}
+
+// NewLoginThrottleService creates a new login throttle service with the default policies
+func NewLoginThrottleService(repo repository.LoginThrottleRepository, events repository.LockoutEventRepository) *LoginThrottleService {
+	return &LoginThrottleService{
+		repo:   repo,
+		events: events,
+		account: throttlePolicy{
+			FreeAttempts:     constants.AccountFreeAttempts,
+			LockoutThreshold: constants.AccountLockoutThreshold,
+			LockoutDuration:  constants.AccountLockoutDuration,
+		},
+		ip: throttlePolicy{
+			FreeAttempts:     constants.IPFreeAttempts,
+			LockoutThreshold: constants.IPLockoutThreshold,
+			LockoutDuration:  constants.IPLockoutDuration,
+		},
+	}
+}
+
+// Check returns a *LoginThrottledError if the account or IP may not attempt a login yet
+func (s *LoginThrottleService) Check(ctx context.Context, email, ip string) error {
+	var wait *LoginThrottledError
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		throttle, err := s.repo.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		if throttle.LockedUntil == nil {
+			continue
+		}
+		if retry := time.Until(*throttle.LockedUntil); retry > 0 && (wait == nil || retry > wait.RetryAfter) {
+			wait = &LoginThrottledError{
+				RetryAfter: retry,
+				Locked:     s.policy(key).LockoutThreshold <= throttle.Failures,
+			}
+		}
+	}
+	if wait != nil {
+		return wait
+	}
+	return nil
+}
+
+// RecordFailure counts a failed login for the account and IP. userID is nil
+// when the email does not belong to an account.
+func (s *LoginThrottleService) RecordFailure(ctx context.Context, email, ip string, userID *uuid.UUID) error {
+	now := time.Now()
+	for _, key := range []string{accountKey(email), ipKey(ip)} {
+		failures, err := s.repo.RecordFailure(ctx, key, now, now.Add(-constants.LoginFailureWindow))
+		if err != nil {
+			return err
+		}
+
+		policy := s.policy(key)
+		delay := backoff(policy, failures)
+		if delay == 0 {
+			continue
+		}
+		until := now.Add(delay)
+		if err := s.repo.SetLockedUntil(ctx, key, until); err != nil {
+			return err
+		}
+
+		if failures >= policy.LockoutThreshold {
+			if err := s.emitLockout(ctx, key, ip, userID, failures, until); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RecordSuccess clears the account's failure counter. The IP counter is kept so
+// that a stuffing attack cannot reset it with one valid credential.
+func (s *LoginThrottleService) RecordSuccess(ctx context.Context, email string) error {
+	return s.repo.Delete(ctx, accountKey(email))
+}
+
+// Unlock clears an account lockout on behalf of an admin
+func (s *LoginThrottleService) Unlock(ctx context.Context, email string, adminID uuid.UUID) error {
+	if err := s.repo.Delete(ctx, accountKey(email)); err != nil {
+		return err
+	}
+	logger.Info("Account %s unlocked by admin %s", email, adminID)
+	return nil
+}
+
+// ListLockouts returns a page of lockout events, newest first
+func (s *LoginThrottleService) ListLockouts(ctx context.Context, page pagination.Params) (pagination.Page[*models.LockoutEvent], error) {
+	events, err := s.events.ListRecent(ctx, page.Fetch(), page.After)
+	return pagination.NewPage(events, page, func(e *models.LockoutEvent) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
+	}), err
+}
+
+func (s *LoginThrottleService) emitLockout(ctx context.Context, key, ip string, userID *uuid.UUID, failures int, until time.Time) error {
+	scope, subject, _ := strings.Cut(key, ":")
+	logger.Warn("Login lockout: scope=%s subject=%s failures=%d ip=%s until=%s", scope, subject, failures, ip, until.Format(time.RFC3339))
+
+	event := &models.LockoutEvent{
+		ID:          uuid.New(),
+		Scope:       scope,
+		Subject:     subject,
+		IPAddress:   ip,
+		Failures:    failures,
+		LockedUntil: until,
+		CreatedAt:   time.Now(),
+	}
+	if scope == constants.LockoutScopeAccount {
+		event.UserID = userID
+	}
+	return s.events.Create(ctx, event)
+}
+
+func (s *LoginThrottleService) policy(key string) throttlePolicy {
+	if strings.HasPrefix(key, constants.LockoutScopeIP+":") {
+		return s.ip
+	}
+	return s.account
+}
+
+// backoff returns how long to block further attempts after the given number of failures
+func backoff(policy throttlePolicy, failures int) time.Duration {
+	if failures >= policy.LockoutThreshold {
+		return policy.LockoutDuration
+	}
+	if failures <= policy.FreeAttempts {
+		return 0
+	}
+	delay := constants.LoginBackoffBase
+	for i := policy.FreeAttempts + 1; i < failures && delay < constants.LoginBackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, constants.LoginBackoffMax)
+}
+
+// accountKey normalises the email so case variations share one counter
+func accountKey(email string) string {
+	return constants.LockoutScopeAccount + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return constants.LockoutScopeIP + ":" + ip
+}
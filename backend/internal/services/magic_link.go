@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/utils"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ErrMagicLinkInvalid is returned for tampered, expired, used or superseded login links
+var ErrMagicLinkInvalid = errors.New("login link is invalid or expired")
+
+// MagicLinkService handles passwordless sign-in with emailed login links.
+// Links carry a short-lived token signed by the TokenIssuer; its jti is
+// recorded so that each link can be used only once.
+type MagicLinkService struct {
+	repo        repository.MagicLinkRepository
+	userRepo    repository.UserRepository
+	tokens      *TokenIssuer
+	sender      utils.EmailSender
+	frontendURL string
+	background  backgroundTasks
+}
+
+// NewMagicLinkService creates a new magic link service
+func NewMagicLinkService(repo repository.MagicLinkRepository, userRepo repository.UserRepository, tokens *TokenIssuer, sender utils.EmailSender, frontendURL string) *MagicLinkService {
+	return &MagicLinkService{
+		repo:        repo,
+		userRepo:    userRepo,
+		tokens:      tokens,
+		sender:      sender,
+		frontendURL: frontendURL,
+	}
+}
+
+// RequestLink emails a login link if the address belongs to a user.
+// It returns nil for unknown addresses and issues the link in the background,
+// swallowing throttled requests and delivery failures, so that callers can
+// learn neither from the response nor from its timing whether an email is
+// registered.
+func (s *MagicLinkService) RequestLink(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	s.background.run(ctx, "RequestLink", func(ctx context.Context) error {
+		return s.sendLink(ctx, user, strings.ToLower(strings.TrimSpace(email)))
+	})
+	return nil
+}
+
+// Wait blocks until the login links being issued in the background are sent
+func (s *MagicLinkService) Wait() {
+	s.background.wait()
+}
+
+func (s *MagicLinkService) sendLink(ctx context.Context, user *models.User, key string) error {
+	throttled, err := s.throttled(ctx, key)
+	if err != nil {
+		return err
+	}
+	if throttled {
+		logger.Warn("RequestLink: login link requests throttled for user_id=%s", user.ID)
+		return nil
+	}
+
+	// Only the most recently requested link is valid
+	if err := s.repo.InvalidateAllForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	link := &models.MagicLinkToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Email:     key,
+		ExpiresAt: now.Add(constants.MagicLinkExpiry),
+		CreatedAt: now,
+	}
+	if err := s.repo.Create(ctx, link); err != nil {
+		return err
+	}
+
+	raw, err := s.tokens.Sign(jwt.MapClaims{
+		"sub": user.ID.String(),
+		"jti": link.ID.String(),
+		"typ": constants.TokenTypeMagicLink,
+		"exp": link.ExpiresAt.Unix(),
+		"iat": now.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	loginURL := fmt.Sprintf("%s/magic-login?token=%s", s.frontendURL, url.QueryEscape(raw))
+	if err := s.sender.Send(ctx, utils.EmailMessage{
+		To:      user.Email,
+		Subject: "Your Mentori login link",
+		Body: fmt.Sprintf("Open this link to sign in to Mentori:\n%s\n\nThe link expires in %d minutes and can be used once. If you did not ask to sign in, you can ignore this email.",
+			loginURL, int(constants.MagicLinkExpiry.Minutes())),
+	}); err != nil {
+		return fmt.Errorf("failed to send login link to user_id=%s: %w", user.ID, err)
+	}
+	return nil
+}
+
+// Verify checks a login link token, consumes it and returns its user.
+// Opening the link proves ownership of the address, so the email is marked
+// as verified.
+func (s *MagicLinkService) Verify(ctx context.Context, rawToken string) (*models.User, error) {
+	claims, err := s.tokens.Parse(rawToken)
+	if err != nil || claims["typ"] != constants.TokenTypeMagicLink {
+		return nil, ErrMagicLinkInvalid
+	}
+	subject, _ := claims["sub"].(string)
+	jti, _ := claims["jti"].(string)
+	userID, err := uuid.Parse(subject)
+	if err != nil {
+		return nil, ErrMagicLinkInvalid
+	}
+	linkID, err := uuid.Parse(jti)
+	if err != nil {
+		return nil, ErrMagicLinkInvalid
+	}
+
+	link, err := s.repo.GetByID(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMagicLinkInvalid
+		}
+		return nil, err
+	}
+	if link.UserID != userID || link.UsedAt != nil || time.Now().After(link.ExpiresAt) {
+		return nil, ErrMagicLinkInvalid
+	}
+
+	ok, err := s.repo.MarkUsed(ctx, link.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMagicLinkInvalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMagicLinkInvalid
+		}
+		return nil, err
+	}
+	if !user.IsVerified {
+		user.IsVerified = true
+		user.UpdatedAt = time.Now()
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// throttled reports whether the email has requested too many links recently
+func (s *MagicLinkService) throttled(ctx context.Context, email string) (bool, error) {
+	latest, err := s.repo.GetLatest(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if time.Since(latest.CreatedAt) < constants.MagicLinkResendInterval {
+		return true, nil
+	}
+
+	count, err := s.repo.CountSince(ctx, email, time.Now().Add(-constants.MagicLinkRequestWindow))
+	if err != nil {
+		return false, err
+	}
+	return count >= constants.MagicLinkMaxRequests, nil
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/geo"
+	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Matching errors
+var (
+	ErrMatchRole      = errors.New("only mentors and mentees can be matched")
+	ErrMatchNoProfile = errors.New("create a profile before looking for matches")
+)
+
+// MatchWeights sets how much each factor counts towards a match score. Only
+// the ratios matter; negative weights are treated as 0.
+type MatchWeights struct {
+	Expertise    float64
+	Interests    float64
+	Location     float64
+	Language     float64
+	Availability float64
+	Capacity     float64
+}
+
+// DefaultMatchWeights returns the weights used unless configured otherwise
+func DefaultMatchWeights() MatchWeights {
+	return MatchWeights{
+		Expertise:    constants.DefaultMatchWeightExpertise,
+		Interests:    constants.DefaultMatchWeightInterests,
+		Location:     constants.DefaultMatchWeightLocation,
+		Language:     constants.DefaultMatchWeightLanguage,
+		Availability: constants.DefaultMatchWeightAvailability,
+		Capacity:     constants.DefaultMatchWeightCapacity,
+	}
+}
+
+// MatchProfile is what the matching engine knows about one side of a pair
+type MatchProfile struct {
+	UserID           uuid.UUID
+	Expertise        []string // Taxonomy slugs; for mentees, what they want help with
+	Interests        []string
+	Location         string
+	MunicipalityCode string // Empty when the location is hidden or not in the gazetteer
+	Languages        []string
+	Availability     []string
+	MaxMentees       int // Mentors only
+	ActiveCount      int // Mentors only: current active mentees
+}
+
+// NewMatchProfile reads the matching fields of a profile as shown to a viewer.
+// The municipality is resolved from the location the viewer sees, so a
+// hidden location is never used.
+func NewMatchProfile(view *models.ProfileView, maxMentees, activeCount int) *MatchProfile {
+	p := &MatchProfile{
+		UserID:       view.UserID,
+		Expertise:    jsonList(view.Expertise),
+		Interests:    jsonList(view.Interests),
+		Location:     view.Location,
+		Languages:    jsonList(view.Languages),
+		Availability: jsonList(view.Availability),
+		MaxMentees:   maxMentees,
+		ActiveCount:  activeCount,
+	}
+	if m, _, ok := geo.ResolveLocation(view.Location); ok {
+		p.MunicipalityCode = m.Code
+	}
+	return p
+}
+
+// OpenSlots is how many more mentees a mentor can take on
+func (p *MatchProfile) OpenSlots() int {
+	return max(p.MaxMentees-p.ActiveCount, 0)
+}
+
+// MatchingService ranks mentors for mentees and mentees for mentors
+type MatchingService struct {
+	profiles    repository.ProfileRepository
+	users       repository.UserRepository
+	mentorships repository.MentorshipRepository
+	privacy     *ProfilePrivacyService
+	weights     MatchWeights
+}
+
+// NewMatchingService creates a new matching service
+func NewMatchingService(profiles repository.ProfileRepository, users repository.UserRepository, mentorships repository.MentorshipRepository, privacy *ProfilePrivacyService, weights MatchWeights) *MatchingService {
+	return &MatchingService{
+		profiles:    profiles,
+		users:       users,
+		mentorships: mentorships,
+		privacy:     privacy,
+		weights:     weights,
+	}
+}
+
+// Matches returns the viewer's best candidates of the opposite role, best
+// first. Candidates are scored on what the viewer may see of their profile,
+// so hidden fields never contribute. Existing mentorship partners and mentors
+// without open slots are left out.
+func (s *MatchingService) Matches(ctx context.Context, viewerID uuid.UUID, viewerRole string, limit int) ([]*models.Match, error) {
+	candidateRole := oppositeRole(viewerRole)
+	if candidateRole == "" {
+		return nil, ErrMatchRole
+	}
+	own, err := s.profiles.GetByUserID(ctx, viewerID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMatchNoProfile
+		}
+		return nil, err
+	}
+	viewer, err := s.users.GetByID(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.mentorships.ActiveMenteeCounts(ctx, []uuid.UUID{viewerID})
+	if err != nil {
+		return nil, err
+	}
+	ownView := s.privacy.Project(own, viewer, constants.ProfileViewPrivate)
+	self := NewMatchProfile(&ownView, own.MaxMentees, active[viewerID])
+
+	scope, err := s.privacy.SearchScope(ctx, viewerID, viewerRole)
+	if err != nil {
+		return nil, err
+	}
+	filters := &models.ProfileFilters{Role: candidateRole, Scope: scope}
+
+	// Every candidate is scored, a page at a time, keeping the best so far
+	var matches []*models.Match
+	var after *pagination.Cursor
+	scored := 0
+	for {
+		results, err := s.profiles.Search(ctx, filters, constants.MatchCandidatePageSize, after)
+		if err != nil {
+			return nil, err
+		}
+		page, err := s.scorePage(ctx, viewerID, viewerRole, candidateRole, self, results)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, page...)
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+		if len(matches) > limit {
+			matches = matches[:limit]
+		}
+		scored += len(results)
+
+		if len(results) < constants.MatchCandidatePageSize {
+			break
+		}
+		last := results[len(results)-1].Profile
+		after = &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	logger.Info("Matches: %d candidates scored for user %s, returning %d", scored, viewerID, len(matches))
+	return matches, nil
+}
+
+// scorePage scores one page of candidate profiles against the viewer. Owners,
+// audiences and mentee counts are loaded for the whole page at once.
+func (s *MatchingService) scorePage(ctx context.Context, viewerID uuid.UUID, viewerRole, candidateRole string, self *MatchProfile, results []*models.ProfileSearchResult) ([]*models.Match, error) {
+	ownerIDs := make([]uuid.UUID, 0, len(results))
+	for _, result := range results {
+		ownerIDs = append(ownerIDs, result.Profile.UserID)
+	}
+	users, err := s.users.GetByIDs(ctx, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[uuid.UUID]*models.User, len(users))
+	for _, user := range users {
+		owners[user.ID] = user
+	}
+	audiences, err := s.privacy.Audiences(ctx, viewerID, viewerRole, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	active := map[uuid.UUID]int{}
+	if candidateRole == constants.RoleMentor {
+		if active, err = s.mentorships.ActiveMenteeCounts(ctx, ownerIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	matches := make([]*models.Match, 0, len(results))
+	for _, result := range results {
+		profile := result.Profile
+		owner, ok := owners[profile.UserID]
+		if !ok {
+			continue
+		}
+		audience := audiences[owner.ID]
+		// Partners are already matched
+		if audience == constants.ProfileViewConnected || !s.privacy.Visible(profile, owner, viewerRole, audience) {
+			continue
+		}
+
+		view := s.privacy.Project(profile, owner, audience)
+		candidate := NewMatchProfile(&view, profile.MaxMentees, active[profile.UserID])
+		mentor, mentee := candidate, self
+		if candidateRole == constants.RoleMentee {
+			mentor, mentee = self, candidate
+		}
+		if candidateRole == constants.RoleMentor && mentor.OpenSlots() == 0 {
+			continue
+		}
+
+		match := s.Score(mentor, mentee)
+		match.Profile = &view
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// Score rates a mentor and mentee pair. The Profile of the result is not set.
+func (s *MatchingService) Score(mentor, mentee *MatchProfile) *models.Match {
+	factors := []models.MatchFactor{
+		expertiseFactor(mentor, mentee),
+		interestsFactor(mentor, mentee),
+		locationFactor(mentor, mentee),
+		languageFactor(mentor, mentee),
+		availabilityFactor(mentor, mentee),
+		capacityFactor(mentor),
+	}
+	weights := map[string]float64{
+		constants.MatchFactorExpertise:    s.weights.Expertise,
+		constants.MatchFactorInterests:    s.weights.Interests,
+		constants.MatchFactorLocation:     s.weights.Location,
+		constants.MatchFactorLanguage:     s.weights.Language,
+		constants.MatchFactorAvailability: s.weights.Availability,
+		constants.MatchFactorCapacity:     s.weights.Capacity,
+	}
+
+	var total float64
+	for _, f := range factors {
+		total += max(weights[f.Name], 0)
+	}
+	match := &models.Match{Factors: factors}
+	for i := range factors {
+		if total > 0 {
+			factors[i].Weight = round3(max(weights[factors[i].Name], 0) / total)
+		}
+		factors[i].Score = round3(factors[i].Score)
+		match.Score += factors[i].Score * factors[i].Weight
+	}
+	match.Score = round3(match.Score)
+	return match
+}
+
+// expertiseFactor is the share of the mentee's expertise areas the mentor covers
+func expertiseFactor(mentor, mentee *MatchProfile) models.MatchFactor {
+	f := models.MatchFactor{Name: constants.MatchFactorExpertise}
+	if len(mentee.Expertise) == 0 {
+		f.Detail = "The mentee has not listed expertise areas"
+		return f
+	}
+	f.Shared = intersect(mentee.Expertise, mentor.Expertise)
+	f.Score = float64(len(f.Shared)) / float64(len(mentee.Expertise))
+	f.Detail = fmt.Sprintf("The mentor covers %d of %d expertise areas", len(f.Shared), len(mentee.Expertise))
+	return f
+}
+
+// interestsFactor is the overlap of both interest lists (Jaccard index)
+func interestsFactor(mentor, mentee *MatchProfile) models.MatchFactor {
+	f := models.MatchFactor{Name: constants.MatchFactorInterests}
+	f.Shared = intersect(mentee.Interests, mentor.Interests)
+	union := len(mentee.Interests) + len(mentor.Interests) - len(f.Shared)
+	if union == 0 {
+		f.Detail = "No interests listed"
+		return f
+	}
+	f.Score = float64(len(f.Shared)) / float64(union)
+	f.Detail = fmt.Sprintf("%d shared interests", len(f.Shared))
+	return f
+}
+
+// locationFactor scores 1 for the same municipality and falls linearly to 0
+// at MatchLocationRangeKm between municipality centres. Locations outside the
+// gazetteer only score when their broadest parts are the same.
+func locationFactor(mentor, mentee *MatchProfile) models.MatchFactor {
+	f := models.MatchFactor{Name: constants.MatchFactorLocation}
+	a, aok := geo.LookupMunicipality(mentor.MunicipalityCode)
+	b, bok := geo.LookupMunicipality(mentee.MunicipalityCode)
+	switch {
+	case aok && bok && a.Code == b.Code:
+		f.Score = 1
+		f.Shared = []string{a.NameFI}
+		f.Detail = "Both in " + a.NameFI
+	case aok && bok:
+		distance := geo.DistanceKm(a, b)
+		f.Score = max(1-distance/constants.MatchLocationRangeKm, 0)
+		f.Detail = fmt.Sprintf("%s and %s are %.0f km apart", a.NameFI, b.NameFI, distance)
+	default:
+		a, b := coarseLocation(mentor.Location), coarseLocation(mentee.Location)
+		switch {
+		case a == "" || b == "":
+			f.Detail = "Location not shown"
+		case strings.EqualFold(a, b):
+			f.Score = 1
+			f.Shared = []string{a}
+			f.Detail = "Both in " + a
+		default:
+			f.Detail = fmt.Sprintf("%s and %s", a, b)
+		}
+	}
+	return f
+}
+
+// languageFactor scores 1 when the pair share a language
+func languageFactor(mentor, mentee *MatchProfile) models.MatchFactor {
+	f := models.MatchFactor{Name: constants.MatchFactorLanguage}
+	f.Shared = intersect(mentee.Languages, mentor.Languages)
+	switch {
+	case len(mentee.Languages) == 0 || len(mentor.Languages) == 0:
+		f.Detail = "Languages not listed"
+	case len(f.Shared) == 0:
+		f.Detail = "No common language"
+	default:
+		f.Score = 1
+		f.Detail = "Common language: " + strings.Join(f.Shared, ", ")
+	}
+	return f
+}
+
+// availabilityFactor is the share of the mentee's availability the mentor shares
+func availabilityFactor(mentor, mentee *MatchProfile) models.MatchFactor {
+	f := models.MatchFactor{Name: constants.MatchFactorAvailability}
+	if len(mentee.Availability) == 0 || len(mentor.Availability) == 0 {
+		f.Detail = "Availability not listed"
+		return f
+	}
+	f.Shared = intersect(mentee.Availability, mentor.Availability)
+	f.Score = float64(len(f.Shared)) / float64(len(mentee.Availability))
+	f.Detail = fmt.Sprintf("%d of %d time slots overlap", len(f.Shared), len(mentee.Availability))
+	return f
+}
+
+// capacityFactor favours mentors with more room, spreading mentees out
+func capacityFactor(mentor *MatchProfile) models.MatchFactor {
+	f := models.MatchFactor{Name: constants.MatchFactorCapacity}
+	if mentor.MaxMentees <= 0 {
+		f.Detail = "The mentor is not taking new mentees"
+		return f
+	}
+	f.Score = float64(mentor.OpenSlots()) / float64(mentor.MaxMentees)
+	f.Detail = fmt.Sprintf("%d of %d mentee slots open", mentor.OpenSlots(), mentor.MaxMentees)
+	return f
+}
+
+// intersect returns the values of a that are also in b, in the order of a
+func intersect(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, v := range b {
+		in[v] = true
+	}
+	var shared []string
+	for _, v := range a {
+		if in[v] {
+			shared = append(shared, v)
+			delete(in, v)
+		}
+	}
+	return shared
+}
+
+// jsonList reads a JSON array of strings, treating anything else as empty
+func jsonList(data datatypes.JSON) []string {
+	var values []string
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &values)
+	}
+	return values
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/pagination"
+	"mentori/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// Mentor application errors
+var (
+	ErrMentorApplicationNotFound = errors.New("mentor application not found")
+	ErrMentorApplicationPending  = errors.New("a mentor application is already waiting for review")
+	ErrMentorApplicationDecided  = errors.New("mentor application has already been reviewed")
+	ErrMentorApplicationRole     = errors.New("only mentees can apply to become mentors")
+)
+
+// MentorApplicationService runs the mentor approval workflow. Applicants keep
+// the mentee role until an admin approves them, and are emailed the decision.
+type MentorApplicationService struct {
+	repo     repository.MentorApplicationRepository
+	userRepo repository.UserRepository
+	sender   utils.EmailSender
+}
+
+// NewMentorApplicationService creates a new mentor application service
+func NewMentorApplicationService(repo repository.MentorApplicationRepository, userRepo repository.UserRepository, sender utils.EmailSender) *MentorApplicationService {
+	return &MentorApplicationService{
+		repo:     repo,
+		userRepo: userRepo,
+		sender:   sender,
+	}
+}
+
+// Apply opens a mentor application for a mentee
+func (s *MentorApplicationService) Apply(ctx context.Context, userID uuid.UUID, motivation string) (*models.MentorApplication, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != constants.RoleMentee {
+		return nil, ErrMentorApplicationRole
+	}
+
+	latest, err := s.repo.GetLatestByUser(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if latest != nil && latest.Status == constants.MentorApplicationPending {
+		return nil, ErrMentorApplicationPending
+	}
+
+	application := &models.MentorApplication{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Motivation: motivation,
+		Status:     constants.MentorApplicationPending,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.Create(ctx, application); err != nil {
+		return nil, err
+	}
+	logger.Info("Mentor application %s opened by user %s", application.ID, userID)
+	return application, nil
+}
+
+// Latest returns the user's most recent application
+func (s *MentorApplicationService) Latest(ctx context.Context, userID uuid.UUID) (*models.MentorApplication, error) {
+	application, err := s.repo.GetLatestByUser(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrMentorApplicationNotFound
+	}
+	return application, err
+}
+
+// List returns a page of applications, oldest first, optionally filtered by status
+func (s *MentorApplicationService) List(ctx context.Context, status string, page pagination.Params) (pagination.Page[*models.MentorApplication], error) {
+	applications, err := s.repo.List(ctx, status, page.Fetch(), page.After)
+	return pagination.NewPage(applications, page, func(a *models.MentorApplication) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: a.CreatedAt, ID: a.ID}
+	}), err
+}
+
+// Approve makes the applicant a mentor
+func (s *MentorApplicationService) Approve(ctx context.Context, id, reviewerID uuid.UUID, reason string) (*models.MentorApplication, error) {
+	return s.decide(ctx, id, reviewerID, constants.MentorApplicationApproved, reason)
+}
+
+// Reject turns the application down; the applicant stays a mentee and may apply again
+func (s *MentorApplicationService) Reject(ctx context.Context, id, reviewerID uuid.UUID, reason string) (*models.MentorApplication, error) {
+	return s.decide(ctx, id, reviewerID, constants.MentorApplicationRejected, reason)
+}
+
+func (s *MentorApplicationService) decide(ctx context.Context, id, reviewerID uuid.UUID, status, reason string) (*models.MentorApplication, error) {
+	application, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrMentorApplicationNotFound
+		}
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, application.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ok, err := s.repo.Decide(ctx, id, status, reason, reviewerID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMentorApplicationDecided
+	}
+	application.Status = status
+	application.Reason = reason
+	application.ReviewedBy = &reviewerID
+	application.ReviewedAt = &now
+	application.Email = user.Email
+	logger.Info("Mentor application %s %s by admin %s", id, status, reviewerID)
+
+	s.notify(ctx, user, application)
+	return application, nil
+}
+
+// notify emails the decision to the applicant. Failures are logged only, the
+// decision stands either way.
+func (s *MentorApplicationService) notify(ctx context.Context, user *models.User, application *models.MentorApplication) {
+	var subject, body string
+	if application.Status == constants.MentorApplicationApproved {
+		subject = "Your Mentori mentor application was approved"
+		body = "Congratulations, you are now a mentor on Mentori."
+	} else {
+		subject = "Your Mentori mentor application"
+		body = "Unfortunately your application to become a mentor on Mentori was not approved. You can keep using Mentori as a mentee and apply again later."
+	}
+	if application.Reason != "" {
+		body += fmt.Sprintf("\n\nMessage from the reviewer:\n%s", application.Reason)
+	}
+
+	if err := s.sender.Send(ctx, utils.EmailMessage{
+		To:      user.Email,
+		Subject: subject,
+		Body:    body,
+	}); err != nil {
+		logger.Error("MentorApplicationService: failed to notify user_id=%s: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/totp"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MFA errors
+var (
+	ErrMFANotAllowed       = errors.New("two-factor authentication is not available for your role")
+	ErrMFAAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrMFASetupRequired    = errors.New("start two-factor setup before enabling it")
+	ErrMFAInvalidCode      = errors.New("invalid two-factor code")
+	ErrMFAInvalidPassword  = errors.New("invalid password")
+	ErrMFAChallengeInvalid = errors.New("MFA challenge is invalid or expired")
+)
+
+// recoveryCodeAlphabet avoids characters that are easy to confuse when typed
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// MFAService manages TOTP enrollment, recovery codes and second-factor checks
+type MFAService struct {
+	userRepo      repository.UserRepository
+	recoveryRepo  repository.MFARecoveryCodeRepository
+	challengeRepo repository.MFAChallengeRepository
+	authz         *AuthorizationService
+}
+
+// NewMFAService creates a new MFA service. Enrollment is limited to roles
+// with the mfa:enroll permission.
+func NewMFAService(userRepo repository.UserRepository, recoveryRepo repository.MFARecoveryCodeRepository, challengeRepo repository.MFAChallengeRepository, authz *AuthorizationService) *MFAService {
+	return &MFAService{
+		userRepo:      userRepo,
+		recoveryRepo:  recoveryRepo,
+		challengeRepo: challengeRepo,
+		authz:         authz,
+	}
+}
+
+// BeginSetup generates a new pending TOTP secret for the user.
+// MFA is not enforced until Enable confirms a code from the authenticator app.
+func (s *MFAService) BeginSetup(ctx context.Context, userID uuid.UUID) (*models.MFASetupResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := s.authz.Can(ctx, user.Role, constants.PermissionMFAEnroll)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, ErrMFANotAllowed
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.MFASecret = secret
+	user.MFALastStep = 0
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &models.MFASetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(constants.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// Enable confirms enrollment with a TOTP code and returns fresh recovery codes
+func (s *MFAService) Enable(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == "" {
+		return nil, ErrMFASetupRequired
+	}
+	accepted, err := s.acceptTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrMFAInvalidCode
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.MFAEnabled = true
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns MFA off after checking the password and a TOTP or recovery
+// code, so that a stolen access token alone cannot turn it off
+func (s *MFAService) Disable(ctx context.Context, userID uuid.UUID, password, code string) error {
+	if err := s.checkPassword(ctx, userID, password); err != nil {
+		return err
+	}
+	user, err := s.Verify(ctx, userID, code)
+	if err != nil {
+		return err
+	}
+
+	if err := s.recoveryRepo.DeleteAll(ctx, user.ID); err != nil {
+		return err
+	}
+	user.MFAEnabled = false
+	user.MFASecret = ""
+	user.MFALastStep = 0
+	user.UpdatedAt = time.Now()
+	return s.userRepo.Update(ctx, user)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking the
+// password and a TOTP code
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, password, code string) ([]string, error) {
+	if err := s.checkPassword(ctx, userID, password); err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	accepted, err := s.acceptTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrMFAInvalidCode
+	}
+	return s.replaceRecoveryCodes(ctx, user.ID)
+}
+
+// checkPassword returns ErrMFAInvalidPassword unless password is the password
+// of a user with MFA enabled. Accounts without a password must set one, e.g.
+// with a password reset, before they can change their two-factor settings.
+func (s *MFAService) checkPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return ErrMFAInvalidPassword
+	}
+	return nil
+}
+
+// Verify checks a TOTP code or an unused recovery code for a user with MFA enabled
+func (s *MFAService) Verify(ctx context.Context, userID uuid.UUID, code string) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	accepted, err := s.acceptTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if accepted {
+		return user, nil
+	}
+
+	ok, err := s.recoveryRepo.Consume(ctx, user.ID, HashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMFAInvalidCode
+	}
+	return user, nil
+}
+
+// StartChallenge records a second login step for a user who passed the first
+// factor. Its ID is carried in the challenge token as the "jti" claim.
+func (s *MFAService) StartChallenge(ctx context.Context, userID uuid.UUID) (*models.MFAChallenge, error) {
+	now := time.Now()
+	challenge := &models.MFAChallenge{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ExpiresAt: now.Add(constants.MFAChallengeExpiry),
+		CreatedAt: now,
+	}
+	if err := s.challengeRepo.Create(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// CompleteChallenge checks a code against an open challenge and consumes the
+// challenge on success. Each challenge accepts MFAChallengeMaxAttempts codes
+// before it stops working.
+func (s *MFAService) CompleteChallenge(ctx context.Context, challengeID, userID uuid.UUID, code string) (*models.User, error) {
+	challenge, err := s.challengeRepo.GetByID(ctx, challengeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrMFAChallengeInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID != userID || challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	ok, err := s.challengeRepo.ConsumeAttempt(ctx, challenge.ID, constants.MFAChallengeMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMFAChallengeInvalid
+	}
+
+	user, err := s.Verify(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// A concurrent request may have completed the challenge with another code
+	used, err := s.challengeRepo.MarkUsed(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrMFAChallengeInvalid
+	}
+	return user, nil
+}
+
+// acceptTOTP validates a code and advances the user's last accepted step so
+// the same code cannot be replayed. The step is advanced with a conditional
+// write, so of two requests racing with one code only the first is accepted.
+func (s *MFAService) acceptTOTP(ctx context.Context, user *models.User, code string) (bool, error) {
+	step, ok := totp.Validate(user.MFASecret, code, time.Now())
+	if !ok || step <= user.MFALastStep {
+		return false, nil
+	}
+	advanced, err := s.userRepo.AdvanceMFAStep(ctx, user.ID, step)
+	if err != nil || !advanced {
+		return false, err
+	}
+	user.MFALastStep = step
+	return true, nil
+}
+
+func (s *MFAService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, constants.MFARecoveryCodeCount)
+	records := make([]*models.MFARecoveryCode, constants.MFARecoveryCodeCount)
+	now := time.Now()
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		records[i] = &models.MFARecoveryCode{
+			ID:        uuid.New(),
+			UserID:    userID,
+			CodeHash:  HashToken(normalizeRecoveryCode(code)),
+			CreatedAt: now,
+		}
+	}
+	if err := s.recoveryRepo.ReplaceAll(ctx, userID, records); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode returns a code formatted as xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	var sb strings.Builder
+	for i := 0; i < 10; i++ {
+		if i == 5 {
+			sb.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(recoveryCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// normalizeRecoveryCode ignores case and separators so users can type codes loosely
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -2,140 +2,136 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
 
 	"mentori/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Default provider endpoints
+const (
+	GoogleJWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+	AppleJWKSURL  = "https://appleid.apple.com/auth/keys"
+)
+
+// ErrNonceRequired is returned for provider tokens verified without a nonce.
+// Sign-ins must always pass one, so that a token issued to another client or
+// for another sign-in cannot be replayed.
+var ErrNonceRequired = errors.New("nonce is required")
+
+// OAuthConfig configures the trusted OAuth providers
+type OAuthConfig struct {
+	GoogleClientIDs []string
+	GoogleJWKSURL   string
+	AppleClientIDs  []string
+	AppleJWKSURL    string
+}
+
 // OAuthService handles OAuth authentication
 type OAuthService struct {
-	httpClient *http.Client
+	google *JWKSVerifier
+	apple  *JWKSVerifier
 }
 
 // NewOAuthService creates a new OAuth service
-func NewOAuthService() *OAuthService {
-	return &OAuthService{
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+func NewOAuthService(cfg OAuthConfig) *OAuthService {
+	if cfg.GoogleJWKSURL == "" {
+		cfg.GoogleJWKSURL = GoogleJWKSURL
 	}
-}
-
-// GoogleUserInfo represents Google user info response
-type GoogleUserInfo struct {
-	Sub           string      `json:"sub"` // User ID
-	Email         string      `json:"email"`
-	EmailVerified interface{} `json:"email_verified"` // Can be bool or string
-	Name          string      `json:"name"`
-	Picture       string      `json:"picture"`
-}
-
-// IsEmailVerified returns the email_verified value as a boolean
-func (g *GoogleUserInfo) IsEmailVerified() bool {
-	switch v := g.EmailVerified.(type) {
-	case bool:
-		return v
-	case string:
-		return v == "true"
-	default:
-		return false
+	if cfg.AppleJWKSURL == "" {
+		cfg.AppleJWKSURL = AppleJWKSURL
+	}
+	return &OAuthService{
+		google: NewJWKSVerifier(JWKSVerifierConfig{
+			JWKSURL:   cfg.GoogleJWKSURL,
+			Issuers:   []string{"https://accounts.google.com", "accounts.google.com"},
+			Audiences: cfg.GoogleClientIDs,
+		}),
+		apple: NewJWKSVerifier(JWKSVerifierConfig{
+			JWKSURL:   cfg.AppleJWKSURL,
+			Issuers:   []string{"https://appleid.apple.com"},
+			Audiences: cfg.AppleClientIDs,
+		}),
 	}
 }
 
-// AppleTokenClaims represents Apple ID token claims
-type AppleTokenClaims struct {
-	Iss            string `json:"iss"`
-	Sub            string `json:"sub"` // User ID
-	Aud            string `json:"aud"`
-	Email          string `json:"email"`
-	EmailVerified  string `json:"email_verified"`
-	IsPrivateEmail string `json:"is_private_email"`
-	jwt.RegisteredClaims
+// Start refreshes the provider key sets in the background until ctx is cancelled
+func (s *OAuthService) Start(ctx context.Context) {
+	s.google.Start(ctx)
+	s.apple.Start(ctx)
 }
 
-// VerifyGoogleToken verifies Google ID token and returns user info
-func (s *OAuthService) VerifyGoogleToken(ctx context.Context, idToken string) (*models.OAuthUser, error) {
-	// Call Google's tokeninfo endpoint to verify the token
-	url := fmt.Sprintf("https://oauth2.googleapis.com/tokeninfo?id_token=%s", idToken)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// VerifyGoogleToken verifies a Google ID token and returns user info
+func (s *OAuthService) VerifyGoogleToken(ctx context.Context, idToken, nonce string) (*models.OAuthUser, error) {
+	if nonce == "" {
+		return nil, ErrNonceRequired
 	}
-
-	resp, err := s.httpClient.Do(req)
+	claims, err := s.google.Verify(ctx, idToken, nonce)
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify token: %w", err)
+		return nil, fmt.Errorf("invalid google token: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("invalid token: %s", string(body))
-	}
+	name, _ := claims["name"].(string)
+	return oauthUserFromClaims(claims, name)
+}
 
-	var userInfo GoogleUserInfo
-	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// VerifyAppleToken verifies an Apple ID token and returns user info.
+// Apple embeds the SHA-256 hash of the client's raw nonce in the token.
+func (s *OAuthService) VerifyAppleToken(ctx context.Context, idToken, nonce string) (*models.OAuthUser, error) {
+	if nonce == "" {
+		return nil, ErrNonceRequired
 	}
+	sum := sha256.Sum256([]byte(nonce))
+	nonce = hex.EncodeToString(sum[:])
 
-	if userInfo.Email == "" || userInfo.Sub == "" {
-		return nil, errors.New("invalid token: missing required fields")
+	claims, err := s.apple.Verify(ctx, idToken, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apple token: %w", err)
 	}
 
-	return &models.OAuthUser{
-		Email:      userInfo.Email,
-		ProviderID: userInfo.Sub,
-		IsVerified: userInfo.IsEmailVerified(),
-		Name:       userInfo.Name,
-	}, nil
+	// Apple only sends the user's name to the client on first sign-in, never in the token
+	return oauthUserFromClaims(claims, "")
 }
 
-// VerifyAppleToken verifies Apple ID token and returns user info
-// Note: For production, you should verify the signature using Apple's public keys
-func (s *OAuthService) VerifyAppleToken(ctx context.Context, idToken string) (*models.OAuthUser, error) {
-	// Parse the token without verification (for development)
-	// In production, verify signature with Apple's public keys from https://appleid.apple.com/auth/keys
-	token, _, err := new(jwt.Parser).ParseUnverified(idToken, &AppleTokenClaims{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse token: %w", err)
-	}
-
-	claims, ok := token.Claims.(*AppleTokenClaims)
-	if !ok {
-		return nil, errors.New("invalid token claims")
+// VerifyToken verifies OAuth token based on provider
+func (s *OAuthService) VerifyToken(ctx context.Context, provider, idToken, nonce string) (*models.OAuthUser, error) {
+	switch provider {
+	case "google":
+		return s.VerifyGoogleToken(ctx, idToken, nonce)
+	case "apple":
+		return s.VerifyAppleToken(ctx, idToken, nonce)
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", provider)
 	}
+}
 
-	if claims.Email == "" || claims.Sub == "" {
+func oauthUserFromClaims(claims jwt.MapClaims, name string) (*models.OAuthUser, error) {
+	sub, _ := claims.GetSubject()
+	email, _ := claims["email"].(string)
+	if email == "" || sub == "" {
 		return nil, errors.New("invalid token: missing required fields")
 	}
 
-	// Apple's email_verified is a string "true" or "false"
-	emailVerified := claims.EmailVerified == "true"
-
 	return &models.OAuthUser{
-		Email:      claims.Email,
-		ProviderID: claims.Sub,
-		IsVerified: emailVerified,
-		Name:       "", // Apple may not provide name in token
+		Email:      email,
+		ProviderID: sub,
+		IsVerified: claimBool(claims["email_verified"]),
+		Name:       name,
 	}, nil
 }
 
-// VerifyToken verifies OAuth token based on provider
-func (s *OAuthService) VerifyToken(ctx context.Context, provider, idToken string) (*models.OAuthUser, error) {
-	switch provider {
-	case "google":
-		return s.VerifyGoogleToken(ctx, idToken)
-	case "apple":
-		return s.VerifyAppleToken(ctx, idToken)
+// claimBool reads a boolean claim that providers may encode as a bool or a string
+func claimBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
 	default:
-		return nil, fmt.Errorf("unsupported provider: %s", provider)
+		return false
 	}
 }
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+
+	"github.com/google/uuid"
+)
+
+// OAuth account errors
+var (
+	ErrOAuthAccountExists     = errors.New("an account with this email already exists, sign in and link the provider instead")
+	ErrOAuthIdentityInUse     = errors.New("this provider account is already linked to another user")
+	ErrOAuthProviderLinked    = errors.New("a different account from this provider is already linked")
+	ErrOAuthIdentityNotFound  = errors.New("provider is not linked to this account")
+	ErrOAuthLastLoginMethod   = errors.New("cannot unlink the only remaining login method")
+	ErrOAuthProviderInvalid   = errors.New("unsupported OAuth provider")
+	ErrOAuthTokenVerification = errors.New("provider token could not be verified")
+	ErrOAuthNonceInvalid      = errors.New("nonce is invalid, expired or already used")
+)
+
+// supportedOAuthProviders lists the providers accepted by OAuthAccountService
+var supportedOAuthProviders = map[string]bool{"google": true, "apple": true}
+
+// OAuthAccountService signs users in with OAuth identities and manages linked providers
+type OAuthAccountService struct {
+	oauth        *OAuthService
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	nonces       repository.OAuthNonceRepository
+}
+
+// NewOAuthAccountService creates a new OAuth account service
+func NewOAuthAccountService(oauth *OAuthService, userRepo repository.UserRepository, identityRepo repository.UserIdentityRepository, nonces repository.OAuthNonceRepository) *OAuthAccountService {
+	return &OAuthAccountService{
+		oauth:        oauth,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		nonces:       nonces,
+	}
+}
+
+// IssueNonce returns a nonce for the client to pass to the provider. Sign-in
+// nonces are issued with a sessionID of uuid.Nil; link nonces belong to the
+// caller's login session and are only accepted from it.
+func (s *OAuthAccountService) IssueNonce(ctx context.Context, sessionID uuid.UUID) (*models.OAuthNonceResponse, error) {
+	raw, err := GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	nonce := &models.OAuthNonce{
+		ID:        uuid.New(),
+		NonceHash: HashToken(raw),
+		ExpiresAt: now.Add(constants.OAuthNonceExpiry),
+		CreatedAt: now,
+	}
+	if sessionID != uuid.Nil {
+		nonce.SessionID = &sessionID
+	}
+	if err := s.nonces.Create(ctx, nonce); err != nil {
+		return nil, err
+	}
+	return &models.OAuthNonceResponse{Nonce: raw, ExpiresAt: nonce.ExpiresAt}, nil
+}
+
+// SignIn finds or creates the user for a provider ID token. An identity is
+// linked to an existing account with the same email only when both the local
+// account and the provider have verified that email. New accounts are mentees.
+// The nonce must come from IssueNonce without a session.
+func (s *OAuthAccountService) SignIn(ctx context.Context, provider, idToken, nonce string) (*models.User, bool, error) {
+	oauthUser, err := s.verify(ctx, provider, idToken, nonce, uuid.Nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	user, err := s.findByIdentity(ctx, provider, oauthUser.ProviderID)
+	if err == nil {
+		return user, false, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, false, err
+	}
+
+	existing, err := s.userRepo.GetByEmail(ctx, oauthUser.Email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, false, err
+	}
+	if existing != nil {
+		if !existing.IsVerified || !oauthUser.IsVerified {
+			return nil, false, ErrOAuthAccountExists
+		}
+		if err := s.createIdentity(ctx, existing.ID, provider, oauthUser); err != nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	}
+
+	providerID := oauthUser.ProviderID
+	now := time.Now()
+	user = &models.User{
+		ID:         uuid.New(),
+		Email:      oauthUser.Email,
+		Role:       constants.RoleMentee,
+		IsVerified: oauthUser.IsVerified,
+		Provider:   provider,
+		ProviderID: &providerID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, false, err
+	}
+	if err := s.createIdentity(ctx, user.ID, provider, oauthUser); err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+// Link attaches a provider identity to the given user. The nonce must come
+// from IssueNonce for the caller's login session.
+func (s *OAuthAccountService) Link(ctx context.Context, userID, sessionID uuid.UUID, provider, idToken, nonce string) (*models.UserIdentity, error) {
+	oauthUser, err := s.verify(ctx, provider, idToken, nonce, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.findByIdentity(ctx, provider, oauthUser.ProviderID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if owner != nil && owner.ID != userID {
+		return nil, ErrOAuthIdentityInUse
+	}
+
+	identities, err := s.identityRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		if identity.Provider != provider {
+			continue
+		}
+		if identity.ProviderID == oauthUser.ProviderID {
+			return identity, nil
+		}
+		return nil, ErrOAuthProviderLinked
+	}
+
+	identity := newIdentity(userID, provider, oauthUser)
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// Unlink removes a provider identity, keeping at least one way to sign in
+func (s *OAuthAccountService) Unlink(ctx context.Context, userID uuid.UUID, provider string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	identities, err := s.identityRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	linked := false
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			linked = true
+		}
+	}
+	if !linked {
+		return ErrOAuthIdentityNotFound
+	}
+	if user.PasswordHash == "" && len(identities) <= 1 {
+		return ErrOAuthLastLoginMethod
+	}
+
+	if err := s.identityRepo.Delete(ctx, userID, provider); err != nil {
+		return err
+	}
+
+	// Stop the sign-up identity from matching on the users table as well
+	if user.Provider == provider && user.ProviderID != nil {
+		user.ProviderID = nil
+		user.UpdatedAt = time.Now()
+		return s.userRepo.Update(ctx, user)
+	}
+	return nil
+}
+
+// ListIdentities returns the providers linked to a user
+func (s *OAuthAccountService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error) {
+	return s.identityRepo.ListByUser(ctx, userID)
+}
+
+// verify checks the provider token, then consumes the nonce it carries so the
+// token cannot be used again
+func (s *OAuthAccountService) verify(ctx context.Context, provider, idToken, nonce string, sessionID uuid.UUID) (*models.OAuthUser, error) {
+	if !supportedOAuthProviders[provider] {
+		return nil, ErrOAuthProviderInvalid
+	}
+	oauthUser, err := s.oauth.VerifyToken(ctx, provider, idToken, nonce)
+	if err != nil {
+		return nil, errors.Join(ErrOAuthTokenVerification, err)
+	}
+	consumed, err := s.nonces.Consume(ctx, HashToken(nonce), sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, errors.Join(ErrOAuthTokenVerification, ErrOAuthNonceInvalid)
+	}
+	return oauthUser, nil
+}
+
+// findByIdentity looks up the sign-up identity on users first, then linked identities
+func (s *OAuthAccountService) findByIdentity(ctx context.Context, provider, providerID string) (*models.User, error) {
+	user, err := s.userRepo.GetByProvider(ctx, provider, providerID)
+	if err == nil || !errors.Is(err, repository.ErrNotFound) {
+		return user, err
+	}
+
+	identity, err := s.identityRepo.GetByProvider(ctx, provider, providerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetByID(ctx, identity.UserID)
+}
+
+func (s *OAuthAccountService) createIdentity(ctx context.Context, userID uuid.UUID, provider string, oauthUser *models.OAuthUser) error {
+	return s.identityRepo.Create(ctx, newIdentity(userID, provider, oauthUser))
+}
+
+func newIdentity(userID uuid.UUID, provider string, oauthUser *models.OAuthUser) *models.UserIdentity {
+	return &models.UserIdentity{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Provider:   provider,
+		ProviderID: oauthUser.ProviderID,
+		Email:      oauthUser.Email,
+		CreatedAt:  time.Now(),
+	}
+}
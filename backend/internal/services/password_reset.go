@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+	"mentori/pkg/utils"
+	"mentori/pkg/validators"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrResetTokenInvalid is returned for unknown, used or expired reset tokens
+var ErrResetTokenInvalid = errors.New("password reset token is invalid or expired")
+
+// PasswordResetService handles forgotten password resets via emailed one-time tokens
+type PasswordResetService struct {
+	repo        repository.PasswordResetRepository
+	userRepo    repository.UserRepository
+	sessions    *SessionService
+	sender      utils.EmailSender
+	frontendURL string
+	policy      validators.PasswordPolicy
+	background  backgroundTasks
+}
+
+// NewPasswordResetService creates a new password reset service
+func NewPasswordResetService(repo repository.PasswordResetRepository, userRepo repository.UserRepository, sessions *SessionService, sender utils.EmailSender, frontendURL string, policy validators.PasswordPolicy) *PasswordResetService {
+	return &PasswordResetService{
+		repo:        repo,
+		userRepo:    userRepo,
+		sessions:    sessions,
+		sender:      sender,
+		frontendURL: frontendURL,
+		policy:      policy,
+	}
+}
+
+// RequestReset emails a reset link if the address belongs to a user.
+// It returns nil for unknown addresses and issues the link in the background,
+// swallowing throttled requests and delivery failures, so that callers can
+// learn neither from the response nor from its timing whether an email is
+// registered.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	s.background.run(ctx, "RequestReset", func(ctx context.Context) error {
+		return s.sendResetLink(ctx, user)
+	})
+	return nil
+}
+
+// Wait blocks until the reset links being issued in the background are sent
+func (s *PasswordResetService) Wait() {
+	s.background.wait()
+}
+
+func (s *PasswordResetService) sendResetLink(ctx context.Context, user *models.User) error {
+	throttled, err := s.throttled(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if throttled {
+		logger.Warn("RequestReset: password reset requests throttled for user_id=%s", user.ID)
+		return nil
+	}
+
+	// Only the most recently requested link is valid
+	if err := s.repo.InvalidateAllForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	raw, err := GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	token := &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: HashToken(raw),
+		ExpiresAt: now.Add(constants.PasswordResetExpiry),
+		CreatedAt: now,
+	}
+	if err := s.repo.Create(ctx, token); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.frontendURL, url.QueryEscape(raw))
+	if err := s.sender.Send(ctx, utils.EmailMessage{
+		To:      user.Email,
+		Subject: "Reset your Mentori password",
+		Body: fmt.Sprintf("Someone requested a password reset for your Mentori account.\n\nOpen this link to choose a new password:\n%s\n\nThe link expires in %d minutes and can be used once. If you did not request a reset, you can ignore this email.",
+			link, int(constants.PasswordResetExpiry.Minutes())),
+	}); err != nil {
+		return fmt.Errorf("failed to send reset email to user_id=%s: %w", user.ID, err)
+	}
+	return nil
+}
+
+// throttled reports whether the user has requested too many reset links recently
+func (s *PasswordResetService) throttled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	now := time.Now()
+	recent, err := s.repo.CountSince(ctx, userID, now.Add(-constants.PasswordResetResendInterval))
+	if err != nil || recent > 0 {
+		return recent > 0, err
+	}
+	count, err := s.repo.CountSince(ctx, userID, now.Add(-constants.PasswordResetRequestWindow))
+	if err != nil {
+		return false, err
+	}
+	return count >= constants.PasswordResetMaxRequests, nil
+}
+
+// ResetPassword consumes a reset token, sets the new password and revokes all
+// of the user's refresh tokens so that existing sessions must log in again.
+// A password rejected by the policy returns a *validators.PasswordError and
+// leaves the token usable for another attempt.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, rawToken, newPassword string) error {
+	token, err := s.repo.GetByHash(ctx, HashToken(rawToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrResetTokenInvalid
+		}
+		return err
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return ErrResetTokenInvalid
+	}
+
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrResetTokenInvalid
+		}
+		return err
+	}
+
+	if err := s.policy.Validate(newPassword, user.Email); err != nil {
+		return err
+	}
+
+	ok, err := s.repo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrResetTokenInvalid
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = string(hashedPassword)
+	user.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return s.sessions.RevokeAllForUser(ctx, user.ID)
+}
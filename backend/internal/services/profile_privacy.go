@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// ProfilePrivacyService decides how much of a profile a viewer may see and
+// builds the matching projection
+type ProfilePrivacyService struct {
+	authz       *AuthorizationService
+	mentorships repository.MentorshipRepository
+}
+
+// NewProfilePrivacyService creates a new profile privacy service
+func NewProfilePrivacyService(authz *AuthorizationService, mentorships repository.MentorshipRepository) *ProfilePrivacyService {
+	return &ProfilePrivacyService{
+		authz:       authz,
+		mentorships: mentorships,
+	}
+}
+
+// Audience returns the profile view the viewer is entitled to for the owner's profile
+// A viewerID of uuid.Nil is an anonymous caller.
+func (s *ProfilePrivacyService) Audience(ctx context.Context, viewerID uuid.UUID, viewerRole string, ownerID uuid.UUID) (string, error) {
+	audiences, err := s.Audiences(ctx, viewerID, viewerRole, []uuid.UUID{ownerID})
+	if err != nil {
+		return "", err
+	}
+	return audiences[ownerID], nil
+}
+
+// Audiences is Audience for many owners at once, e.g. a page of search
+// results, with one lookup of the viewer's mentorships
+func (s *ProfilePrivacyService) Audiences(ctx context.Context, viewerID uuid.UUID, viewerRole string, ownerIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	audiences := make(map[uuid.UUID]string, len(ownerIDs))
+	for _, id := range ownerIDs {
+		audiences[id] = constants.ProfileViewPublic
+	}
+	if viewerID == uuid.Nil {
+		return audiences, nil
+	}
+
+	staff, err := s.authz.Can(ctx, viewerRole, constants.PermissionProfilesReadPrivate)
+	if err != nil {
+		return nil, err
+	}
+	connected := make(map[uuid.UUID]bool)
+	if !staff {
+		partners, err := s.mentorships.ActivePartners(ctx, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range partners {
+			connected[id] = true
+		}
+	}
+
+	for _, id := range ownerIDs {
+		switch {
+		case id == viewerID || staff:
+			audiences[id] = constants.ProfileViewPrivate
+		case connected[id]:
+			audiences[id] = constants.ProfileViewConnected
+		}
+	}
+	return audiences, nil
+}
+
+// Visible reports whether a profile may be shown to the audience at all.
+// Inactive profiles are only shown in the private view, and public viewers
+// must match the owner's visibility setting. viewerRole is empty for
+// anonymous callers.
+func (s *ProfilePrivacyService) Visible(profile *models.Profile, owner *models.User, viewerRole, audience string) bool {
+	switch {
+	case audience == constants.ProfileViewPrivate:
+		return true
+	case !profile.IsActive:
+		return false
+	case audience == constants.ProfileViewConnected:
+		return true
+	}
+
+	switch profile.Visibility {
+	case constants.ProfileVisibilitySignedIn:
+		return viewerRole != ""
+	case constants.ProfileVisibilityOppositeRole:
+		return viewerRole != "" && oppositeRole(viewerRole) == owner.Role
+	default:
+		return true
+	}
+}
+
+// SearchScope returns the part of the directory the viewer may search.
+// A viewerID of uuid.Nil is an anonymous caller.
+func (s *ProfilePrivacyService) SearchScope(ctx context.Context, viewerID uuid.UUID, viewerRole string) (*models.ProfileSearchScope, error) {
+	if viewerID == uuid.Nil {
+		return &models.ProfileSearchScope{}, nil
+	}
+	staff, err := s.authz.Can(ctx, viewerRole, constants.PermissionProfilesReadPrivate)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ProfileSearchScope{
+		All:          staff,
+		SignedIn:     true,
+		OppositeRole: oppositeRole(viewerRole),
+	}, nil
+}
+
+// Project returns the profile as seen by the audience
+func (s *ProfilePrivacyService) Project(profile *models.Profile, owner *models.User, audience string) models.ProfileView {
+	view := models.ProfileView{
+		ID:           profile.ID,
+		UserID:       profile.UserID,
+		Role:         owner.Role,
+		FirstName:    profile.FirstName,
+		LastName:     profile.LastName,
+		Bio:          profile.Bio,
+		AvatarURL:    profile.AvatarURL,
+		Expertise:    profile.Expertise,
+		Interests:    profile.Interests,
+		Location:     profile.Location,
+		Languages:    profile.Languages,
+		Availability: profile.Availability,
+		View:         audience,
+		CreatedAt:    profile.CreatedAt,
+	}
+
+	switch audience {
+	case constants.ProfileViewPrivate:
+		view.Email = owner.Email
+		isActive := profile.IsActive
+		view.IsActive = &isActive
+	case constants.ProfileViewConnected:
+		view.Email = owner.Email
+	default:
+		if !profile.ShowLastName {
+			view.LastName = initial(profile.LastName)
+		}
+		if !profile.ShowExactLocation {
+			view.Location = coarseLocation(profile.Location)
+		}
+		hideFields(&view, profile.HiddenFields)
+	}
+	return view
+}
+
+// hideFields blanks the fields the owner has hidden
+func hideFields(view *models.ProfileView, hidden datatypes.JSON) {
+	var fields []string
+	if len(hidden) > 0 {
+		if err := json.Unmarshal(hidden, &fields); err != nil {
+			logger.Warn("ProfilePrivacyService: unreadable hidden_fields for profile_id=%s: %v", view.ID, err)
+		}
+	}
+	for _, field := range fields {
+		switch field {
+		case "first_name":
+			view.FirstName = ""
+		case "last_name":
+			view.LastName = ""
+		case "bio":
+			view.Bio = ""
+		case "avatar_url":
+			view.AvatarURL = ""
+		case "expertise":
+			view.Expertise = datatypes.JSON("[]")
+		case "interests":
+			view.Interests = datatypes.JSON("[]")
+		case "location":
+			view.Location = ""
+		case "languages":
+			view.Languages = datatypes.JSON("[]")
+		case "availability":
+			view.Availability = datatypes.JSON("[]")
+		}
+	}
+}
+
+// oppositeRole returns the role on the other side of a mentorship, or "" for
+// roles that do not take part in mentorships
+func oppositeRole(role string) string {
+	switch role {
+	case constants.RoleMentor:
+		return constants.RoleMentee
+	case constants.RoleMentee:
+		return constants.RoleMentor
+	default:
+		return ""
+	}
+}
+
+// initial shortens a name to its first letter, e.g. "Virtanen" to "V."
+func initial(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	r, _ := utf8.DecodeRuneInString(name)
+	return string(r) + "."
+}
+
+// coarseLocation keeps the broadest part of a comma-separated location,
+// e.g. "Kallio, Helsinki" becomes "Helsinki"
+func coarseLocation(location string) string {
+	parts := strings.Split(location, ",")
+	return strings.TrimSpace(parts[len(parts)-1])
+}
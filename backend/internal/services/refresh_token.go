@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// Refresh token errors
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// RefreshTokenService issues, rotates and revokes refresh tokens.
+// Only a SHA-256 hash of each token is stored; the raw value is returned to
+// the client once and never persisted.
+type RefreshTokenService struct {
+	repo repository.RefreshTokenRepository
+	ttl  time.Duration
+}
+
+// NewRefreshTokenService creates a new refresh token service
+func NewRefreshTokenService(repo repository.RefreshTokenRepository) *RefreshTokenService {
+	return &RefreshTokenService{
+		repo: repo,
+		ttl:  constants.RefreshTokenExpiry,
+	}
+}
+
+// Issue creates a refresh token that starts a new token family.
+// mfaVerified records whether the login completed a second factor.
+func (s *RefreshTokenService) Issue(ctx context.Context, userID uuid.UUID, mfaVerified bool) (string, *models.RefreshToken, error) {
+	raw, token, err := s.newToken(userID, uuid.New(), mfaVerified)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.repo.Create(ctx, token); err != nil {
+		return "", nil, err
+	}
+	return raw, token, nil
+}
+
+// Rotate consumes a refresh token and returns its successor in the same family.
+// Presenting a token that was already rotated or revoked revokes the whole family.
+func (s *RefreshTokenService) Rotate(ctx context.Context, rawToken string) (string, *models.RefreshToken, error) {
+	current, err := s.repo.GetByHash(ctx, HashToken(rawToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return "", nil, ErrRefreshTokenInvalid
+		}
+		return "", nil, err
+	}
+
+	if current.RevokedAt != nil {
+		return "", nil, s.handleReuse(ctx, current)
+	}
+	if time.Now().After(current.ExpiresAt) {
+		return "", nil, ErrRefreshTokenInvalid
+	}
+
+	nextID := uuid.New()
+	ok, err := s.repo.MarkReplaced(ctx, current.ID, nextID)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		// Lost a race with another request presenting the same token
+		return "", nil, s.handleReuse(ctx, current)
+	}
+
+	raw, next, err := s.newToken(current.UserID, current.FamilyID, current.MFAVerified)
+	if err != nil {
+		return "", nil, err
+	}
+	next.ID = nextID
+	if err := s.repo.Create(ctx, next); err != nil {
+		return "", nil, err
+	}
+	return raw, next, nil
+}
+
+// Revoke revokes the family of the given refresh token and returns the token.
+// Unknown tokens are ignored and return nil.
+func (s *RefreshTokenService) Revoke(ctx context.Context, rawToken string) (*models.RefreshToken, error) {
+	current, err := s.repo.GetByHash(ctx, HashToken(rawToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return current, s.repo.RevokeFamily(ctx, current.FamilyID)
+}
+
+// FamilyOf returns the family, and so the login session, of a refresh token
+func (s *RefreshTokenService) FamilyOf(ctx context.Context, rawToken string) (uuid.UUID, error) {
+	token, err := s.repo.GetByHash(ctx, HashToken(rawToken))
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return token.FamilyID, nil
+}
+
+// RevokeFamily revokes every token rotated from the same login
+func (s *RefreshTokenService) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return s.repo.RevokeFamily(ctx, familyID)
+}
+
+// RevokeAllForUser revokes every outstanding refresh token of a user
+func (s *RefreshTokenService) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.RevokeAllForUser(ctx, userID)
+}
+
+func (s *RefreshTokenService) newToken(userID, familyID uuid.UUID, mfaVerified bool) (string, *models.RefreshToken, error) {
+	raw, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	return raw, &models.RefreshToken{
+		ID:          uuid.New(),
+		UserID:      userID,
+		FamilyID:    familyID,
+		TokenHash:   HashToken(raw),
+		ExpiresAt:   now.Add(s.ttl),
+		MFAVerified: mfaVerified,
+		CreatedAt:   now,
+	}, nil
+}
+
+func (s *RefreshTokenService) handleReuse(ctx context.Context, token *models.RefreshToken) error {
+	logger.Warn("Refresh token reuse detected: user_id=%s family_id=%s", token.UserID, token.FamilyID)
+	if err := s.repo.RevokeFamily(ctx, token.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// GenerateOpaqueToken returns a random 256-bit URL-safe token
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex-encoded SHA-256 hash of an opaque token
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
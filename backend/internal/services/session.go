@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+
+	"github.com/google/uuid"
+)
+
+// Session errors
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session has been revoked")
+)
+
+// SessionInfo describes the device a login came from
+type SessionInfo struct {
+	DeviceName string // Optional name supplied by the client, otherwise derived from UserAgent
+	UserAgent  string
+	IPAddress  string
+}
+
+// SessionService tracks logins per device. Each session owns one refresh
+// token family; revoking the session revokes the family and rejects access
+// tokens carrying its ID.
+type SessionService struct {
+	repo          repository.AuthSessionRepository
+	refreshTokens *RefreshTokenService
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(repo repository.AuthSessionRepository, refreshTokens *RefreshTokenService) *SessionService {
+	return &SessionService{
+		repo:          repo,
+		refreshTokens: refreshTokens,
+	}
+}
+
+// Start records a new login and issues its first refresh token
+func (s *SessionService) Start(ctx context.Context, userID uuid.UUID, mfaVerified bool, info SessionInfo) (string, *models.AuthSession, error) {
+	raw, token, err := s.refreshTokens.Issue(ctx, userID, mfaVerified)
+	if err != nil {
+		return "", nil, err
+	}
+
+	deviceName := strings.TrimSpace(info.DeviceName)
+	if deviceName == "" {
+		deviceName = describeDevice(info.UserAgent)
+	}
+
+	now := time.Now()
+	session := &models.AuthSession{
+		ID:         token.FamilyID,
+		UserID:     userID,
+		DeviceName: truncate(deviceName, constants.SessionDeviceNameMax),
+		UserAgent:  truncate(info.UserAgent, 512),
+		IPAddress:  info.IPAddress,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return "", nil, err
+	}
+	return raw, session, nil
+}
+
+// Refresh rotates a refresh token and marks its session as used.
+// Reuse of a rotated token ends the whole session.
+func (s *SessionService) Refresh(ctx context.Context, rawToken string) (string, *models.RefreshToken, error) {
+	raw, token, err := s.refreshTokens.Rotate(ctx, rawToken)
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenReused) {
+			if familyID, familyErr := s.refreshTokens.FamilyOf(ctx, rawToken); familyErr == nil {
+				if revokeErr := s.repo.Revoke(ctx, familyID); revokeErr != nil {
+					return "", nil, revokeErr
+				}
+			}
+		}
+		return "", nil, err
+	}
+
+	if err := s.repo.Touch(ctx, token.FamilyID, time.Now()); err != nil {
+		return "", nil, err
+	}
+	return raw, token, nil
+}
+
+// Logout ends the session of the given refresh token. Unknown tokens are ignored.
+func (s *SessionService) Logout(ctx context.Context, rawToken string) error {
+	token, err := s.refreshTokens.Revoke(ctx, rawToken)
+	if err != nil || token == nil {
+		return err
+	}
+	return s.repo.Revoke(ctx, token.FamilyID)
+}
+
+// List returns the user's active sessions, most recently used first
+func (s *SessionService) List(ctx context.Context, userID uuid.UUID) ([]*models.AuthSession, error) {
+	return s.repo.ListActiveByUser(ctx, userID, time.Now().Add(-constants.RefreshTokenExpiry))
+}
+
+// Revoke ends one of the user's sessions
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID uuid.UUID) error {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	return s.end(ctx, session.ID)
+}
+
+// RevokeOthers ends every active session of the user except currentID and
+// returns how many were ended
+func (s *SessionService) RevokeOthers(ctx context.Context, userID, currentID uuid.UUID) (int, error) {
+	sessions, err := s.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if session.ID == currentID {
+			continue
+		}
+		if err := s.end(ctx, session.ID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// RevokeAllForUser ends every session and refresh token of the user, e.g. after a password reset
+func (s *SessionService) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	if err := s.repo.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.refreshTokens.RevokeAllForUser(ctx, userID)
+}
+
+// Validate checks that an access token's session is still active and records
+// its use at most once per SessionTouchInterval
+func (s *SessionService) Validate(ctx context.Context, sessionID uuid.UUID) error {
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if session.RevokedAt != nil {
+		return ErrSessionRevoked
+	}
+
+	if now := time.Now(); now.Sub(session.LastUsedAt) > constants.SessionTouchInterval {
+		return s.repo.Touch(ctx, session.ID, now)
+	}
+	return nil
+}
+
+func (s *SessionService) end(ctx context.Context, sessionID uuid.UUID) error {
+	if err := s.repo.Revoke(ctx, sessionID); err != nil {
+		return err
+	}
+	return s.refreshTokens.RevokeFamily(ctx, sessionID)
+}
+
+// describeDevice derives a short "Browser on OS" label from a User-Agent header
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"edg/", "Edge"},
+		{"opr/", "Opera"},
+		{"firefox/", "Firefox"},
+		{"chrome/", "Chrome"},
+		{"safari/", "Safari"},
+		{"okhttp", "Android app"},
+		{"cfnetwork", "iOS app"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	platform := "unknown device"
+	for _, o := range []struct{ token, name string }{
+		{"iphone", "iPhone"},
+		{"ipad", "iPad"},
+		{"android", "Android"},
+		{"windows", "Windows"},
+		{"mac os", "macOS"},
+		{"linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			platform = o.name
+			break
+		}
+	}
+
+	return browser + " on " + platform
+}
+
+// truncate shortens s to at most max characters
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/logger"
+)
+
+// Taxonomy errors
+var (
+	ErrTaxonomyUnknown    = errors.New("unknown term")
+	ErrTaxonomyDeprecated = errors.New("term is no longer available")
+)
+
+// TaxonomyService validates the expertise and interests profiles list and
+// serves them localised. Terms are cached and reloaded every TaxonomyCacheTTL.
+type TaxonomyService struct {
+	repo  repository.TaxonomyRepository
+	terms *reloadingCache[*taxonomySnapshot]
+}
+
+// taxonomySnapshot indexes the terms of one load
+type taxonomySnapshot struct {
+	ordered []*models.TaxonomyTerm
+	bySlug  map[string]map[string]*models.TaxonomyTerm // kind -> slug
+	byLabel map[string]map[string]*models.TaxonomyTerm // kind -> lower-case English label
+}
+
+// NewTaxonomyService creates a new taxonomy service
+func NewTaxonomyService(repo repository.TaxonomyRepository) *TaxonomyService {
+	s := &TaxonomyService{repo: repo}
+	s.terms = newReloadingCache("TaxonomyService", constants.TaxonomyCacheTTL, s.load)
+	return s
+}
+
+// Normalize validates the values a profile lists for a kind and returns
+// their slugs. Merged terms are replaced by the term they were merged into,
+// and duplicates are dropped. Deprecated terms may only be kept when they are
+// already in current. English labels are accepted in place of slugs for
+// clients written before the taxonomy.
+func (s *TaxonomyService) Normalize(ctx context.Context, kind string, values, current []string) ([]string, error) {
+	terms, err := s.terms.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kept := make(map[string]bool, len(current))
+	for _, slug := range current {
+		kept[slug] = true
+	}
+
+	slugs := make([]string, 0, len(values))
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		term, ok := terms.bySlug[kind][value]
+		if !ok {
+			term, ok = terms.byLabel[kind][strings.ToLower(value)]
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrTaxonomyUnknown, value)
+		}
+
+		original := term.Slug
+		for depth := 0; term.MergedInto != nil; depth++ {
+			next, ok := terms.bySlug[kind][*term.MergedInto]
+			if !ok || depth >= constants.TaxonomyMaxMergeDepth {
+				logger.Error("TaxonomyService: %s term %q has a broken merge chain", kind, original)
+				return nil, fmt.Errorf("%w: %q", ErrTaxonomyDeprecated, original)
+			}
+			term = next
+		}
+		if term.DeprecatedAt != nil && !kept[original] && !kept[term.Slug] {
+			return nil, fmt.Errorf("%w: %q", ErrTaxonomyDeprecated, original)
+		}
+
+		if !seen[term.Slug] {
+			seen[term.Slug] = true
+			slugs = append(slugs, term.Slug)
+		}
+	}
+	return slugs, nil
+}
+
+// Localized returns the terms currently offered, labelled in the language
+func (s *TaxonomyService) Localized(ctx context.Context, language string) (*models.TaxonomyResponse, error) {
+	terms, err := s.terms.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.TaxonomyResponse{
+		Language:  language,
+		Expertise: []models.TaxonomyOption{},
+		Interests: []models.TaxonomyOption{},
+	}
+	for _, term := range terms.ordered {
+		if term.DeprecatedAt != nil || term.MergedInto != nil {
+			continue
+		}
+		option := models.TaxonomyOption{Slug: term.Slug, Label: term.LabelEN}
+		if language == constants.LanguageFinnish && term.LabelFI != "" {
+			option.Label = term.LabelFI
+		}
+		switch term.Kind {
+		case constants.TaxonomyKindExpertise:
+			resp.Expertise = append(resp.Expertise, option)
+		case constants.TaxonomyKindInterest:
+			resp.Interests = append(resp.Interests, option)
+		}
+	}
+	return resp, nil
+}
+
+// Reload reads the terms from the database
+func (s *TaxonomyService) Reload(ctx context.Context) error {
+	_, err := s.terms.reload(ctx)
+	return err
+}
+
+func (s *TaxonomyService) load(ctx context.Context) (*taxonomySnapshot, error) {
+	list, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := &taxonomySnapshot{
+		ordered: list,
+		bySlug:  make(map[string]map[string]*models.TaxonomyTerm),
+		byLabel: make(map[string]map[string]*models.TaxonomyTerm),
+	}
+	for _, term := range list {
+		if terms.bySlug[term.Kind] == nil {
+			terms.bySlug[term.Kind] = make(map[string]*models.TaxonomyTerm)
+			terms.byLabel[term.Kind] = make(map[string]*models.TaxonomyTerm)
+		}
+		terms.bySlug[term.Kind][term.Slug] = term
+		terms.byLabel[term.Kind][strings.ToLower(term.LabelEN)] = term
+	}
+
+	return terms, nil
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing or verification
+const minRSAKeyBits = 2048
+
+// TokenIssuerConfig configures a TokenIssuer
+type TokenIssuerConfig struct {
+	Issuer string // "iss" claim set on issued tokens and required on verified ones
+	// SigningKey is an Ed25519 or RSA private key. Nil generates an ephemeral
+	// Ed25519 key, which is only suitable for development.
+	SigningKey crypto.Signer
+	// VerificationKeys are previous public keys still accepted while tokens
+	// signed with them have not expired
+	VerificationKeys []crypto.PublicKey
+}
+
+// TokenIssuer signs Mentori JWTs with an asymmetric key and verifies them
+// against the current and previous keys. Keys are identified by their RFC 7638
+// thumbprint in the "kid" header, and the public keys are published as a JWKS
+// document so other services can verify tokens without a shared secret.
+type TokenIssuer struct {
+	issuer string
+	signer crypto.Signer
+	kid    string
+	method jwt.SigningMethod
+	keys   map[string]issuerKey
+	jwks   []byte
+}
+
+type issuerKey struct {
+	public crypto.PublicKey
+	alg    string
+}
+
+// NewTokenIssuer creates a token issuer from the signing key and any previous verification keys
+func NewTokenIssuer(cfg TokenIssuerConfig) (*TokenIssuer, error) {
+	signer := cfg.SigningKey
+	if signer == nil {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		signer = key
+	}
+
+	t := &TokenIssuer{
+		issuer: cfg.Issuer,
+		signer: signer,
+		keys:   make(map[string]issuerKey),
+	}
+
+	var set jsonWebKeySet
+	for i, public := range append([]crypto.PublicKey{signer.Public()}, cfg.VerificationKeys...) {
+		jwk, err := newJSONWebKey(public)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := t.keys[jwk.Kid]; dup {
+			continue
+		}
+		if i == 0 {
+			t.kid = jwk.Kid
+			t.method = jwt.GetSigningMethod(jwk.Alg)
+		}
+		t.keys[jwk.Kid] = issuerKey{public: public, alg: jwk.Alg}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	jwks, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+	t.jwks = jwks
+	return t, nil
+}
+
+// Sign sets the issuer claim and signs the claims with the current key
+func (t *TokenIssuer) Sign(claims jwt.MapClaims) (string, error) {
+	if t.issuer != "" {
+		claims["iss"] = t.issuer
+	}
+	token := jwt.NewWithClaims(t.method, claims)
+	token.Header["kid"] = t.kid
+	return token.SignedString(t.signer)
+}
+
+// Parse verifies the signature, expiry and issuer of a token signed by any known key
+func (t *TokenIssuer) Parse(tokenString string) (jwt.MapClaims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if t.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(t.issuer))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.NewParser(opts...).ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := t.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+		// Each key verifies only the algorithm it was published with
+		if token.Method.Alg() != key.alg {
+			return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+		}
+		return key.public, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// KeyID returns the kid of the current signing key
+func (t *TokenIssuer) KeyID() string {
+	return t.kid
+}
+
+// JWKS returns the JSON Web Key Set document with every verification key
+func (t *TokenIssuer) JWKS() []byte {
+	return t.jwks
+}
+
+// LoadSigningKey reads an Ed25519 or RSA private key from a PEM file (PKCS#8 or PKCS#1)
+func LoadSigningKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %w", path, err)
+	}
+
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return k, nil
+	case *rsa.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("signing key %s must be Ed25519 or RSA", path)
+	}
+}
+
+// LoadVerificationKey reads a public key, or the public half of a private key, from a PEM file
+func LoadVerificationKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if block.Type != "PUBLIC KEY" {
+		signer, err := LoadSigningKey(path)
+		if err != nil {
+			return nil, err
+		}
+		return signer.Public(), nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse verification key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
+
+// newJSONWebKey converts a public key to a JWK whose kid is its RFC 7638 thumbprint
+func newJSONWebKey(public crypto.PublicKey) (jsonWebKey, error) {
+	var jwk jsonWebKey
+	var canonical string
+	switch k := public.(type) {
+	case ed25519.PublicKey:
+		jwk = jsonWebKey{
+			Kty: "OKP",
+			Alg: jwt.SigningMethodEdDSA.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}
+		canonical = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, jwk.X)
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return jwk, fmt.Errorf("RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+		jwk = jsonWebKey{
+			Kty: "RSA",
+			Alg: jwt.SigningMethodRS256.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}
+		canonical = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	default:
+		return jwk, errors.New("token keys must be Ed25519 or RSA")
+	}
+
+	sum := sha256.Sum256([]byte(canonical))
+	jwk.Kid = base64.RawURLEncoding.EncodeToString(sum[:])
+	jwk.Use = "sig"
+	return jwk, nil
+}
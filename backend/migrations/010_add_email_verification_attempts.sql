@@ -0,0 +1,2 @@
+-- Track failed confirmation attempts per verification code
+ALTER TABLE email_verifications ADD COLUMN IF NOT EXISTS attempts INTEGER DEFAULT 0;
@@ -0,0 +1,19 @@
+-- Create user_identities table so a user can sign in with several OAuth providers
+CREATE TABLE IF NOT EXISTS user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_provider_id ON user_identities(provider, provider_id);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+
+-- Backfill identities for users who signed up with an OAuth provider
+INSERT INTO user_identities (user_id, provider, provider_id, email)
+SELECT id, provider, provider_id, email
+FROM users
+WHERE provider IS NOT NULL AND provider <> 'local' AND provider_id IS NOT NULL
+ON CONFLICT (provider, provider_id) DO NOTHING;
@@ -0,0 +1,22 @@
+-- Failed login counters keyed by "account:<email>" or "ip:<address>"
+CREATE TABLE IF NOT EXISTS login_throttles (
+    key VARCHAR(320) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP
+);
+
+-- Account and IP lockouts caused by repeated failed logins
+CREATE TABLE IF NOT EXISTS lockout_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    scope VARCHAR(20) NOT NULL,
+    subject VARCHAR(320) NOT NULL,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ip_address VARCHAR(45),
+    failures INTEGER NOT NULL,
+    locked_until TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lockout_events_created_at ON lockout_events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_lockout_events_user_id ON lockout_events(user_id);
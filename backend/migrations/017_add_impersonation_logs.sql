@@ -0,0 +1,18 @@
+-- Audit log of admins impersonating users: token issuance and every request made with the token
+CREATE TABLE IF NOT EXISTS impersonation_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID NOT NULL,
+    subject_id UUID NOT NULL,
+    session_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    method VARCHAR(10),
+    path VARCHAR(512),
+    status INTEGER,
+    reason TEXT,
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- No foreign keys: the audit trail must outlive deleted users
+CREATE INDEX IF NOT EXISTS idx_impersonation_logs_actor_id ON impersonation_logs(actor_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_logs_subject_created ON impersonation_logs(subject_id, created_at DESC);
@@ -0,0 +1,56 @@
+-- Roles and the permissions granted to them. Permission names come from the
+-- registry in pkg/constants; unknown names are ignored by the server.
+--
+-- New roles need no code change, e.g. a moderator:
+--   INSERT INTO roles (name, description) VALUES ('moderator', 'Community moderation');
+--   INSERT INTO role_permissions (role, permission) VALUES
+--       ('moderator', 'users:unlock'), ('moderator', 'audit:read'), ('moderator', 'mfa:enroll');
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+-- Seed the built-in roles. Permissions are only inserted together with a newly
+-- created role, so permissions removed later by an operator stay removed.
+WITH new_roles AS (
+    INSERT INTO roles (name, description) VALUES
+        ('admin', 'Platform administrator'),
+        ('mentor', 'Mentor offering guidance'),
+        ('mentee', 'Mentee looking for a mentor')
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT defaults.role, defaults.permission
+FROM (VALUES
+    ('admin', 'users:delete'),
+    ('admin', 'users:unlock'),
+    ('admin', 'users:impersonate'),
+    ('admin', 'users:manage_roles'),
+    ('admin', 'profiles:read_private'),
+    ('admin', 'audit:read'),
+    ('admin', 'mfa:enroll'),
+    ('mentor', 'mfa:enroll')
+) AS defaults(role, permission)
+JOIN new_roles ON new_roles.name = defaults.role;
+
+-- users.role used to be limited by a CHECK constraint; it now references roles
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_role;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'fk_users_role'
+    ) THEN
+        ALTER TABLE users ADD CONSTRAINT fk_users_role
+            FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
+    END IF;
+END $$;
@@ -0,0 +1,36 @@
+-- Mentor applications. Users who sign up as mentors start as mentees and
+-- become mentors once an admin approves their application.
+CREATE TABLE IF NOT EXISTS mentor_applications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    motivation TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reason TEXT,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_mentor_applications_status'
+    ) THEN
+        ALTER TABLE mentor_applications ADD CONSTRAINT chk_mentor_applications_status
+            CHECK (status IN ('pending', 'approved', 'rejected'));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_mentor_applications_user_id ON mentor_applications(user_id);
+CREATE INDEX IF NOT EXISTS idx_mentor_applications_status ON mentor_applications(status, created_at);
+
+-- At most one open application per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mentor_applications_one_pending
+    ON mentor_applications(user_id) WHERE status = 'pending';
+
+-- Let admins review applications unless an operator has already granted the
+-- permission to some role
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', 'mentors:approve'
+WHERE EXISTS (SELECT 1 FROM roles WHERE name = 'admin')
+  AND NOT EXISTS (SELECT 1 FROM role_permissions WHERE permission = 'mentors:approve');
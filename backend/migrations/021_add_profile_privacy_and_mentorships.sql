@@ -0,0 +1,22 @@
+-- Profile privacy settings. Viewers without a mentorship with the owner see
+-- only the last name's initial and the broadest part of the location unless
+-- the owner opts in to showing them.
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS show_last_name BOOLEAN DEFAULT FALSE;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS show_exact_location BOOLEAN DEFAULT FALSE;
+
+-- Mentor-mentee pairs
+CREATE TABLE IF NOT EXISTS mentorships (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    mentor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mentee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ended_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mentorships_mentor_id ON mentorships(mentor_id);
+CREATE INDEX IF NOT EXISTS idx_mentorships_mentee_id ON mentorships(mentee_id);
+
+-- A mentor and mentee have at most one active mentorship
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mentorships_active_pair
+    ON mentorships(mentor_id, mentee_id) WHERE status = 'active';
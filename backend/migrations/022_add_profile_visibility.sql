@@ -0,0 +1,9 @@
+-- Profile visibility. Owners choose who may find and open their profile,
+-- hide single fields from viewers without a mentorship, and can leave search
+-- altogether while staying reachable by existing mentorship partners.
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'everyone';
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS hidden_fields JSONB DEFAULT '[]'::jsonb;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS hidden_from_search BOOLEAN DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_profiles_search_visibility
+    ON profiles(visibility) WHERE is_active AND NOT hidden_from_search;
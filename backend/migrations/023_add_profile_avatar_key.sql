@@ -0,0 +1,3 @@
+-- Uploaded avatars. avatar_key is the storage prefix of the thumbnails so the
+-- previous upload can be deleted when the avatar is replaced.
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS avatar_key VARCHAR(255) DEFAULT '';
@@ -0,0 +1,94 @@
+-- Expertise and interest taxonomy. Profiles store stable slugs; labels come
+-- in English and Finnish. To retire a term, set deprecated_at so it is no
+-- longer offered, and set merged_into as well to have profile writes rewrite
+-- it to the replacement, e.g.
+--   UPDATE taxonomy_terms SET deprecated_at = NOW(), merged_into = 'gym-fitness'
+--   WHERE kind = 'interest' AND slug = 'indoor-sports';
+CREATE TABLE IF NOT EXISTS taxonomy_terms (
+    kind VARCHAR(20) NOT NULL,
+    slug VARCHAR(64) NOT NULL,
+    label_en TEXT NOT NULL,
+    label_fi TEXT NOT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    deprecated_at TIMESTAMP,
+    merged_into VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (kind, slug)
+);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_taxonomy_terms_kind'
+    ) THEN
+        ALTER TABLE taxonomy_terms ADD CONSTRAINT chk_taxonomy_terms_kind
+            CHECK (kind IN ('expertise', 'interest'));
+    END IF;
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'fk_taxonomy_terms_merged_into'
+    ) THEN
+        ALTER TABLE taxonomy_terms ADD CONSTRAINT fk_taxonomy_terms_merged_into
+            FOREIGN KEY (kind, merged_into) REFERENCES taxonomy_terms(kind, slug);
+    END IF;
+END $$;
+
+-- The options previously hard-coded in the backend and frontend
+INSERT INTO taxonomy_terms (kind, slug, label_en, label_fi, sort_order) VALUES
+    ('expertise', 'find-a-job', 'Find a Job', 'Työnhaku', 10),
+    ('expertise', 'bachelors-degree', 'Bachelor''s Degree', 'Kandidaatin tutkinto', 20),
+    ('expertise', 'masters-degree', 'Master''s Degree', 'Maisterin tutkinto', 30),
+    ('expertise', 'doctoral-studies', 'Doctoral Studies', 'Tohtoriopinnot', 40),
+    ('expertise', 'yki-test-preparation', 'YKI Test Preparation', 'YKI-testiin valmistautuminen', 50),
+    ('expertise', 'finnish-marriage-family', 'Finnish Marriage & Family', 'Avioliitto ja perhe Suomessa', 60),
+    ('expertise', 'work-life-balance', 'Work-Life Balance', 'Työn ja vapaa-ajan tasapaino', 70),
+    ('expertise', 'starting-a-business', 'Starting a Business', 'Yrityksen perustaminen', 80),
+    ('expertise', 'housing-relocation', 'Housing & Relocation', 'Asuminen ja muutto', 90),
+    ('expertise', 'finnish-language-learning', 'Finnish Language Learning', 'Suomen kielen opiskelu', 100),
+    ('expertise', 'healthcare-system', 'Healthcare System', 'Terveydenhuoltojärjestelmä', 110),
+    ('expertise', 'education-system', 'Education System', 'Koulutusjärjestelmä', 120),
+    ('expertise', 'banking-finance', 'Banking & Finance', 'Pankkiasiat ja talous', 130),
+    ('expertise', 'integration-culture', 'Integration & Culture', 'Kotoutuminen ja kulttuuri', 140),
+    ('expertise', 'networking-socializing', 'Networking & Socializing', 'Verkostoituminen ja sosiaalinen elämä', 150),
+    ('interest', 'reading-books', 'Reading & Books', 'Lukeminen ja kirjat', 10),
+    ('interest', 'bars-nightlife', 'Bars & Nightlife', 'Baarit ja yöelämä', 20),
+    ('interest', 'musical-instruments', 'Musical Instruments', 'Soittimet', 30),
+    ('interest', 'hiking-outdoor', 'Hiking & Outdoor', 'Retkeily ja ulkoilu', 40),
+    ('interest', 'indoor-sports', 'Indoor Sports', 'Sisäliikunta', 50),
+    ('interest', 'gym-fitness', 'Gym & Fitness', 'Kuntosali ja kuntoilu', 60),
+    ('interest', 'winter-sports', 'Winter Sports', 'Talviurheilu', 70),
+    ('interest', 'board-games', 'Board Games', 'Lautapelit', 80),
+    ('interest', 'coffee-culture', 'Coffee Culture', 'Kahvikulttuuri', 90),
+    ('interest', 'foodie-restaurants', 'Foodie & Restaurants', 'Ruoka ja ravintolat', 100),
+    ('interest', 'arts-museums', 'Arts & Museums', 'Taide ja museot', 110),
+    ('interest', 'tech-gaming', 'Tech & Gaming', 'Teknologia ja pelaaminen', 120),
+    ('interest', 'music-concerts', 'Music & Concerts', 'Musiikki ja konsertit', 130),
+    ('interest', 'photography', 'Photography', 'Valokuvaus', 140),
+    ('interest', 'crafts-diy', 'Crafts & DIY', 'Käsityöt ja tee-se-itse', 150),
+    ('interest', 'movies-tv-shows', 'Movies & TV Shows', 'Elokuvat ja TV-sarjat', 160),
+    ('interest', 'cooking-baking', 'Cooking & Baking', 'Ruoanlaitto ja leivonta', 170),
+    ('interest', 'traveling', 'Traveling', 'Matkailu', 180),
+    ('interest', 'cycling', 'Cycling', 'Pyöräily', 190),
+    ('interest', 'running', 'Running', 'Juoksu', 200)
+ON CONFLICT (kind, slug) DO NOTHING;
+
+-- Profiles written before the taxonomy store English labels; replace the
+-- labels that match a term with its slug and keep anything else as it is
+UPDATE profiles p SET expertise = (
+    SELECT COALESCE(jsonb_agg(COALESCE(t.slug, e.value) ORDER BY e.ord), '[]'::jsonb)
+    FROM jsonb_array_elements_text(p.expertise) WITH ORDINALITY AS e(value, ord)
+    LEFT JOIN taxonomy_terms t ON t.kind = 'expertise' AND t.label_en = e.value
+)
+WHERE jsonb_typeof(p.expertise) = 'array' AND EXISTS (
+    SELECT 1 FROM jsonb_array_elements_text(p.expertise) AS e(value)
+    JOIN taxonomy_terms t ON t.kind = 'expertise' AND t.label_en = e.value
+);
+
+UPDATE profiles p SET interests = (
+    SELECT COALESCE(jsonb_agg(COALESCE(t.slug, e.value) ORDER BY e.ord), '[]'::jsonb)
+    FROM jsonb_array_elements_text(p.interests) WITH ORDINALITY AS e(value, ord)
+    LEFT JOIN taxonomy_terms t ON t.kind = 'interest' AND t.label_en = e.value
+)
+WHERE jsonb_typeof(p.interests) = 'array' AND EXISTS (
+    SELECT 1 FROM jsonb_array_elements_text(p.interests) AS e(value)
+    JOIN taxonomy_terms t ON t.kind = 'interest' AND t.label_en = e.value
+);
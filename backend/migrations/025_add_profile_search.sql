@@ -0,0 +1,62 @@
+-- Free-text profile search. search_vector holds the name, the Finnish and
+-- English labels of the profile's expertise and interests, and the bio,
+-- stemmed in both languages. search_text holds the name and term labels for
+-- trigram matching, which finds profiles despite typos. Fields the owner hid
+-- are left out of both, so a search never reveals them.
+--
+-- Both columns are filled by a trigger on every write. Renamed taxonomy labels
+-- reach a profile on its next save; to refresh them all at once, run
+--   UPDATE profiles SET search_text = search_text;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS search_vector TSVECTOR;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS search_text TEXT NOT NULL DEFAULT '';
+
+CREATE OR REPLACE FUNCTION profiles_search_document() RETURNS TRIGGER AS $$
+DECLARE
+    hidden JSONB := COALESCE(NEW.hidden_fields, '[]'::jsonb);
+    display_name TEXT := '';
+    bio_text TEXT := '';
+    term_labels TEXT := '';
+BEGIN
+    IF NOT hidden @> '["first_name"]'::jsonb THEN
+        display_name := COALESCE(NEW.first_name, '');
+    END IF;
+    -- The last name is only searchable where it is shown in full
+    IF NEW.show_last_name AND NOT hidden @> '["last_name"]'::jsonb THEN
+        display_name := display_name || ' ' || COALESCE(NEW.last_name, '');
+    END IF;
+    IF NOT hidden @> '["bio"]'::jsonb THEN
+        bio_text := COALESCE(NEW.bio, '');
+    END IF;
+
+    SELECT COALESCE(string_agg(t.label_en || ' ' || t.label_fi, ' '), '') INTO term_labels
+    FROM taxonomy_terms t
+    WHERE (t.kind = 'expertise' AND NOT hidden @> '["expertise"]'::jsonb
+            AND COALESCE(NEW.expertise, '[]'::jsonb) @> jsonb_build_array(t.slug))
+       OR (t.kind = 'interest' AND NOT hidden @> '["interests"]'::jsonb
+            AND COALESCE(NEW.interests, '[]'::jsonb) @> jsonb_build_array(t.slug));
+
+    NEW.search_text := lower(trim(display_name || ' ' || term_labels));
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', display_name), 'A') ||
+        setweight(to_tsvector('finnish', term_labels), 'B') ||
+        setweight(to_tsvector('english', term_labels), 'B') ||
+        setweight(to_tsvector('finnish', bio_text), 'C') ||
+        setweight(to_tsvector('english', bio_text), 'C');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_profiles_search_document ON profiles;
+CREATE TRIGGER trg_profiles_search_document
+    BEFORE INSERT OR UPDATE ON profiles
+    FOR EACH ROW EXECUTE FUNCTION profiles_search_document();
+
+-- Fill the columns for profiles written before the trigger existed
+UPDATE profiles SET search_text = search_text WHERE search_vector IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_profiles_search_vector
+    ON profiles USING GIN (search_vector) WHERE is_active AND NOT hidden_from_search;
+CREATE INDEX IF NOT EXISTS idx_profiles_search_text_trgm
+    ON profiles USING GIN (search_text gin_trgm_ops) WHERE is_active AND NOT hidden_from_search;
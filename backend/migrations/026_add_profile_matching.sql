@@ -0,0 +1,6 @@
+-- Matching inputs: spoken languages (ISO 639-1 codes), coarse weekly
+-- availability and, for mentors, how many active mentees they take on.
+-- Active mentee counts use idx_mentorships_active_pair.
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS languages JSONB DEFAULT '[]'::jsonb;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS availability JSONB DEFAULT '[]'::jsonb;
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS max_mentees INTEGER NOT NULL DEFAULT 3;
@@ -0,0 +1,50 @@
+-- Cohorts: a batch of mentees assigned to a pool of mentors in one go. The
+-- computed pairs are a draft that admins review and adjust; publishing the
+-- cohort creates the mentorships.
+CREATE TABLE IF NOT EXISTS cohorts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    min_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    published_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    published_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_cohorts_status'
+    ) THEN
+        ALTER TABLE cohorts ADD CONSTRAINT chk_cohorts_status
+            CHECK (status IN ('draft', 'published'));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_cohorts_status ON cohorts(status);
+
+CREATE TABLE IF NOT EXISTS cohort_mentors (
+    cohort_id UUID NOT NULL REFERENCES cohorts(id) ON DELETE CASCADE,
+    mentor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    capacity INTEGER NOT NULL,
+    PRIMARY KEY (cohort_id, mentor_id)
+);
+
+CREATE TABLE IF NOT EXISTS cohort_pairs (
+    cohort_id UUID NOT NULL REFERENCES cohorts(id) ON DELETE CASCADE,
+    mentee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mentor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    factors JSONB,
+    adjusted BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (cohort_id, mentee_id)
+);
+
+-- Let admins run cohort assignments unless an operator has already granted
+-- the permission to some role
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', 'mentorships:assign'
+WHERE EXISTS (SELECT 1 FROM roles WHERE name = 'admin')
+  AND NOT EXISTS (SELECT 1 FROM role_permissions WHERE permission = 'mentorships:assign');
@@ -0,0 +1,420 @@
+-- Municipality of the profile location, from the gazetteer in pkg/geo.
+-- Profile writes set it; existing locations are matched below on the broadest
+-- part of the location against the Finnish and Swedish municipality names.
+-- Near and region searches filter on it.
+ALTER TABLE profiles ADD COLUMN IF NOT EXISTS municipality_code VARCHAR(3) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_profiles_municipality_code ON profiles (municipality_code);
+
+UPDATE profiles SET municipality_code = names.code
+FROM (VALUES
+    ('alajärvi', '005'),
+    ('alavieska', '009'),
+    ('alavus', '010'),
+    ('alavo', '010'),
+    ('asikkala', '016'),
+    ('askola', '018'),
+    ('aura', '019'),
+    ('akaa', '020'),
+    ('brändö', '035'),
+    ('eckerö', '043'),
+    ('enonkoski', '046'),
+    ('enontekiö', '047'),
+    ('enontekis', '047'),
+    ('espoo', '049'),
+    ('esbo', '049'),
+    ('eura', '050'),
+    ('eurajoki', '051'),
+    ('euraåminne', '051'),
+    ('evijärvi', '052'),
+    ('finström', '060'),
+    ('forssa', '061'),
+    ('föglö', '062'),
+    ('geta', '065'),
+    ('haapajärvi', '069'),
+    ('haapavesi', '071'),
+    ('hailuoto', '072'),
+    ('karlö', '072'),
+    ('halsua', '074'),
+    ('hamina', '075'),
+    ('fredrikshamn', '075'),
+    ('hammarland', '076'),
+    ('hankasalmi', '077'),
+    ('hanko', '078'),
+    ('hangö', '078'),
+    ('harjavalta', '079'),
+    ('hartola', '081'),
+    ('gustav adolfs', '081'),
+    ('hattula', '082'),
+    ('hausjärvi', '086'),
+    ('heinävesi', '090'),
+    ('helsinki', '091'),
+    ('helsingfors', '091'),
+    ('vantaa', '092'),
+    ('vanda', '092'),
+    ('hirvensalmi', '097'),
+    ('hollola', '098'),
+    ('huittinen', '102'),
+    ('vittis', '102'),
+    ('humppila', '103'),
+    ('hyrynsalmi', '105'),
+    ('hyvinkää', '106'),
+    ('hyvinge', '106'),
+    ('hämeenkyrö', '108'),
+    ('tavastkyro', '108'),
+    ('hämeenlinna', '109'),
+    ('tavastehus', '109'),
+    ('heinola', '111'),
+    ('ii', '139'),
+    ('iisalmi', '140'),
+    ('idensalmi', '140'),
+    ('iitti', '142'),
+    ('ikaalinen', '143'),
+    ('ikalis', '143'),
+    ('ilmajoki', '145'),
+    ('ilmola', '145'),
+    ('ilomantsi', '146'),
+    ('ilomants', '146'),
+    ('inari', '148'),
+    ('enare', '148'),
+    ('inkoo', '149'),
+    ('ingå', '149'),
+    ('isojoki', '151'),
+    ('storå', '151'),
+    ('isokyrö', '152'),
+    ('storkyro', '152'),
+    ('imatra', '153'),
+    ('janakkala', '165'),
+    ('joensuu', '167'),
+    ('jokioinen', '169'),
+    ('jockis', '169'),
+    ('jomala', '170'),
+    ('joroinen', '171'),
+    ('jorois', '171'),
+    ('joutsa', '172'),
+    ('juuka', '176'),
+    ('juupajoki', '177'),
+    ('juva', '178'),
+    ('jockas', '178'),
+    ('jyväskylä', '179'),
+    ('jämijärvi', '181'),
+    ('jämsä', '182'),
+    ('järvenpää', '186'),
+    ('träskända', '186'),
+    ('kaarina', '202'),
+    ('s:t karins', '202'),
+    ('kaavi', '204'),
+    ('kajaani', '205'),
+    ('kajana', '205'),
+    ('kalajoki', '208'),
+    ('kangasala', '211'),
+    ('kangasniemi', '213'),
+    ('kankaanpää', '214'),
+    ('kannonkoski', '216'),
+    ('kannus', '217'),
+    ('karijoki', '218'),
+    ('bötom', '218'),
+    ('karkkila', '224'),
+    ('högfors', '224'),
+    ('karstula', '226'),
+    ('karvia', '230'),
+    ('kaskinen', '231'),
+    ('kaskö', '231'),
+    ('kauhajoki', '232'),
+    ('kauhava', '233'),
+    ('kauniainen', '235'),
+    ('grankulla', '235'),
+    ('kaustinen', '236'),
+    ('kaustby', '236'),
+    ('keitele', '239'),
+    ('kemi', '240'),
+    ('keminmaa', '241'),
+    ('kempele', '244'),
+    ('kerava', '245'),
+    ('kervo', '245'),
+    ('keuruu', '249'),
+    ('kihniö', '250'),
+    ('kinnula', '256'),
+    ('kirkkonummi', '257'),
+    ('kyrkslätt', '257'),
+    ('kitee', '260'),
+    ('kittilä', '261'),
+    ('kiuruvesi', '263'),
+    ('kivijärvi', '265'),
+    ('kokemäki', '271'),
+    ('kumo', '271'),
+    ('kokkola', '272'),
+    ('karleby', '272'),
+    ('kolari', '273'),
+    ('konnevesi', '275'),
+    ('kontiolahti', '276'),
+    ('korsnäs', '280'),
+    ('koski tl', '284'),
+    ('kotka', '285'),
+    ('kouvola', '286'),
+    ('kristiinankaupunki', '287'),
+    ('kristinestad', '287'),
+    ('kruunupyy', '288'),
+    ('kronoby', '288'),
+    ('kuhmo', '290'),
+    ('kuhmoinen', '291'),
+    ('kuhmois', '291'),
+    ('kumlinge', '295'),
+    ('kuopio', '297'),
+    ('kuortane', '300'),
+    ('kurikka', '301'),
+    ('kustavi', '304'),
+    ('gustavs', '304'),
+    ('kuusamo', '305'),
+    ('outokumpu', '309'),
+    ('kyyjärvi', '312'),
+    ('kärkölä', '316'),
+    ('kärsämäki', '317'),
+    ('kökar', '318'),
+    ('kemijärvi', '320'),
+    ('kemiönsaari', '322'),
+    ('kimitoön', '322'),
+    ('lahti', '398'),
+    ('lahtis', '398'),
+    ('laihia', '399'),
+    ('laihela', '399'),
+    ('laitila', '400'),
+    ('lapinlahti', '402'),
+    ('lappajärvi', '403'),
+    ('lappeenranta', '405'),
+    ('villmanstrand', '405'),
+    ('lapinjärvi', '407'),
+    ('lappträsk', '407'),
+    ('lapua', '408'),
+    ('lappo', '408'),
+    ('laukaa', '410'),
+    ('lemi', '416'),
+    ('lemland', '417'),
+    ('lempäälä', '418'),
+    ('leppävirta', '420'),
+    ('lestijärvi', '421'),
+    ('lieksa', '422'),
+    ('lieto', '423'),
+    ('lundo', '423'),
+    ('liminka', '425'),
+    ('limingo', '425'),
+    ('liperi', '426'),
+    ('loimaa', '430'),
+    ('loppi', '433'),
+    ('loviisa', '434'),
+    ('lovisa', '434'),
+    ('luhanka', '435'),
+    ('lumijoki', '436'),
+    ('lumparland', '438'),
+    ('luoto', '440'),
+    ('larsmo', '440'),
+    ('luumäki', '441'),
+    ('lohja', '444'),
+    ('lojo', '444'),
+    ('parainen', '445'),
+    ('pargas', '445'),
+    ('maalahti', '475'),
+    ('malax', '475'),
+    ('maarianhamina', '478'),
+    ('mariehamn', '478'),
+    ('marttila', '480'),
+    ('masku', '481'),
+    ('merijärvi', '483'),
+    ('merikarvia', '484'),
+    ('sastmola', '484'),
+    ('miehikkälä', '489'),
+    ('mikkeli', '491'),
+    ('s:t michel', '491'),
+    ('muhos', '494'),
+    ('multia', '495'),
+    ('muonio', '498'),
+    ('mustasaari', '499'),
+    ('korsholm', '499'),
+    ('muurame', '500'),
+    ('mynämäki', '503'),
+    ('myrskylä', '504'),
+    ('mörskom', '504'),
+    ('mäntsälä', '505'),
+    ('mäntyharju', '507'),
+    ('mänttä-vilppula', '508'),
+    ('naantali', '529'),
+    ('nådendal', '529'),
+    ('nakkila', '531'),
+    ('nivala', '535'),
+    ('nokia', '536'),
+    ('nousiainen', '538'),
+    ('nousis', '538'),
+    ('nurmes', '541'),
+    ('nurmijärvi', '543'),
+    ('närpiö', '545'),
+    ('närpes', '545'),
+    ('orimattila', '560'),
+    ('oripää', '561'),
+    ('orivesi', '562'),
+    ('oulainen', '563'),
+    ('oulu', '564'),
+    ('uleåborg', '564'),
+    ('padasjoki', '576'),
+    ('paimio', '577'),
+    ('pemar', '577'),
+    ('paltamo', '578'),
+    ('parikkala', '580'),
+    ('parkano', '581'),
+    ('pelkosenniemi', '583'),
+    ('perho', '584'),
+    ('pertunmaa', '588'),
+    ('petäjävesi', '592'),
+    ('pieksämäki', '593'),
+    ('pielavesi', '595'),
+    ('pietarsaari', '598'),
+    ('jakobstad', '598'),
+    ('pedersören kunta', '599'),
+    ('pedersöre', '599'),
+    ('pihtipudas', '601'),
+    ('pirkkala', '604'),
+    ('birkala', '604'),
+    ('polvijärvi', '607'),
+    ('pomarkku', '608'),
+    ('påmark', '608'),
+    ('pori', '609'),
+    ('björneborg', '609'),
+    ('pornainen', '611'),
+    ('borgnäs', '611'),
+    ('posio', '614'),
+    ('pudasjärvi', '615'),
+    ('pukkila', '616'),
+    ('punkalaidun', '619'),
+    ('puolanka', '620'),
+    ('puumala', '623'),
+    ('pyhtää', '624'),
+    ('pyttis', '624'),
+    ('pyhäjoki', '625'),
+    ('pyhäjärvi', '626'),
+    ('pyhäntä', '630'),
+    ('pyhäranta', '631'),
+    ('pälkäne', '635'),
+    ('pöytyä', '636'),
+    ('porvoo', '638'),
+    ('borgå', '638'),
+    ('raahe', '678'),
+    ('brahestad', '678'),
+    ('raisio', '680'),
+    ('reso', '680'),
+    ('rantasalmi', '681'),
+    ('ranua', '683'),
+    ('rauma', '684'),
+    ('raumo', '684'),
+    ('rautalampi', '686'),
+    ('rautavaara', '687'),
+    ('rautjärvi', '689'),
+    ('reisjärvi', '691'),
+    ('riihimäki', '694'),
+    ('ristijärvi', '697'),
+    ('rovaniemi', '698'),
+    ('ruokolahti', '700'),
+    ('ruovesi', '702'),
+    ('rusko', '704'),
+    ('rääkkylä', '707'),
+    ('raasepori', '710'),
+    ('raseborg', '710'),
+    ('saarijärvi', '729'),
+    ('salla', '732'),
+    ('salo', '734'),
+    ('saltvik', '736'),
+    ('sauvo', '738'),
+    ('sagu', '738'),
+    ('savitaipale', '739'),
+    ('savonlinna', '740'),
+    ('nyslott', '740'),
+    ('savukoski', '742'),
+    ('seinäjoki', '743'),
+    ('sievi', '746'),
+    ('siikainen', '747'),
+    ('siikajoki', '748'),
+    ('siilinjärvi', '749'),
+    ('simo', '751'),
+    ('sipoo', '753'),
+    ('sibbo', '753'),
+    ('siuntio', '755'),
+    ('sjundeå', '755'),
+    ('sodankylä', '758'),
+    ('soini', '759'),
+    ('somero', '761'),
+    ('sonkajärvi', '762'),
+    ('sotkamo', '765'),
+    ('sottunga', '766'),
+    ('sulkava', '768'),
+    ('sund', '771'),
+    ('suomussalmi', '777'),
+    ('suonenjoki', '778'),
+    ('sysmä', '781'),
+    ('säkylä', '783'),
+    ('sastamala', '790'),
+    ('siikalatva', '791'),
+    ('taipalsaari', '831'),
+    ('taivalkoski', '832'),
+    ('taivassalo', '833'),
+    ('tövsala', '833'),
+    ('tammela', '834'),
+    ('tampere', '837'),
+    ('tammerfors', '837'),
+    ('tervo', '844'),
+    ('tervola', '845'),
+    ('teuva', '846'),
+    ('östermark', '846'),
+    ('tohmajärvi', '848'),
+    ('toholampi', '849'),
+    ('toivakka', '850'),
+    ('tornio', '851'),
+    ('torneå', '851'),
+    ('turku', '853'),
+    ('åbo', '853'),
+    ('pello', '854'),
+    ('tuusniemi', '857'),
+    ('tuusula', '858'),
+    ('tusby', '858'),
+    ('tyrnävä', '859'),
+    ('ulvila', '886'),
+    ('ulvsby', '886'),
+    ('urjala', '887'),
+    ('utajärvi', '889'),
+    ('utsjoki', '890'),
+    ('utsjok', '890'),
+    ('uurainen', '892'),
+    ('uusikaarlepyy', '893'),
+    ('nykarleby', '893'),
+    ('uusikaupunki', '895'),
+    ('nystad', '895'),
+    ('vaasa', '905'),
+    ('vasa', '905'),
+    ('valkeakoski', '908'),
+    ('varkaus', '915'),
+    ('vehmaa', '918'),
+    ('vesanto', '921'),
+    ('vesilahti', '922'),
+    ('veteli', '924'),
+    ('vetil', '924'),
+    ('vieremä', '925'),
+    ('vaala', '926'),
+    ('vihti', '927'),
+    ('vichtis', '927'),
+    ('viitasaari', '931'),
+    ('vimpeli', '934'),
+    ('vindala', '934'),
+    ('virolahti', '935'),
+    ('vederlax', '935'),
+    ('virrat', '936'),
+    ('virdois', '936'),
+    ('vårdö', '941'),
+    ('vöyri', '946'),
+    ('vörå', '946'),
+    ('ylitornio', '976'),
+    ('övertorneå', '976'),
+    ('ylivieska', '977'),
+    ('ylöjärvi', '980'),
+    ('ypäjä', '981'),
+    ('ähtäri', '989'),
+    ('etseri', '989'),
+    ('äänekoski', '992')
+) AS names(name, code)
+WHERE profiles.municipality_code = ''
+  AND lower(btrim(regexp_replace(profiles.location, '^.*,', ''))) = names.name;
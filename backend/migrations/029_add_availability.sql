@@ -0,0 +1,101 @@
+-- Mentor availability calendars: weekly windows in the mentor's timezone,
+-- overrides that replace the windows of a date, and blackouts. Open slots are
+-- cut from these, less the sessions already booked.
+CREATE TABLE IF NOT EXISTS mentor_calendars (
+    mentor_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'Europe/Helsinki',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS availability_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    mentor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    weekday INTEGER NOT NULL,
+    start_time VARCHAR(5) NOT NULL,
+    end_time VARCHAR(5) NOT NULL
+);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_availability_rules_weekday'
+    ) THEN
+        ALTER TABLE availability_rules ADD CONSTRAINT chk_availability_rules_weekday
+            CHECK (weekday BETWEEN 0 AND 6);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_availability_rules_mentor_id ON availability_rules(mentor_id);
+
+CREATE TABLE IF NOT EXISTS availability_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    mentor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    date VARCHAR(10) NOT NULL,
+    start_time VARCHAR(5) NOT NULL,
+    end_time VARCHAR(5) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_availability_overrides_mentor_date ON availability_overrides(mentor_id, date);
+
+CREATE TABLE IF NOT EXISTS availability_blackouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    mentor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    reason VARCHAR(200),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_availability_blackouts_period'
+    ) THEN
+        ALTER TABLE availability_blackouts ADD CONSTRAINT chk_availability_blackouts_period
+            CHECK (ends_at > starts_at);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_availability_blackouts_mentor_id ON availability_blackouts(mentor_id);
+
+-- Mentoring sessions, as in the sessions table of docs/database-schema.md.
+-- Open slots leave out the time of pending, accepted and scheduled sessions.
+-- scheduled_at is TIMESTAMPTZ so it compares with the calendar times above.
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    mentor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    mentee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    scheduled_at TIMESTAMPTZ,
+    duration INTEGER NOT NULL DEFAULT 60,
+    meeting_link VARCHAR(500),
+    notes TEXT,
+    mentee_notes TEXT,
+    mentor_notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_sessions_status'
+    ) THEN
+        ALTER TABLE sessions ADD CONSTRAINT chk_sessions_status
+            CHECK (status IN ('pending', 'accepted', 'rejected', 'scheduled', 'completed', 'cancelled'));
+    END IF;
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_sessions_duration'
+    ) THEN
+        ALTER TABLE sessions ADD CONSTRAINT chk_sessions_duration
+            CHECK (duration > 0);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_sessions_mentor_id ON sessions(mentor_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_mentee_id ON sessions(mentee_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_status ON sessions(status);
+CREATE INDEX IF NOT EXISTS idx_sessions_scheduled_at ON sessions(scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_sessions_mentor_status ON sessions(mentor_id, status);
+CREATE INDEX IF NOT EXISTS idx_sessions_mentee_status ON sessions(mentee_id, status);
@@ -0,0 +1,12 @@
+-- Nonces issued for OAuth sign-ins and links. A row is deleted when a provider
+-- token carrying the nonce is accepted. Link nonces name the login session.
+CREATE TABLE IF NOT EXISTS oauth_nonces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    nonce_hash VARCHAR(64) NOT NULL,
+    session_id UUID REFERENCES auth_sessions(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oauth_nonces_nonce_hash ON oauth_nonces(nonce_hash);
+CREATE INDEX IF NOT EXISTS idx_oauth_nonces_session_id ON oauth_nonces(session_id);
@@ -0,0 +1,140 @@
+// Package assignment solves capacitated assignment problems, such as pairing
+// a cohort of mentees with a pool of mentors who each take a limited number.
+package assignment
+
+import "math"
+
+// costScale turns score differences into integer costs, keeping six decimals
+const costScale = 1e6
+
+// Solve assigns each row to at most one column so that column j receives at
+// most capacity[j] rows. It assigns as many rows as possible and, among those
+// assignments, maximises the total score. Each row of scores has one entry
+// per column, and a negative score marks a pair that must not be assigned.
+// The result holds the column of each row, or -1 for rows left unassigned.
+//
+// It runs a min-cost max-flow with successive shortest paths, which is
+// globally optimal unlike greedy pairing, in O(rows * (nodes² + pairs)).
+func Solve(scores [][]float64, capacity []int) []int {
+	rows, cols := len(scores), len(capacity)
+	assigned := make([]int, rows)
+	for i := range assigned {
+		assigned[i] = -1
+	}
+
+	best := 0.0
+	for _, row := range scores {
+		for _, s := range row[:cols] {
+			best = math.Max(best, s)
+		}
+	}
+
+	// Nodes: source, rows, columns, sink
+	source, sink := 0, rows+cols+1
+	g := newGraph(rows + cols + 2)
+	for i := 0; i < rows; i++ {
+		g.addEdge(source, 1+i, 1, 0)
+		for j, s := range scores[i][:cols] {
+			if s >= 0 && capacity[j] > 0 {
+				g.addEdge(1+i, 1+rows+j, 1, int64(math.Round((best-s)*costScale)))
+			}
+		}
+	}
+	for j, c := range capacity {
+		if c > 0 {
+			g.addEdge(1+rows+j, sink, c, 0)
+		}
+	}
+
+	g.minCostMaxFlow(source, sink)
+
+	for i := 0; i < rows; i++ {
+		for _, e := range g.edges[1+i] {
+			if e.to > rows && e.to <= rows+cols && e.cap == 0 {
+				assigned[i] = e.to - 1 - rows
+			}
+		}
+	}
+	return assigned
+}
+
+type edge struct {
+	to, rev int // Target node and index of the reverse edge in edges[to]
+	cap     int
+	cost    int64
+}
+
+type graph struct {
+	edges [][]edge
+}
+
+func newGraph(nodes int) *graph {
+	return &graph{edges: make([][]edge, nodes)}
+}
+
+func (g *graph) addEdge(from, to, capacity int, cost int64) {
+	g.edges[from] = append(g.edges[from], edge{to: to, rev: len(g.edges[to]), cap: capacity, cost: cost})
+	g.edges[to] = append(g.edges[to], edge{to: from, rev: len(g.edges[from]) - 1, cap: 0, cost: -cost})
+}
+
+// minCostMaxFlow augments along cheapest paths until the sink is unreachable.
+// Node potentials keep reduced costs non-negative so Dijkstra applies.
+func (g *graph) minCostMaxFlow(source, sink int) {
+	n := len(g.edges)
+	potential := make([]int64, n)
+	dist := make([]int64, n)
+	done := make([]bool, n)
+	prevNode := make([]int, n)
+	prevEdge := make([]int, n)
+
+	for {
+		for v := range dist {
+			dist[v] = math.MaxInt64
+			done[v] = false
+		}
+		dist[source] = 0
+
+		// Dense Dijkstra: the graph is small and nearly complete
+		for {
+			u := -1
+			for v := 0; v < n; v++ {
+				if !done[v] && dist[v] != math.MaxInt64 && (u < 0 || dist[v] < dist[u]) {
+					u = v
+				}
+			}
+			if u < 0 {
+				break
+			}
+			done[u] = true
+			for i, e := range g.edges[u] {
+				if e.cap == 0 {
+					continue
+				}
+				d := dist[u] + e.cost + potential[u] - potential[e.to]
+				if d < dist[e.to] {
+					dist[e.to] = d
+					prevNode[e.to] = u
+					prevEdge[e.to] = i
+				}
+			}
+		}
+		if dist[sink] == math.MaxInt64 {
+			return
+		}
+		for v := range potential {
+			if dist[v] != math.MaxInt64 {
+				potential[v] += dist[v]
+			}
+		}
+
+		flow := math.MaxInt
+		for v := sink; v != source; v = prevNode[v] {
+			flow = min(flow, g.edges[prevNode[v]][prevEdge[v]].cap)
+		}
+		for v := sink; v != source; v = prevNode[v] {
+			e := &g.edges[prevNode[v]][prevEdge[v]]
+			e.cap -= flow
+			g.edges[v][e.rev].cap += flow
+		}
+	}
+}
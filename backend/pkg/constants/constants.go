@@ -1,6 +1,9 @@
 package constants
 
-// User roles
+import "time"
+
+// Built-in user roles. Further roles, such as a moderator, are rows in the
+// roles table and get their permissions from role_permissions.
 const (
 	RoleMentor = "mentor"
 	RoleMentee = "mentee"
@@ -10,6 +13,66 @@ const (
 // Valid roles for validation
 var ValidRoles = []string{RoleMentor, RoleMentee, RoleAdmin}
 
+// Mentorship statuses
+const (
+	MentorshipActive = "active"
+	MentorshipEnded  = "ended"
+)
+
+// Profile views, from least to most detail. Public viewers see a shortened
+// last name and location unless the owner chooses otherwise.
+const (
+	ProfileViewPublic    = "public"
+	ProfileViewConnected = "connected" // Viewer has an active mentorship with the owner
+	ProfileViewPrivate   = "private"   // Owner, or staff with profiles:read_private
+)
+
+// Who may find and open a profile, besides the owner, staff and mentorship partners
+const (
+	ProfileVisibilityEveryone     = "everyone"      // Anonymous callers too
+	ProfileVisibilitySignedIn     = "signed_in"     // Any signed-in user
+	ProfileVisibilityOppositeRole = "opposite_role" // Mentees for a mentor's profile and mentors for a mentee's
+)
+
+// Mentor application statuses
+const (
+	MentorApplicationPending  = "pending"
+	MentorApplicationApproved = "approved"
+	MentorApplicationRejected = "rejected"
+)
+
+// Cohort statuses. A cohort's assignment is a draft until it is published.
+const (
+	CohortDraft     = "draft"
+	CohortPublished = "published"
+)
+
+// Permissions checked by the API, named "<resource>:<action>"
+const (
+	PermissionUsersDelete         = "users:delete"
+	PermissionUsersUnlock         = "users:unlock"
+	PermissionUsersImpersonate    = "users:impersonate"
+	PermissionUsersManageRoles    = "users:manage_roles"
+	PermissionProfilesReadPrivate = "profiles:read_private"
+	PermissionAuditRead           = "audit:read"
+	PermissionMFAEnroll           = "mfa:enroll"
+	PermissionMentorsApprove      = "mentors:approve"
+	PermissionMentorshipsAssign   = "mentorships:assign"
+)
+
+// Permissions is the registry of every permission a role can be granted
+var Permissions = []string{
+	PermissionUsersDelete,
+	PermissionUsersUnlock,
+	PermissionUsersImpersonate,
+	PermissionUsersManageRoles,
+	PermissionProfilesReadPrivate,
+	PermissionAuditRead,
+	PermissionMFAEnroll,
+	PermissionMentorsApprove,
+	PermissionMentorshipsAssign,
+}
+
 // Session status
 const (
 	SessionStatusPending   = "pending"
@@ -30,6 +93,13 @@ var ValidSessionStatuses = []string{
 	SessionStatusCancelled,
 }
 
+// Mentoring sessions that take a mentor's time
+var BookedSessionStatuses = []string{
+	SessionStatusPending,
+	SessionStatusAccepted,
+	SessionStatusScheduled,
+}
+
 // API versioning
 const (
 	APIVersion = "v1"
@@ -42,10 +112,129 @@ const (
 	MaxPageSize     = 100
 )
 
-// Token expiration (in hours)
+// Token expiration
+const (
+	AccessTokenExpiry  = 15 * time.Minute
+	RefreshTokenExpiry = 168 * time.Hour // 7 days
+)
+
+// Login sessions
+const (
+	SessionTouchInterval = 5 * time.Minute // How often last_used_at is written for an active session
+	SessionDeviceNameMax = 100
+)
+
+// Email verification. Each email may request VerificationMaxRequests codes
+// per VerificationRequestWindow, and at most one per VerificationResendInterval.
+// With VerificationMaxAttempts guesses per code, that caps the guesses at
+// 25 an hour.
+const (
+	VerificationCodeExpiry     = 15 * time.Minute
+	VerificationMaxAttempts    = 5
+	VerificationResendInterval = time.Minute
+	VerificationRequestWindow  = time.Hour
+	VerificationMaxRequests    = 5
+)
+
+// Password reset. Each account may request PasswordResetMaxRequests links per
+// PasswordResetRequestWindow, and at most one per PasswordResetResendInterval.
+const (
+	PasswordResetExpiry         = time.Hour
+	PasswordResetResendInterval = time.Minute
+	PasswordResetRequestWindow  = time.Hour
+	PasswordResetMaxRequests    = 5
+)
+
+// Password policy defaults. bcrypt ignores everything after the first 72
+// bytes, so longer passwords are rejected instead of silently truncated.
+const (
+	DefaultPasswordMinLength  = 10
+	DefaultPasswordMinClasses = 3 // Of lowercase, uppercase, digits and symbols
+	PasswordMaxBytes          = 72
+)
+
+// Magic link sign-in. Each email may request MagicLinkMaxRequests links per
+// MagicLinkRequestWindow, and at most one per MagicLinkResendInterval.
+const (
+	MagicLinkExpiry         = 15 * time.Minute
+	MagicLinkResendInterval = time.Minute
+	MagicLinkRequestWindow  = time.Hour
+	MagicLinkMaxRequests    = 5
+)
+
+// Role permissions are cached and reloaded from the database after this long,
+// so permission changes apply without a restart
+const RolePermissionsCacheTTL = time.Minute
+
+// Admin impersonation
 const (
-	AccessTokenExpiry  = 24  // 24 hours
-	RefreshTokenExpiry = 168 // 7 days
+	ImpersonationTokenExpiry = 15 * time.Minute
+
+	ImpersonationActionIssued  = "token_issued"
+	ImpersonationActionRequest = "request"
+)
+
+// Personal API keys. Keys look like "mnt_<prefix>_<secret>"; the prefix
+// identifies the key and only a hash of the whole key is stored.
+const (
+	APIKeyPrefix        = "mnt_"
+	APIKeyAuthScheme    = "ApiKey"
+	APIKeyDefaultExpiry = 90 * 24 * time.Hour
+	APIKeyMaxExpiryDays = 365
+	APIKeyMaxPerUser    = 10
+	APIKeyTouchInterval = 5 * time.Minute // How often last_used_at is written for a key in use
+	APIKeyScopeRead     = "read"          // GET and HEAD requests
+	APIKeyScopeWrite    = "write"         // Requests that change data
+)
+
+// Avatar uploads. Every upload is stored as square JPEG thumbnails under
+// "avatars/<user>/<upload>/<size>.jpg" and the previous upload is deleted.
+const (
+	AvatarMaxBytes             = 5 << 20   // Largest accepted upload
+	AvatarMaxPixels            = 8_000_000 // Larger images are rejected before decoding
+	AvatarMaxConcurrentDecodes = 4         // A decoded image takes up to 4 bytes per pixel
+	AvatarDisplaySize          = 256       // Size used for the profile's avatar_url
+	AvatarJPEGQuality          = 85
+	AvatarFormField            = "image"
+)
+
+// AvatarSizes are the thumbnail sizes in pixels, largest first
+var AvatarSizes = []int{512, AvatarDisplaySize, 128, 64}
+
+// OAuthNonceExpiry is how long a nonce issued for an OAuth sign-in or link can be used
+const OAuthNonceExpiry = 10 * time.Minute
+
+// Multi-factor authentication. Failed codes also count towards the login
+// throttle, so repeated challenges cannot be used to keep guessing.
+const (
+	MFAChallengeExpiry      = 5 * time.Minute
+	MFAChallengeMaxAttempts = 5 // Codes tried against one challenge before it is burned
+	MFARecoveryCodeCount    = 10
+	MFAIssuer               = "Mentori"
+)
+
+// Login throttling. Failures within LoginFailureWindow are counted per account
+// and per client IP; after the free attempts each failure doubles the wait from
+// LoginBackoffBase up to LoginBackoffMax, and reaching the lockout threshold
+// blocks further attempts for the lockout duration.
+const (
+	LoginFailureWindow = time.Hour
+	LoginBackoffBase   = time.Second
+	LoginBackoffMax    = 5 * time.Minute
+
+	AccountFreeAttempts     = 3
+	AccountLockoutThreshold = 10
+	AccountLockoutDuration  = 30 * time.Minute
+
+	IPFreeAttempts     = 20
+	IPLockoutThreshold = 100
+	IPLockoutDuration  = time.Hour
+)
+
+// Lockout scopes
+const (
+	LockoutScopeAccount = "account"
+	LockoutScopeIP      = "ip"
 )
 
 // HTTP header names
@@ -53,6 +242,7 @@ const (
 	HeaderAuthorization = "Authorization"
 	HeaderContentType   = "Content-Type"
 	HeaderAccept        = "Accept"
+	HeaderDeviceName    = "X-Device-Name" // Optional client-supplied name for a login session
 )
 
 // Content types
@@ -65,6 +255,20 @@ const (
 	ContextKeyUserID    = "user_id"
 	ContextKeyUserEmail = "user_email"
 	ContextKeyUserRole  = "user_role"
+	ContextKeyMFA       = "mfa_verified"
+	ContextKeySessionID = "session_id"
+	// Set only for impersonation tokens: the admin acting as the user
+	ContextKeyActorID    = "actor_id"
+	ContextKeyActorEmail = "actor_email"
+	// Set only for requests authenticated with a personal API key
+	ContextKeyAPIKeyID = "api_key_id"
+)
+
+// JWT token types ("typ" claim)
+const (
+	TokenTypeAccess       = "access"
+	TokenTypeMFAChallenge = "mfa_challenge"
+	TokenTypeMagicLink    = "magic_link"
 )
 
 // Environment values
@@ -82,45 +286,85 @@ const (
 	LogLevelError = "error"
 )
 
-// Expertise options for mentors/mentees
-var ExpertiseOptions = []string{
-	"Find a Job",
-	"Bachelor's Degree",
-	"Master's Degree",
-	"Doctoral Studies",
-	"YKI Test Preparation",
-	"Finnish Marriage & Family",
-	"Work-Life Balance",
-	"Starting a Business",
-	"Housing & Relocation",
-	"Finnish Language Learning",
-	"Healthcare System",
-	"Education System",
-	"Banking & Finance",
-	"Integration & Culture",
-	"Networking & Socializing",
-}
+// Taxonomy kinds. Expertise areas and interests are rows in taxonomy_terms
+// with stable slugs and English and Finnish labels.
+const (
+	TaxonomyKindExpertise = "expertise"
+	TaxonomyKindInterest  = "interest"
+	TaxonomyCacheTTL      = 5 * time.Minute
+	TaxonomyMaxMergeDepth = 8 // Longest merged_into chain followed before giving up
+)
 
-// Interest options for mentors/mentees
-var InterestOptions = []string{
-	"Reading & Books",
-	"Bars & Nightlife",
-	"Musical Instruments",
-	"Hiking & Outdoor",
-	"Indoor Sports",
-	"Gym & Fitness",
-	"Winter Sports",
-	"Board Games",
-	"Coffee Culture",
-	"Foodie & Restaurants",
-	"Arts & Museums",
-	"Tech & Gaming",
-	"Music & Concerts",
-	"Photography",
-	"Crafts & DIY",
-	"Movies & TV Shows",
-	"Cooking & Baking",
-	"Traveling",
-	"Cycling",
-	"Running",
+// Languages the API can localise labels into, the default first
+const (
+	LanguageEnglish = "en"
+	LanguageFinnish = "fi"
+)
+
+var SupportedLanguages = []string{LanguageEnglish, LanguageFinnish}
+
+// Free-text profile search. Matches come from Finnish and English full-text
+// search on the whole profile plus trigram similarity on names and terms,
+// which tolerates typos.
+const (
+	ProfileSearchMaxQueryLength = 200
+	ProfileSearchTrigramWeight  = 0.5 // Share of trigram similarity in the rank
+	ProfileSearchHighlightWords = 30  // Longest bio excerpt returned with a result
+)
+
+// Location search around a municipality. Distances are between municipality
+// centres, see pkg/geo.
+const (
+	DefaultSearchRadiusKm = 30.0
+	MaxSearchRadiusKm     = 1200.0 // Covers the whole country
+)
+
+// Mentor availability calendars
+const (
+	DefaultTimezone    = "Europe/Helsinki"
+	DefaultSlotMinutes = 60
+	MinSlotMinutes     = 15
+	MaxSlotMinutes     = 240
+	MaxOpenSlotsDays   = 31 // Longest range of one open slots request
+)
+
+// Mentor capacity: how many active mentees a mentor takes on
+const (
+	DefaultMaxMentees = 3
+)
+
+// Coarse weekly availability used for matching
+const (
+	AvailabilityWeekdayMorning   = "weekday_morning"
+	AvailabilityWeekdayAfternoon = "weekday_afternoon"
+	AvailabilityWeekdayEvening   = "weekday_evening"
+	AvailabilityWeekendMorning   = "weekend_morning"
+	AvailabilityWeekendAfternoon = "weekend_afternoon"
+	AvailabilityWeekendEvening   = "weekend_evening"
+)
+
+var AvailabilitySlots = []string{
+	AvailabilityWeekdayMorning, AvailabilityWeekdayAfternoon, AvailabilityWeekdayEvening,
+	AvailabilityWeekendMorning, AvailabilityWeekendAfternoon, AvailabilityWeekendEvening,
 }
+
+// Mentor-mentee matching. Each factor scores 0-1 and the match score is their
+// weighted average; the weights can be overridden with MATCH_WEIGHT_* settings.
+const (
+	MatchFactorExpertise    = "expertise"
+	MatchFactorInterests    = "interests"
+	MatchFactorLocation     = "location"
+	MatchFactorLanguage     = "language"
+	MatchFactorAvailability = "availability"
+	MatchFactorCapacity     = "capacity"
+
+	DefaultMatchWeightExpertise    = 4.0
+	DefaultMatchWeightInterests    = 2.0
+	DefaultMatchWeightLocation     = 2.0
+	DefaultMatchWeightLanguage     = 3.0
+	DefaultMatchWeightAvailability = 2.0
+	DefaultMatchWeightCapacity     = 1.0
+
+	MatchCandidatePageSize = 500   // Candidates loaded and scored at a time
+	MatchLocationRangeKm   = 150.0 // Distance at which the location factor reaches 0
+)
@@ -40,10 +40,10 @@ func InitDB(databaseURL string) {
 	// Optional: reset DB when explicitly requested (development only)
 	if os.Getenv("RESET_DB") == "true" {
 		log.Println("RESET_DB=true: Dropping tables before migration (development only)")
-		DB.Migrator().DropTable(&models.User{}, &models.Profile{}, &models.EmailVerification{})
+		DB.Migrator().DropTable(&models.MentoringSession{}, &models.AvailabilityBlackout{}, &models.AvailabilityOverride{}, &models.AvailabilityRule{}, &models.MentorCalendar{}, &models.User{}, &models.Profile{}, &models.EmailVerification{}, &models.RefreshToken{}, &models.PasswordResetToken{}, &models.UserIdentity{}, &models.MFARecoveryCode{}, &models.MFAChallenge{}, &models.OAuthNonce{}, &models.AuthSession{}, &models.LoginThrottle{}, &models.LockoutEvent{}, &models.MagicLinkToken{}, &models.ImpersonationLog{}, &models.APIKey{}, &models.MentorApplication{}, &models.Mentorship{}, &models.CohortPair{}, &models.CohortMentor{}, &models.Cohort{}, &models.TaxonomyTerm{}, &models.RolePermission{}, &models.Role{})
 	}
 
-	if err := DB.AutoMigrate(&models.User{}, &models.Profile{}, &models.EmailVerification{}); err != nil {
+	if err := DB.AutoMigrate(&models.Role{}, &models.RolePermission{}, &models.User{}, &models.Profile{}, &models.EmailVerification{}, &models.RefreshToken{}, &models.PasswordResetToken{}, &models.UserIdentity{}, &models.MFARecoveryCode{}, &models.MFAChallenge{}, &models.OAuthNonce{}, &models.AuthSession{}, &models.LoginThrottle{}, &models.LockoutEvent{}, &models.MagicLinkToken{}, &models.ImpersonationLog{}, &models.APIKey{}, &models.MentorApplication{}, &models.Mentorship{}, &models.Cohort{}, &models.CohortMentor{}, &models.CohortPair{}, &models.TaxonomyTerm{}, &models.MentorCalendar{}, &models.AvailabilityRule{}, &models.AvailabilityOverride{}, &models.AvailabilityBlackout{}, &models.MentoringSession{}); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
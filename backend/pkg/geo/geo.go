@@ -0,0 +1,201 @@
+// Package geo is a gazetteer of Finnish municipalities and regions
+// (maakunnat). Municipalities are identified by their Statistics Finland code
+// and placed at their administrative centre.
+package geo
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//go:embed municipalities.csv
+var municipalityList string
+
+// Region is a Finnish region
+type Region struct {
+	Code   string // Two digits, e.g. "01" for Uusimaa
+	NameFI string
+	NameSV string
+	NameEN string
+}
+
+// Municipality is a Finnish municipality
+type Municipality struct {
+	Code       string // Three digits, e.g. "091" for Helsinki
+	NameFI     string
+	NameSV     string // Only where the Swedish name differs
+	RegionCode string
+	Lat, Lon   float64
+}
+
+var regions = []Region{
+	{"01", "Uusimaa", "Nyland", "Uusimaa"},
+	{"02", "Varsinais-Suomi", "Egentliga Finland", "Southwest Finland"},
+	{"04", "Satakunta", "Satakunta", "Satakunta"},
+	{"05", "Kanta-Häme", "Egentliga Tavastland", "Tavastia Proper"},
+	{"06", "Pirkanmaa", "Birkaland", "Pirkanmaa"},
+	{"07", "Päijät-Häme", "Päijänne-Tavastland", "Päijät-Häme"},
+	{"08", "Kymenlaakso", "Kymmenedalen", "Kymenlaakso"},
+	{"09", "Etelä-Karjala", "Södra Karelen", "South Karelia"},
+	{"10", "Etelä-Savo", "Södra Savolax", "South Savo"},
+	{"11", "Pohjois-Savo", "Norra Savolax", "North Savo"},
+	{"12", "Pohjois-Karjala", "Norra Karelen", "North Karelia"},
+	{"13", "Keski-Suomi", "Mellersta Finland", "Central Finland"},
+	{"14", "Etelä-Pohjanmaa", "Södra Österbotten", "South Ostrobothnia"},
+	{"15", "Pohjanmaa", "Österbotten", "Ostrobothnia"},
+	{"16", "Keski-Pohjanmaa", "Mellersta Österbotten", "Central Ostrobothnia"},
+	{"17", "Pohjois-Pohjanmaa", "Norra Österbotten", "North Ostrobothnia"},
+	{"18", "Kainuu", "Kajanaland", "Kainuu"},
+	{"19", "Lappi", "Lappland", "Lapland"},
+	{"21", "Ahvenanmaa", "Åland", "Åland"},
+}
+
+var (
+	municipalities     = parseMunicipalities(municipalityList)
+	municipalityByCode = make(map[string]Municipality, len(municipalities))
+	municipalityByName = make(map[string]Municipality, 2*len(municipalities))
+	regionByCode       = make(map[string]Region, len(regions))
+	regionByName       = make(map[string]Region, 3*len(regions))
+)
+
+func init() {
+	for _, r := range regions {
+		regionByCode[r.Code] = r
+		for _, name := range []string{r.NameFI, r.NameSV, r.NameEN} {
+			regionByName[nameKey(name)] = r
+		}
+	}
+	for _, m := range municipalities {
+		if _, ok := regionByCode[m.RegionCode]; !ok {
+			panic(fmt.Sprintf("geo: municipality %s is in unknown region %s", m.Code, m.RegionCode))
+		}
+		municipalityByCode[m.Code] = m
+		for _, name := range []string{m.NameFI, m.NameSV} {
+			if name == "" {
+				continue
+			}
+			if other, ok := municipalityByName[nameKey(name)]; ok && other.Code != m.Code {
+				panic(fmt.Sprintf("geo: %q names both %s and %s", name, other.Code, m.Code))
+			}
+			municipalityByName[nameKey(name)] = m
+		}
+	}
+}
+
+func parseMunicipalities(list string) []Municipality {
+	records, err := csv.NewReader(strings.NewReader(list)).ReadAll()
+	if err != nil {
+		panic("geo: unreadable municipalities.csv: " + err.Error())
+	}
+	result := make([]Municipality, 0, len(records))
+	for _, record := range records[1:] { // Skip the header
+		lat, latErr := strconv.ParseFloat(record[4], 64)
+		lon, lonErr := strconv.ParseFloat(record[5], 64)
+		if latErr != nil || lonErr != nil {
+			panic("geo: invalid coordinates for municipality " + record[0])
+		}
+		result = append(result, Municipality{
+			Code:       record[0],
+			NameFI:     record[1],
+			NameSV:     record[2],
+			RegionCode: record[3],
+			Lat:        lat,
+			Lon:        lon,
+		})
+	}
+	return result
+}
+
+// nameKey folds case and the Nordic letters, so that "Jyvaskyla" finds
+// Jyväskylä and "Abo" finds Turku
+func nameKey(name string) string {
+	return strings.NewReplacer("ä", "a", "ö", "o", "å", "a").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// LookupMunicipality returns the municipality with the code
+func LookupMunicipality(code string) (Municipality, bool) {
+	m, ok := municipalityByCode[code]
+	return m, ok
+}
+
+// FindMunicipality returns the municipality with the Finnish or Swedish name
+// or the code
+func FindMunicipality(name string) (Municipality, bool) {
+	if m, ok := municipalityByCode[strings.TrimSpace(name)]; ok {
+		return m, true
+	}
+	m, ok := municipalityByName[nameKey(name)]
+	return m, ok
+}
+
+// ResolveLocation finds the municipality of a comma-separated location such
+// as "Kallio, Helsinki", trying the broadest part first. It also returns the
+// location with that part spelled as the municipality's Finnish name.
+func ResolveLocation(location string) (Municipality, string, bool) {
+	parts := strings.Split(location, ",")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if m, ok := FindMunicipality(parts[i]); ok {
+			parts[i] = m.NameFI
+			if i > 0 {
+				parts[i] = " " + m.NameFI
+			}
+			return m, strings.TrimSpace(strings.Join(parts, ",")), true
+		}
+	}
+	return Municipality{}, location, false
+}
+
+// FindRegion returns the region with the Finnish, Swedish or English name or
+// the code
+func FindRegion(name string) (Region, bool) {
+	if r, ok := regionByCode[strings.TrimSpace(name)]; ok {
+		return r, true
+	}
+	r, ok := regionByName[nameKey(name)]
+	return r, ok
+}
+
+// Region returns the region of the municipality
+func (m Municipality) Region() Region {
+	return regionByCode[m.RegionCode]
+}
+
+// InRegion returns the municipalities of the region, by code
+func InRegion(regionCode string) []Municipality {
+	var result []Municipality
+	for _, m := range municipalities {
+		if m.RegionCode == regionCode {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Within returns the municipalities whose centre is at most radiusKm from the
+// centre of the given one
+func Within(center Municipality, radiusKm float64) []Municipality {
+	var result []Municipality
+	for _, m := range municipalities {
+		if DistanceKm(center, m) <= radiusKm {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// DistanceKm is the great-circle distance between the centres of two
+// municipalities
+func DistanceKm(a, b Municipality) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,117 @@
+// Package pagination implements the cursor pagination shared by list
+// endpoints. Lists are sorted by a stable key that ends with a unique ID, and
+// a cursor records the key of the last item returned, so the next page starts
+// right after it however deep it is (keyset pagination).
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"mentori/pkg/constants"
+
+	"github.com/google/uuid"
+)
+
+// Pagination errors
+var (
+	ErrInvalidLimit  = fmt.Errorf("limit must be between 1 and %d", constants.MaxPageSize)
+	ErrInvalidCursor = errors.New("cursor is invalid or belongs to another query")
+)
+
+// Page is the envelope of every list response
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// Pass as the cursor parameter to fetch the next page; absent on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Number of matching items when the first page was fetched. Only some
+	// lists count it, and it may drift as items are added or removed.
+	EstimatedTotal *int64 `json:"estimated_total,omitempty"`
+}
+
+// Cursor is a position in a list sorted by rank (search results only), then
+// creation time, then ID. Clients only ever see it encoded.
+type Cursor struct {
+	Rank      *float64  `json:"r,omitempty"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// Encode returns the opaque form of the cursor used in URLs
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses an encoded cursor; an empty string is the start of the list
+func Decode(encoded string) (*Cursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// Params are the paging parameters of a list request
+type Params struct {
+	Limit int     // Items per page
+	After *Cursor // Nil for the first page
+}
+
+// Parse reads the limit and cursor query parameters. An empty limit means
+// constants.DefaultPageSize; limits above constants.MaxPageSize are rejected.
+func Parse(limit, cursor string) (Params, error) {
+	p := Params{Limit: constants.DefaultPageSize}
+	if limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > constants.MaxPageSize {
+			return p, ErrInvalidLimit
+		}
+		p.Limit = n
+	}
+	after, err := Decode(cursor)
+	if err != nil {
+		return p, err
+	}
+	p.After = after
+	return p, nil
+}
+
+// Fetch is how many items to load for a page: one more than the limit, to
+// tell whether a next page exists
+func (p Params) Fetch() int {
+	return p.Limit + 1
+}
+
+// NewPage builds a page from the items loaded after the cursor, at most
+// Fetch() of them. cursorOf returns the position of an item.
+func NewPage[T any](items []T, p Params, cursorOf func(T) Cursor) Page[T] {
+	page := Page[T]{Items: items}
+	if len(items) > p.Limit {
+		page.Items = items[:p.Limit]
+		page.NextCursor = cursorOf(page.Items[p.Limit-1]).Encode()
+	}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	return page
+}
+
+// All wraps a complete list that is never paged
+func All[T any](items []T) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return Page[T]{Items: items}
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"mentori/pkg/logger"
+)
+
+// EmailMessage is a plain-text email
+type EmailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// EmailSender delivers emails. Implementations must be safe for concurrent use.
+type EmailSender interface {
+	Send(ctx context.Context, msg EmailMessage) error
+}
+
+// SMTPSender sends emails through an SMTP server using PLAIN auth
+type SMTPSender struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPSender creates a new SMTP email sender
+func NewSMTPSender(host, port, username, password, from string) *SMTPSender {
+	return &SMTPSender{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send delivers the message via SMTP
+func (s *SMTPSender) Send(ctx context.Context, msg EmailMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	headers := []string{
+		"From: " + s.from,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	body := strings.Join(headers, "\r\n") + "\r\n\r\n" + msg.Body
+
+	addr := net.JoinHostPort(s.host, s.port)
+	if err := smtp.SendMail(addr, auth, s.from, []string{msg.To}, []byte(body)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// LogSender writes emails to the application log instead of sending them.
+// Intended for local development when no SMTP server is configured.
+type LogSender struct{}
+
+// NewLogSender creates a new log-only email sender
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send logs the message
+func (s *LogSender) Send(ctx context.Context, msg EmailMessage) error {
+	logger.Info("Email to=%s subject=%q body=%q", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// MemorySender records emails in memory. Intended for tests.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []EmailMessage
+}
+
+// NewMemorySender creates a new in-memory email sender
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send records the message
+func (s *MemorySender) Send(ctx context.Context, msg EmailMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of all recorded messages
+func (s *MemorySender) Messages() []EmailMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]EmailMessage(nil), s.messages...)
+}
+
+// Last returns the most recently recorded message sent to the given address
+func (s *MemorySender) Last(to string) (EmailMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if s.messages[i].To == to {
+			return s.messages[i], true
+		}
+	}
+	return EmailMessage{}, false
+}
@@ -4,6 +4,8 @@ import (
 	"mentori/internal/models"
 	"mentori/pkg/constants"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -27,6 +29,17 @@ func GetUserIDFromContext(c *gin.Context) (uuid.UUID, error) {
 	}
 }
 
+// GetActorIDFromContext returns the admin acting through an impersonation
+// token, and false for ordinary requests
+func GetActorIDFromContext(c *gin.Context) (uuid.UUID, bool) {
+	actorID, exists := c.Get(constants.ContextKeyActorID)
+	if !exists {
+		return uuid.Nil, false
+	}
+	id, ok := actorID.(uuid.UUID)
+	return id, ok
+}
+
 // GetUserRoleFromContext extracts user role from context
 func GetUserRoleFromContext(c *gin.Context) (string, error) {
 	role, exists := c.Get(constants.ContextKeyUserRole)
@@ -86,3 +99,28 @@ func RespondWithMessage(c *gin.Context, statusCode int, message string) {
 		"message": message,
 	})
 }
+
+// PreferredLanguage picks the best supported language from an Accept-Language
+// header such as "fi-FI,fi;q=0.9,en;q=0.8", matching on the primary subtag.
+// The first supported language is the fallback.
+func PreferredLanguage(acceptLanguage string, supported []string) string {
+	best, bestQ := supported[0], 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		for _, lang := range supported {
+			if primary == lang && q > bestQ {
+				best, bestQ = lang, q
+			}
+		}
+	}
+	return best
+}
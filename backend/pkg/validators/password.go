@@ -0,0 +1,119 @@
+package validators
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"mentori/pkg/constants"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+// commonPasswords holds the bundled list of common and breached passwords
+var commonPasswords = parseCommonPasswords(commonPasswordList)
+
+// PasswordError explains why a password was rejected by a PasswordPolicy
+type PasswordError struct {
+	Reason string
+}
+
+func (e *PasswordError) Error() string {
+	return e.Reason
+}
+
+// PasswordPolicy describes the rules a new password must satisfy
+type PasswordPolicy struct {
+	MinLength  int // Minimum length in characters
+	MinClasses int // Character classes required out of lowercase, uppercase, digits and symbols
+}
+
+// DefaultPasswordPolicy returns the policy used when none is configured
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:  constants.DefaultPasswordMinLength,
+		MinClasses: constants.DefaultPasswordMinClasses,
+	}
+}
+
+// Validate checks a new password for the account with the given email and
+// returns a *PasswordError describing the first rule it breaks
+func (p PasswordPolicy) Validate(password, email string) error {
+	if password == "" {
+		return &PasswordError{Reason: "password is required"}
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return &PasswordError{Reason: fmt.Sprintf("password must be at least %d characters long", p.MinLength)}
+	}
+	if len(password) > constants.PasswordMaxBytes {
+		return &PasswordError{Reason: fmt.Sprintf("password must not exceed %d bytes", constants.PasswordMaxBytes)}
+	}
+	if classes := characterClasses(password); classes < p.MinClasses {
+		return &PasswordError{Reason: fmt.Sprintf("password must contain at least %d of: lowercase letters, uppercase letters, digits and symbols", p.MinClasses)}
+	}
+
+	lower := strings.ToLower(password)
+	if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok && len(local) >= 3 && strings.Contains(lower, local) {
+		return &PasswordError{Reason: "password must not contain your email address"}
+	}
+	if isCommonPassword(lower) {
+		return &PasswordError{Reason: "password is too common or has appeared in a data breach"}
+	}
+	return nil
+}
+
+// characterClasses counts how many of lowercase, uppercase, digits and symbols appear
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	return classes
+}
+
+// isCommonPassword matches the lowercased password against the bundled list,
+// also with trailing digits and symbols removed ("password123!" -> "password")
+func isCommonPassword(lower string) bool {
+	if _, ok := commonPasswords[lower]; ok {
+		return true
+	}
+	base := strings.TrimRightFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if base == "" || base == lower {
+		return false
+	}
+	_, ok := commonPasswords[base]
+	return ok
+}
+
+func parseCommonPasswords(list string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
@@ -24,20 +24,9 @@ func ValidateEmail(email string) error {
 	return nil
 }
 
-// ValidatePassword checks if a password meets requirements
+// ValidatePassword checks a password against the default password policy
 func ValidatePassword(password string) error {
-	if password == "" {
-		return errors.New("password is required")
-	}
-	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters long")
-	}
-	// Add more password requirements as needed
-	// - At least one uppercase letter
-	// - At least one lowercase letter
-	// - At least one number
-	// - At least one special character
-	return nil
+	return DefaultPasswordPolicy().Validate(password, "")
 }
 
 // ValidateRole checks if a role is valid
@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyFixture authenticates a mentor's API keys against the routes of the
+// server
+type apiKeyFixture struct {
+	*serverFixture
+	svc  *services.APIKeyService
+	user *models.User
+}
+
+func newAPIKeyFixture(t *testing.T) *apiKeyFixture {
+	t.Helper()
+	f := &apiKeyFixture{serverFixture: newServerFixture(t)}
+	f.svc = services.NewAPIKeyService(f.apiKeys, f.users)
+	f.user = f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Partner", IsActive: true})
+	return f
+}
+
+// request sends an empty JSON object to the /api/v1 path with the key
+func (f *apiKeyFixture) request(method, path, key string) int {
+	req := httptest.NewRequest(method, "/api/v1"+path, strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	return f.send(req, constants.APIKeyAuthScheme+" "+key).Code
+}
+
+func TestAPIKeyCreateAndAuthenticate(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	ctx := context.Background()
+
+	raw, key, err := f.svc.Create(ctx, f.user.ID, "CRM sync", []string{constants.APIKeyScopeRead}, 0)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !strings.HasPrefix(raw, key.Prefix+"_") || !strings.HasPrefix(key.Prefix, constants.APIKeyPrefix) {
+		t.Fatalf("key %q should start with its prefix %q", raw, key.Prefix)
+	}
+	if key.KeyHash == raw || strings.Contains(key.KeyHash, raw) {
+		t.Fatal("raw key must not be stored")
+	}
+	if time.Until(key.ExpiresAt) < constants.APIKeyDefaultExpiry-time.Minute {
+		t.Fatalf("expected default expiry, got %s", key.ExpiresAt)
+	}
+
+	if code := f.request(http.MethodGet, "/auth/profile", raw); code != http.StatusOK {
+		t.Fatalf("read with read scope: expected 200, got %d", code)
+	}
+	if code := f.request(http.MethodPut, "/profiles", raw); code != http.StatusForbidden {
+		t.Fatalf("write with read scope: expected 403, got %d", code)
+	}
+	if code := f.request(http.MethodPost, "/auth/password", raw); code != http.StatusForbidden {
+		t.Fatalf("account security endpoint: expected 403, got %d", code)
+	}
+	if code := f.request(http.MethodGet, "/auth/profile", raw+"x"); code != http.StatusUnauthorized {
+		t.Fatalf("tampered key: expected 401, got %d", code)
+	}
+
+	listed, err := f.svc.List(ctx, f.user.ID)
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("List = %d keys, %v", len(listed), err)
+	}
+	if listed[0].LastUsedAt == nil {
+		t.Fatal("expected last_used_at to be recorded")
+	}
+}
+
+func TestAPIKeyRevokeAndExpiry(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	ctx := context.Background()
+	scopes := []string{constants.APIKeyScopeRead, constants.APIKeyScopeWrite}
+
+	revoked, revokedKey, _ := f.svc.Create(ctx, f.user.ID, "old script", scopes, 0)
+	expired, expiredKey, _ := f.svc.Create(ctx, f.user.ID, "trial", scopes, time.Hour)
+	if code := f.request(http.MethodPut, "/profiles", revoked); code != http.StatusOK {
+		t.Fatalf("write with write scope: expected 200, got %d", code)
+	}
+
+	if err := f.svc.Revoke(ctx, uuid.New(), revokedKey.ID); !errors.Is(err, services.ErrAPIKeyNotFound) {
+		t.Fatalf("revoking another user's key: expected ErrAPIKeyNotFound, got %v", err)
+	}
+	if err := f.svc.Revoke(ctx, f.user.ID, revokedKey.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	f.apiKeys.expire(expiredKey.ID)
+
+	for name, raw := range map[string]string{"revoked": revoked, "expired": expired} {
+		if code := f.request(http.MethodGet, "/auth/profile", raw); code != http.StatusUnauthorized {
+			t.Fatalf("%s key: expected 401, got %d", name, code)
+		}
+	}
+	if listed, _ := f.svc.List(ctx, f.user.ID); len(listed) != 0 {
+		t.Fatalf("expected no active keys, got %d", len(listed))
+	}
+}
+
+func TestAPIKeyLimit(t *testing.T) {
+	f := newAPIKeyFixture(t)
+	ctx := context.Background()
+
+	for i := 0; i < constants.APIKeyMaxPerUser; i++ {
+		if _, _, err := f.svc.Create(ctx, f.user.ID, "key", []string{constants.APIKeyScopeRead}, 0); err != nil {
+			t.Fatalf("Create %d: %v", i, err)
+		}
+	}
+	if _, _, err := f.svc.Create(ctx, f.user.ID, "one too many", []string{constants.APIKeyScopeRead}, 0); !errors.Is(err, services.ErrAPIKeyLimit) {
+		t.Fatalf("expected ErrAPIKeyLimit, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"math/rand"
+	"testing"
+
+	"mentori/pkg/assignment"
+)
+
+// bruteForce tries every assignment and returns the best count and total score
+func bruteForce(scores [][]float64, capacity []int) (int, float64) {
+	used := make([]int, len(capacity))
+	bestCount, bestTotal := 0, 0.0
+	var try func(row, count int, total float64)
+	try = func(row, count int, total float64) {
+		if row == len(scores) {
+			if count > bestCount || (count == bestCount && total > bestTotal+1e-9) {
+				bestCount, bestTotal = count, total
+			}
+			return
+		}
+		try(row+1, count, total)
+		for j, s := range scores[row] {
+			if s >= 0 && used[j] < capacity[j] {
+				used[j]++
+				try(row+1, count+1, total+s)
+				used[j]--
+			}
+		}
+	}
+	try(0, 0, 0)
+	return bestCount, bestTotal
+}
+
+func TestAssignmentBeatsGreedy(t *testing.T) {
+	// Greedy gives row 0 its best column and leaves row 1 with 0.1
+	scores := [][]float64{
+		{0.9, 0.8},
+		{0.85, 0.1},
+	}
+	got := assignment.Solve(scores, []int{1, 1})
+	if got[0] != 1 || got[1] != 0 {
+		t.Fatalf("expected the globally optimal [1 0], got %v", got)
+	}
+}
+
+func TestAssignmentMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		rows, cols := 1+rng.Intn(6), 1+rng.Intn(4)
+		scores := make([][]float64, rows)
+		for i := range scores {
+			scores[i] = make([]float64, cols)
+			for j := range scores[i] {
+				scores[i][j] = float64(rng.Intn(1000)) / 1000
+				if rng.Intn(5) == 0 {
+					scores[i][j] = -1 // Not allowed
+				}
+			}
+		}
+		capacity := make([]int, cols)
+		for j := range capacity {
+			capacity[j] = rng.Intn(3)
+		}
+
+		got := assignment.Solve(scores, capacity)
+		used := make([]int, cols)
+		count, total := 0, 0.0
+		for i, j := range got {
+			if j < 0 {
+				continue
+			}
+			if scores[i][j] < 0 {
+				t.Fatalf("case %d: disallowed pair %d-%d assigned", n, i, j)
+			}
+			used[j]++
+			count++
+			total += scores[i][j]
+		}
+		for j := range used {
+			if used[j] > capacity[j] {
+				t.Fatalf("case %d: column %d over capacity", n, j)
+			}
+		}
+		wantCount, wantTotal := bruteForce(scores, capacity)
+		if count != wantCount || total < wantTotal-1e-6 {
+			t.Fatalf("case %d: got %d pairs scoring %.3f, best is %d scoring %.3f (%v, %v)", n, count, total, wantCount, wantTotal, scores, capacity)
+		}
+	}
+}
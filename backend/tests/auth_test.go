@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mentori/internal/services"
+
+	"github.com/google/uuid"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	ctx := context.Background()
+	svc := services.NewRefreshTokenService(newMemoryRefreshTokenRepo())
+	userID := uuid.New()
+
+	first, issued, err := svc.Issue(ctx, userID, false)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	second, rotated, err := svc.Rotate(ctx, first)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if second == first {
+		t.Fatal("rotated token must differ from the original")
+	}
+	if rotated.FamilyID != issued.FamilyID || rotated.UserID != userID {
+		t.Fatal("rotated token must stay in the same family and user")
+	}
+
+	if _, _, err := svc.Rotate(ctx, second); err != nil {
+		t.Fatalf("Rotate of the latest token: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	svc := services.NewRefreshTokenService(newMemoryRefreshTokenRepo())
+
+	first, _, err := svc.Issue(ctx, uuid.New(), false)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	second, _, err := svc.Rotate(ctx, first)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	if _, _, err := svc.Rotate(ctx, first); !errors.Is(err, services.ErrRefreshTokenReused) {
+		t.Fatalf("expected reuse error, got %v", err)
+	}
+	if _, _, err := svc.Rotate(ctx, second); !errors.Is(err, services.ErrRefreshTokenReused) {
+		t.Fatalf("expected family to be revoked after reuse, got %v", err)
+	}
+}
+
+func TestRefreshTokenRevoke(t *testing.T) {
+	ctx := context.Background()
+	svc := services.NewRefreshTokenService(newMemoryRefreshTokenRepo())
+
+	token, _, err := svc.Issue(ctx, uuid.New(), false)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := svc.Revoke(ctx, token); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, _, err := svc.Rotate(ctx, token); err == nil {
+		t.Fatal("revoked token must not rotate")
+	}
+	if _, _, err := svc.Rotate(ctx, "unknown"); !errors.Is(err, services.ErrRefreshTokenInvalid) {
+		t.Fatalf("expected invalid token error, got %v", err)
+	}
+}
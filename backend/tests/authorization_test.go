@@ -0,0 +1,203 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mentori/internal/handlers"
+	"mentori/internal/middleware"
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newTestAuthorizationService() *services.AuthorizationService {
+	return services.NewAuthorizationService(newMemoryRoleRepo())
+}
+
+func TestAuthorizationDefaultRoles(t *testing.T) {
+	ctx := context.Background()
+	authz := newTestAuthorizationService()
+
+	cases := []struct {
+		role       string
+		permission string
+		want       bool
+	}{
+		{constants.RoleAdmin, constants.PermissionUsersDelete, true},
+		{constants.RoleAdmin, constants.PermissionAuditRead, true},
+		{constants.RoleMentor, constants.PermissionMFAEnroll, true},
+		{constants.RoleMentor, constants.PermissionUsersDelete, false},
+		{constants.RoleMentee, constants.PermissionMFAEnroll, false},
+		{"ghost", constants.PermissionAuditRead, false},
+	}
+	for _, tc := range cases {
+		got, err := authz.Can(ctx, tc.role, tc.permission)
+		if err != nil {
+			t.Fatalf("Can(%s, %s): %v", tc.role, tc.permission, err)
+		}
+		if got != tc.want {
+			t.Errorf("Can(%s, %s) = %v, want %v", tc.role, tc.permission, got, tc.want)
+		}
+	}
+}
+
+func TestAuthorizationRoleFromDatabase(t *testing.T) {
+	ctx := context.Background()
+	roles := newMemoryRoleRepo()
+	roles.setRole("moderator", constants.PermissionAuditRead, "posts:hide")
+	authz := services.NewAuthorizationService(roles)
+
+	exists, err := authz.RoleExists(ctx, "moderator")
+	if err != nil || !exists {
+		t.Fatalf("RoleExists(moderator) = %v, %v", exists, err)
+	}
+	if ok, _ := authz.Can(ctx, "moderator", constants.PermissionAuditRead); !ok {
+		t.Fatal("moderator should be able to read the audit log")
+	}
+	if ok, _ := authz.Can(ctx, "moderator", constants.PermissionUsersDelete); ok {
+		t.Fatal("moderator should not be able to delete users")
+	}
+	// Permissions missing from the registry are ignored
+	if ok, _ := authz.Can(ctx, "moderator", "posts:hide"); ok {
+		t.Fatal("unknown permission should not be granted")
+	}
+
+	// Changes apply on reload; a failed reload keeps the last good roles
+	roles.setRole("moderator")
+	if err := authz.Reload(ctx); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if ok, _ := authz.Can(ctx, "moderator", constants.PermissionAuditRead); ok {
+		t.Fatal("revoked permission should not be granted after reload")
+	}
+	roles.err = errors.New("database unavailable")
+	if err := authz.Reload(ctx); err == nil {
+		t.Fatal("expected reload error")
+	}
+	if exists, err := authz.RoleExists(ctx, "moderator"); err != nil || !exists {
+		t.Fatalf("cached roles should survive a failed reload, got %v, %v", exists, err)
+	}
+}
+
+func TestAuthorizerRequire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	perms := middleware.NewAuthorizer(newTestAuthorizationService())
+
+	request := func(role string) int {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if role != "" {
+				c.Set(constants.ContextKeyUserRole, role)
+			}
+		})
+		r.DELETE("/users", perms.Require(constants.PermissionUsersDelete), func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users", nil))
+		return w.Code
+	}
+
+	if code := request(constants.RoleAdmin); code != http.StatusNoContent {
+		t.Fatalf("admin: expected 204, got %d", code)
+	}
+	if code := request(constants.RoleMentor); code != http.StatusForbidden {
+		t.Fatalf("mentor: expected 403, got %d", code)
+	}
+	if code := request(""); code != http.StatusUnauthorized {
+		t.Fatalf("no role: expected 401, got %d", code)
+	}
+}
+
+func TestAuthorizationCovers(t *testing.T) {
+	ctx := context.Background()
+	roles := newMemoryRoleRepo()
+	roles.setRole("moderator", constants.PermissionUsersManageRoles, constants.PermissionMFAEnroll)
+	authz := services.NewAuthorizationService(roles)
+
+	cases := []struct {
+		role, other string
+		want        bool
+	}{
+		{"moderator", constants.RoleMentor, true},
+		{"moderator", constants.RoleMentee, true},
+		{"moderator", "moderator", true},
+		{"moderator", constants.RoleAdmin, false},
+		{constants.RoleAdmin, "moderator", true},
+		{constants.RoleMentor, "moderator", false},
+	}
+	for _, tc := range cases {
+		got, err := authz.Covers(ctx, tc.role, tc.other)
+		if err != nil {
+			t.Fatalf("Covers(%s, %s): %v", tc.role, tc.other, err)
+		}
+		if got != tc.want {
+			t.Errorf("Covers(%s, %s) = %v, want %v", tc.role, tc.other, got, tc.want)
+		}
+	}
+}
+
+func TestUpdateUserRoleStaysWithinCallerPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	roles := newMemoryRoleRepo()
+	roles.setRole("moderator", constants.PermissionUsersManageRoles, constants.PermissionMFAEnroll)
+	authz := services.NewAuthorizationService(roles)
+	users := newMemoryUserRepo()
+	handler := handlers.NewAdminHandler(users, nil, newTestSessionService(), nil, nil, authz)
+
+	newUser := func(role string) uuid.UUID {
+		id := uuid.New()
+		if err := users.Create(ctx, &models.User{ID: id, Email: id.String() + "@example.com", Role: role}); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		return id
+	}
+	moderator := newUser("moderator")
+	admin := newUser(constants.RoleAdmin)
+	mentee := newUser(constants.RoleMentee)
+
+	update := func(callerID uuid.UUID, callerRole string, userID uuid.UUID, role string) int {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set(constants.ContextKeyUserID, callerID)
+			c.Set(constants.ContextKeyUserRole, callerRole)
+		})
+		r.PUT("/admin/users/:userId/role", handler.UpdateUserRole)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPut, "/admin/users/"+userID.String()+"/role", strings.NewReader(`{"role":"`+role+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := update(moderator, "moderator", moderator, constants.RoleAdmin); code != http.StatusForbidden {
+		t.Fatalf("own role: expected 403, got %d", code)
+	}
+	if code := update(moderator, "moderator", mentee, constants.RoleAdmin); code != http.StatusForbidden {
+		t.Fatalf("granting admin: expected 403, got %d", code)
+	}
+	if code := update(moderator, "moderator", admin, constants.RoleMentee); code != http.StatusForbidden {
+		t.Fatalf("demoting an admin: expected 403, got %d", code)
+	}
+	if u, _ := users.GetByID(ctx, admin); u.Role != constants.RoleAdmin {
+		t.Fatalf("admin role changed to %s", u.Role)
+	}
+	if code := update(moderator, "moderator", mentee, constants.RoleMentor); code != http.StatusOK {
+		t.Fatalf("granting mentor: expected 200, got %d", code)
+	}
+	if code := update(admin, constants.RoleAdmin, mentee, "moderator"); code != http.StatusOK {
+		t.Fatalf("admin granting moderator: expected 200, got %d", code)
+	}
+	if code := update(admin, constants.RoleAdmin, admin, constants.RoleMentee); code != http.StatusForbidden {
+		t.Fatalf("admin changing own role: expected 403, got %d", code)
+	}
+}
@@ -0,0 +1,334 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// availabilityFixture serves the availability routes of the server to
+// signed-in users
+type availabilityFixture struct {
+	*serverFixture
+	auth     map[uuid.UUID]string
+	mentor   *models.User
+	helsinki *time.Location
+}
+
+func newAvailabilityFixture(t *testing.T) *availabilityFixture {
+	t.Helper()
+	helsinki, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	f := &availabilityFixture{serverFixture: newServerFixture(t), auth: map[uuid.UUID]string{}, helsinki: helsinki}
+	f.mentor = f.addUser(t, constants.RoleMentor)
+	return f
+}
+
+// addUser creates a user with an active profile everyone may see
+func (f *availabilityFixture) addUser(t *testing.T, role string) *models.User {
+	t.Helper()
+	return f.serverFixture.addUser(t, role, &models.Profile{FirstName: "Test", IsActive: true})
+}
+
+// do sends a request as the user and decodes a successful response into out
+func (f *availabilityFixture) do(t *testing.T, user *models.User, method, path string, body, out interface{}) (int, models.ErrorResponse) {
+	t.Helper()
+	auth, ok := f.auth[user.ID]
+	if !ok {
+		auth = f.signIn(t, user, false)
+		f.auth[user.ID] = auth
+	}
+	return f.serverFixture.do(t, auth, method, path, body, out)
+}
+
+// openSlots returns the local start times of the mentor's open slots on the day
+func (f *availabilityFixture) openSlots(t *testing.T, day time.Time) []string {
+	t.Helper()
+	query := url.Values{
+		"from": {day.Format(time.RFC3339)},
+		"to":   {day.AddDate(0, 0, 1).Format(time.RFC3339)},
+	}
+	var page pagination.Page[models.OpenSlot]
+	if code, failure := f.do(t, f.mentor, http.MethodGet, "/mentors/"+f.mentor.ID.String()+"/open-slots?"+query.Encode(), nil, &page); code != http.StatusOK {
+		t.Fatalf("open slots: expected 200, got %d %+v", code, failure)
+	}
+	starts := make([]string, len(page.Items))
+	for i, slot := range page.Items {
+		starts[i] = slot.StartsAt.In(f.helsinki).Format("15:04")
+	}
+	return starts
+}
+
+// nextDay returns the start of a day in Helsinki, at least two days ahead,
+// that satisfies the condition
+func (f *availabilityFixture) nextDay(t *testing.T, condition func(day time.Time) bool) time.Time {
+	t.Helper()
+	now := time.Now().In(f.helsinki)
+	day := time.Date(now.Year(), now.Month(), now.Day()+2, 0, 0, 0, 0, f.helsinki)
+	for i := 0; i < 400; i++ {
+		if condition(day) {
+			return day
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	t.Fatal("no such day within a year")
+	return day
+}
+
+func weekly(day time.Time, start, end string) models.UpdateAvailabilityRequest {
+	return models.UpdateAvailabilityRequest{Weekly: []models.AvailabilityWindowRequest{
+		{Weekday: int(day.Weekday()), StartTime: start, EndTime: end},
+	}}
+}
+
+func TestOpenSlotsFollowWeeklyWindowsInTimezone(t *testing.T) {
+	f := newAvailabilityFixture(t)
+	day := f.nextDay(t, func(day time.Time) bool { return day.Weekday() == time.Monday })
+
+	var availability models.Availability
+	if code, failure := f.do(t, f.mentor, http.MethodPut, "/availability", weekly(day, "09:00", "12:00"), &availability); code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d %+v", code, failure)
+	}
+	if availability.Timezone != constants.DefaultTimezone || len(availability.Weekly) != 1 {
+		t.Fatalf("expected one window in Europe/Helsinki, got %+v", availability)
+	}
+	if got := f.openSlots(t, day); len(got) != 3 || got[0] != "09:00" || got[2] != "11:00" {
+		t.Fatalf("expected 09:00, 10:00 and 11:00, got %v", got)
+	}
+	if got := f.openSlots(t, day.AddDate(0, 0, 1)); len(got) != 0 {
+		t.Fatalf("expected no slots on Tuesday, got %v", got)
+	}
+
+	// Windows open at the time on the clock on the days clocks change
+	changeDay := f.nextDay(t, func(day time.Time) bool {
+		_, midnight := day.Zone()
+		_, evening := day.Add(23 * time.Hour).Zone()
+		return midnight != evening
+	})
+	if code, failure := f.do(t, f.mentor, http.MethodPut, "/availability", weekly(changeDay, "09:00", "10:00"), nil); code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d %+v", code, failure)
+	}
+	if got := f.openSlots(t, changeDay); len(got) != 1 || got[0] != "09:00" {
+		t.Fatalf("expected 09:00 on %s, got %v", changeDay.Format("2006-01-02"), got)
+	}
+
+	// Another timezone moves the windows
+	update := weekly(day, "09:00", "10:00")
+	update.Timezone = "Europe/Stockholm"
+	if code, failure := f.do(t, f.mentor, http.MethodPut, "/availability", update, nil); code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d %+v", code, failure)
+	}
+	if got := f.openSlots(t, day); len(got) != 1 || got[0] != "10:00" {
+		t.Fatalf("09:00 in Stockholm is 10:00 in Helsinki, got %v", got)
+	}
+}
+
+func TestOpenSlotsUseOverridesBlackoutsAndBookings(t *testing.T) {
+	f := newAvailabilityFixture(t)
+	day := f.nextDay(t, func(day time.Time) bool { return day.Weekday() == time.Wednesday })
+	if code, failure := f.do(t, f.mentor, http.MethodPut, "/availability", weekly(day, "09:00", "13:00"), nil); code != http.StatusOK {
+		t.Fatalf("update: expected 200, got %d %+v", code, failure)
+	}
+	at := func(day time.Time, hour int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, f.helsinki)
+	}
+
+	// A blackout, a booked session and a cancelled one
+	blackout := models.AvailabilityBlackoutRequest{StartsAt: at(day, 9), EndsAt: at(day, 10), Reason: "Dentist"}
+	if code, failure := f.do(t, f.mentor, http.MethodPost, "/availability/blackouts", blackout, nil); code != http.StatusCreated {
+		t.Fatalf("blackout: expected 201, got %d %+v", code, failure)
+	}
+	f.mentoringSessions.book(f.mentor.ID, at(day, 10), time.Hour, constants.SessionStatusAccepted)
+	f.mentoringSessions.book(f.mentor.ID, at(day, 11), time.Hour, constants.SessionStatusCancelled)
+	if got := f.openSlots(t, day); len(got) != 2 || got[0] != "11:00" || got[1] != "12:00" {
+		t.Fatalf("expected 11:00 and 12:00, got %v", got)
+	}
+
+	// Overrides replace the weekly windows of their date only
+	next := day.AddDate(0, 0, 7)
+	for _, window := range [][2]string{{"14:00", "15:00"}, {"15:00", "16:00"}} {
+		override := models.AvailabilityOverrideRequest{Date: next.Format("2006-01-02"), StartTime: window[0], EndTime: window[1]}
+		if code, failure := f.do(t, f.mentor, http.MethodPost, "/availability/overrides", override, nil); code != http.StatusCreated {
+			t.Fatalf("override: expected 201, got %d %+v", code, failure)
+		}
+	}
+	if got := f.openSlots(t, next); len(got) != 2 || got[0] != "14:00" || got[1] != "15:00" {
+		t.Fatalf("expected 14:00 and 15:00, got %v", got)
+	}
+	if got := f.openSlots(t, next.AddDate(0, 0, 7)); len(got) != 4 {
+		t.Fatalf("expected the weekly 09:00 to 13:00 the week after, got %v", got)
+	}
+
+	// Touching windows merge, so longer slots can span them
+	query := url.Values{
+		"from":         {next.Format(time.RFC3339)},
+		"to":           {next.AddDate(0, 0, 1).Format(time.RFC3339)},
+		"slot_minutes": {"120"},
+	}
+	var page pagination.Page[models.OpenSlot]
+	if code, failure := f.do(t, f.mentor, http.MethodGet, "/mentors/"+f.mentor.ID.String()+"/open-slots?"+query.Encode(), nil, &page); code != http.StatusOK || len(page.Items) != 1 {
+		t.Fatalf("expected one two-hour slot, got %d %+v %+v", code, page.Items, failure)
+	}
+
+	var availability models.Availability
+	f.do(t, f.mentor, http.MethodGet, "/availability", nil, &availability)
+	if len(availability.Overrides) != 2 || len(availability.Blackouts) != 1 || availability.Blackouts[0].Reason != "Dentist" {
+		t.Fatalf("expected two overrides and one blackout, got %+v", availability)
+	}
+}
+
+func TestAvailabilityIsForMentorsOnly(t *testing.T) {
+	f := newAvailabilityFixture(t)
+	mentee := f.addUser(t, constants.RoleMentee)
+
+	if code, _ := f.do(t, mentee, http.MethodGet, "/availability", nil, nil); code != http.StatusForbidden {
+		t.Fatalf("mentee calendar: expected 403, got %d", code)
+	}
+	update := models.UpdateAvailabilityRequest{Weekly: []models.AvailabilityWindowRequest{{Weekday: 1, StartTime: "09:00", EndTime: "10:00"}}}
+	if code, _ := f.do(t, mentee, http.MethodPut, "/availability", update, nil); code != http.StatusForbidden {
+		t.Fatalf("mentee update: expected 403, got %d", code)
+	}
+	if code, _ := f.do(t, f.mentor, http.MethodGet, "/mentors/"+mentee.ID.String()+"/open-slots", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("open slots of a mentee: expected 404, got %d", code)
+	}
+
+	// Anyone signed in can see a mentor's open slots
+	var page pagination.Page[models.OpenSlot]
+	if code, _ := f.do(t, mentee, http.MethodGet, "/mentors/"+f.mentor.ID.String()+"/open-slots", nil, &page); code != http.StatusOK || page.Items == nil {
+		t.Fatalf("open slots: expected 200 with an empty list, got %d %+v", code, page)
+	}
+}
+
+func TestOpenSlotsFollowProfileVisibility(t *testing.T) {
+	f := newAvailabilityFixture(t)
+	mentee := f.addUser(t, constants.RoleMentee)
+	otherMentor := f.addUser(t, constants.RoleMentor)
+	path := "/mentors/" + f.mentor.ID.String() + "/open-slots"
+
+	profile, err := f.profiles.GetByUserID(context.Background(), f.mentor.ID)
+	if err != nil {
+		t.Fatalf("GetByUserID: %v", err)
+	}
+	profile.Visibility = constants.ProfileVisibilityOppositeRole
+	if err := f.profiles.Update(context.Background(), profile); err != nil {
+		t.Fatalf("update profile: %v", err)
+	}
+	if code, _ := f.do(t, otherMentor, http.MethodGet, path, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("mentor shown only to mentees, other mentor: expected 404, got %d", code)
+	}
+	if code, _ := f.do(t, mentee, http.MethodGet, path, nil, &pagination.Page[models.OpenSlot]{}); code != http.StatusOK {
+		t.Fatalf("mentor shown only to mentees, mentee: expected 200, got %d", code)
+	}
+
+	profile.Visibility = constants.ProfileVisibilityEveryone
+	profile.IsActive = false
+	if err := f.profiles.Update(context.Background(), profile); err != nil {
+		t.Fatalf("update profile: %v", err)
+	}
+	if code, _ := f.do(t, mentee, http.MethodGet, path, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("inactive profile: expected 404, got %d", code)
+	}
+	if code, _ := f.do(t, f.mentor, http.MethodGet, path, nil, &pagination.Page[models.OpenSlot]{}); code != http.StatusOK {
+		t.Fatalf("own open slots with an inactive profile: expected 200, got %d", code)
+	}
+}
+
+func TestAvailabilityRejectsInvalidInput(t *testing.T) {
+	f := newAvailabilityFixture(t)
+	window := func(weekday int, start, end string) models.UpdateAvailabilityRequest {
+		return models.UpdateAvailabilityRequest{Weekly: []models.AvailabilityWindowRequest{{Weekday: weekday, StartTime: start, EndTime: end}}}
+	}
+	badTimezone := window(1, "09:00", "10:00")
+	badTimezone.Timezone = "Mars/Olympus"
+	for _, update := range []models.UpdateAvailabilityRequest{
+		badTimezone,
+		window(7, "09:00", "10:00"),
+		window(1, "10:00", "09:00"),
+		window(1, "9:00", "10:00"),
+		window(1, "22:00", "24:30"),
+	} {
+		if code, _ := f.do(t, f.mentor, http.MethodPut, "/availability", update, nil); code != http.StatusBadRequest {
+			t.Fatalf("%+v: expected 400, got %d", update, code)
+		}
+	}
+	if code, _ := f.do(t, f.mentor, http.MethodPut, "/availability", window(1, "22:00", "24:00"), nil); code != http.StatusOK {
+		t.Fatalf("window until midnight: expected 200, got %d", code)
+	}
+
+	yesterday := time.Now().In(f.helsinki).AddDate(0, 0, -1).Format("2006-01-02")
+	override := models.AvailabilityOverrideRequest{Date: yesterday, StartTime: "09:00", EndTime: "10:00"}
+	if code, _ := f.do(t, f.mentor, http.MethodPost, "/availability/overrides", override, nil); code != http.StatusBadRequest {
+		t.Fatalf("override in the past: expected 400, got %d", code)
+	}
+	now := time.Now()
+	blackout := models.AvailabilityBlackoutRequest{StartsAt: now.Add(2 * time.Hour), EndsAt: now.Add(time.Hour)}
+	if code, _ := f.do(t, f.mentor, http.MethodPost, "/availability/blackouts", blackout, nil); code != http.StatusBadRequest {
+		t.Fatalf("blackout ending before it starts: expected 400, got %d", code)
+	}
+
+	cases := []struct {
+		query url.Values
+		field string
+	}{
+		{url.Values{"from": {"tomorrow"}}, "from"},
+		{url.Values{"to": {"2030-01-01"}}, "to"},
+		{url.Values{"slot_minutes": {"an hour"}}, "slot_minutes"},
+		{url.Values{"slot_minutes": {"5"}}, ""},
+		{url.Values{"from": {now.Format(time.RFC3339)}, "to": {now.AddDate(0, 2, 0).Format(time.RFC3339)}}, ""},
+		{url.Values{"from": {now.Format(time.RFC3339)}, "to": {now.Add(-time.Hour).Format(time.RFC3339)}}, ""},
+	}
+	for _, tc := range cases {
+		path := "/mentors/" + f.mentor.ID.String() + "/open-slots?" + tc.query.Encode()
+		if code, failure := f.do(t, f.mentor, http.MethodGet, path, nil, nil); code != http.StatusBadRequest || failure.Field != tc.field {
+			t.Fatalf("%v: expected 400 on %q, got %d %+v", tc.query, tc.field, code, failure)
+		}
+	}
+}
+
+func TestDeleteAvailabilityEntries(t *testing.T) {
+	f := newAvailabilityFixture(t)
+	other := f.addUser(t, constants.RoleMentor)
+	tomorrow := time.Now().In(f.helsinki).AddDate(0, 0, 1).Format("2006-01-02")
+
+	var override models.AvailabilityOverride
+	request := models.AvailabilityOverrideRequest{Date: tomorrow, StartTime: "09:00", EndTime: "10:00"}
+	if code, failure := f.do(t, f.mentor, http.MethodPost, "/availability/overrides", request, &override); code != http.StatusCreated {
+		t.Fatalf("override: expected 201, got %d %+v", code, failure)
+	}
+	var blackout models.AvailabilityBlackout
+	period := models.AvailabilityBlackoutRequest{StartsAt: time.Now().Add(time.Hour), EndsAt: time.Now().Add(48 * time.Hour)}
+	if code, failure := f.do(t, f.mentor, http.MethodPost, "/availability/blackouts", period, &blackout); code != http.StatusCreated {
+		t.Fatalf("blackout: expected 201, got %d %+v", code, failure)
+	}
+
+	for _, path := range []string{"/availability/overrides/" + override.ID.String(), "/availability/blackouts/" + blackout.ID.String()} {
+		if code, _ := f.do(t, other, http.MethodDelete, path, nil, nil); code != http.StatusNotFound {
+			t.Fatalf("%s by another mentor: expected 404, got %d", path, code)
+		}
+		if code, _ := f.do(t, f.mentor, http.MethodDelete, path, nil, nil); code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, code)
+		}
+		if code, _ := f.do(t, f.mentor, http.MethodDelete, path, nil, nil); code != http.StatusNotFound {
+			t.Fatalf("%s again: expected 404, got %d", path, code)
+		}
+	}
+	if code, _ := f.do(t, f.mentor, http.MethodDelete, "/availability/overrides/not-a-uuid", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("invalid ID: expected 400, got %d", code)
+	}
+
+	var availability models.Availability
+	f.do(t, f.mentor, http.MethodGet, "/availability", nil, &availability)
+	if len(availability.Overrides) != 0 || len(availability.Blackouts) != 0 {
+		t.Fatalf("expected nothing left, got %+v", availability)
+	}
+}
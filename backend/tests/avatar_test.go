@@ -0,0 +1,373 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/storage"
+
+	"github.com/google/uuid"
+)
+
+// s3Stub is a minimal MinIO-style server for path-style PUT, GET and DELETE.
+// It checks that requests are signed and that the payload hash matches.
+type s3Stub struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newS3Stub(t *testing.T) (*s3Stub, *httptest.Server) {
+	stub := &s3Stub{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+	return stub, srv
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") ||
+		r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		s.objects[r.URL.Path] = body
+	case http.MethodGet:
+		obj, ok := s.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(obj)
+	case http.MethodDelete:
+		delete(s.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *s3Stub) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.objects {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func newAvatarFixture(t *testing.T, store storage.Store) (*services.AvatarService, *memoryProfileRepo, uuid.UUID) {
+	t.Helper()
+	profiles := newMemoryProfileRepo()
+	userID := uuid.New()
+	if err := profiles.Create(context.Background(), &models.Profile{ID: uuid.New(), UserID: userID, IsActive: true, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("create profile: %v", err)
+	}
+	return services.NewAvatarService(profiles, store), profiles, userID
+}
+
+// sidewaysPhoto is a 40x20 JPEG, red on the left and blue on the right, whose
+// EXIF data says it must be rotated 90° clockwise and carries a camera serial
+func sidewaysPhoto(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			if x < 20 {
+				img.Set(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.RGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	// TIFF header and IFD0 with a single orientation entry, then the serial
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = append(tiff, 0x00, 0x01) // One entry
+	tiff = append(tiff, 0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x06, 0x00, 0x00)
+	tiff = append(tiff, 0x00, 0x00, 0x00, 0x00) // No next IFD
+	tiff = append(tiff, []byte("SERIAL-0042")...)
+	exif := append([]byte("Exif\x00\x00"), tiff...)
+
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(exif)+2))
+	segment = append(segment, exif...)
+
+	jpg := buf.Bytes()
+	return append(append(append([]byte{}, jpg[:2]...), segment...), jpg[2:]...)
+}
+
+func pngImage(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestAvatarUploadLocal(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewLocalStore(dir, "http://localhost:8080/uploads/")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	svc, profiles, userID := newAvatarFixture(t, store)
+
+	avatar, err := svc.Upload(context.Background(), userID, sidewaysPhoto(t))
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if len(avatar.Sizes) != len(constants.AvatarSizes) || avatar.AvatarURL != avatar.Sizes["256"] {
+		t.Fatalf("unexpected response: %+v", avatar)
+	}
+	profile, _ := profiles.GetByUserID(context.Background(), userID)
+	if profile.AvatarURL != avatar.AvatarURL || !strings.HasPrefix(profile.AvatarURL, "http://localhost:8080/uploads/avatars/"+userID.String()+"/") {
+		t.Fatalf("profile avatar_url = %q", profile.AvatarURL)
+	}
+
+	for size, url := range avatar.Sizes {
+		data, err := os.ReadFile(filepath.Join(dir, strings.TrimPrefix(url, "http://localhost:8080/uploads/")))
+		if err != nil {
+			t.Fatalf("thumbnail %s: %v", size, err)
+		}
+		if bytes.Contains(data, []byte("Exif")) || bytes.Contains(data, []byte("SERIAL-0042")) {
+			t.Fatalf("thumbnail %s still carries EXIF data", size)
+		}
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("thumbnail %s: %v", size, err)
+		}
+		if b := img.Bounds(); b.Dx() != b.Dy() || size != strconv.Itoa(b.Dx()) {
+			t.Fatalf("thumbnail %s has size %v", size, b)
+		}
+
+		// Rotated upright, the red half is on top
+		top, bottom := img.At(0, 0).(color.YCbCr), img.At(0, img.Bounds().Dy()-1).(color.YCbCr)
+		if r, _, b := color.YCbCrToRGB(top.Y, top.Cb, top.Cr); r < 180 || b > 80 {
+			t.Fatalf("thumbnail %s: expected red top, got %v", size, top)
+		}
+		if r, _, b := color.YCbCrToRGB(bottom.Y, bottom.Cb, bottom.Cr); b < 180 || r > 80 {
+			t.Fatalf("thumbnail %s: expected blue bottom, got %v", size, bottom)
+		}
+	}
+}
+
+func TestAvatarReplaceDeletesPreviousUpload(t *testing.T) {
+	stub, srv := newS3Stub(t)
+	store, err := storage.NewS3Store(storage.S3Config{
+		Endpoint:        srv.URL,
+		Bucket:          "avatars",
+		AccessKeyID:     "test-key",
+		SecretAccessKey: "test-secret",
+		PublicURL:       "https://cdn.example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+	svc, profiles, userID := newAvatarFixture(t, store)
+	ctx := context.Background()
+
+	first, err := svc.Upload(ctx, userID, pngImage(t, 300, 200))
+	if err != nil {
+		t.Fatalf("first upload: %v", err)
+	}
+	if len(stub.keys()) != len(constants.AvatarSizes) {
+		t.Fatalf("expected %d objects, got %v", len(constants.AvatarSizes), stub.keys())
+	}
+	if !strings.HasPrefix(first.AvatarURL, "https://cdn.example.com/avatars/"+userID.String()+"/") {
+		t.Fatalf("avatar_url = %q", first.AvatarURL)
+	}
+
+	second, err := svc.Upload(ctx, userID, pngImage(t, 64, 64))
+	if err != nil {
+		t.Fatalf("second upload: %v", err)
+	}
+	// Objects live at /<bucket>/<key>; only the second upload's remain
+	prefix := "/avatars" + strings.TrimSuffix(strings.TrimPrefix(second.AvatarURL, "https://cdn.example.com"), "256.jpg")
+	keys := stub.keys()
+	if len(keys) != len(constants.AvatarSizes) {
+		t.Fatalf("previous upload was not deleted: %v", keys)
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			t.Fatalf("unexpected object %s after replacing %s", key, first.AvatarURL)
+		}
+	}
+
+	// Deleting the profile removes the remaining upload too
+	profile, _ := profiles.GetByUserID(ctx, userID)
+	svc.Discard(ctx, profile)
+	if keys := stub.keys(); len(keys) != 0 {
+		t.Fatalf("expected no objects after discard, got %v", keys)
+	}
+}
+
+// racingStore runs a hook before the first object of an upload is stored,
+// standing in for requests that change the profile while the image is saved
+type racingStore struct {
+	storage.Store
+	once sync.Once
+	hook func()
+}
+
+func (s *racingStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	s.once.Do(s.hook)
+	return s.Store.Put(ctx, key, data, contentType)
+}
+
+func TestAvatarUploadKeepsConcurrentChanges(t *testing.T) {
+	stub, srv := newS3Stub(t)
+	s3, err := storage.NewS3Store(storage.S3Config{
+		Endpoint:        srv.URL,
+		Bucket:          "avatars",
+		AccessKeyID:     "test-key",
+		SecretAccessKey: "test-secret",
+		PublicURL:       "https://cdn.example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewS3Store: %v", err)
+	}
+	ctx := context.Background()
+	store := &racingStore{Store: s3}
+	svc, profiles, userID := newAvatarFixture(t, store)
+
+	// A profile edit saved meanwhile is not reverted
+	store.hook = func() {
+		profile, _ := profiles.GetByUserID(ctx, userID)
+		profile.Bio = "Edited during the upload"
+		profiles.Update(ctx, profile)
+	}
+	first, err := svc.Upload(ctx, userID, pngImage(t, 64, 64))
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	profile, _ := profiles.GetByUserID(ctx, userID)
+	if profile.Bio != "Edited during the upload" || profile.AvatarURL != first.AvatarURL {
+		t.Fatalf("bio %q, avatar_url %q after upload", profile.Bio, profile.AvatarURL)
+	}
+
+	// An upload that lost the race to another one leaves no objects behind
+	store.once = sync.Once{}
+	store.hook = func() {
+		profile, _ := profiles.GetByUserID(ctx, userID)
+		profile.AvatarURL = "https://cdn.example.com/avatars/other/256.jpg"
+		profile.AvatarKey = "avatars/other"
+		profiles.Update(ctx, profile)
+	}
+	if _, err := svc.Upload(ctx, userID, pngImage(t, 64, 64)); !errors.Is(err, services.ErrAvatarConflict) {
+		t.Fatalf("expected ErrAvatarConflict, got %v", err)
+	}
+	if keys := stub.keys(); len(keys) != len(constants.AvatarSizes) {
+		t.Fatalf("the losing upload's objects were kept: %v", keys)
+	}
+	if profile, _ := profiles.GetByUserID(ctx, userID); profile.AvatarKey != "avatars/other" {
+		t.Fatalf("avatar_key = %q, want the other upload's", profile.AvatarKey)
+	}
+}
+
+func TestAvatarUploadRejects(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir(), "http://localhost/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	svc, _, userID := newAvatarFixture(t, store)
+	ctx := context.Background()
+
+	// PNGs whose header claims the given dimensions
+	claiming := func(width, height uint32) []byte {
+		data := pngImage(t, 1, 1)
+		binary.BigEndian.PutUint32(data[16:], width)
+		binary.BigEndian.PutUint32(data[20:], height)
+		binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+		return data
+	}
+
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	for name, tc := range map[string]struct {
+		userID uuid.UUID
+		data   []byte
+		want   error
+	}{
+		"text":            {userID, []byte("<svg xmlns='http://www.w3.org/2000/svg'/>"), services.ErrAvatarUnsupported},
+		"gif":             {userID, gif, services.ErrAvatarUnsupported},
+		"too large":       {userID, append(pngImage(t, 8, 8), make([]byte, constants.AvatarMaxBytes)...), services.ErrAvatarTooLarge},
+		"too many pixels": {userID, claiming(10000, 10000), services.ErrAvatarDimensions},
+		"12 MP photo":     {userID, claiming(4000, 3000), services.ErrAvatarDimensions},
+		"no profile":      {uuid.New(), pngImage(t, 8, 8), services.ErrAvatarNoProfile},
+	} {
+		if _, err := svc.Upload(ctx, tc.userID, tc.data); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestUploadAvatarHandler(t *testing.T) {
+	f := newServerFixture(t)
+	user := f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Aino", IsActive: true})
+	auth := f.signIn(t, user, false)
+
+	upload := func(field string, data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile(field, "me.png")
+		part.Write(data)
+		form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/profiles/me/image", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		return f.send(req, auth)
+	}
+
+	w := upload(constants.AvatarFormField, pngImage(t, 32, 32))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var avatar models.AvatarResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &avatar); err != nil || avatar.Sizes["512"] == "" {
+		t.Fatalf("unexpected response %s: %v", w.Body.String(), err)
+	}
+
+	if w := upload("file", pngImage(t, 32, 32)); w.Code != http.StatusBadRequest {
+		t.Fatalf("wrong field: expected 400, got %d", w.Code)
+	}
+	if w := upload(constants.AvatarFormField, []byte("not an image")); w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("text upload: expected 415, got %d", w.Code)
+	}
+	if w := upload(constants.AvatarFormField, make([]byte, constants.AvatarMaxBytes+128<<10)); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized upload: expected 413, got %d", w.Code)
+	}
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"mentori/internal/models"
+	"mentori/pkg/config"
+	"mentori/pkg/constants"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// cohortFixture serves the admin cohort routes of the server, matching on
+// expertise alone
+type cohortFixture struct {
+	*serverFixture
+	admin *models.User
+	auth  string
+}
+
+func newCohortFixture(t *testing.T) *cohortFixture {
+	f := &cohortFixture{serverFixture: newServerFixture(t, func(cfg *config.Config) {
+		cfg.MatchWeightExpertise = 1
+		cfg.MatchWeightInterests = 0
+		cfg.MatchWeightLocation = 0
+		cfg.MatchWeightLanguage = 0
+		cfg.MatchWeightAvailability = 0
+		cfg.MatchWeightCapacity = 0
+	})}
+	f.admin = f.addUser(t, constants.RoleAdmin, nil)
+	f.auth = f.signIn(t, f.admin, true)
+	return f
+}
+
+func (f *cohortFixture) do(t *testing.T, method, path string, body interface{}) (int, models.Cohort) {
+	t.Helper()
+	var cohort models.Cohort
+	code, _ := f.serverFixture.do(t, f.auth, method, path, body, &cohort)
+	return code, cohort
+}
+
+func mentorOf(cohort models.Cohort, menteeID uuid.UUID) *uuid.UUID {
+	for _, pair := range cohort.Pairs {
+		if pair.MenteeID == menteeID {
+			return pair.MentorID
+		}
+	}
+	return nil
+}
+
+// cohortUsers is a small cohort where pairing greedily by best score is not optimal
+type cohortUsers struct {
+	broad, narrow   *models.User // Mentors with one open slot each
+	flexible, picky *models.User // Mentees: picky only fits broad
+	offTopic        *models.User // Fits no mentor above the minimum score
+}
+
+func (f *cohortFixture) addCohortUsers(t *testing.T) cohortUsers {
+	return cohortUsers{
+		broad: f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Broad", IsActive: true, MaxMentees: 1,
+			Expertise: datatypes.JSON(`["find-a-job","banking-finance"]`)}),
+		narrow: f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Narrow", IsActive: true, MaxMentees: 1,
+			Expertise: datatypes.JSON(`["find-a-job"]`)}),
+		flexible: f.addUser(t, constants.RoleMentee, &models.Profile{FirstName: "Flexible", IsActive: true,
+			Expertise: datatypes.JSON(`["find-a-job","banking-finance"]`)}),
+		picky: f.addUser(t, constants.RoleMentee, &models.Profile{FirstName: "Picky", IsActive: true,
+			Expertise: datatypes.JSON(`["banking-finance"]`)}),
+		offTopic: f.addUser(t, constants.RoleMentee, &models.Profile{FirstName: "Off topic", IsActive: true,
+			Expertise: datatypes.JSON(`["coffee-culture"]`)}),
+	}
+}
+
+func (f *cohortFixture) draft(t *testing.T, u cohortUsers) models.Cohort {
+	t.Helper()
+	code, cohort := f.do(t, http.MethodPost, "/admin/cohorts", models.CreateCohortRequest{
+		Name:      "Spring",
+		MenteeIDs: []uuid.UUID{u.flexible.ID, u.picky.ID, u.offTopic.ID},
+		Mentors:   []models.CohortMentorRequest{{MentorID: u.broad.ID}, {MentorID: u.narrow.ID}},
+		MinScore:  0.1,
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", code)
+	}
+	return cohort
+}
+
+func TestCreateCohortAssignsOptimally(t *testing.T) {
+	f := newCohortFixture(t)
+	u := f.addCohortUsers(t)
+	cohort := f.draft(t, u)
+
+	if cohort.Status != constants.CohortDraft {
+		t.Fatalf("expected a draft, got %q", cohort.Status)
+	}
+	// Greedy would give flexible the broad mentor and leave picky without one
+	if m := mentorOf(cohort, u.picky.ID); m == nil || *m != u.broad.ID {
+		t.Fatalf("picky: expected the broad mentor, got %v", m)
+	}
+	if m := mentorOf(cohort, u.flexible.ID); m == nil || *m != u.narrow.ID {
+		t.Fatalf("flexible: expected the narrow mentor, got %v", m)
+	}
+	if m := mentorOf(cohort, u.offTopic.ID); m != nil {
+		t.Fatalf("off topic: expected no mentor below the minimum score, got %v", m)
+	}
+	if cohort.Assigned != 2 || cohort.Unassigned != 1 || cohort.TotalScore != 1.5 {
+		t.Fatalf("unexpected summary: %d assigned, %d unassigned, total %v", cohort.Assigned, cohort.Unassigned, cohort.TotalScore)
+	}
+	for _, m := range cohort.Mentors {
+		if m.Capacity != 1 {
+			t.Fatalf("capacity should default to open slots, got %d", m.Capacity)
+		}
+	}
+}
+
+func TestCreateCohortSkipsExistingMentorships(t *testing.T) {
+	f := newCohortFixture(t)
+	u := f.addCohortUsers(t)
+	f.mentorships.pair(u.broad.ID, u.picky.ID)
+	capacity := 1
+
+	code, cohort := f.do(t, http.MethodPost, "/admin/cohorts", models.CreateCohortRequest{
+		Name:      "Autumn",
+		MenteeIDs: []uuid.UUID{u.picky.ID},
+		Mentors:   []models.CohortMentorRequest{{MentorID: u.broad.ID, Capacity: &capacity}},
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if m := mentorOf(cohort, u.picky.ID); m != nil {
+		t.Fatalf("existing partners were paired again")
+	}
+
+	code, _ = f.do(t, http.MethodPost, "/admin/cohorts", models.CreateCohortRequest{
+		Name:      "Wrong roles",
+		MenteeIDs: []uuid.UUID{u.broad.ID},
+		Mentors:   []models.CohortMentorRequest{{MentorID: u.narrow.ID}},
+	})
+	if code != http.StatusBadRequest {
+		t.Fatalf("mentor listed as mentee: expected 400, got %d", code)
+	}
+}
+
+func TestReassignCohortPair(t *testing.T) {
+	f := newCohortFixture(t)
+	u := f.addCohortUsers(t)
+	cohort := f.draft(t, u)
+	pairPath := func(mentee *models.User) string {
+		return "/admin/cohorts/" + cohort.ID.String() + "/pairs/" + mentee.ID.String()
+	}
+
+	if code, _ := f.do(t, http.MethodPut, pairPath(u.offTopic), models.UpdateCohortPairRequest{MentorID: &u.narrow.ID}); code != http.StatusConflict {
+		t.Fatalf("mentor at capacity: expected 409, got %d", code)
+	}
+	if code, _ := f.do(t, http.MethodPut, pairPath(u.offTopic), models.UpdateCohortPairRequest{MentorID: &f.admin.ID}); code != http.StatusBadRequest {
+		t.Fatalf("mentor outside the pool: expected 400, got %d", code)
+	}
+
+	if code, _ := f.do(t, http.MethodPut, pairPath(u.flexible), models.UpdateCohortPairRequest{}); code != http.StatusOK {
+		t.Fatalf("unassign: expected 200, got %d", code)
+	}
+	code, updated := f.do(t, http.MethodPut, pairPath(u.offTopic), models.UpdateCohortPairRequest{MentorID: &u.narrow.ID})
+	if code != http.StatusOK {
+		t.Fatalf("reassign: expected 200, got %d", code)
+	}
+	for _, pair := range updated.Pairs {
+		if pair.MenteeID == u.offTopic.ID && (pair.MentorID == nil || *pair.MentorID != u.narrow.ID || !pair.Adjusted || pair.Score != 0) {
+			t.Fatalf("unexpected adjusted pair %+v", pair)
+		}
+	}
+	if updated.Assigned != 2 || updated.TotalScore != 1 {
+		t.Fatalf("summary not updated: %d assigned, total %v", updated.Assigned, updated.TotalScore)
+	}
+}
+
+func TestPublishCohort(t *testing.T) {
+	f := newCohortFixture(t)
+	u := f.addCohortUsers(t)
+	cohort := f.draft(t, u)
+	publishPath := "/admin/cohorts/" + cohort.ID.String() + "/publish"
+
+	code, published := f.do(t, http.MethodPost, publishPath, nil)
+	if code != http.StatusOK {
+		t.Fatalf("publish: expected 200, got %d", code)
+	}
+	if published.Status != constants.CohortPublished || published.PublishedBy == nil || *published.PublishedBy != f.admin.ID {
+		t.Fatalf("unexpected published cohort %+v", published)
+	}
+	ctx := context.Background()
+	for _, pair := range [][2]uuid.UUID{{u.broad.ID, u.picky.ID}, {u.narrow.ID, u.flexible.ID}} {
+		if ok, _ := f.mentorships.ActiveBetween(ctx, pair[0], pair[1]); !ok {
+			t.Fatalf("no mentorship created for %v", pair)
+		}
+	}
+	if counts, _ := f.mentorships.ActiveMenteeCounts(ctx, []uuid.UUID{u.broad.ID, u.narrow.ID}); counts[u.broad.ID]+counts[u.narrow.ID] != 2 {
+		t.Fatalf("expected exactly two mentorships, got %v", counts)
+	}
+
+	if code, _ := f.do(t, http.MethodPost, publishPath, nil); code != http.StatusConflict {
+		t.Fatalf("publish twice: expected 409, got %d", code)
+	}
+	pairPath := "/admin/cohorts/" + cohort.ID.String() + "/pairs/" + u.offTopic.ID.String()
+	if code, _ := f.do(t, http.MethodPut, pairPath, models.UpdateCohortPairRequest{}); code != http.StatusConflict {
+		t.Fatalf("edit after publishing: expected 409, got %d", code)
+	}
+	if code, _ := f.do(t, http.MethodGet, "/admin/cohorts/"+uuid.NewString(), nil); code != http.StatusNotFound {
+		t.Fatalf("unknown cohort: expected 404, got %d", code)
+	}
+}
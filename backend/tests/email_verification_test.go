@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+func newVerificationFixture(t *testing.T) (*services.EmailVerificationService, *memoryUserRepo, *memoryEmailVerificationRepo, *utils.MemorySender) {
+	t.Helper()
+	users := newMemoryUserRepo()
+	codes := newMemoryEmailVerificationRepo()
+	sender := utils.NewMemorySender()
+	if err := users.Create(context.Background(), &models.User{ID: uuid.New(), Email: "mentee@example.com", Role: constants.RoleMentee}); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return services.NewEmailVerificationService(codes, users, sender), users, codes, sender
+}
+
+func sentCode(t *testing.T, sender *utils.MemorySender, to string) string {
+	t.Helper()
+	msg, ok := sender.Last(to)
+	if !ok {
+		t.Fatalf("no email sent to %s", to)
+	}
+	code := codePattern.FindString(msg.Body)
+	if code == "" {
+		t.Fatalf("no code in email body %q", msg.Body)
+	}
+	return code
+}
+
+func TestEmailVerificationConfirm(t *testing.T) {
+	ctx := context.Background()
+	svc, users, _, sender := newVerificationFixture(t)
+
+	if err := svc.RequestCode(ctx, "mentee@example.com"); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	svc.Wait()
+	code := sentCode(t, sender, "mentee@example.com")
+
+	if _, err := svc.ConfirmCode(ctx, "mentee@example.com", code); err != nil {
+		t.Fatalf("ConfirmCode: %v", err)
+	}
+	user, _ := users.GetByEmail(ctx, "mentee@example.com")
+	if !user.IsVerified {
+		t.Fatal("user should be verified")
+	}
+
+	if _, err := svc.ConfirmCode(ctx, "mentee@example.com", code); !errors.Is(err, services.ErrVerificationCodeInvalid) {
+		t.Fatalf("code must be single-use, got %v", err)
+	}
+}
+
+func TestEmailVerificationUnknownEmailIsSilent(t *testing.T) {
+	svc, _, _, sender := newVerificationFixture(t)
+
+	if err := svc.RequestCode(context.Background(), "nobody@example.com"); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	svc.Wait()
+	if len(sender.Messages()) != 0 {
+		t.Fatal("no email should be sent to unknown addresses")
+	}
+}
+
+func TestEmailVerificationResendThrottle(t *testing.T) {
+	ctx := context.Background()
+	svc, _, codes, sender := newVerificationFixture(t)
+
+	if err := svc.RequestCode(ctx, "mentee@example.com"); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	svc.Wait()
+	first := sentCode(t, sender, "mentee@example.com")
+
+	// Throttled requests look like any other, but send nothing
+	if err := svc.RequestCode(ctx, "mentee@example.com"); err != nil {
+		t.Fatalf("throttled RequestCode: %v", err)
+	}
+	svc.Wait()
+	if n := len(sender.Messages()); n != 1 {
+		t.Fatalf("expected the throttled request to send nothing, got %d emails", n)
+	}
+
+	codes.backdate(constants.VerificationResendInterval + time.Second)
+	if err := svc.RequestCode(ctx, "mentee@example.com"); err != nil {
+		t.Fatalf("RequestCode after interval: %v", err)
+	}
+	svc.Wait()
+	second := sentCode(t, sender, "mentee@example.com")
+	if first != second {
+		if _, err := svc.ConfirmCode(ctx, "mentee@example.com", first); err == nil {
+			t.Fatal("superseded code must not verify")
+		}
+	}
+}
+
+func TestEmailVerificationLimitsCodesPerWindow(t *testing.T) {
+	ctx := context.Background()
+	svc, _, codes, sender := newVerificationFixture(t)
+
+	for i := 0; i < constants.VerificationMaxRequests+2; i++ {
+		if err := svc.RequestCode(ctx, "mentee@example.com"); err != nil {
+			t.Fatalf("RequestCode: %v", err)
+		}
+		svc.Wait()
+		codes.backdate(constants.VerificationResendInterval + time.Second)
+	}
+	if n := len(sender.Messages()); n != constants.VerificationMaxRequests {
+		t.Fatalf("expected at most %d codes per window, got %d", constants.VerificationMaxRequests, n)
+	}
+}
+
+func TestEmailVerificationAttemptLimit(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, sender := newVerificationFixture(t)
+
+	if err := svc.RequestCode(ctx, "mentee@example.com"); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	svc.Wait()
+	code := sentCode(t, sender, "mentee@example.com")
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	for i := 0; i < constants.VerificationMaxAttempts; i++ {
+		if _, err := svc.ConfirmCode(ctx, "mentee@example.com", wrong); !errors.Is(err, services.ErrVerificationCodeInvalid) {
+			t.Fatalf("attempt %d: expected invalid code, got %v", i+1, err)
+		}
+	}
+	if _, err := svc.ConfirmCode(ctx, "mentee@example.com", code); !errors.Is(err, services.ErrVerificationTooMany) {
+		t.Fatalf("expected attempt limit, got %v", err)
+	}
+}
+
+func TestEmailVerificationConcurrentGuessesStayWithinLimit(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _, sender := newVerificationFixture(t)
+
+	if err := svc.RequestCode(ctx, "mentee@example.com"); err != nil {
+		t.Fatalf("RequestCode: %v", err)
+	}
+	svc.Wait()
+	wrong := "000000"
+	if sentCode(t, sender, "mentee@example.com") == wrong {
+		wrong = "111111"
+	}
+
+	var wg sync.WaitGroup
+	var compared atomic.Int32
+	for i := 0; i < 4*constants.VerificationMaxAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.ConfirmCode(ctx, "mentee@example.com", wrong); errors.Is(err, services.ErrVerificationCodeInvalid) {
+				compared.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := int(compared.Load()); n != constants.VerificationMaxAttempts {
+		t.Fatalf("expected %d guesses to be compared, got %d", constants.VerificationMaxAttempts, n)
+	}
+}
+
+func TestEmailVerificationRoutes(t *testing.T) {
+	ctx := context.Background()
+	f := newServerFixture(t)
+	if err := f.users.Create(ctx, &models.User{ID: uuid.New(), Email: "mentee@example.com", Role: constants.RoleMentee}); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	request := models.VerificationRequest{Email: "mentee@example.com"}
+	if code, failure := f.do(t, "", http.MethodPost, "/auth/verify-email/request", request, nil); code != http.StatusAccepted {
+		t.Fatalf("request: expected 202, got %d %+v", code, failure)
+	}
+	f.server.Wait()
+	code := sentCode(t, f.email, "mentee@example.com")
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	confirm := models.VerificationConfirmRequest{Email: "mentee@example.com", Code: wrong}
+	if status, _ := f.do(t, "", http.MethodPost, "/auth/verify-email/confirm", confirm, nil); status != http.StatusBadRequest {
+		t.Fatalf("wrong code: expected 400, got %d", status)
+	}
+	confirm.Code = code
+	var user models.UserResponse
+	if status, failure := f.do(t, "", http.MethodPost, "/auth/verify-email/confirm", confirm, &user); status != http.StatusOK {
+		t.Fatalf("confirm: expected 200, got %d %+v", status, failure)
+	}
+	if stored, _ := f.users.GetByEmail(ctx, "mentee@example.com"); !stored.IsVerified {
+		t.Fatal("expected the user to be verified")
+	}
+}
@@ -0,0 +1,1539 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"html"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/repository"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// memoryUserRepo is an in-memory UserRepository for tests
+type memoryUserRepo struct {
+	mu    sync.Mutex
+	users map[uuid.UUID]*models.User
+}
+
+func newMemoryUserRepo() *memoryUserRepo {
+	return &memoryUserRepo{users: make(map[uuid.UUID]*models.User)}
+}
+
+func (r *memoryUserRepo) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r *memoryUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[id]; ok {
+		copied := *u
+		return &copied, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryUserRepo) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []*models.User
+	for _, id := range ids {
+		if u, ok := r.users[id]; ok {
+			copied := *u
+			users = append(users, &copied)
+		}
+	}
+	return users, nil
+}
+
+func (r *memoryUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Email == email {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryUserRepo) GetByProvider(ctx context.Context, provider, providerID string) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.Provider == provider && u.ProviderID != nil && *u.ProviderID == providerID {
+			copied := *u
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryUserRepo) Update(ctx context.Context, user *models.User) error {
+	return r.Create(ctx, user)
+}
+
+func (r *memoryUserRepo) AdvanceMFAStep(ctx context.Context, id uuid.UUID, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[id]
+	if !ok || u.MFALastStep >= step {
+		return false, nil
+	}
+	u.MFALastStep = step
+	return true, nil
+}
+
+func (r *memoryUserRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, id)
+	return nil
+}
+
+// memoryProfileRepo is an in-memory ProfileRepository for tests
+type memoryProfileRepo struct {
+	mu       sync.Mutex
+	profiles map[uuid.UUID]*models.Profile
+	users    *memoryUserRepo // Owner roles for opposite-role visibility; optional
+}
+
+func newMemoryProfileRepo() *memoryProfileRepo {
+	return &memoryProfileRepo{profiles: make(map[uuid.UUID]*models.Profile)}
+}
+
+func (r *memoryProfileRepo) Create(ctx context.Context, profile *models.Profile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *profile
+	r.profiles[profile.ID] = &copied
+	return nil
+}
+
+func (r *memoryProfileRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.profiles[id]; ok {
+		copied := *p
+		return &copied, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryProfileRepo) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.profiles {
+		if p.UserID == userID {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryProfileRepo) Update(ctx context.Context, profile *models.Profile) error {
+	return r.Create(ctx, profile)
+}
+
+func (r *memoryProfileRepo) SetAvatar(ctx context.Context, id uuid.UUID, previousKey, avatarURL, avatarKey string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.profiles[id]
+	if !ok || p.AvatarKey != previousKey {
+		return false, nil
+	}
+	p.AvatarURL = avatarURL
+	p.AvatarKey = avatarKey
+	p.UpdatedAt = time.Now()
+	return true, nil
+}
+
+func (r *memoryProfileRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.profiles, id)
+	return nil
+}
+
+func (r *memoryProfileRepo) Search(ctx context.Context, filters *models.ProfileFilters, limit int, after *pagination.Cursor) ([]*models.ProfileSearchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := r.search(ctx, filters)
+	sort.Slice(result, func(i, j int) bool { return before(result[i], result[j]) })
+	if after != nil {
+		if (after.Rank != nil) != (filters.Query != "") {
+			return nil, pagination.ErrInvalidCursor
+		}
+		position := &models.ProfileSearchResult{Profile: &models.Profile{ID: after.ID, CreatedAt: after.CreatedAt}}
+		if after.Rank != nil {
+			position.Rank = *after.Rank
+		}
+		start := sort.Search(len(result), func(i int) bool { return before(position, result[i]) })
+		result = result[start:]
+	}
+	return result[:min(limit, len(result))], nil
+}
+
+func (r *memoryProfileRepo) Count(ctx context.Context, filters *models.ProfileFilters) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return int64(len(r.search(ctx, filters))), nil
+}
+
+// before orders search results as the GORM repository does: by rank, then
+// oldest first, then by ID
+func before(a, b *models.ProfileSearchResult) bool {
+	if a.Rank != b.Rank {
+		return a.Rank > b.Rank
+	}
+	if !a.Profile.CreatedAt.Equal(b.Profile.CreatedAt) {
+		return a.Profile.CreatedAt.Before(b.Profile.CreatedAt)
+	}
+	return bytes.Compare(a.Profile.ID[:], b.Profile.ID[:]) < 0
+}
+
+func (r *memoryProfileRepo) search(ctx context.Context, filters *models.ProfileFilters) []*models.ProfileSearchResult {
+	scope := filters.Scope
+	if scope == nil {
+		scope = &models.ProfileSearchScope{}
+	}
+	var result []*models.ProfileSearchResult
+	for _, p := range r.profiles {
+		if !p.IsActive || p.HiddenFromSearch || !r.inScope(ctx, p, scope) {
+			continue
+		}
+		if filters.Municipalities != nil && (!slices.Contains(*filters.Municipalities, p.MunicipalityCode) || (!scope.All && hidesField(p, "location"))) {
+			continue
+		}
+		copied := *p
+		hit := &models.ProfileSearchResult{Profile: &copied}
+		if filters.Query != "" && !matchQuery(hit, filters.Query) {
+			continue
+		}
+		result = append(result, hit)
+	}
+	return result
+}
+
+// matchQuery stands in for full-text search: every word of the query found
+// in a field the owner has not hidden adds to the rank, and the first match
+// in the bio is highlighted
+func matchQuery(hit *models.ProfileSearchResult, query string) bool {
+	p := hit.Profile
+	var hidden []string
+	_ = json.Unmarshal(p.HiddenFields, &hidden)
+	fields := map[string]string{"first_name": p.FirstName, "bio": p.Bio, "expertise": string(p.Expertise), "interests": string(p.Interests)}
+	if p.ShowLastName {
+		fields["last_name"] = p.LastName
+	}
+	for _, field := range hidden {
+		delete(fields, field)
+	}
+
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		for field, value := range fields {
+			i := strings.Index(strings.ToLower(value), word)
+			if i < 0 {
+				continue
+			}
+			hit.Rank++
+			if field == "bio" && hit.Highlight == "" {
+				hit.Highlight = html.EscapeString(value[:i]) + "<mark>" + html.EscapeString(value[i:i+len(word)]) + "</mark>" + html.EscapeString(value[i+len(word):])
+			}
+		}
+	}
+	return hit.Rank > 0
+}
+
+// hidesField reports whether the owner has hidden the field from other users
+func hidesField(p *models.Profile, field string) bool {
+	var hidden []string
+	_ = json.Unmarshal(p.HiddenFields, &hidden)
+	return slices.Contains(hidden, field)
+}
+
+func (r *memoryProfileRepo) inScope(ctx context.Context, p *models.Profile, scope *models.ProfileSearchScope) bool {
+	switch {
+	case scope.All, p.Visibility == "", p.Visibility == constants.ProfileVisibilityEveryone:
+		return true
+	case p.Visibility == constants.ProfileVisibilitySignedIn:
+		return scope.SignedIn
+	case p.Visibility == constants.ProfileVisibilityOppositeRole && scope.OppositeRole != "" && r.users != nil:
+		owner, err := r.users.GetByID(ctx, p.UserID)
+		return err == nil && owner.Role == scope.OppositeRole
+	}
+	return false
+}
+
+// memoryTaxonomyRepo is an in-memory TaxonomyRepository for tests
+type memoryTaxonomyRepo struct {
+	mu    sync.Mutex
+	terms []*models.TaxonomyTerm
+}
+
+func newMemoryTaxonomyRepo(terms ...*models.TaxonomyTerm) *memoryTaxonomyRepo {
+	return &memoryTaxonomyRepo{terms: terms}
+}
+
+func (r *memoryTaxonomyRepo) List(ctx context.Context) ([]*models.TaxonomyTerm, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	terms := make([]*models.TaxonomyTerm, 0, len(r.terms))
+	for _, t := range r.terms {
+		copied := *t
+		terms = append(terms, &copied)
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		if terms[i].Kind != terms[j].Kind {
+			return terms[i].Kind < terms[j].Kind
+		}
+		return terms[i].SortOrder < terms[j].SortOrder
+	})
+	return terms, nil
+}
+
+// memoryMentorshipRepo is an in-memory MentorshipRepository for tests
+type memoryMentorshipRepo struct {
+	mu          sync.Mutex
+	mentorships []*models.Mentorship
+}
+
+func newMemoryMentorshipRepo() *memoryMentorshipRepo {
+	return &memoryMentorshipRepo{}
+}
+
+func (r *memoryMentorshipRepo) ActiveBetween(ctx context.Context, userA, userB uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.mentorships {
+		if m.Status == constants.MentorshipActive &&
+			((m.MentorID == userA && m.MenteeID == userB) || (m.MentorID == userB && m.MenteeID == userA)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryMentorshipRepo) ActivePartners(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var partners []uuid.UUID
+	for _, m := range r.mentorships {
+		switch {
+		case m.Status != constants.MentorshipActive:
+		case m.MentorID == userID:
+			partners = append(partners, m.MenteeID)
+		case m.MenteeID == userID:
+			partners = append(partners, m.MentorID)
+		}
+	}
+	return partners, nil
+}
+
+func (r *memoryMentorshipRepo) ActiveMenteeCounts(ctx context.Context, mentorIDs []uuid.UUID) (map[uuid.UUID]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[uuid.UUID]int, len(mentorIDs))
+	for _, id := range mentorIDs {
+		for _, m := range r.mentorships {
+			if m.MentorID == id && m.Status == constants.MentorshipActive {
+				counts[id]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (r *memoryMentorshipRepo) ActiveMentors(ctx context.Context, menteeIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mentors := make(map[uuid.UUID][]uuid.UUID, len(menteeIDs))
+	for _, id := range menteeIDs {
+		for _, m := range r.mentorships {
+			if m.MenteeID == id && m.Status == constants.MentorshipActive {
+				mentors[id] = append(mentors[id], m.MentorID)
+			}
+		}
+	}
+	return mentors, nil
+}
+
+// pair records an active mentorship
+func (r *memoryMentorshipRepo) pair(mentorID, menteeID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mentorships = append(r.mentorships, &models.Mentorship{
+		ID:        uuid.New(),
+		MentorID:  mentorID,
+		MenteeID:  menteeID,
+		Status:    constants.MentorshipActive,
+		CreatedAt: time.Now(),
+	})
+}
+
+// memoryRefreshTokenRepo is an in-memory RefreshTokenRepository for tests
+type memoryRefreshTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]*models.RefreshToken
+}
+
+func newMemoryRefreshTokenRepo() *memoryRefreshTokenRepo {
+	return &memoryRefreshTokenRepo{tokens: make(map[uuid.UUID]*models.RefreshToken)}
+}
+
+func (r *memoryRefreshTokenRepo) Create(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *token
+	r.tokens[token.ID] = &copied
+	return nil
+}
+
+func (r *memoryRefreshTokenRepo) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryRefreshTokenRepo) MarkReplaced(ctx context.Context, id, replacedByID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok || t.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.RevokedAt = &now
+	t.ReplacedByID = &replacedByID
+	return true, nil
+}
+
+func (r *memoryRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	r.revokeWhere(func(t *models.RefreshToken) bool { return t.FamilyID == familyID })
+	return nil
+}
+
+func (r *memoryRefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	r.revokeWhere(func(t *models.RefreshToken) bool { return t.UserID == userID })
+	return nil
+}
+
+func (r *memoryRefreshTokenRepo) revokeWhere(match func(*models.RefreshToken) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, t := range r.tokens {
+		if match(t) && t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+	}
+}
+
+// memoryEmailVerificationRepo is an in-memory EmailVerificationRepository for tests
+type memoryEmailVerificationRepo struct {
+	mu            sync.Mutex
+	verifications []*models.EmailVerification
+}
+
+func newMemoryEmailVerificationRepo() *memoryEmailVerificationRepo {
+	return &memoryEmailVerificationRepo{}
+}
+
+func (r *memoryEmailVerificationRepo) Create(ctx context.Context, verification *models.EmailVerification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *verification
+	r.verifications = append(r.verifications, &copied)
+	return nil
+}
+
+func (r *memoryEmailVerificationRepo) GetLatest(ctx context.Context, email string) (*models.EmailVerification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.verifications) - 1; i >= 0; i-- {
+		if r.verifications[i].Email == email {
+			copied := *r.verifications[i]
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryEmailVerificationRepo) CountSince(ctx context.Context, email string, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, v := range r.verifications {
+		if v.Email == email && v.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryEmailVerificationRepo) ConsumeAttempt(ctx context.Context, id uuid.UUID, max int) (bool, error) {
+	consumed := false
+	r.update(id, func(v *models.EmailVerification) {
+		if v.Attempts < max {
+			v.Attempts++
+			consumed = true
+		}
+	})
+	return consumed, nil
+}
+
+func (r *memoryEmailVerificationRepo) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	r.update(id, func(v *models.EmailVerification) { v.IsUsed = true })
+	return nil
+}
+
+func (r *memoryEmailVerificationRepo) InvalidateAll(ctx context.Context, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.verifications {
+		if v.Email == email {
+			v.IsUsed = true
+		}
+	}
+	return nil
+}
+
+func (r *memoryEmailVerificationRepo) update(id uuid.UUID, fn func(*models.EmailVerification)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.verifications {
+		if v.ID == id {
+			fn(v)
+		}
+	}
+}
+
+// backdate shifts all stored codes into the past, e.g. to get past resend throttling
+func (r *memoryEmailVerificationRepo) backdate(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range r.verifications {
+		v.CreatedAt = v.CreatedAt.Add(-d)
+	}
+}
+
+// memoryPasswordResetRepo is an in-memory PasswordResetRepository for tests
+type memoryPasswordResetRepo struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]*models.PasswordResetToken
+}
+
+func newMemoryPasswordResetRepo() *memoryPasswordResetRepo {
+	return &memoryPasswordResetRepo{tokens: make(map[uuid.UUID]*models.PasswordResetToken)}
+}
+
+func (r *memoryPasswordResetRepo) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *token
+	r.tokens[token.ID] = &copied
+	return nil
+}
+
+func (r *memoryPasswordResetRepo) GetByHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryPasswordResetRepo) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tokens[id]
+	if !ok || t.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	t.UsedAt = &now
+	return true, nil
+}
+
+func (r *memoryPasswordResetRepo) InvalidateAllForUser(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memoryPasswordResetRepo) CountSince(ctx context.Context, userID uuid.UUID, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// backdate moves every token's creation time into the past
+func (r *memoryPasswordResetRepo) backdate(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tokens {
+		t.CreatedAt = t.CreatedAt.Add(-d)
+	}
+}
+
+// memoryUserIdentityRepo is an in-memory UserIdentityRepository for tests
+type memoryUserIdentityRepo struct {
+	mu         sync.Mutex
+	identities []*models.UserIdentity
+}
+
+func newMemoryUserIdentityRepo() *memoryUserIdentityRepo {
+	return &memoryUserIdentityRepo{}
+}
+
+func (r *memoryUserIdentityRepo) Create(ctx context.Context, identity *models.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *identity
+	r.identities = append(r.identities, &copied)
+	return nil
+}
+
+func (r *memoryUserIdentityRepo) GetByProvider(ctx context.Context, provider, providerID string) (*models.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, i := range r.identities {
+		if i.Provider == provider && i.ProviderID == providerID {
+			copied := *i
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryUserIdentityRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]*models.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.UserIdentity
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			copied := *i
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryUserIdentityRepo) Delete(ctx context.Context, userID uuid.UUID, provider string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.identities[:0]
+	for _, i := range r.identities {
+		if i.UserID != userID || i.Provider != provider {
+			kept = append(kept, i)
+		}
+	}
+	r.identities = kept
+	return nil
+}
+
+// memoryMFARecoveryCodeRepo is an in-memory MFARecoveryCodeRepository for tests
+type memoryMFARecoveryCodeRepo struct {
+	mu    sync.Mutex
+	codes []*models.MFARecoveryCode
+}
+
+func newMemoryMFARecoveryCodeRepo() *memoryMFARecoveryCodeRepo {
+	return &memoryMFARecoveryCodeRepo{}
+}
+
+func (r *memoryMFARecoveryCodeRepo) ReplaceAll(ctx context.Context, userID uuid.UUID, codes []*models.MFARecoveryCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.codes[:0]
+	for _, c := range r.codes {
+		if c.UserID != userID {
+			kept = append(kept, c)
+		}
+	}
+	for _, c := range codes {
+		copied := *c
+		kept = append(kept, &copied)
+	}
+	r.codes = kept
+	return nil
+}
+
+func (r *memoryMFARecoveryCodeRepo) Consume(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.codes {
+		if c.UserID == userID && c.CodeHash == codeHash && c.UsedAt == nil {
+			now := time.Now()
+			c.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryMFARecoveryCodeRepo) DeleteAll(ctx context.Context, userID uuid.UUID) error {
+	return r.ReplaceAll(ctx, userID, nil)
+}
+
+// memoryAuthSessionRepo is an in-memory AuthSessionRepository for tests
+type memoryAuthSessionRepo struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*models.AuthSession
+}
+
+func newMemoryAuthSessionRepo() *memoryAuthSessionRepo {
+	return &memoryAuthSessionRepo{sessions: make(map[uuid.UUID]*models.AuthSession)}
+}
+
+func (r *memoryAuthSessionRepo) Create(ctx context.Context, session *models.AuthSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *memoryAuthSessionRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[id]; ok {
+		copied := *s
+		return &copied, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryAuthSessionRepo) ListActiveByUser(ctx context.Context, userID uuid.UUID, since time.Time) ([]*models.AuthSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.AuthSession
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil && s.LastUsedAt.After(since) {
+			copied := *s
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LastUsedAt.After(result[j].LastUsedAt) })
+	return result, nil
+}
+
+func (r *memoryAuthSessionRepo) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[id]; ok {
+		s.LastUsedAt = at
+	}
+	return nil
+}
+
+func (r *memoryAuthSessionRepo) Revoke(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[id]; ok && s.RevokedAt == nil {
+		now := time.Now()
+		s.RevokedAt = &now
+	}
+	return nil
+}
+
+func (r *memoryAuthSessionRepo) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, s := range r.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			s.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// memoryAPIKeyRepo is an in-memory APIKeyRepository for tests
+type memoryAPIKeyRepo struct {
+	mu   sync.Mutex
+	keys map[uuid.UUID]*models.APIKey
+}
+
+func newMemoryAPIKeyRepo() *memoryAPIKeyRepo {
+	return &memoryAPIKeyRepo{keys: make(map[uuid.UUID]*models.APIKey)}
+}
+
+func (r *memoryAPIKeyRepo) Create(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *key
+	r.keys[key.ID] = &copied
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			copied := *k
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryAPIKeyRepo) ListActiveByUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.APIKey
+	for _, k := range r.keys {
+		if k.UserID == userID && k.RevokedAt == nil && k.ExpiresAt.After(now) {
+			copied := *k
+			result = append(result, &copied)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	return result, nil
+}
+
+func (r *memoryAPIKeyRepo) Touch(ctx context.Context, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if k, ok := r.keys[id]; ok {
+		k.LastUsedAt = &at
+	}
+	return nil
+}
+
+func (r *memoryAPIKeyRepo) Revoke(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok || k.UserID != userID || k.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	return true, nil
+}
+
+// expire moves a key's expiry into the past
+func (r *memoryAPIKeyRepo) expire(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if k, ok := r.keys[id]; ok {
+		k.ExpiresAt = time.Now().Add(-time.Second)
+	}
+}
+
+// memoryMentorApplicationRepo is an in-memory MentorApplicationRepository for tests
+type memoryMentorApplicationRepo struct {
+	mu           sync.Mutex
+	applications []*models.MentorApplication
+	users        *memoryUserRepo // Applicants promoted on approval
+}
+
+func newMemoryMentorApplicationRepo(users *memoryUserRepo) *memoryMentorApplicationRepo {
+	return &memoryMentorApplicationRepo{users: users}
+}
+
+func (r *memoryMentorApplicationRepo) Create(ctx context.Context, application *models.MentorApplication) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.applications {
+		if a.UserID == application.UserID && a.Status == constants.MentorApplicationPending {
+			return errors.New("duplicate pending application")
+		}
+	}
+	copied := *application
+	r.applications = append(r.applications, &copied)
+	return nil
+}
+
+func (r *memoryMentorApplicationRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.MentorApplication, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.applications {
+		if a.ID == id {
+			copied := *a
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryMentorApplicationRepo) GetLatestByUser(ctx context.Context, userID uuid.UUID) (*models.MentorApplication, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.applications) - 1; i >= 0; i-- {
+		if r.applications[i].UserID == userID {
+			copied := *r.applications[i]
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryMentorApplicationRepo) List(ctx context.Context, status string, limit int, after *pagination.Cursor) ([]*models.MentorApplication, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.MentorApplication
+	for _, a := range r.applications {
+		if len(result) == limit {
+			break
+		}
+		if (status != "" && a.Status != status) || !afterCursor(a.CreatedAt, a.ID, after, false) {
+			continue
+		}
+		copied := *a
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (r *memoryMentorApplicationRepo) Decide(ctx context.Context, id uuid.UUID, status, reason string, reviewerID uuid.UUID, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range r.applications {
+		if a.ID == id && a.Status == constants.MentorApplicationPending {
+			a.Status = status
+			a.Reason = reason
+			a.ReviewedBy = &reviewerID
+			a.ReviewedAt = &at
+			if status == constants.MentorApplicationApproved {
+				r.users.mu.Lock()
+				if u, ok := r.users.users[a.UserID]; ok && u.Role == constants.RoleMentee {
+					u.Role = constants.RoleMentor
+					u.UpdatedAt = at
+				}
+				r.users.mu.Unlock()
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// memoryLoginThrottleRepo is an in-memory LoginThrottleRepository for tests
+type memoryLoginThrottleRepo struct {
+	mu        sync.Mutex
+	throttles map[string]*models.LoginThrottle
+}
+
+func newMemoryLoginThrottleRepo() *memoryLoginThrottleRepo {
+	return &memoryLoginThrottleRepo{throttles: make(map[string]*models.LoginThrottle)}
+}
+
+func (r *memoryLoginThrottleRepo) Get(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.throttles[key]; ok {
+		copied := *t
+		return &copied, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryLoginThrottleRepo) RecordFailure(ctx context.Context, key string, at, windowStart time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.throttles[key]
+	if !ok {
+		t = &models.LoginThrottle{Key: key}
+		r.throttles[key] = t
+	}
+	if t.LastFailureAt.Before(windowStart) {
+		t.Failures = 0
+	}
+	t.Failures++
+	t.LastFailureAt = at
+	return t.Failures, nil
+}
+
+func (r *memoryLoginThrottleRepo) SetLockedUntil(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.throttles[key]; ok {
+		t.LockedUntil = &until
+	}
+	return nil
+}
+
+func (r *memoryLoginThrottleRepo) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.throttles, key)
+	return nil
+}
+
+// memoryLockoutEventRepo is an in-memory LockoutEventRepository for tests
+type memoryLockoutEventRepo struct {
+	mu     sync.Mutex
+	events []*models.LockoutEvent
+}
+
+func newMemoryLockoutEventRepo() *memoryLockoutEventRepo {
+	return &memoryLockoutEventRepo{}
+}
+
+func (r *memoryLockoutEventRepo) Create(ctx context.Context, event *models.LockoutEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *event
+	r.events = append(r.events, &copied)
+	return nil
+}
+
+func (r *memoryLockoutEventRepo) ListRecent(ctx context.Context, limit int, after *pagination.Cursor) ([]*models.LockoutEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.LockoutEvent
+	for i := len(r.events) - 1; i >= 0 && len(result) < limit; i-- {
+		if !afterCursor(r.events[i].CreatedAt, r.events[i].ID, after, true) {
+			continue
+		}
+		copied := *r.events[i]
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+// memoryMFAChallengeRepo is an in-memory MFAChallengeRepository for tests
+type memoryMFAChallengeRepo struct {
+	mu         sync.Mutex
+	challenges map[uuid.UUID]*models.MFAChallenge
+}
+
+func newMemoryMFAChallengeRepo() *memoryMFAChallengeRepo {
+	return &memoryMFAChallengeRepo{challenges: make(map[uuid.UUID]*models.MFAChallenge)}
+}
+
+func (r *memoryMFAChallengeRepo) Create(ctx context.Context, challenge *models.MFAChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *challenge
+	r.challenges[challenge.ID] = &copied
+	return nil
+}
+
+func (r *memoryMFAChallengeRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.MFAChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.challenges[id]; ok {
+		copied := *c
+		return &copied, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryMFAChallengeRepo) ConsumeAttempt(ctx context.Context, id uuid.UUID, max int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.challenges[id]
+	if !ok || c.Attempts >= max {
+		return false, nil
+	}
+	c.Attempts++
+	return true, nil
+}
+
+func (r *memoryMFAChallengeRepo) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.challenges[id]
+	if !ok || c.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	c.UsedAt = &now
+	return true, nil
+}
+
+// memoryOAuthNonceRepo is an in-memory OAuthNonceRepository for tests
+type memoryOAuthNonceRepo struct {
+	mu     sync.Mutex
+	nonces map[string]*models.OAuthNonce
+}
+
+func newMemoryOAuthNonceRepo() *memoryOAuthNonceRepo {
+	return &memoryOAuthNonceRepo{nonces: make(map[string]*models.OAuthNonce)}
+}
+
+func (r *memoryOAuthNonceRepo) Create(ctx context.Context, nonce *models.OAuthNonce) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *nonce
+	r.nonces[nonce.NonceHash] = &copied
+	return nil
+}
+
+func (r *memoryOAuthNonceRepo) Consume(ctx context.Context, nonceHash string, sessionID uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, ok := r.nonces[nonceHash]
+	if !ok || !time.Now().Before(n.ExpiresAt) {
+		return false, nil
+	}
+	if (n.SessionID == nil) != (sessionID == uuid.Nil) || (n.SessionID != nil && *n.SessionID != sessionID) {
+		return false, nil
+	}
+	delete(r.nonces, nonceHash)
+	return true, nil
+}
+
+// memoryMagicLinkRepo is an in-memory MagicLinkRepository for tests
+type memoryMagicLinkRepo struct {
+	mu    sync.Mutex
+	links map[uuid.UUID]*models.MagicLinkToken
+}
+
+func newMemoryMagicLinkRepo() *memoryMagicLinkRepo {
+	return &memoryMagicLinkRepo{links: make(map[uuid.UUID]*models.MagicLinkToken)}
+}
+
+func (r *memoryMagicLinkRepo) Create(ctx context.Context, token *models.MagicLinkToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *token
+	r.links[token.ID] = &copied
+	return nil
+}
+
+func (r *memoryMagicLinkRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.MagicLinkToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if l, ok := r.links[id]; ok {
+		copied := *l
+		return &copied, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryMagicLinkRepo) GetLatest(ctx context.Context, email string) (*models.MagicLinkToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var latest *models.MagicLinkToken
+	for _, l := range r.links {
+		if l.Email == email && (latest == nil || l.CreatedAt.After(latest.CreatedAt)) {
+			latest = l
+		}
+	}
+	if latest == nil {
+		return nil, repository.ErrNotFound
+	}
+	copied := *latest
+	return &copied, nil
+}
+
+func (r *memoryMagicLinkRepo) CountSince(ctx context.Context, email string, since time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, l := range r.links {
+		if l.Email == email && l.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryMagicLinkRepo) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.links[id]
+	if !ok || l.UsedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	l.UsedAt = &now
+	return true, nil
+}
+
+func (r *memoryMagicLinkRepo) InvalidateAllForUser(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, l := range r.links {
+		if l.UserID == userID && l.UsedAt == nil {
+			l.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+// backdate moves every link's creation time into the past
+func (r *memoryMagicLinkRepo) backdate(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.links {
+		l.CreatedAt = l.CreatedAt.Add(-d)
+	}
+}
+
+// memoryImpersonationLogRepo is an in-memory ImpersonationLogRepository for tests
+type memoryImpersonationLogRepo struct {
+	mu      sync.Mutex
+	entries []*models.ImpersonationLog
+}
+
+func newMemoryImpersonationLogRepo() *memoryImpersonationLogRepo {
+	return &memoryImpersonationLogRepo{}
+}
+
+func (r *memoryImpersonationLogRepo) Create(ctx context.Context, entry *models.ImpersonationLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *entry
+	r.entries = append(r.entries, &copied)
+	return nil
+}
+
+func (r *memoryImpersonationLogRepo) List(ctx context.Context, subjectID *uuid.UUID, limit int, after *pagination.Cursor) ([]*models.ImpersonationLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*models.ImpersonationLog
+	for i := len(r.entries) - 1; i >= 0 && len(result) < limit; i-- {
+		if (subjectID != nil && r.entries[i].SubjectID != *subjectID) || !afterCursor(r.entries[i].CreatedAt, r.entries[i].ID, after, true) {
+			continue
+		}
+		copied := *r.entries[i]
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+// memoryRoleRepo is an in-memory RoleRepository for tests, seeded with the
+// roles from migration 018
+type memoryRoleRepo struct {
+	mu    sync.Mutex
+	roles map[string][]string
+	err   error
+}
+
+func newMemoryRoleRepo() *memoryRoleRepo {
+	return &memoryRoleRepo{roles: map[string][]string{
+		constants.RoleAdmin:  append([]string(nil), constants.Permissions...),
+		constants.RoleMentor: {constants.PermissionMFAEnroll},
+		constants.RoleMentee: {},
+	}}
+}
+
+func (r *memoryRoleRepo) List(ctx context.Context) ([]*models.Role, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	var result []*models.Role
+	for name, permissions := range r.roles {
+		role := &models.Role{Name: name}
+		for _, p := range permissions {
+			role.Permissions = append(role.Permissions, models.RolePermission{Role: name, Permission: p})
+		}
+		result = append(result, role)
+	}
+	return result, nil
+}
+
+// setRole defines or replaces a role, as an operator would in the database
+func (r *memoryRoleRepo) setRole(name string, permissions ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.roles[name] = permissions
+}
+
+// memoryCohortRepo is an in-memory CohortRepository for tests. Publishing
+// records the mentorships in the given mentorship repository.
+type memoryCohortRepo struct {
+	mu          sync.Mutex
+	cohorts     map[uuid.UUID]*models.Cohort
+	mentorships *memoryMentorshipRepo
+}
+
+func newMemoryCohortRepo(mentorships *memoryMentorshipRepo) *memoryCohortRepo {
+	return &memoryCohortRepo{cohorts: make(map[uuid.UUID]*models.Cohort), mentorships: mentorships}
+}
+
+func copyCohort(cohort *models.Cohort) *models.Cohort {
+	copied := *cohort
+	copied.Mentors = append([]models.CohortMentor(nil), cohort.Mentors...)
+	copied.Pairs = append([]models.CohortPair(nil), cohort.Pairs...)
+	return &copied
+}
+
+func (r *memoryCohortRepo) Create(ctx context.Context, cohort *models.Cohort) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cohorts[cohort.ID] = copyCohort(cohort)
+	return nil
+}
+
+func (r *memoryCohortRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Cohort, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cohort, ok := r.cohorts[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return copyCohort(cohort), nil
+}
+
+func (r *memoryCohortRepo) List(ctx context.Context, limit int, after *pagination.Cursor) ([]*models.Cohort, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var cohorts []*models.Cohort
+	for _, c := range r.cohorts {
+		if !afterCursor(c.CreatedAt, c.ID, after, true) {
+			continue
+		}
+		copied := *c
+		copied.Mentors, copied.Pairs = nil, nil
+		cohorts = append(cohorts, &copied)
+	}
+	sort.Slice(cohorts, func(i, j int) bool { return cohorts[i].CreatedAt.After(cohorts[j].CreatedAt) })
+	if len(cohorts) > limit {
+		cohorts = cohorts[:limit]
+	}
+	return cohorts, nil
+}
+
+func (r *memoryCohortRepo) UpdatePair(ctx context.Context, pair *models.CohortPair) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cohort, ok := r.cohorts[pair.CohortID]
+	if !ok || cohort.Status != constants.CohortDraft {
+		return false, nil
+	}
+	for i := range cohort.Pairs {
+		if cohort.Pairs[i].MenteeID == pair.MenteeID {
+			cohort.Pairs[i] = *pair
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryCohortRepo) Publish(ctx context.Context, id, publisherID uuid.UUID, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cohort, ok := r.cohorts[id]
+	if !ok || cohort.Status != constants.CohortDraft {
+		return false, nil
+	}
+	cohort.Status = constants.CohortPublished
+	cohort.PublishedBy = &publisherID
+	cohort.PublishedAt = &at
+	for _, pair := range cohort.Pairs {
+		if pair.MentorID != nil {
+			r.mentorships.pair(*pair.MentorID, pair.MenteeID)
+		}
+	}
+	return true, nil
+}
+
+// memoryAvailabilityRepo is an in-memory AvailabilityRepository for tests
+type memoryAvailabilityRepo struct {
+	mu        sync.Mutex
+	calendars map[uuid.UUID]models.MentorCalendar
+	rules     []models.AvailabilityRule
+	overrides []models.AvailabilityOverride
+	blackouts []models.AvailabilityBlackout
+}
+
+func newMemoryAvailabilityRepo() *memoryAvailabilityRepo {
+	return &memoryAvailabilityRepo{calendars: make(map[uuid.UUID]models.MentorCalendar)}
+}
+
+func (r *memoryAvailabilityRepo) GetCalendar(ctx context.Context, mentorID uuid.UUID) (*models.MentorCalendar, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calendar, ok := r.calendars[mentorID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &calendar, nil
+}
+
+func (r *memoryAvailabilityRepo) ReplaceWeekly(ctx context.Context, calendar *models.MentorCalendar, rules []*models.AvailabilityRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calendars[calendar.MentorID] = *calendar
+	r.rules = slices.DeleteFunc(r.rules, func(rule models.AvailabilityRule) bool { return rule.MentorID == calendar.MentorID })
+	for _, rule := range rules {
+		r.rules = append(r.rules, *rule)
+	}
+	return nil
+}
+
+func (r *memoryAvailabilityRepo) ListWeekly(ctx context.Context, mentorID uuid.UUID) ([]*models.AvailabilityRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rules []*models.AvailabilityRule
+	for _, rule := range r.rules {
+		if rule.MentorID == mentorID {
+			copied := rule
+			rules = append(rules, &copied)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Weekday != rules[j].Weekday {
+			return rules[i].Weekday < rules[j].Weekday
+		}
+		return rules[i].StartTime < rules[j].StartTime
+	})
+	return rules, nil
+}
+
+func (r *memoryAvailabilityRepo) CreateOverride(ctx context.Context, override *models.AvailabilityOverride) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.overrides = append(r.overrides, *override)
+	return nil
+}
+
+func (r *memoryAvailabilityRepo) ListOverrides(ctx context.Context, mentorID uuid.UUID, from, to string) ([]*models.AvailabilityOverride, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var overrides []*models.AvailabilityOverride
+	for _, override := range r.overrides {
+		if override.MentorID == mentorID && override.Date >= from && (to == "" || override.Date <= to) {
+			copied := override
+			overrides = append(overrides, &copied)
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Date != overrides[j].Date {
+			return overrides[i].Date < overrides[j].Date
+		}
+		return overrides[i].StartTime < overrides[j].StartTime
+	})
+	return overrides, nil
+}
+
+func (r *memoryAvailabilityRepo) DeleteOverride(ctx context.Context, mentorID, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before := len(r.overrides)
+	r.overrides = slices.DeleteFunc(r.overrides, func(o models.AvailabilityOverride) bool { return o.MentorID == mentorID && o.ID == id })
+	return len(r.overrides) < before, nil
+}
+
+func (r *memoryAvailabilityRepo) CreateBlackout(ctx context.Context, blackout *models.AvailabilityBlackout) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blackouts = append(r.blackouts, *blackout)
+	return nil
+}
+
+func (r *memoryAvailabilityRepo) ListBlackouts(ctx context.Context, mentorID uuid.UUID, from, to time.Time) ([]*models.AvailabilityBlackout, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var blackouts []*models.AvailabilityBlackout
+	for _, blackout := range r.blackouts {
+		if blackout.MentorID == mentorID && blackout.EndsAt.After(from) && (to.IsZero() || blackout.StartsAt.Before(to)) {
+			copied := blackout
+			blackouts = append(blackouts, &copied)
+		}
+	}
+	sort.Slice(blackouts, func(i, j int) bool { return blackouts[i].StartsAt.Before(blackouts[j].StartsAt) })
+	return blackouts, nil
+}
+
+func (r *memoryAvailabilityRepo) DeleteBlackout(ctx context.Context, mentorID, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	before := len(r.blackouts)
+	r.blackouts = slices.DeleteFunc(r.blackouts, func(b models.AvailabilityBlackout) bool { return b.MentorID == mentorID && b.ID == id })
+	return len(r.blackouts) < before, nil
+}
+
+// memoryMentoringSessionRepo is an in-memory MentoringSessionRepository for tests
+type memoryMentoringSessionRepo struct {
+	mu       sync.Mutex
+	sessions []models.MentoringSession
+}
+
+func newMemoryMentoringSessionRepo() *memoryMentoringSessionRepo {
+	return &memoryMentoringSessionRepo{}
+}
+
+func (r *memoryMentoringSessionRepo) ListBooked(ctx context.Context, mentorID uuid.UUID, from, to time.Time) ([]*models.MentoringSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []*models.MentoringSession
+	for _, session := range r.sessions {
+		if session.MentorID == mentorID && slices.Contains(constants.BookedSessionStatuses, session.Status) &&
+			session.ScheduledAt != nil && session.ScheduledAt.Before(to) && session.EndsAt().After(from) {
+			copied := session
+			sessions = append(sessions, &copied)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ScheduledAt.Before(*sessions[j].ScheduledAt) })
+	return sessions, nil
+}
+
+// book adds a session with the mentor
+func (r *memoryMentoringSessionRepo) book(mentorID uuid.UUID, scheduledAt time.Time, duration time.Duration, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = append(r.sessions, models.MentoringSession{
+		ID:          uuid.New(),
+		MentorID:    mentorID,
+		MenteeID:    uuid.New(),
+		ScheduledAt: &scheduledAt,
+		Duration:    int(duration / time.Minute),
+		Status:      status,
+	})
+}
+
+// afterCursor reports whether an item sorted by creation time and ID comes
+// after the cursor, as in the GORM repositories
+func afterCursor(createdAt time.Time, id uuid.UUID, after *pagination.Cursor, newestFirst bool) bool {
+	if after == nil {
+		return true
+	}
+	order := createdAt.Compare(after.CreatedAt)
+	if order == 0 {
+		order = bytes.Compare(id[:], after.ID[:])
+	}
+	if newestFirst {
+		return order < 0
+	}
+	return order > 0
+}
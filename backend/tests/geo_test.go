@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"mentori/internal/handlers"
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/geo"
+	"mentori/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestResolveLocation(t *testing.T) {
+	cases := []struct {
+		location, code, normalized string
+	}{
+		{"helsinki", "091", "Helsinki"},
+		{"Helsingfors", "091", "Helsinki"},
+		{"Kallio, helsinki", "091", "Kallio, Helsinki"},
+		{"Jyvaskyla", "179", "Jyväskylä"},
+		{" Åbo ", "853", "Turku"},
+		{"Helsinki, Finland", "091", "Helsinki, Finland"},
+	}
+	for _, tc := range cases {
+		m, normalized, ok := geo.ResolveLocation(tc.location)
+		if !ok || m.Code != tc.code || normalized != tc.normalized {
+			t.Fatalf("%q: got %s %q %v, expected %s %q", tc.location, m.Code, normalized, ok, tc.code, tc.normalized)
+		}
+	}
+	if _, _, ok := geo.ResolveLocation("Remote"); ok {
+		t.Fatal("Remote should not resolve")
+	}
+
+	helsinki, _ := geo.FindMunicipality("Helsinki")
+	espoo, _ := geo.FindMunicipality("Espoo")
+	if d := geo.DistanceKm(helsinki, espoo); d < 10 || d > 25 {
+		t.Fatalf("Helsinki to Espoo should be about 16 km, got %.1f", d)
+	}
+	if region, ok := geo.FindRegion("nyland"); !ok || region.Code != helsinki.RegionCode || espoo.Region().NameFI != "Uusimaa" {
+		t.Fatalf("Helsinki and Espoo should be in Uusimaa, got %+v", region)
+	}
+}
+
+func TestUpdateProfileNormalizesLocation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := newMemoryUserRepo()
+	profiles := newMemoryProfileRepo()
+	user := &models.User{ID: uuid.New(), Email: "location@example.com", Role: constants.RoleMentee}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	store, err := storage.NewLocalStore(t.TempDir(), "http://localhost/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	privacy := services.NewProfilePrivacyService(newTestAuthorizationService(), newMemoryMentorshipRepo())
+	h := handlers.NewProfileHandler(profiles, users, privacy, services.NewAvatarService(profiles, store), newTestTaxonomyService())
+
+	router := gin.New()
+	router.PUT("/profiles", func(c *gin.Context) {
+		c.Set("user", jwt.MapClaims{"user_id": user.ID.String()})
+	}, h.UpdateProfile)
+
+	update := func(location string) *models.Profile {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, "/profiles", bytes.NewBufferString(`{"location": "`+location+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d: %s", location, w.Code, w.Body.String())
+		}
+		profile, _ := profiles.GetByUserID(context.Background(), user.ID)
+		return profile
+	}
+
+	// The first update creates the profile, the second updates it
+	if profile := update("Tapiola, esbo"); profile.Location != "Tapiola, Espoo" || profile.MunicipalityCode != "049" {
+		t.Fatalf("expected Espoo, got %q %q", profile.Location, profile.MunicipalityCode)
+	}
+	if profile := update("Berlin"); profile.Location != "Berlin" || profile.MunicipalityCode != "" {
+		t.Fatalf("locations abroad should be kept without a code, got %q %q", profile.Location, profile.MunicipalityCode)
+	}
+}
+
+func TestGetPublicProfilesNear(t *testing.T) {
+	f := newProfileFixture(t)
+	place := func(name, location, hidden string) {
+		m, normalized, _ := geo.ResolveLocation(location)
+		f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: name, Location: normalized, MunicipalityCode: m.Code,
+			ShowExactLocation: true, HiddenFields: datatypes.JSON(hidden), IsActive: true})
+	}
+	place("Aino", "Helsinki", `[]`)
+	place("Eero", "Espoo", `[]`)
+	place("Juho", "Tampere", `[]`)
+	place("Kaisa", "Porvoo", `[]`)
+	place("Liisa", "Vantaa", `["location"]`)
+
+	names := func(query url.Values) map[string]*float64 {
+		t.Helper()
+		code, page, failure := f.page(t, query)
+		if code != http.StatusOK {
+			t.Fatalf("%v: expected 200, got %d %+v", query, code, failure)
+		}
+		found := make(map[string]*float64)
+		for _, view := range page.Items {
+			found[view.FirstName] = view.DistanceKm
+		}
+		return found
+	}
+
+	// Espoo is next door; Porvoo is about 50 km away and Tampere much further
+	found := names(url.Values{"near": {"helsingfors"}})
+	if len(found) != 2 || found["Aino"] == nil || *found["Aino"] != 0 || found["Eero"] == nil || *found["Eero"] < 10 || *found["Eero"] > 25 {
+		t.Fatalf("near Helsinki: expected Aino and Eero with distances, got %v", found)
+	}
+	if found := names(url.Values{"near": {"Helsinki"}, "radius_km": {"60"}}); len(found) != 3 || found["Kaisa"] == nil {
+		t.Fatalf("within 60 km of Helsinki: expected Porvoo too, got %v", found)
+	}
+
+	// Region filters carry no distance, and never find profiles hiding their location
+	found = names(url.Values{"region": {"Uusimaa"}})
+	if len(found) != 3 || found["Aino"] != nil {
+		t.Fatalf("Uusimaa: expected Aino, Eero and Kaisa without distances, got %v", found)
+	}
+	if found := names(url.Values{"near": {"Helsinki"}, "region": {"Pirkanmaa"}}); len(found) != 0 {
+		t.Fatalf("near Helsinki in Pirkanmaa: expected nothing, got %v", found)
+	}
+}
+
+func TestGetPublicProfilesRejectsInvalidGeoFilters(t *testing.T) {
+	f := newProfileFixture(t)
+	cases := []struct {
+		query url.Values
+		field string
+	}{
+		{url.Values{"near": {"Atlantis"}}, "near"},
+		{url.Values{"near": {"Helsinki"}, "radius_km": {"-1"}}, "radius_km"},
+		{url.Values{"near": {"Helsinki"}, "radius_km": {"far"}}, "radius_km"},
+		{url.Values{"radius_km": {"10"}}, "radius_km"},
+		{url.Values{"region": {"Narnia"}}, "region"},
+	}
+	for _, tc := range cases {
+		if code, _, failure := f.page(t, tc.query); code != http.StatusBadRequest || failure.Field != tc.field {
+			t.Fatalf("%v: expected 400 on %s, got %d %+v", tc.query, tc.field, code, failure)
+		}
+	}
+}
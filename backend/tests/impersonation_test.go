@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mentori/internal/middleware"
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type impersonationFixture struct {
+	svc      *services.ImpersonationService
+	logs     *memoryImpersonationLogRepo
+	sessions *services.SessionService
+	router   *gin.Engine
+	admin    *models.User
+	mentee   *models.User
+	session  *models.AuthSession
+}
+
+func newImpersonationFixture(t *testing.T) *impersonationFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	users := newMemoryUserRepo()
+	admin := &models.User{ID: uuid.New(), Email: "support@example.com", Role: constants.RoleAdmin}
+	mentee := &models.User{ID: uuid.New(), Email: "mentee@example.com", Role: constants.RoleMentee}
+	for _, u := range []*models.User{admin, mentee} {
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+
+	issuer := newTestTokenIssuer(t, services.TokenIssuerConfig{})
+	sessions := newTestSessionService()
+	_, session, err := sessions.Start(ctx, admin.ID, true, services.SessionInfo{})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	logs := newMemoryImpersonationLogRepo()
+	svc := services.NewImpersonationService(logs, users, issuer, newTestAuthorizationService())
+
+	r := gin.New()
+	r.Use(middleware.AuditImpersonation(svc))
+	authed := r.Group("/", middleware.JWTAuth(issuer, sessions, nil))
+	authed.GET("/dashboard", func(c *gin.Context) {
+		userID, _ := utils.GetUserIDFromContext(c)
+		actorID, _ := utils.GetActorIDFromContext(c)
+		c.JSON(http.StatusOK, gin.H{"user_id": userID, "actor_id": actorID})
+	})
+	authed.DELETE("/profile", middleware.RejectImpersonation(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	return &impersonationFixture{svc: svc, logs: logs, sessions: sessions, router: r, admin: admin, mentee: mentee, session: session}
+}
+
+func (f *impersonationFixture) request(method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set(constants.HeaderAuthorization, "Bearer "+token)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestImpersonationTokenActsAsUser(t *testing.T) {
+	ctx := context.Background()
+	f := newImpersonationFixture(t)
+
+	token, subject, err := f.svc.Start(ctx, f.admin.ID, f.session.ID, f.mentee.ID, "ticket 4711: dashboard empty", "203.0.113.7")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if subject.ID != f.mentee.ID {
+		t.Fatalf("expected subject %s, got %s", f.mentee.ID, subject.ID)
+	}
+
+	if code := f.request(http.MethodGet, "/dashboard", token); code != http.StatusOK {
+		t.Fatalf("expected 200 for read-only endpoint, got %d", code)
+	}
+	if code := f.request(http.MethodDelete, "/profile", token); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for destructive endpoint, got %d", code)
+	}
+
+	page, err := f.svc.List(ctx, &f.mentee.ID, pagination.Params{Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	entries := page.Items
+	if len(entries) != 3 {
+		t.Fatalf("expected issue entry and 2 request entries, got %d", len(entries))
+	}
+	refused, read, issued := entries[0], entries[1], entries[2]
+	if issued.Action != constants.ImpersonationActionIssued || issued.Reason == "" || issued.ActorID != f.admin.ID {
+		t.Fatalf("unexpected issue entry: %+v", issued)
+	}
+	if read.Action != constants.ImpersonationActionRequest || read.Path != "/dashboard" || read.Status != http.StatusOK || read.ActorID != f.admin.ID {
+		t.Fatalf("unexpected request entry: %+v", read)
+	}
+	if refused.Method != http.MethodDelete || refused.Status != http.StatusForbidden {
+		t.Fatalf("refused request should be audited too: %+v", refused)
+	}
+}
+
+func TestImpersonationEndsWithAdminSession(t *testing.T) {
+	ctx := context.Background()
+	f := newImpersonationFixture(t)
+
+	token, _, err := f.svc.Start(ctx, f.admin.ID, f.session.ID, f.mentee.ID, "debugging", "")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := f.sessions.Revoke(ctx, f.admin.ID, f.session.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if code := f.request(http.MethodGet, "/dashboard", token); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 after the admin session is revoked, got %d", code)
+	}
+}
+
+func TestImpersonationNotAllowed(t *testing.T) {
+	ctx := context.Background()
+	f := newImpersonationFixture(t)
+
+	if _, _, err := f.svc.Start(ctx, f.admin.ID, f.session.ID, f.admin.ID, "self", ""); !errors.Is(err, services.ErrImpersonationNotAllowed) {
+		t.Fatalf("expected self impersonation to be refused, got %v", err)
+	}
+	if _, _, err := f.svc.Start(ctx, f.mentee.ID, f.session.ID, f.admin.ID, "escalation", ""); !errors.Is(err, services.ErrImpersonationNotAllowed) {
+		t.Fatalf("expected admin impersonation to be refused, got %v", err)
+	}
+	if _, _, err := f.svc.Start(ctx, f.admin.ID, f.session.ID, uuid.New(), "missing", ""); !errors.Is(err, services.ErrImpersonationTarget) {
+		t.Fatalf("expected unknown user error, got %v", err)
+	}
+
+	// Ordinary requests are not audited
+	if page, _ := f.svc.List(ctx, nil, pagination.Params{Limit: 10}); len(page.Items) != 0 {
+		t.Fatalf("expected empty audit log, got %d entries", len(page.Items))
+	}
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+func TestLoginThrottleBackoffAfterFreeAttempts(t *testing.T) {
+	ctx := context.Background()
+	svc := services.NewLoginThrottleService(newMemoryLoginThrottleRepo(), newMemoryLockoutEventRepo())
+
+	for i := 0; i < constants.AccountFreeAttempts; i++ {
+		if err := svc.RecordFailure(ctx, "aino@example.com", "203.0.113.7", nil); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+	if err := svc.Check(ctx, "aino@example.com", "203.0.113.7"); err != nil {
+		t.Fatalf("free attempts should not be throttled, got %v", err)
+	}
+
+	if err := svc.RecordFailure(ctx, "aino@example.com", "203.0.113.7", nil); err != nil {
+		t.Fatalf("RecordFailure: %v", err)
+	}
+	var throttled *services.LoginThrottledError
+	if err := svc.Check(ctx, "AINO@example.com", "198.51.100.1"); !errors.As(err, &throttled) {
+		t.Fatalf("expected backoff for the account from any IP and letter case, got %v", err)
+	}
+	if throttled.Locked || throttled.RetryAfter > constants.LoginBackoffBase {
+		t.Fatalf("expected a short backoff, got %+v", throttled)
+	}
+}
+
+func TestLoginThrottleLockoutEmitsEvent(t *testing.T) {
+	ctx := context.Background()
+	events := newMemoryLockoutEventRepo()
+	svc := services.NewLoginThrottleService(newMemoryLoginThrottleRepo(), events)
+	userID := uuid.New()
+
+	for i := 0; i < constants.AccountLockoutThreshold; i++ {
+		if err := svc.RecordFailure(ctx, "aino@example.com", "203.0.113.7", &userID); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+
+	var throttled *services.LoginThrottledError
+	if err := svc.Check(ctx, "aino@example.com", "198.51.100.1"); !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("expected account lockout, got %v", err)
+	}
+	if throttled.RetryAfter <= constants.AccountLockoutDuration-time.Minute {
+		t.Fatalf("expected lockout of about %s, got %s", constants.AccountLockoutDuration, throttled.RetryAfter)
+	}
+
+	page, err := svc.ListLockouts(ctx, pagination.Params{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListLockouts: %v", err)
+	}
+	lockouts := page.Items
+	if len(lockouts) != 1 {
+		t.Fatalf("expected 1 lockout event, got %d", len(lockouts))
+	}
+	event := lockouts[0]
+	if event.Scope != constants.LockoutScopeAccount || event.Subject != "aino@example.com" || event.UserID == nil || *event.UserID != userID {
+		t.Fatalf("unexpected lockout event: %+v", event)
+	}
+}
+
+func TestLoginThrottleIPLockoutAcrossAccounts(t *testing.T) {
+	ctx := context.Background()
+	svc := services.NewLoginThrottleService(newMemoryLoginThrottleRepo(), newMemoryLockoutEventRepo())
+
+	// Credential stuffing: one failure each against many accounts from one IP
+	for i := 0; i < constants.IPLockoutThreshold; i++ {
+		email := uuid.NewString() + "@example.com"
+		if err := svc.RecordFailure(ctx, email, "203.0.113.7", nil); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+
+	var throttled *services.LoginThrottledError
+	if err := svc.Check(ctx, "fresh@example.com", "203.0.113.7"); !errors.As(err, &throttled) || !throttled.Locked {
+		t.Fatalf("expected IP lockout, got %v", err)
+	}
+	if err := svc.Check(ctx, "fresh@example.com", "198.51.100.1"); err != nil {
+		t.Fatalf("other IPs should not be affected, got %v", err)
+	}
+}
+
+func TestLoginThrottleUnlockAndSuccess(t *testing.T) {
+	ctx := context.Background()
+	svc := services.NewLoginThrottleService(newMemoryLoginThrottleRepo(), newMemoryLockoutEventRepo())
+
+	for i := 0; i < constants.AccountLockoutThreshold; i++ {
+		if err := svc.RecordFailure(ctx, "aino@example.com", "203.0.113.7", nil); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+	if err := svc.Unlock(ctx, "aino@example.com", uuid.New()); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if err := svc.Check(ctx, "aino@example.com", "198.51.100.1"); err != nil {
+		t.Fatalf("unlocked account should be allowed, got %v", err)
+	}
+
+	// A successful login resets the account counter but not the IP counter
+	for i := 0; i <= constants.IPFreeAttempts; i++ {
+		if err := svc.RecordFailure(ctx, "aino@example.com", "203.0.113.7", nil); err != nil {
+			t.Fatalf("RecordFailure: %v", err)
+		}
+	}
+	if err := svc.RecordSuccess(ctx, "aino@example.com"); err != nil {
+		t.Fatalf("RecordSuccess: %v", err)
+	}
+	if err := svc.Check(ctx, "aino@example.com", "198.51.100.1"); err != nil {
+		t.Fatalf("account counter should be cleared, got %v", err)
+	}
+	if err := svc.Check(ctx, "aino@example.com", "203.0.113.7"); err == nil {
+		t.Fatal("IP backoff should survive a successful login")
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+func newMagicLinkFixture(t *testing.T) (*services.MagicLinkService, *memoryUserRepo, *memoryMagicLinkRepo, *utils.MemorySender) {
+	t.Helper()
+	users := newMemoryUserRepo()
+	links := newMemoryMagicLinkRepo()
+	sender := utils.NewMemorySender()
+	if err := users.Create(context.Background(), &models.User{ID: uuid.New(), Email: "newcomer@example.com", Role: constants.RoleMentee}); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	svc := services.NewMagicLinkService(links, users, newTestTokenIssuer(t, services.TokenIssuerConfig{}), sender, "http://localhost:3000")
+	return svc, users, links, sender
+}
+
+func sentLoginToken(t *testing.T, sender *utils.MemorySender, to string) string {
+	t.Helper()
+	msg, ok := sender.Last(to)
+	if !ok {
+		t.Fatalf("no email sent to %s", to)
+	}
+	link, err := url.Parse(resetLinkPattern.FindString(msg.Body))
+	if err != nil {
+		t.Fatalf("parse login link: %v", err)
+	}
+	return link.Query().Get("token")
+}
+
+func TestMagicLinkFlow(t *testing.T) {
+	ctx := context.Background()
+	svc, users, _, sender := newMagicLinkFixture(t)
+
+	if err := svc.RequestLink(ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("RequestLink for unknown email: %v", err)
+	}
+	svc.Wait()
+	if len(sender.Messages()) != 0 {
+		t.Fatal("no email should be sent to unknown addresses")
+	}
+
+	if err := svc.RequestLink(ctx, "newcomer@example.com"); err != nil {
+		t.Fatalf("RequestLink: %v", err)
+	}
+	svc.Wait()
+	token := sentLoginToken(t, sender, "newcomer@example.com")
+
+	user, err := svc.Verify(ctx, token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if user.Email != "newcomer@example.com" {
+		t.Fatalf("unexpected user %s", user.Email)
+	}
+	if stored, _ := users.GetByEmail(ctx, user.Email); !stored.IsVerified {
+		t.Fatal("signing in with a login link should verify the email")
+	}
+
+	if _, err := svc.Verify(ctx, token); !errors.Is(err, services.ErrMagicLinkInvalid) {
+		t.Fatalf("login link must be single-use, got %v", err)
+	}
+}
+
+func TestMagicLinkRejectsForeignAndSupersededTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, _, links, sender := newMagicLinkFixture(t)
+
+	if err := svc.RequestLink(ctx, "newcomer@example.com"); err != nil {
+		t.Fatalf("RequestLink: %v", err)
+	}
+	svc.Wait()
+	first := sentLoginToken(t, sender, "newcomer@example.com")
+
+	links.backdate(constants.MagicLinkResendInterval)
+	if err := svc.RequestLink(ctx, "newcomer@example.com"); err != nil {
+		t.Fatalf("RequestLink: %v", err)
+	}
+	svc.Wait()
+	if _, err := svc.Verify(ctx, first); !errors.Is(err, services.ErrMagicLinkInvalid) {
+		t.Fatalf("older link should be superseded, got %v", err)
+	}
+
+	// A token signed by another key must be rejected even with a known jti
+	other := newTestTokenIssuer(t, services.TokenIssuerConfig{})
+	forged, err := other.Sign(accessClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if _, err := svc.Verify(ctx, forged); !errors.Is(err, services.ErrMagicLinkInvalid) {
+		t.Fatalf("expected forged token to be rejected, got %v", err)
+	}
+}
+
+func TestMagicLinkThrottlesPerEmail(t *testing.T) {
+	ctx := context.Background()
+	svc, _, links, sender := newMagicLinkFixture(t)
+
+	if err := svc.RequestLink(ctx, "newcomer@example.com"); err != nil {
+		t.Fatalf("RequestLink: %v", err)
+	}
+	svc.Wait()
+	if err := svc.RequestLink(ctx, "newcomer@example.com"); err != nil {
+		t.Fatalf("RequestLink: %v", err)
+	}
+	svc.Wait()
+	if n := len(sender.Messages()); n != 1 {
+		t.Fatalf("a second link within the resend interval should not be sent, got %d emails", n)
+	}
+
+	for i := 1; i < constants.MagicLinkMaxRequests+2; i++ {
+		links.backdate(constants.MagicLinkResendInterval + time.Second)
+		if err := svc.RequestLink(ctx, "newcomer@example.com"); err != nil {
+			t.Fatalf("RequestLink: %v", err)
+		}
+		svc.Wait()
+	}
+	if n := len(sender.Messages()); n != constants.MagicLinkMaxRequests {
+		t.Fatalf("expected at most %d emails per window, got %d", constants.MagicLinkMaxRequests, n)
+	}
+}
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mentori/internal/handlers"
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/geo"
+	"mentori/pkg/pagination"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+)
+
+// matchFixture serves GET /matches over the profile fixture's repositories
+type matchFixture struct {
+	*profileFixture
+}
+
+func newMatchFixture(t *testing.T) *matchFixture {
+	f := &matchFixture{newProfileFixture(t)}
+	privacy := services.NewProfilePrivacyService(newTestAuthorizationService(), f.mentorships)
+	matching := services.NewMatchingService(f.profiles, f.users, f.mentorships, privacy, services.DefaultMatchWeights())
+	f.router.GET("/matches", func(c *gin.Context) {
+		c.Set(constants.ContextKeyUserID, c.GetHeader("X-Test-User"))
+		c.Set(constants.ContextKeyUserRole, c.GetHeader("X-Test-Role"))
+	}, handlers.NewMatchingHandler(matching).GetMatches)
+	return f
+}
+
+func (f *matchFixture) matches(t *testing.T, viewer *models.User) (int, []models.Match) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/matches", nil)
+	req.Header.Set("X-Test-User", viewer.ID.String())
+	req.Header.Set("X-Test-Role", viewer.Role)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+
+	var page pagination.Page[models.Match]
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return w.Code, page.Items
+}
+
+func factor(m models.Match, name string) models.MatchFactor {
+	for _, f := range m.Factors {
+		if f.Name == name {
+			return f
+		}
+	}
+	return models.MatchFactor{}
+}
+
+func TestMatchScore(t *testing.T) {
+	matching := services.NewMatchingService(nil, nil, nil, nil, services.MatchWeights{Expertise: 1, Language: 1})
+	mentor := &services.MatchProfile{Expertise: []string{"find-a-job", "banking-finance"}, Languages: []string{"fi", "en"}, MaxMentees: 2, ActiveCount: 1}
+	mentee := &services.MatchProfile{Expertise: []string{"find-a-job", "housing-relocation"}, Languages: []string{"en"}}
+
+	match := matching.Score(mentor, mentee)
+	if match.Score != 0.75 {
+		t.Fatalf("expected (0.5 + 1) / 2, got %v", match.Score)
+	}
+	expertise := factor(*match, constants.MatchFactorExpertise)
+	if expertise.Score != 0.5 || expertise.Weight != 0.5 || len(expertise.Shared) != 1 || expertise.Shared[0] != "find-a-job" {
+		t.Fatalf("unexpected expertise factor %+v", expertise)
+	}
+	if capacity := factor(*match, constants.MatchFactorCapacity); capacity.Score != 0.5 || capacity.Weight != 0 {
+		t.Fatalf("unweighted capacity should still be explained: %+v", capacity)
+	}
+	if len(match.Factors) != 6 {
+		t.Fatalf("expected all six factors, got %d", len(match.Factors))
+	}
+}
+
+func TestMatchLocationByDistance(t *testing.T) {
+	matching := services.NewMatchingService(nil, nil, nil, nil, services.MatchWeights{Location: 1})
+	code := func(name string) string {
+		m, ok := geo.FindMunicipality(name)
+		if !ok {
+			t.Fatalf("unknown municipality %s", name)
+		}
+		return m.Code
+	}
+	mentee := &services.MatchProfile{MunicipalityCode: code("Helsinki")}
+
+	nearby := factor(*matching.Score(&services.MatchProfile{MunicipalityCode: code("Espoo")}, mentee), constants.MatchFactorLocation)
+	if nearby.Score <= 0.8 || nearby.Score >= 1 {
+		t.Fatalf("a neighbouring municipality should score close to 1, got %+v", nearby)
+	}
+	far := factor(*matching.Score(&services.MatchProfile{MunicipalityCode: code("Rovaniemi")}, mentee), constants.MatchFactorLocation)
+	if far.Score != 0 {
+		t.Fatalf("a municipality out of range should score 0, got %+v", far)
+	}
+}
+
+func TestGetMatchesScoresEveryCandidate(t *testing.T) {
+	f := newMatchFixture(t)
+	mentee := f.addUser(t, constants.RoleMentee, &models.Profile{FirstName: "Priya", IsActive: true,
+		Expertise: datatypes.JSON(`["find-a-job"]`)})
+	// A full page of older candidates comes before the best one
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < constants.MatchCandidatePageSize; i++ {
+		f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Older", IsActive: true, MaxMentees: 3,
+			CreatedAt: start.Add(time.Duration(i) * time.Millisecond)})
+	}
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Newest", IsActive: true, MaxMentees: 3,
+		Expertise: datatypes.JSON(`["find-a-job"]`)})
+
+	code, matches := f.matches(t, mentee)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(matches) == 0 || matches[0].Profile.FirstName != "Newest" {
+		t.Fatal("candidates after the first page must be scored")
+	}
+}
+
+func TestGetMatches(t *testing.T) {
+	f := newMatchFixture(t)
+	mentee := f.addUser(t, constants.RoleMentee, &models.Profile{FirstName: "Priya", IsActive: true, Location: "Helsinki",
+		Expertise: datatypes.JSON(`["find-a-job","networking-socializing"]`), Languages: datatypes.JSON(`["en"]`)})
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Maria", IsActive: true, Location: "Kallio, Helsinki", MaxMentees: 3,
+		Expertise: datatypes.JSON(`["find-a-job","networking-socializing"]`), Languages: datatypes.JSON(`["fi","en"]`)})
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Jukka", IsActive: true, Location: "Tampere", MaxMentees: 3,
+		Expertise: datatypes.JSON(`["find-a-job"]`), Languages: datatypes.JSON(`["fi"]`)})
+	// Expertise the owner hid does not count
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Sanna", IsActive: true, MaxMentees: 3,
+		Expertise: datatypes.JSON(`["find-a-job","networking-socializing"]`), HiddenFields: datatypes.JSON(`["expertise"]`)})
+	full := f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Full", IsActive: true, MaxMentees: 1,
+		Expertise: datatypes.JSON(`["find-a-job"]`)})
+	f.mentorships.pair(full.ID, f.addUser(t, constants.RoleMentee, nil).ID)
+	partner := f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Partner", IsActive: true, MaxMentees: 3,
+		Expertise: datatypes.JSON(`["find-a-job"]`)})
+	f.mentorships.pair(partner.ID, mentee.ID)
+	f.addUser(t, constants.RoleMentee, &models.Profile{FirstName: "Other mentee", IsActive: true})
+
+	code, matches := f.matches(t, mentee)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.Profile.FirstName)
+	}
+	if len(names) != 3 || names[0] != "Maria" || names[1] != "Jukka" || names[2] != "Sanna" {
+		t.Fatalf("expected Maria, Jukka, Sanna, got %v", names)
+	}
+	if location := factor(matches[0], constants.MatchFactorLocation); location.Score != 1 || location.Detail != "Both in Helsinki" {
+		t.Fatalf("unexpected location factor %+v", location)
+	}
+	if expertise := factor(matches[2], constants.MatchFactorExpertise); expertise.Score != 0 || len(expertise.Shared) != 0 {
+		t.Fatalf("hidden expertise was used: %+v", expertise)
+	}
+	for i := 1; i < len(matches); i++ {
+		if matches[i].Score > matches[i-1].Score {
+			t.Fatalf("matches not ordered by score: %v", matches)
+		}
+	}
+}
+
+func TestGetMatchesRequiresMenteeOrMentorProfile(t *testing.T) {
+	f := newMatchFixture(t)
+	if code, _ := f.matches(t, f.addUser(t, constants.RoleAdmin, &models.Profile{FirstName: "Admin", IsActive: true})); code != http.StatusForbidden {
+		t.Fatalf("admin: expected 403, got %d", code)
+	}
+	if code, _ := f.matches(t, f.addUser(t, constants.RoleMentee, nil)); code != http.StatusNotFound {
+		t.Fatalf("no profile: expected 404, got %d", code)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+func newMentorApplicationFixture(t *testing.T) (*services.MentorApplicationService, *memoryUserRepo, *utils.MemorySender, *models.User) {
+	t.Helper()
+	users := newMemoryUserRepo()
+	applicant := &models.User{ID: uuid.New(), Email: "aspiring@example.com", Role: constants.RoleMentee}
+	if err := users.Create(context.Background(), applicant); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	sender := utils.NewMemorySender()
+	return services.NewMentorApplicationService(newMemoryMentorApplicationRepo(users), users, sender), users, sender, applicant
+}
+
+func TestRegisterRequestRejectsAdminRole(t *testing.T) {
+	for role, valid := range map[string]bool{
+		constants.RoleMentor: true,
+		constants.RoleMentee: true,
+		constants.RoleAdmin:  false,
+		"moderator":          false,
+	} {
+		req := models.RegisterRequest{Email: "new@example.com", Password: "Tall-Birch-42", Role: role}
+		if err := binding.Validator.ValidateStruct(&req); (err == nil) != valid {
+			t.Errorf("role %q: valid = %v, got error %v", role, valid, err)
+		}
+	}
+}
+
+func TestMentorApplicationApprove(t *testing.T) {
+	svc, users, sender, applicant := newMentorApplicationFixture(t)
+	ctx := context.Background()
+	adminID := uuid.New()
+
+	application, err := svc.Apply(ctx, applicant.ID, "Ten years of backend work")
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if application.Status != constants.MentorApplicationPending {
+		t.Fatalf("expected pending, got %s", application.Status)
+	}
+	if _, err := svc.Apply(ctx, applicant.ID, ""); !errors.Is(err, services.ErrMentorApplicationPending) {
+		t.Fatalf("second application: expected ErrMentorApplicationPending, got %v", err)
+	}
+
+	pending, err := svc.List(ctx, constants.MentorApplicationPending, pagination.Params{Limit: 10})
+	if err != nil || len(pending.Items) != 1 {
+		t.Fatalf("List pending = %d, %v", len(pending.Items), err)
+	}
+
+	approved, err := svc.Approve(ctx, application.ID, adminID, "")
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if approved.Status != constants.MentorApplicationApproved || approved.ReviewedBy == nil || *approved.ReviewedBy != adminID {
+		t.Fatalf("unexpected application: %+v", approved)
+	}
+	user, _ := users.GetByID(ctx, applicant.ID)
+	if user.Role != constants.RoleMentor {
+		t.Fatalf("expected mentor role, got %s", user.Role)
+	}
+	if msg, ok := sender.Last(applicant.Email); !ok || !strings.Contains(msg.Subject, "approved") {
+		t.Fatalf("expected approval email, got %+v", msg)
+	}
+
+	if _, err := svc.Reject(ctx, application.ID, adminID, "changed my mind"); !errors.Is(err, services.ErrMentorApplicationDecided) {
+		t.Fatalf("deciding twice: expected ErrMentorApplicationDecided, got %v", err)
+	}
+	if _, err := svc.Apply(ctx, applicant.ID, ""); !errors.Is(err, services.ErrMentorApplicationRole) {
+		t.Fatalf("mentor applying: expected ErrMentorApplicationRole, got %v", err)
+	}
+}
+
+func TestMentorApplicationReject(t *testing.T) {
+	svc, users, sender, applicant := newMentorApplicationFixture(t)
+	ctx := context.Background()
+
+	application, err := svc.Apply(ctx, applicant.ID, "")
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if _, err := svc.Reject(ctx, application.ID, uuid.New(), "Please complete your profile first"); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+
+	user, _ := users.GetByID(ctx, applicant.ID)
+	if user.Role != constants.RoleMentee {
+		t.Fatalf("rejected applicant should stay a mentee, got %s", user.Role)
+	}
+	msg, ok := sender.Last(applicant.Email)
+	if !ok || !strings.Contains(msg.Body, "Please complete your profile first") {
+		t.Fatalf("expected rejection email with reason, got %+v", msg)
+	}
+
+	// A rejected applicant may apply again
+	if _, err := svc.Apply(ctx, applicant.ID, "Profile is complete now"); err != nil {
+		t.Fatalf("reapply: %v", err)
+	}
+	latest, err := svc.Latest(ctx, applicant.ID)
+	if err != nil || latest.Status != constants.MentorApplicationPending {
+		t.Fatalf("Latest = %+v, %v", latest, err)
+	}
+}
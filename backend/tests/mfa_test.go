@@ -0,0 +1,258 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/totp"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestTOTPMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B SHA-1 secret "12345678901234567890", truncated to 6 digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := totp.Code(secret, totp.Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code: %v", err)
+		}
+		if code != tt.code {
+			t.Fatalf("at %d expected %s, got %s", tt.unix, tt.code, code)
+		}
+	}
+}
+
+func currentTOTP(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := totp.Code(secret, totp.Step(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("Code: %v", err)
+	}
+	return code
+}
+
+// mfaTestPassword is the password of users made by newMFATestUser
+const mfaTestPassword = "Tall-Birch-42"
+
+func newMFATestUser(t *testing.T, users *memoryUserRepo, role string) *models.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(mfaTestPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := &models.User{ID: uuid.New(), Email: role + "@example.com", Role: role, PasswordHash: string(hash)}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+func TestMFAEnrollmentAndVerify(t *testing.T) {
+	ctx := context.Background()
+	users := newMemoryUserRepo()
+	svc := services.NewMFAService(users, newMemoryMFARecoveryCodeRepo(), newMemoryMFAChallengeRepo(), newTestAuthorizationService())
+
+	mentee := newMFATestUser(t, users, constants.RoleMentee)
+	if _, err := svc.BeginSetup(ctx, mentee.ID); !errors.Is(err, services.ErrMFANotAllowed) {
+		t.Fatalf("expected mentees to be refused, got %v", err)
+	}
+
+	admin := newMFATestUser(t, users, constants.RoleAdmin)
+	setup, err := svc.BeginSetup(ctx, admin.ID)
+	if err != nil {
+		t.Fatalf("BeginSetup: %v", err)
+	}
+	if !strings.HasPrefix(setup.ProvisioningURI, "otpauth://totp/") || !strings.Contains(setup.ProvisioningURI, setup.Secret) {
+		t.Fatalf("unexpected provisioning URI: %s", setup.ProvisioningURI)
+	}
+
+	if _, err := svc.Enable(ctx, admin.ID, "000000"); !errors.Is(err, services.ErrMFAInvalidCode) {
+		t.Fatalf("expected invalid code, got %v", err)
+	}
+	enrollCode := currentTOTP(t, setup.Secret, -1)
+	codes, err := svc.Enable(ctx, admin.ID, enrollCode)
+	if err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+	if len(codes) != constants.MFARecoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", constants.MFARecoveryCodeCount, len(codes))
+	}
+
+	// A code at or before the last accepted step cannot be replayed
+	if _, err := svc.Verify(ctx, admin.ID, enrollCode); !errors.Is(err, services.ErrMFAInvalidCode) {
+		t.Fatalf("expected replayed code to be rejected, got %v", err)
+	}
+	if _, err := svc.Verify(ctx, admin.ID, currentTOTP(t, setup.Secret, 0)); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestMFARecoveryCodesAreSingleUse(t *testing.T) {
+	ctx := context.Background()
+	users := newMemoryUserRepo()
+	svc := services.NewMFAService(users, newMemoryMFARecoveryCodeRepo(), newMemoryMFAChallengeRepo(), newTestAuthorizationService())
+
+	mentor := newMFATestUser(t, users, constants.RoleMentor)
+	setup, err := svc.BeginSetup(ctx, mentor.ID)
+	if err != nil {
+		t.Fatalf("BeginSetup: %v", err)
+	}
+	codes, err := svc.Enable(ctx, mentor.ID, currentTOTP(t, setup.Secret, 0))
+	if err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+
+	// Recovery codes are accepted regardless of case and separators
+	if _, err := svc.Verify(ctx, mentor.ID, strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))); err != nil {
+		t.Fatalf("Verify with recovery code: %v", err)
+	}
+	if _, err := svc.Verify(ctx, mentor.ID, codes[0]); !errors.Is(err, services.ErrMFAInvalidCode) {
+		t.Fatalf("expected used recovery code to be rejected, got %v", err)
+	}
+
+	if err := svc.Disable(ctx, mentor.ID, mfaTestPassword, codes[1]); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+	if _, err := svc.Verify(ctx, mentor.ID, codes[2]); !errors.Is(err, services.ErrMFANotEnabled) {
+		t.Fatalf("expected MFA to be disabled, got %v", err)
+	}
+}
+
+func TestMFAChangesNeedThePassword(t *testing.T) {
+	ctx := context.Background()
+	users := newMemoryUserRepo()
+	svc := services.NewMFAService(users, newMemoryMFARecoveryCodeRepo(), newMemoryMFAChallengeRepo(), newTestAuthorizationService())
+
+	mentor := newMFATestUser(t, users, constants.RoleMentor)
+	setup, err := svc.BeginSetup(ctx, mentor.ID)
+	if err != nil {
+		t.Fatalf("BeginSetup: %v", err)
+	}
+	codes, err := svc.Enable(ctx, mentor.ID, currentTOTP(t, setup.Secret, -1))
+	if err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+
+	if _, err := svc.RegenerateRecoveryCodes(ctx, mentor.ID, "wrong-password", currentTOTP(t, setup.Secret, 0)); !errors.Is(err, services.ErrMFAInvalidPassword) {
+		t.Fatalf("regenerate with a wrong password: expected ErrMFAInvalidPassword, got %v", err)
+	}
+	if err := svc.Disable(ctx, mentor.ID, "wrong-password", codes[0]); !errors.Is(err, services.ErrMFAInvalidPassword) {
+		t.Fatalf("disable with a wrong password: expected ErrMFAInvalidPassword, got %v", err)
+	}
+	// The recovery code was not spent on the refused request
+	if err := svc.Disable(ctx, mentor.ID, mfaTestPassword, codes[0]); err != nil {
+		t.Fatalf("Disable: %v", err)
+	}
+}
+
+func TestMFAConcurrentCodeIsAcceptedOnce(t *testing.T) {
+	ctx := context.Background()
+	users := newMemoryUserRepo()
+	svc := services.NewMFAService(users, newMemoryMFARecoveryCodeRepo(), newMemoryMFAChallengeRepo(), newTestAuthorizationService())
+
+	mentor := newMFATestUser(t, users, constants.RoleMentor)
+	setup, err := svc.BeginSetup(ctx, mentor.ID)
+	if err != nil {
+		t.Fatalf("BeginSetup: %v", err)
+	}
+	if _, err := svc.Enable(ctx, mentor.ID, currentTOTP(t, setup.Secret, -1)); err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+
+	code := currentTOTP(t, setup.Secret, 0)
+	var accepted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.Verify(ctx, mentor.ID, code); err == nil {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := accepted.Load(); n != 1 {
+		t.Fatalf("expected the code to be accepted once, got %d", n)
+	}
+}
+
+func TestMFAChallengeIsSingleUseAndLimited(t *testing.T) {
+	ctx := context.Background()
+	users := newMemoryUserRepo()
+	svc := services.NewMFAService(users, newMemoryMFARecoveryCodeRepo(), newMemoryMFAChallengeRepo(), newTestAuthorizationService())
+
+	mentor := newMFATestUser(t, users, constants.RoleMentor)
+	setup, err := svc.BeginSetup(ctx, mentor.ID)
+	if err != nil {
+		t.Fatalf("BeginSetup: %v", err)
+	}
+	codes, err := svc.Enable(ctx, mentor.ID, currentTOTP(t, setup.Secret, 0))
+	if err != nil {
+		t.Fatalf("Enable: %v", err)
+	}
+
+	challenge, err := svc.StartChallenge(ctx, mentor.ID)
+	if err != nil {
+		t.Fatalf("StartChallenge: %v", err)
+	}
+	if _, err := svc.CompleteChallenge(ctx, challenge.ID, uuid.New(), codes[0]); !errors.Is(err, services.ErrMFAChallengeInvalid) {
+		t.Fatalf("expected challenge of another user to be rejected, got %v", err)
+	}
+	if _, err := svc.CompleteChallenge(ctx, challenge.ID, mentor.ID, codes[0]); err != nil {
+		t.Fatalf("CompleteChallenge: %v", err)
+	}
+	if _, err := svc.CompleteChallenge(ctx, challenge.ID, mentor.ID, codes[1]); !errors.Is(err, services.ErrMFAChallengeInvalid) {
+		t.Fatalf("expected completed challenge to be rejected, got %v", err)
+	}
+
+	// Wrong codes burn the challenge even if a valid code follows
+	challenge, err = svc.StartChallenge(ctx, mentor.ID)
+	if err != nil {
+		t.Fatalf("StartChallenge: %v", err)
+	}
+	for i := 0; i < constants.MFAChallengeMaxAttempts; i++ {
+		if _, err := svc.CompleteChallenge(ctx, challenge.ID, mentor.ID, "000000"); !errors.Is(err, services.ErrMFAInvalidCode) {
+			t.Fatalf("attempt %d: expected invalid code, got %v", i+1, err)
+		}
+	}
+	if _, err := svc.CompleteChallenge(ctx, challenge.ID, mentor.ID, codes[1]); !errors.Is(err, services.ErrMFAChallengeInvalid) {
+		t.Fatalf("expected challenge to be burned after %d attempts, got %v", constants.MFAChallengeMaxAttempts, err)
+	}
+}
+
+func TestRefreshTokenRotationKeepsMFAVerified(t *testing.T) {
+	ctx := context.Background()
+	svc := services.NewRefreshTokenService(newMemoryRefreshTokenRepo())
+
+	raw, _, err := svc.Issue(ctx, uuid.New(), true)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	_, rotated, err := svc.Rotate(ctx, raw)
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if !rotated.MFAVerified {
+		t.Fatal("rotated token must keep the MFA verification of the login")
+	}
+}
@@ -0,0 +1,374 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// jwksStub serves a JWKS document for a set of RSA keys and counts fetches
+type jwksStub struct {
+	server  *httptest.Server
+	keys    map[string]*rsa.PrivateKey
+	fetches atomic.Int32
+}
+
+func newJWKSStub(t *testing.T, kids ...string) *jwksStub {
+	t.Helper()
+	stub := &jwksStub{keys: make(map[string]*rsa.PrivateKey)}
+	for _, kid := range kids {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+		stub.keys[kid] = key
+	}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.fetches.Add(1)
+		var keys []map[string]string
+		for kid, key := range stub.keys {
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+func (s *jwksStub) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(s.keys[kid])
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+// testNonce is the nonce in googleClaims
+const testNonce = "n-0S6_WzA2Mj"
+
+func googleClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            "https://accounts.google.com",
+		"aud":            "mentori-web",
+		"sub":            "google-123",
+		"email":          "mentee@example.com",
+		"email_verified": true,
+		"name":           "Test Mentee",
+		"nonce":          testNonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func newStubOAuthService(stub *jwksStub) *services.OAuthService {
+	return services.NewOAuthService(services.OAuthConfig{
+		GoogleClientIDs: []string{"mentori-web"},
+		GoogleJWKSURL:   stub.server.URL,
+		AppleClientIDs:  []string{"com.mentori.app"},
+		AppleJWKSURL:    stub.server.URL,
+	})
+}
+
+func TestOAuthVerifyGoogleToken(t *testing.T) {
+	stub := newJWKSStub(t, "key-1")
+	svc := newStubOAuthService(stub)
+
+	user, err := svc.VerifyToken(context.Background(), "google", stub.sign(t, "key-1", googleClaims()), testNonce)
+	if err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	if user.ProviderID != "google-123" || user.Email != "mentee@example.com" || !user.IsVerified || user.Name != "Test Mentee" {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	// Second verification is served from the key cache
+	if _, err := svc.VerifyToken(context.Background(), "google", stub.sign(t, "key-1", googleClaims()), testNonce); err != nil {
+		t.Fatalf("VerifyToken (cached): %v", err)
+	}
+	if n := stub.fetches.Load(); n != 1 {
+		t.Fatalf("expected 1 JWKS fetch, got %d", n)
+	}
+}
+
+func TestOAuthRejectsInvalidTokens(t *testing.T) {
+	stub := newJWKSStub(t, "key-1")
+	forger := newJWKSStub(t, "key-1")
+	svc := newStubOAuthService(stub)
+
+	tests := []struct {
+		name   string
+		token  func() string
+		nonce  string
+		mutate func(jwt.MapClaims)
+	}{
+		{name: "forged signature", token: func() string { return forger.sign(t, "key-1", googleClaims()) }},
+		{name: "wrong audience", mutate: func(c jwt.MapClaims) { c["aud"] = "someone-else" }},
+		{name: "wrong issuer", mutate: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{name: "expired", mutate: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{name: "wrong nonce", nonce: "other-nonce"},
+		{name: "missing nonce", nonce: "-"},
+		{name: "no nonce claim", mutate: func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{name: "unsigned", token: func() string {
+			signed, _ := jwt.NewWithClaims(jwt.SigningMethodNone, googleClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return signed
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var token string
+			if tt.token != nil {
+				token = tt.token()
+			} else {
+				claims := googleClaims()
+				if tt.mutate != nil {
+					tt.mutate(claims)
+				}
+				token = stub.sign(t, "key-1", claims)
+			}
+			nonce := testNonce
+			if tt.nonce == "-" {
+				nonce = ""
+			} else if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			if _, err := svc.VerifyToken(context.Background(), "google", token, nonce); err == nil {
+				t.Fatal("expected verification to fail")
+			}
+		})
+	}
+}
+
+func TestOAuthVerifyAppleTokenWithHashedNonce(t *testing.T) {
+	stub := newJWKSStub(t, "apple-1")
+	svc := newStubOAuthService(stub)
+
+	claims := jwt.MapClaims{
+		"iss":            "https://appleid.apple.com",
+		"aud":            "com.mentori.app",
+		"sub":            "apple-456",
+		"email":          "mentor@privaterelay.appleid.com",
+		"email_verified": "true",
+		"nonce":          "raw-nonce",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+
+	// Apple tokens carry the hash of the nonce, never the raw value
+	if _, err := svc.VerifyToken(context.Background(), "apple", stub.sign(t, "apple-1", claims), "raw-nonce"); err == nil {
+		t.Fatal("expected nonce mismatch for an unhashed nonce")
+	}
+
+	sum := sha256.Sum256([]byte("raw-nonce"))
+	claims["nonce"] = hex.EncodeToString(sum[:])
+	user, err := svc.VerifyToken(context.Background(), "apple", stub.sign(t, "apple-1", claims), "raw-nonce")
+	if err != nil {
+		t.Fatalf("VerifyToken: %v", err)
+	}
+	if user.ProviderID != "apple-456" || !user.IsVerified {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+}
+
+func TestJWKSVerifierKeyRotation(t *testing.T) {
+	stub := newJWKSStub(t, "old")
+	verifier := services.NewJWKSVerifier(services.JWKSVerifierConfig{
+		JWKSURL:   stub.server.URL,
+		Issuers:   []string{"https://accounts.google.com"},
+		Audiences: []string{"mentori-web"},
+	})
+	if err := verifier.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// Rotate in a new key after the cache was populated
+	rotated := newJWKSStub(t, "new")
+	stub.keys["new"] = rotated.keys["new"]
+
+	// A miss right after a fetch is not refetched, to protect the provider from abuse
+	if _, err := verifier.Verify(context.Background(), stub.sign(t, "new", googleClaims()), testNonce); err == nil {
+		t.Fatal("expected unknown key within the minimum refresh interval")
+	}
+	if err := verifier.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), stub.sign(t, "new", googleClaims()), testNonce); err != nil {
+		t.Fatalf("Verify after refresh: %v", err)
+	}
+}
+
+// issueNonce returns a nonce from the account service, for sign-ins with a
+// sessionID of uuid.Nil
+func issueNonce(t *testing.T, accounts *services.OAuthAccountService, sessionID uuid.UUID) string {
+	t.Helper()
+	nonce, err := accounts.IssueNonce(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("IssueNonce: %v", err)
+	}
+	return nonce.Nonce
+}
+
+// googleToken signs googleClaims carrying the nonce
+func googleToken(t *testing.T, stub *jwksStub, nonce string) string {
+	t.Helper()
+	claims := googleClaims()
+	claims["nonce"] = nonce
+	return stub.sign(t, "key-1", claims)
+}
+
+// appleToken signs an Apple ID token carrying the hash of the nonce
+func appleToken(t *testing.T, stub *jwksStub, nonce string) string {
+	t.Helper()
+	sum := sha256.Sum256([]byte(nonce))
+	return stub.sign(t, "key-1", jwt.MapClaims{
+		"iss":   "https://appleid.apple.com",
+		"aud":   "com.mentori.app",
+		"sub":   "apple-789",
+		"email": "relay@privaterelay.appleid.com",
+		"nonce": hex.EncodeToString(sum[:]),
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+}
+
+func TestOAuthAccountSignInAndLinking(t *testing.T) {
+	ctx := context.Background()
+	stub := newJWKSStub(t, "key-1")
+	users := newMemoryUserRepo()
+	accounts := services.NewOAuthAccountService(newStubOAuthService(stub), users, newMemoryUserIdentityRepo(), newMemoryOAuthNonceRepo())
+
+	// First sign-in creates the account
+	nonce := issueNonce(t, accounts, uuid.Nil)
+	user, created, err := accounts.SignIn(ctx, "google", googleToken(t, stub, nonce), nonce)
+	if err != nil {
+		t.Fatalf("SignIn: %v", err)
+	}
+	if !created || user.Role != constants.RoleMentee || !user.IsVerified {
+		t.Fatalf("unexpected new user: created=%v %+v", created, user)
+	}
+
+	// Second sign-in finds the same account
+	nonce = issueNonce(t, accounts, uuid.Nil)
+	again, created, err := accounts.SignIn(ctx, "google", googleToken(t, stub, nonce), nonce)
+	if err != nil || created || again.ID != user.ID {
+		t.Fatalf("expected existing user, got created=%v err=%v", created, err)
+	}
+
+	// Link Apple and sign in with it
+	sessionID := uuid.New()
+	linkNonce := issueNonce(t, accounts, sessionID)
+	if _, err := accounts.Link(ctx, user.ID, sessionID, "apple", appleToken(t, stub, linkNonce), linkNonce); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	nonce = issueNonce(t, accounts, uuid.Nil)
+	viaApple, _, err := accounts.SignIn(ctx, "apple", appleToken(t, stub, nonce), nonce)
+	if err != nil || viaApple.ID != user.ID {
+		t.Fatalf("expected Apple sign-in to reach the linked user, err=%v", err)
+	}
+
+	// Password-less user keeps at least one provider
+	if err := accounts.Unlink(ctx, user.ID, "google"); err != nil {
+		t.Fatalf("Unlink google: %v", err)
+	}
+	if err := accounts.Unlink(ctx, user.ID, "apple"); !errors.Is(err, services.ErrOAuthLastLoginMethod) {
+		t.Fatalf("expected last login method error, got %v", err)
+	}
+}
+
+func TestOAuthNonceIsIssuedAndSingleUse(t *testing.T) {
+	ctx := context.Background()
+	stub := newJWKSStub(t, "key-1")
+	accounts := services.NewOAuthAccountService(newStubOAuthService(stub), newMemoryUserRepo(), newMemoryUserIdentityRepo(), newMemoryOAuthNonceRepo())
+
+	// A nonce the server never issued is refused even if the token carries it
+	if _, _, err := accounts.SignIn(ctx, "google", googleToken(t, stub, testNonce), testNonce); !errors.Is(err, services.ErrOAuthNonceInvalid) {
+		t.Fatalf("client-chosen nonce: expected ErrOAuthNonceInvalid, got %v", err)
+	}
+
+	// A token that fails verification leaves the nonce usable
+	nonce := issueNonce(t, accounts, uuid.Nil)
+	if _, _, err := accounts.SignIn(ctx, "google", googleToken(t, stub, "other"), nonce); !errors.Is(err, services.ErrOAuthTokenVerification) {
+		t.Fatalf("mismatched nonce: expected ErrOAuthTokenVerification, got %v", err)
+	}
+	token := googleToken(t, stub, nonce)
+	user, _, err := accounts.SignIn(ctx, "google", token, nonce)
+	if err != nil {
+		t.Fatalf("SignIn: %v", err)
+	}
+	if _, _, err := accounts.SignIn(ctx, "google", token, nonce); !errors.Is(err, services.ErrOAuthNonceInvalid) {
+		t.Fatalf("replayed token: expected ErrOAuthNonceInvalid, got %v", err)
+	}
+
+	// Link nonces only work for the session they were issued to, and sign-in
+	// nonces not at all
+	sessionID := uuid.New()
+	linkNonce := issueNonce(t, accounts, sessionID)
+	if _, err := accounts.Link(ctx, user.ID, uuid.New(), "apple", appleToken(t, stub, linkNonce), linkNonce); !errors.Is(err, services.ErrOAuthNonceInvalid) {
+		t.Fatalf("link nonce from another session: expected ErrOAuthNonceInvalid, got %v", err)
+	}
+	if _, _, err := accounts.SignIn(ctx, "apple", appleToken(t, stub, linkNonce), linkNonce); !errors.Is(err, services.ErrOAuthNonceInvalid) {
+		t.Fatalf("link nonce used to sign in: expected ErrOAuthNonceInvalid, got %v", err)
+	}
+	signInNonce := issueNonce(t, accounts, uuid.Nil)
+	if _, err := accounts.Link(ctx, user.ID, sessionID, "apple", appleToken(t, stub, signInNonce), signInNonce); !errors.Is(err, services.ErrOAuthNonceInvalid) {
+		t.Fatalf("sign-in nonce used to link: expected ErrOAuthNonceInvalid, got %v", err)
+	}
+	if _, err := accounts.Link(ctx, user.ID, sessionID, "apple", appleToken(t, stub, linkNonce), linkNonce); err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+}
+
+func TestOAuthSignInDoesNotTakeOverUnverifiedAccount(t *testing.T) {
+	ctx := context.Background()
+	stub := newJWKSStub(t, "key-1")
+	users := newMemoryUserRepo()
+	accounts := services.NewOAuthAccountService(newStubOAuthService(stub), users, newMemoryUserIdentityRepo(), newMemoryOAuthNonceRepo())
+
+	local := &models.User{ID: uuid.New(), Email: "mentee@example.com", Role: constants.RoleMentee, PasswordHash: "hash"}
+	if err := users.Create(ctx, local); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	nonce := issueNonce(t, accounts, uuid.Nil)
+	if _, _, err := accounts.SignIn(ctx, "google", googleToken(t, stub, nonce), nonce); !errors.Is(err, services.ErrOAuthAccountExists) {
+		t.Fatalf("expected account exists error, got %v", err)
+	}
+
+	local.IsVerified = true
+	if err := users.Update(ctx, local); err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+	nonce = issueNonce(t, accounts, uuid.Nil)
+	linked, created, err := accounts.SignIn(ctx, "google", googleToken(t, stub, nonce), nonce)
+	if err != nil || created || linked.ID != local.ID {
+		t.Fatalf("expected link to verified local account, created=%v err=%v", created, err)
+	}
+}
@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
+
+	"github.com/google/uuid"
+)
+
+// page fetches one page of public profiles as an anonymous viewer
+func (f *profileFixture) page(t *testing.T, query url.Values) (int, pagination.Page[models.ProfileView], models.ErrorResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/profiles/public?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+
+	var page pagination.Page[models.ProfileView]
+	var failure models.ErrorResponse
+	target := interface{}(&page)
+	if w.Code != http.StatusOK {
+		target = &failure
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), target); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return w.Code, page, failure
+}
+
+func TestPaginationParse(t *testing.T) {
+	page, err := pagination.Parse("", "")
+	if err != nil || page.Limit != constants.DefaultPageSize || page.After != nil {
+		t.Fatalf("defaults: got %+v, %v", page, err)
+	}
+	for _, limit := range []string{"0", "-1", "abc", "101"} {
+		if _, err := pagination.Parse(limit, ""); !errors.Is(err, pagination.ErrInvalidLimit) {
+			t.Fatalf("limit %q: expected ErrInvalidLimit, got %v", limit, err)
+		}
+	}
+	for _, cursor := range []string{"not base64!", "e30", pagination.Cursor{}.Encode()} {
+		if _, err := pagination.Parse("", cursor); !errors.Is(err, pagination.ErrInvalidCursor) {
+			t.Fatalf("cursor %q: expected ErrInvalidCursor, got %v", cursor, err)
+		}
+	}
+
+	rank := 0.1 + 0.2
+	cursor := pagination.Cursor{Rank: &rank, CreatedAt: time.Now(), ID: uuid.New()}
+	decoded, err := pagination.Decode(cursor.Encode())
+	if err != nil || *decoded.Rank != rank || !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+		t.Fatalf("round trip: got %+v, %v", decoded, err)
+	}
+}
+
+func TestGetPublicProfilesPages(t *testing.T) {
+	f := newProfileFixture(t)
+	names := []string{"Aino", "Eero", "Helmi", "Juho", "Kaisa"}
+	joined := time.Now().Add(-time.Hour)
+	for i, name := range names {
+		f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: name, IsActive: true, CreatedAt: joined.Add(time.Duration(i) * time.Minute)})
+	}
+
+	var seen []string
+	query := url.Values{"limit": {"2"}}
+	for pages := 1; ; pages++ {
+		code, page, _ := f.page(t, query)
+		if code != http.StatusOK {
+			t.Fatalf("page %d: expected 200, got %d", pages, code)
+		}
+		if pages == 1 && (page.EstimatedTotal == nil || *page.EstimatedTotal != 5) {
+			t.Fatalf("first page should estimate 5 profiles, got %v", page.EstimatedTotal)
+		}
+		if pages > 1 && page.EstimatedTotal != nil {
+			t.Fatalf("page %d should not count again", pages)
+		}
+		for _, view := range page.Items {
+			seen = append(seen, view.FirstName)
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Fatalf("expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+	if len(seen) != len(names) {
+		t.Fatalf("expected every profile once, got %v", seen)
+	}
+	for i, name := range names {
+		if seen[i] != name {
+			t.Fatalf("expected oldest first %v, got %v", names, seen)
+		}
+	}
+}
+
+func TestGetPublicProfilesSearchPages(t *testing.T) {
+	f := newProfileFixture(t)
+	joined := time.Now().Add(-time.Hour)
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Aino", Bio: "Helsinki design jobs", IsActive: true, CreatedAt: joined.Add(2 * time.Minute)})
+	// Equal ranks keep the oldest first
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Mikko", Bio: "Helsinki", IsActive: true, CreatedAt: joined})
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Liisa", Bio: "Helsinki", IsActive: true, CreatedAt: joined.Add(time.Minute)})
+
+	code, first, _ := f.page(t, url.Values{"q": {"helsinki design"}, "limit": {"2"}})
+	if code != http.StatusOK || len(first.Items) != 2 || first.Items[0].FirstName != "Aino" || first.NextCursor == "" {
+		t.Fatalf("unexpected first page %d %+v", code, first)
+	}
+	code, second, _ := f.page(t, url.Values{"q": {"helsinki design"}, "limit": {"2"}, "cursor": {first.NextCursor}})
+	if code != http.StatusOK || len(second.Items) != 1 || second.Items[0].FirstName != "Liisa" || second.NextCursor != "" {
+		t.Fatalf("unexpected second page %d %+v", code, second)
+	}
+
+	// A search cursor only continues the same kind of listing
+	if code, _, failure := f.page(t, url.Values{"cursor": {first.NextCursor}}); code != http.StatusBadRequest || failure.Field != "cursor" {
+		t.Fatalf("search cursor without q: expected 400 on cursor, got %d %+v", code, failure)
+	}
+}
+
+func TestGetPublicProfilesRejectsInvalidPaging(t *testing.T) {
+	f := newProfileFixture(t)
+	if code, _, failure := f.page(t, url.Values{"limit": {"101"}}); code != http.StatusBadRequest || failure.Field != "limit" {
+		t.Fatalf("limit above the maximum: expected 400 on limit, got %d %+v", code, failure)
+	}
+	if code, _, failure := f.page(t, url.Values{"cursor": {"garbage"}}); code != http.StatusBadRequest || failure.Field != "cursor" {
+		t.Fatalf("garbage cursor: expected 400 on cursor, got %d %+v", code, failure)
+	}
+	code, page, _ := f.page(t, url.Values{})
+	if code != http.StatusOK || page.Items == nil || len(page.Items) != 0 || page.NextCursor != "" {
+		t.Fatalf("empty listing: expected an empty page, got %d %+v", code, page)
+	}
+}
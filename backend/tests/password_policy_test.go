@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"mentori/pkg/validators"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := validators.DefaultPasswordPolicy()
+
+	cases := []struct {
+		name     string
+		password string
+		email    string
+		valid    bool
+	}{
+		{"strong", "Tall-Birch-42", "aino@example.com", true},
+		{"passphrase with spaces", "correct Horse battery 7", "aino@example.com", true},
+		{"empty", "", "aino@example.com", false},
+		{"too short", "Ab1!", "aino@example.com", false},
+		{"too few classes", "alllowercaseletters", "aino@example.com", false},
+		{"contains email local part", "Ainovirtanen-99", "ainovirtanen@example.com", false},
+		{"email local part in other case", "xx-AINOVIRTANEN-1", "ainovirtanen@example.com", false},
+		{"common password", "Qwertyuiop1", "aino@example.com", false},
+		{"common password with suffix", "Password123!", "aino@example.com", false},
+		{"finnish common password", "Salasana2024", "aino@example.com", false},
+		{"less common password", "Fishtank2024", "aino@example.com", false},
+		{"72 bytes", "Aa1-" + strings.Repeat("x", 68), "aino@example.com", true},
+		{"over bcrypt limit", "Aa1-" + strings.Repeat("x", 69), "aino@example.com", false},
+		{"multibyte over bcrypt limit", "Åa1-" + strings.Repeat("ä", 35), "aino@example.com", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Validate(tc.password, tc.email)
+			if tc.valid && err != nil {
+				t.Fatalf("expected valid password, got %v", err)
+			}
+			if !tc.valid {
+				var weak *validators.PasswordError
+				if !errors.As(err, &weak) {
+					t.Fatalf("expected *PasswordError, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyConfigurable(t *testing.T) {
+	relaxed := validators.PasswordPolicy{MinLength: 8, MinClasses: 1}
+	if err := relaxed.Validate("blue-river-stones", ""); err != nil {
+		t.Fatalf("relaxed policy should accept a long lowercase passphrase, got %v", err)
+	}
+
+	strict := validators.PasswordPolicy{MinLength: 16, MinClasses: 4}
+	if err := strict.Validate("Tall-Birch-42", ""); err == nil {
+		t.Fatal("strict policy should reject a 13 character password")
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/utils"
+	"mentori/pkg/validators"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var resetLinkPattern = regexp.MustCompile(`https?://\S+`)
+
+func TestPasswordResetFlow(t *testing.T) {
+	ctx := context.Background()
+	users := newMemoryUserRepo()
+	sessions := services.NewSessionService(newMemoryAuthSessionRepo(), services.NewRefreshTokenService(newMemoryRefreshTokenRepo()))
+	sender := utils.NewMemorySender()
+	svc := services.NewPasswordResetService(newMemoryPasswordResetRepo(), users, sessions, sender, "http://localhost:3000", validators.DefaultPasswordPolicy())
+
+	user := &models.User{ID: uuid.New(), Email: "mentor@example.com", Role: constants.RoleMentor, PasswordHash: "old"}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	refreshToken, session, err := sessions.Start(ctx, user.ID, false, services.SessionInfo{})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	if err := svc.RequestReset(ctx, "nobody@example.com"); err != nil {
+		t.Fatalf("RequestReset for unknown email: %v", err)
+	}
+	svc.Wait()
+	if len(sender.Messages()) != 0 {
+		t.Fatal("no email should be sent to unknown addresses")
+	}
+
+	if err := svc.RequestReset(ctx, user.Email); err != nil {
+		t.Fatalf("RequestReset: %v", err)
+	}
+	svc.Wait()
+	msg, ok := sender.Last(user.Email)
+	if !ok {
+		t.Fatal("expected reset email")
+	}
+	link, err := url.Parse(resetLinkPattern.FindString(msg.Body))
+	if err != nil {
+		t.Fatalf("parse reset link: %v", err)
+	}
+	token := link.Query().Get("token")
+
+	var weak *validators.PasswordError
+	if err := svc.ResetPassword(ctx, token, "password"); !errors.As(err, &weak) {
+		t.Fatalf("expected password policy error, got %v", err)
+	}
+
+	// A rejected password leaves the token usable
+	if err := svc.ResetPassword(ctx, token, "Tall-Birch-42"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	updated, _ := users.GetByID(ctx, user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(updated.PasswordHash), []byte("Tall-Birch-42")) != nil {
+		t.Fatal("password was not updated")
+	}
+	if _, _, err := sessions.Refresh(ctx, refreshToken); err == nil {
+		t.Fatal("existing refresh tokens must be revoked")
+	}
+	if err := sessions.Validate(ctx, session.ID); !errors.Is(err, services.ErrSessionRevoked) {
+		t.Fatalf("existing sessions must be revoked, got %v", err)
+	}
+
+	if err := svc.ResetPassword(ctx, token, "another-password"); !errors.Is(err, services.ErrResetTokenInvalid) {
+		t.Fatalf("token must be single-use, got %v", err)
+	}
+}
+
+func TestPasswordResetThrottlesPerAccount(t *testing.T) {
+	ctx := context.Background()
+	users := newMemoryUserRepo()
+	tokens := newMemoryPasswordResetRepo()
+	sessions := services.NewSessionService(newMemoryAuthSessionRepo(), services.NewRefreshTokenService(newMemoryRefreshTokenRepo()))
+	sender := utils.NewMemorySender()
+	svc := services.NewPasswordResetService(tokens, users, sessions, sender, "http://localhost:3000", validators.DefaultPasswordPolicy())
+	if err := users.Create(ctx, &models.User{ID: uuid.New(), Email: "mentor@example.com", Role: constants.RoleMentor}); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := svc.RequestReset(ctx, "mentor@example.com"); err != nil {
+			t.Fatalf("RequestReset: %v", err)
+		}
+		svc.Wait()
+	}
+	if n := len(sender.Messages()); n != 1 {
+		t.Fatalf("a second link within the resend interval should not be sent, got %d emails", n)
+	}
+
+	for i := 1; i < constants.PasswordResetMaxRequests+2; i++ {
+		tokens.backdate(constants.PasswordResetResendInterval + time.Second)
+		if err := svc.RequestReset(ctx, "mentor@example.com"); err != nil {
+			t.Fatalf("RequestReset: %v", err)
+		}
+		svc.Wait()
+	}
+	if n := len(sender.Messages()); n != constants.PasswordResetMaxRequests {
+		t.Fatalf("expected at most %d emails per window, got %d", constants.PasswordResetMaxRequests, n)
+	}
+}
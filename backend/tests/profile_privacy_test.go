@@ -0,0 +1,319 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mentori/internal/handlers"
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
+	"mentori/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type profileFixture struct {
+	router      *gin.Engine
+	profiles    *memoryProfileRepo
+	users       *memoryUserRepo
+	mentorships *memoryMentorshipRepo
+}
+
+func newProfileFixture(t *testing.T) *profileFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	f := &profileFixture{
+		profiles:    newMemoryProfileRepo(),
+		users:       newMemoryUserRepo(),
+		mentorships: newMemoryMentorshipRepo(),
+	}
+	privacy := services.NewProfilePrivacyService(newTestAuthorizationService(), f.mentorships)
+	store, err := storage.NewLocalStore(t.TempDir(), "http://localhost/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	h := handlers.NewProfileHandler(f.profiles, f.users, privacy, services.NewAvatarService(f.profiles, store), newTestTaxonomyService())
+
+	f.profiles.users = f.users
+
+	// Stand-in for OptionalJWTAuth: the viewer comes from test headers and
+	// requests without them are anonymous
+	f.router = gin.New()
+	profiles := f.router.Group("/profiles", func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set(constants.ContextKeyUserID, userID)
+			c.Set(constants.ContextKeyUserRole, c.GetHeader("X-Test-Role"))
+		}
+	})
+	profiles.GET("/public", h.GetPublicProfiles)
+	profiles.GET("/:id", h.GetProfileByID)
+	return f
+}
+
+func (f *profileFixture) addUser(t *testing.T, role string, profile *models.Profile) *models.User {
+	t.Helper()
+	ctx := context.Background()
+	user := &models.User{ID: uuid.New(), Email: role + uuid.NewString()[:8] + "@example.com", Role: role}
+	if err := f.users.Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if profile != nil {
+		profile.ID = uuid.New()
+		profile.UserID = user.ID
+		if profile.CreatedAt.IsZero() {
+			profile.CreatedAt = time.Now()
+		}
+		if err := f.profiles.Create(ctx, profile); err != nil {
+			t.Fatalf("create profile: %v", err)
+		}
+	}
+	return user
+}
+
+func (f *profileFixture) get(t *testing.T, viewer *models.User, profileID uuid.UUID) (int, models.ProfileView) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/profiles/"+profileID.String(), nil)
+	req.Header.Set("X-Test-User", viewer.ID.String())
+	req.Header.Set("X-Test-Role", viewer.Role)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+
+	var view models.ProfileView
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &view); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return w.Code, view
+}
+
+// search lists the profiles the viewer finds; a nil viewer is anonymous
+func (f *profileFixture) search(t *testing.T, viewer *models.User) []models.ProfileView {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/profiles/public", nil)
+	if viewer != nil {
+		req.Header.Set("X-Test-User", viewer.ID.String())
+		req.Header.Set("X-Test-Role", viewer.Role)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("search: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var page pagination.Page[models.ProfileView]
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return page.Items
+}
+
+func firstNames(views []models.ProfileView) map[string]bool {
+	names := make(map[string]bool)
+	for _, v := range views {
+		names[v.FirstName] = true
+	}
+	return names
+}
+
+func TestGetProfileByIDProjection(t *testing.T) {
+	f := newProfileFixture(t)
+	profile := &models.Profile{FirstName: "Aino", LastName: "Virtanen", Location: "Kallio, Helsinki", IsActive: true}
+	owner := f.addUser(t, constants.RoleMentor, profile)
+	stranger := f.addUser(t, constants.RoleMentee, nil)
+	mentee := f.addUser(t, constants.RoleMentee, nil)
+	admin := f.addUser(t, constants.RoleAdmin, nil)
+	f.mentorships.pair(owner.ID, mentee.ID)
+
+	code, view := f.get(t, stranger, profile.ID)
+	if code != http.StatusOK {
+		t.Fatalf("public view: expected 200, got %d", code)
+	}
+	if view.View != constants.ProfileViewPublic || view.LastName != "V." || view.Location != "Helsinki" || view.Email != "" {
+		t.Fatalf("unexpected public view: %+v", view)
+	}
+	if view.Role != constants.RoleMentor || view.FirstName != "Aino" {
+		t.Fatalf("public view should keep role and first name: %+v", view)
+	}
+
+	_, view = f.get(t, mentee, profile.ID)
+	if view.View != constants.ProfileViewConnected || view.LastName != "Virtanen" || view.Location != "Kallio, Helsinki" || view.Email != owner.Email {
+		t.Fatalf("unexpected connected view: %+v", view)
+	}
+
+	for _, viewer := range []*models.User{owner, admin} {
+		_, view = f.get(t, viewer, profile.ID)
+		if view.View != constants.ProfileViewPrivate || view.IsActive == nil || view.Email != owner.Email {
+			t.Fatalf("unexpected private view for %s: %+v", viewer.Role, view)
+		}
+	}
+}
+
+func TestGetProfileByIDPrivacySettings(t *testing.T) {
+	f := newProfileFixture(t)
+	profile := &models.Profile{FirstName: "Eero", LastName: "Korhonen", Location: "Tampere", IsActive: true, ShowLastName: true, ShowExactLocation: true}
+	f.addUser(t, constants.RoleMentor, profile)
+	stranger := f.addUser(t, constants.RoleMentee, nil)
+
+	_, view := f.get(t, stranger, profile.ID)
+	if view.LastName != "Korhonen" || view.Location != "Tampere" {
+		t.Fatalf("owner opted in to full name and location: %+v", view)
+	}
+}
+
+func TestGetProfileByIDInactive(t *testing.T) {
+	f := newProfileFixture(t)
+	profile := &models.Profile{FirstName: "Liisa", LastName: "Mäkinen", IsActive: false}
+	owner := f.addUser(t, constants.RoleMentor, profile)
+	stranger := f.addUser(t, constants.RoleMentee, nil)
+	admin := f.addUser(t, constants.RoleAdmin, nil)
+
+	if code, _ := f.get(t, stranger, profile.ID); code != http.StatusNotFound {
+		t.Fatalf("inactive profile: expected 404, got %d", code)
+	}
+	if code, _ := f.get(t, stranger, uuid.New()); code != http.StatusNotFound {
+		t.Fatalf("unknown profile: expected 404, got %d", code)
+	}
+	for _, viewer := range []*models.User{owner, admin} {
+		if code, _ := f.get(t, viewer, profile.ID); code != http.StatusOK {
+			t.Fatalf("inactive profile for %s: expected 200, got %d", viewer.Role, code)
+		}
+	}
+}
+
+func TestProfileSearchVisibility(t *testing.T) {
+	f := newProfileFixture(t)
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Everyone", IsActive: true, Visibility: constants.ProfileVisibilityEveryone})
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "SignedIn", IsActive: true, Visibility: constants.ProfileVisibilitySignedIn})
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Mentees", IsActive: true, Visibility: constants.ProfileVisibilityOppositeRole})
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Unlisted", IsActive: true, Visibility: constants.ProfileVisibilityEveryone, HiddenFromSearch: true})
+	mentee := f.addUser(t, constants.RoleMentee, nil)
+	mentor := f.addUser(t, constants.RoleMentor, nil)
+	admin := f.addUser(t, constants.RoleAdmin, nil)
+
+	for _, tc := range []struct {
+		name   string
+		viewer *models.User
+		want   []string
+	}{
+		{"anonymous", nil, []string{"Everyone"}},
+		{"mentee", mentee, []string{"Everyone", "SignedIn", "Mentees"}},
+		{"mentor", mentor, []string{"Everyone", "SignedIn"}},
+		{"admin", admin, []string{"Everyone", "SignedIn", "Mentees"}},
+	} {
+		got := firstNames(f.search(t, tc.viewer))
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+			continue
+		}
+		for _, name := range tc.want {
+			if !got[name] {
+				t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+			}
+		}
+	}
+}
+
+func TestUpdateProfileRejectsEmptyVisibility(t *testing.T) {
+	f := newProfileFixture(t)
+	owner := f.addUser(t, constants.RoleMentor, nil)
+	store, err := storage.NewLocalStore(t.TempDir(), "http://localhost/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	privacy := services.NewProfilePrivacyService(newTestAuthorizationService(), f.mentorships)
+	h := handlers.NewProfileHandler(f.profiles, f.users, privacy, services.NewAvatarService(f.profiles, store), newTestTaxonomyService())
+	f.router.PUT("/profiles", func(c *gin.Context) {
+		c.Set("user", jwt.MapClaims{"user_id": owner.ID.String()})
+	}, h.UpdateProfile)
+
+	update := func(body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/profiles", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		f.router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Neither when the update creates the profile nor when it changes it
+	if code := update(`{"visibility": ""}`); code != http.StatusBadRequest {
+		t.Fatalf("empty visibility on create: expected 400, got %d", code)
+	}
+	if code := update(`{"visibility": "signed_in"}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if code := update(`{"visibility": ""}`); code != http.StatusBadRequest {
+		t.Fatalf("empty visibility on update: expected 400, got %d", code)
+	}
+	profile, _ := f.profiles.GetByUserID(context.Background(), owner.ID)
+	if profile.Visibility != constants.ProfileVisibilitySignedIn {
+		t.Fatalf("visibility = %q, want signed_in", profile.Visibility)
+	}
+}
+
+func TestProfileVisibilityByID(t *testing.T) {
+	f := newProfileFixture(t)
+	mentees := &models.Profile{FirstName: "Aino", IsActive: true, Visibility: constants.ProfileVisibilityOppositeRole}
+	unlisted := &models.Profile{FirstName: "Eero", IsActive: true, Visibility: constants.ProfileVisibilityOppositeRole, HiddenFromSearch: true}
+	f.addUser(t, constants.RoleMentor, mentees)
+	unlistedOwner := f.addUser(t, constants.RoleMentee, unlisted)
+	mentee := f.addUser(t, constants.RoleMentee, nil)
+	mentor := f.addUser(t, constants.RoleMentor, nil)
+	partner := f.addUser(t, constants.RoleMentor, nil)
+	f.mentorships.pair(partner.ID, unlistedOwner.ID)
+
+	if code, _ := f.get(t, mentee, mentees.ID); code != http.StatusOK {
+		t.Fatalf("mentee viewing a mentor's opposite-role profile: expected 200, got %d", code)
+	}
+	if code, _ := f.get(t, mentor, mentees.ID); code != http.StatusNotFound {
+		t.Fatalf("mentor viewing a mentor's opposite-role profile: expected 404, got %d", code)
+	}
+
+	// Hiding from search keeps the profile reachable for existing mentorships
+	if names := firstNames(f.search(t, partner)); names["Eero"] {
+		t.Fatalf("profile hidden from search was listed: %v", names)
+	}
+	if code, view := f.get(t, partner, unlisted.ID); code != http.StatusOK || view.View != constants.ProfileViewConnected {
+		t.Fatalf("mentorship partner: expected connected view, got %d %+v", code, view)
+	}
+}
+
+func TestProfileHiddenFields(t *testing.T) {
+	f := newProfileFixture(t)
+	profile := &models.Profile{
+		FirstName:    "Liisa",
+		LastName:     "Mäkinen",
+		Bio:          "Product designer",
+		Expertise:    datatypes.JSON(`["design"]`),
+		Location:     "Turku",
+		Languages:    datatypes.JSON(`["fi","en"]`),
+		Availability: datatypes.JSON(`["weekday_evening"]`),
+		IsActive:     true,
+		HiddenFields: datatypes.JSON(`["bio","expertise","location","languages","availability"]`),
+	}
+	owner := f.addUser(t, constants.RoleMentor, profile)
+	stranger := f.addUser(t, constants.RoleMentee, nil)
+	mentee := f.addUser(t, constants.RoleMentee, nil)
+	f.mentorships.pair(owner.ID, mentee.ID)
+
+	_, view := f.get(t, stranger, profile.ID)
+	if view.Bio != "" || view.Location != "" || string(view.Expertise) != "[]" || string(view.Languages) != "[]" || string(view.Availability) != "[]" || view.FirstName != "Liisa" {
+		t.Fatalf("hidden fields shown to a public viewer: %+v", view)
+	}
+
+	_, view = f.get(t, mentee, profile.ID)
+	if view.Bio != "Product designer" || view.Location != "Turku" || string(view.Expertise) != `["design"]` || string(view.Languages) != `["fi","en"]` {
+		t.Fatalf("hidden fields should be shown to a mentorship partner: %+v", view)
+	}
+}
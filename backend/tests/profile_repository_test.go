@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"mentori/internal/models"
+	gormrepo "mentori/internal/repository/gorm"
+	"mentori/pkg/constants"
+	"mentori/pkg/database"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newDryRunDB returns a Postgres GORM handle that builds statements without
+// running them, and the SQL of every query it was asked to run
+func newDryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=mentori_dry_run"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open dry-run database: %v", err)
+	}
+	var statements []string
+	capture := func(tx *gorm.DB) {
+		statements = append(statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+	}
+	if err := db.Callback().Query().After("gorm:query").Register("tests:capture", capture); err != nil {
+		t.Fatalf("register capture callback: %v", err)
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("tests:capture", capture); err != nil {
+		t.Fatalf("register capture callback: %v", err)
+	}
+	return db, &statements
+}
+
+// newPostgresDB migrates the database at DATABASE_URL as the server does and
+// returns a transaction that is rolled back when the test ends. Tests that
+// need it are skipped when DATABASE_URL is not set.
+func newPostgresDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	t.Chdir("..") // The SQL migrations are read from ./migrations
+	database.InitDB(url)
+	tx := database.GetDB().Begin()
+	if tx.Error != nil {
+		t.Fatalf("begin: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+// searchSQL returns the SQL the profile repository runs to search with filters
+func searchSQL(t *testing.T, filters *models.ProfileFilters) string {
+	t.Helper()
+	db, statements := newDryRunDB(t)
+	// Scanning rows is not supported in dry-run mode, the statement is built regardless
+	_, err := gormrepo.NewProfileRepository(db).Search(context.Background(), filters, 10, nil)
+	if err != nil && !errors.Is(err, gorm.ErrDryRunModeUnsupported) {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(*statements) != 1 {
+		t.Fatalf("expected one query, got %d", len(*statements))
+	}
+	return (*statements)[0]
+}
+
+func TestProfileSearchSQLMatchesCoarseLocation(t *testing.T) {
+	sql := searchSQL(t, &models.ProfileFilters{Location: "kallio"})
+	if !strings.Contains(sql, "CASE WHEN profiles.show_exact_location THEN profiles.location") {
+		t.Fatalf("public search must match the coarse location of profiles without an exact location:\n%s", sql)
+	}
+
+	sql = searchSQL(t, &models.ProfileFilters{Location: "kallio", Scope: &models.ProfileSearchScope{All: true}})
+	if strings.Contains(sql, "show_exact_location") {
+		t.Fatalf("staff search should match the exact location:\n%s", sql)
+	}
+}
+
+func TestProfileSearchSQLMatchesAnyTerm(t *testing.T) {
+	expertise := []string{"go", "product-management"}
+	interests := []string{"climbing"}
+	sql := searchSQL(t, &models.ProfileFilters{Expertise: &expertise, Interests: &interests})
+
+	for _, want := range []string{
+		"jsonb_exists_any(profiles.expertise, ARRAY['go','product-management']::text[])",
+		"jsonb_exists_any(profiles.interests, ARRAY['climbing']::text[])",
+	} {
+		if !strings.Contains(sql, want) {
+			t.Fatalf("expected %s in:\n%s", want, sql)
+		}
+	}
+}
+
+func TestProfileSearchPostgres(t *testing.T) {
+	db := newPostgresDB(t)
+	ctx := context.Background()
+	repo := gormrepo.NewProfileRepository(db)
+
+	add := func(firstName, bio string) uuid.UUID {
+		user := &models.User{ID: uuid.New(), Role: constants.RoleMentor}
+		user.Email = user.ID.String() + "@example.com"
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		profile := &models.Profile{ID: uuid.New(), UserID: user.ID, FirstName: firstName, Bio: bio, IsActive: true}
+		if err := db.Create(profile).Error; err != nil {
+			t.Fatalf("create profile: %v", err)
+		}
+		return profile.ID
+	}
+	librarian := add("Aino", "Työskentelen kirjastossa ja harrastan suunnistusta.")
+	teacher := add("Mikko", "Backend developer who writes Python and teaches Python to beginners.")
+	analyst := add("Liisa", "Data analyst, with some Python on the side.")
+	johanna := add("Johanna", "Product designer.")
+
+	// search returns the test's profiles found for the query, best first,
+	// leaving out any other rows in the database
+	ours := map[uuid.UUID]bool{librarian: true, teacher: true, analyst: true, johanna: true}
+	search := func(q string) []*models.ProfileSearchResult {
+		t.Helper()
+		results, err := repo.Search(ctx, &models.ProfileFilters{Query: q}, 100, nil)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", q, err)
+		}
+		var found []*models.ProfileSearchResult
+		for _, r := range results {
+			if ours[r.Profile.ID] {
+				found = append(found, r)
+			}
+		}
+		return found
+	}
+
+	// Ranked by ts_rank, the bio mentioning Python twice first
+	found := search("python")
+	if len(found) != 2 || found[0].Profile.ID != teacher || found[1].Profile.ID != analyst {
+		t.Fatalf("expected Mikko ranked above Liisa, got %d results", len(found))
+	}
+	if found[0].Rank <= found[1].Rank {
+		t.Fatalf("expected descending ranks, got %v and %v", found[0].Rank, found[1].Rank)
+	}
+	if !strings.Contains(found[1].Highlight, "<mark>Python</mark>") {
+		t.Fatalf("expected ts_headline to mark the match, got %q", found[1].Highlight)
+	}
+
+	// Finnish stemming matches the inessive form in the bio
+	found = search("kirjasto")
+	if len(found) != 1 || found[0].Profile.ID != librarian {
+		t.Fatalf("expected the Finnish bio to match its stem, got %d results", len(found))
+	}
+	if !strings.Contains(found[0].Highlight, "<mark>kirjastossa</mark>") {
+		t.Fatalf("expected the inflected word to be marked, got %q", found[0].Highlight)
+	}
+
+	// A misspelled name is found through trigram similarity alone
+	found = search("Johana")
+	if len(found) != 1 || found[0].Profile.ID != johanna {
+		t.Fatalf("expected the misspelled name to find Johanna, got %d results", len(found))
+	}
+	if found[0].Rank <= 0 {
+		t.Fatalf("expected a positive trigram rank, got %v", found[0].Rank)
+	}
+}
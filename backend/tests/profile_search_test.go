@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"mentori/internal/models"
+	"mentori/pkg/constants"
+	"mentori/pkg/pagination"
+
+	"gorm.io/datatypes"
+)
+
+// query runs an anonymous free-text search
+func (f *profileFixture) query(t *testing.T, q string) (int, []models.ProfileView) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/profiles/public?q="+url.QueryEscape(q), nil)
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+
+	var page pagination.Page[models.ProfileView]
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return w.Code, page.Items
+}
+
+func TestProfileSearchQuery(t *testing.T) {
+	f := newProfileFixture(t)
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Aino", Bio: "I moved to Helsinki for a job in design", IsActive: true,
+		Expertise: datatypes.JSON(`["find-a-job"]`)})
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Mikko", Bio: "Happy to talk about Helsinki", IsActive: true})
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Liisa", Bio: "Tampere based", IsActive: true})
+
+	code, views := f.query(t, "helsinki job")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(views) != 2 || views[0].FirstName != "Aino" || views[1].FirstName != "Mikko" {
+		t.Fatalf("expected Aino ranked above Mikko, got %+v", views)
+	}
+	if views[0].Rank == nil || views[1].Rank == nil || *views[0].Rank <= *views[1].Rank {
+		t.Fatalf("expected descending ranks, got %v and %v", views[0].Rank, views[1].Rank)
+	}
+	if !strings.Contains(views[1].Highlight, "<mark>Helsinki</mark>") {
+		t.Fatalf("expected a highlighted bio excerpt, got %q", views[1].Highlight)
+	}
+
+	// Without a query there is no rank or excerpt
+	for _, v := range f.search(t, nil) {
+		if v.Rank != nil || v.Highlight != "" {
+			t.Fatalf("unexpected search fields without a query: %+v", v)
+		}
+	}
+}
+
+func TestProfileSearchQuerySkipsHiddenFields(t *testing.T) {
+	f := newProfileFixture(t)
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Aino", LastName: "Virtanen", Bio: "Designer in Oulu", IsActive: true,
+		HiddenFields: datatypes.JSON(`["bio"]`)})
+	f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Mikko", LastName: "Korhonen", IsActive: true, ShowLastName: true})
+
+	if _, views := f.query(t, "oulu"); len(views) != 0 {
+		t.Fatalf("hidden bio should not be searchable, got %+v", views)
+	}
+	if _, views := f.query(t, "virtanen"); len(views) != 0 {
+		t.Fatalf("last name shown as an initial should not be searchable, got %+v", views)
+	}
+	if _, views := f.query(t, "korhonen"); len(views) != 1 || views[0].FirstName != "Mikko" {
+		t.Fatalf("expected the last name shown in full to be searchable, got %+v", views)
+	}
+}
+
+func TestProfileSearchQueryTooLong(t *testing.T) {
+	f := newProfileFixture(t)
+	if code, _ := f.query(t, strings.Repeat("a", constants.ProfileSearchMaxQueryLength+1)); code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", code)
+	}
+}
@@ -0,0 +1,353 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mentori/internal/models"
+	"mentori/internal/server"
+	"mentori/internal/services"
+	"mentori/pkg/config"
+	"mentori/pkg/constants"
+	"mentori/pkg/storage"
+	"mentori/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// serverFixture serves the routes of cmd/server, middleware included, over
+// in-memory repositories. Callers authenticate as clients do, with access
+// tokens, API keys or impersonation tokens.
+type serverFixture struct {
+	server            *server.Server
+	tokens            *services.TokenIssuer
+	sessions          *services.SessionService
+	email             *utils.MemorySender
+	users             *memoryUserRepo
+	profiles          *memoryProfileRepo
+	mentorships       *memoryMentorshipRepo
+	roles             *memoryRoleRepo
+	apiKeys           *memoryAPIKeyRepo
+	impersonationLogs *memoryImpersonationLogRepo
+	mentoringSessions *memoryMentoringSessionRepo
+	mfaRecoveryCodes  *memoryMFARecoveryCodeRepo
+}
+
+// newServerFixture starts the server with the default settings, changed by
+// configure if given
+func newServerFixture(t *testing.T, configure ...func(cfg *config.Config)) *serverFixture {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		FrontendURL:             "http://localhost:3000",
+		PasswordMinLength:       constants.DefaultPasswordMinLength,
+		PasswordMinClasses:      constants.DefaultPasswordMinClasses,
+		AvatarStorage:           "local",
+		UploadsDir:              t.TempDir(),
+		UploadsBaseURL:          "http://localhost/uploads",
+		MatchWeightExpertise:    constants.DefaultMatchWeightExpertise,
+		MatchWeightInterests:    constants.DefaultMatchWeightInterests,
+		MatchWeightLocation:     constants.DefaultMatchWeightLocation,
+		MatchWeightLanguage:     constants.DefaultMatchWeightLanguage,
+		MatchWeightAvailability: constants.DefaultMatchWeightAvailability,
+		MatchWeightCapacity:     constants.DefaultMatchWeightCapacity,
+	}
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	avatars, err := storage.NewLocalStore(cfg.UploadsDir, cfg.UploadsBaseURL)
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+
+	f := &serverFixture{
+		tokens:            newTestTokenIssuer(t, services.TokenIssuerConfig{}),
+		email:             utils.NewMemorySender(),
+		users:             newMemoryUserRepo(),
+		profiles:          newMemoryProfileRepo(),
+		mentorships:       newMemoryMentorshipRepo(),
+		roles:             newMemoryRoleRepo(),
+		apiKeys:           newMemoryAPIKeyRepo(),
+		impersonationLogs: newMemoryImpersonationLogRepo(),
+		mentoringSessions: newMemoryMentoringSessionRepo(),
+		mfaRecoveryCodes:  newMemoryMFARecoveryCodeRepo(),
+	}
+	f.profiles.users = f.users
+	refreshTokens, authSessions := newMemoryRefreshTokenRepo(), newMemoryAuthSessionRepo()
+	f.sessions = services.NewSessionService(authSessions, services.NewRefreshTokenService(refreshTokens))
+
+	f.server = server.New(cfg, server.Repositories{
+		Users:              f.users,
+		Profiles:           f.profiles,
+		RefreshTokens:      refreshTokens,
+		EmailVerifications: newMemoryEmailVerificationRepo(),
+		PasswordResets:     newMemoryPasswordResetRepo(),
+		Identities:         newMemoryUserIdentityRepo(),
+		MFARecoveryCodes:   f.mfaRecoveryCodes,
+		MFAChallenges:      newMemoryMFAChallengeRepo(),
+		OAuthNonces:        newMemoryOAuthNonceRepo(),
+		Sessions:           authSessions,
+		LoginThrottles:     newMemoryLoginThrottleRepo(),
+		LockoutEvents:      newMemoryLockoutEventRepo(),
+		MagicLinks:         newMemoryMagicLinkRepo(),
+		ImpersonationLogs:  f.impersonationLogs,
+		Roles:              f.roles,
+		APIKeys:            f.apiKeys,
+		MentorApplications: newMemoryMentorApplicationRepo(f.users),
+		Mentorships:        f.mentorships,
+		Taxonomy:           newTestTaxonomyRepo(),
+		Cohorts:            newMemoryCohortRepo(f.mentorships),
+		Availability:       newMemoryAvailabilityRepo(),
+		MentoringSessions:  f.mentoringSessions,
+	}, server.Dependencies{
+		Email:   f.email,
+		Avatars: avatars,
+		Tokens:  f.tokens,
+		OAuth:   services.NewOAuthService(services.OAuthConfig{}),
+	})
+	t.Cleanup(f.server.Wait)
+	return f
+}
+
+// addUser creates a user, with the profile if it is not nil
+func (f *serverFixture) addUser(t *testing.T, role string, profile *models.Profile) *models.User {
+	t.Helper()
+	ctx := context.Background()
+	user := &models.User{ID: uuid.New(), Email: role + uuid.NewString()[:8] + "@example.com", Role: role, IsVerified: true}
+	if err := f.users.Create(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if profile != nil {
+		profile.ID = uuid.New()
+		profile.UserID = user.ID
+		if profile.CreatedAt.IsZero() {
+			profile.CreatedAt = time.Now()
+		}
+		if err := f.profiles.Create(ctx, profile); err != nil {
+			t.Fatalf("create profile: %v", err)
+		}
+	}
+	return user
+}
+
+// signIn starts a login session for the user and returns the Authorization
+// header of its access token. mfa marks the session as two-factor verified.
+func (f *serverFixture) signIn(t *testing.T, user *models.User, mfa bool) string {
+	t.Helper()
+	_, session, err := f.sessions.Start(context.Background(), user.ID, mfa, services.SessionInfo{})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	return "Bearer " + f.accessToken(t, user, session.ID, mfa)
+}
+
+// accessToken signs an access token for the session as the login handlers do
+func (f *serverFixture) accessToken(t *testing.T, user *models.User, sessionID uuid.UUID, mfa bool) string {
+	t.Helper()
+	token, err := f.tokens.Sign(jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"email":   user.Email,
+		"role":    user.Role,
+		"sid":     sessionID.String(),
+		"typ":     constants.TokenTypeAccess,
+		"mfa":     mfa,
+		"exp":     time.Now().Add(constants.AccessTokenExpiry).Unix(),
+		"iat":     time.Now().Unix(),
+	})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+// apiKey creates a personal API key for the user and returns its
+// Authorization header
+func (f *serverFixture) apiKey(t *testing.T, user *models.User, scopes ...string) string {
+	t.Helper()
+	raw, _, err := services.NewAPIKeyService(f.apiKeys, f.users).Create(context.Background(), user.ID, "test", scopes, 0)
+	if err != nil {
+		t.Fatalf("create API key: %v", err)
+	}
+	return constants.APIKeyAuthScheme + " " + raw
+}
+
+// impersonate signs the admin in and returns the Authorization header of a
+// token for acting as the user
+func (f *serverFixture) impersonate(t *testing.T, admin, user *models.User) string {
+	t.Helper()
+	ctx := context.Background()
+	_, session, err := f.sessions.Start(ctx, admin.ID, true, services.SessionInfo{})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	impersonation := services.NewImpersonationService(f.impersonationLogs, f.users, f.tokens, services.NewAuthorizationService(f.roles))
+	token, _, err := impersonation.Start(ctx, admin.ID, session.ID, user.ID, "support ticket", "")
+	if err != nil {
+		t.Fatalf("impersonate: %v", err)
+	}
+	return "Bearer " + token
+}
+
+// send serves the request with the Authorization header, if any
+func (f *serverFixture) send(req *http.Request, auth string) *httptest.ResponseRecorder {
+	if auth != "" {
+		req.Header.Set(constants.HeaderAuthorization, auth)
+	}
+	w := httptest.NewRecorder()
+	f.server.Handler().ServeHTTP(w, req)
+	return w
+}
+
+// do sends a JSON request to the /api/v1 path and decodes a successful
+// response into out
+func (f *serverFixture) do(t *testing.T, auth, method, path string, body, out interface{}) (int, models.ErrorResponse) {
+	t.Helper()
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		reader = &buf
+	}
+	req := httptest.NewRequest(method, "/api/v1"+path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := f.send(req, auth)
+
+	var failure models.ErrorResponse
+	target := interface{}(&failure)
+	if w.Code < 300 {
+		target = out
+	}
+	if target != nil && w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), target); err != nil {
+			t.Fatalf("decode %s %s: %v", method, path, err)
+		}
+	}
+	return w.Code, failure
+}
+
+func TestServerRejectsImpersonatedWrites(t *testing.T) {
+	f := newServerFixture(t)
+	admin := f.addUser(t, constants.RoleAdmin, nil)
+	mentor := f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Aino", IsActive: true})
+	auth := f.impersonate(t, admin, mentor)
+
+	// Reads go through and are audited
+	if code, failure := f.do(t, auth, http.MethodGet, "/auth/profile", nil, nil); code != http.StatusOK {
+		t.Fatalf("GET /auth/profile: expected 200, got %d %+v", code, failure)
+	}
+	if code, failure := f.do(t, auth, http.MethodGet, "/availability", nil, nil); code != http.StatusOK {
+		t.Fatalf("GET /availability: expected 200, got %d %+v", code, failure)
+	}
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/profiles"},
+		{http.MethodPut, "/profiles"},
+		{http.MethodDelete, "/profiles"},
+		{http.MethodPost, "/profiles/me/image"},
+		{http.MethodPut, "/availability"},
+		{http.MethodPost, "/availability/overrides"},
+		{http.MethodDelete, "/availability/overrides/" + uuid.NewString()},
+		{http.MethodPost, "/availability/blackouts"},
+		{http.MethodDelete, "/availability/blackouts/" + uuid.NewString()},
+		{http.MethodPost, "/auth/password"},
+		{http.MethodPost, "/auth/api-keys"},
+		{http.MethodPost, "/auth/mfa/setup"},
+		{http.MethodPost, "/auth/oauth/link/nonce"},
+		{http.MethodPost, "/auth/sessions/revoke-others"},
+	} {
+		if code, _ := f.do(t, auth, route.method, route.path, struct{}{}, nil); code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403 when impersonating, got %d", route.method, route.path, code)
+		}
+	}
+
+	// Every request made with the token is in the audit log
+	entries, _ := f.impersonationLogs.List(context.Background(), &mentor.ID, 100, nil)
+	var audited int
+	for _, e := range entries {
+		if e.Action == constants.ImpersonationActionRequest {
+			audited++
+		}
+	}
+	if audited != 16 {
+		t.Fatalf("expected 16 audited requests, got %d", audited)
+	}
+}
+
+func TestServerLimitsAPIKeys(t *testing.T) {
+	f := newServerFixture(t)
+	mentor := f.addUser(t, constants.RoleMentor, &models.Profile{FirstName: "Aino", IsActive: true})
+	read := f.apiKey(t, mentor, constants.APIKeyScopeRead)
+	write := f.apiKey(t, mentor, constants.APIKeyScopeRead, constants.APIKeyScopeWrite)
+
+	if code, failure := f.do(t, read, http.MethodGet, "/profiles", nil, nil); code != http.StatusOK {
+		t.Fatalf("read with read scope: expected 200, got %d %+v", code, failure)
+	}
+	if code, _ := f.do(t, read, http.MethodPut, "/profiles", struct{}{}, nil); code != http.StatusForbidden {
+		t.Fatalf("write with read scope: expected 403, got %d", code)
+	}
+	if code, failure := f.do(t, write, http.MethodPut, "/profiles", map[string]string{"bio": "Updated by a script"}, nil); code != http.StatusOK {
+		t.Fatalf("write with write scope: expected 200, got %d %+v", code, failure)
+	}
+
+	// Account security and admin routes need a signed-in user
+	for _, route := range []struct{ method, path string }{
+		{http.MethodDelete, "/profiles"},
+		{http.MethodPost, "/auth/password"},
+		{http.MethodGet, "/auth/sessions"},
+		{http.MethodPost, "/auth/api-keys"},
+		{http.MethodPost, "/auth/mfa/setup"},
+		{http.MethodPost, "/auth/mfa/disable"},
+		{http.MethodGet, "/auth/oauth/identities"},
+		{http.MethodPost, "/auth/mentor-application"},
+		{http.MethodGet, "/admin/lockouts"},
+	} {
+		if code, _ := f.do(t, write, route.method, route.path, struct{}{}, nil); code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403 with an API key, got %d", route.method, route.path, code)
+		}
+	}
+}
+
+func TestServerRequiresMFAForMFAChanges(t *testing.T) {
+	f := newServerFixture(t)
+	user := newMFATestUser(t, f.users, constants.RoleAdmin)
+	user.MFAEnabled = true
+	user.MFASecret = "JBSWY3DPEHPK3PXP"
+	if err := f.users.Update(context.Background(), user); err != nil {
+		t.Fatalf("enable MFA: %v", err)
+	}
+	change := models.MFAChangeRequest{Password: mfaTestPassword, Code: "123456"}
+
+	// A session that has not completed two-factor login cannot turn it off
+	if status, _ := f.do(t, f.signIn(t, user, false), http.MethodPost, "/auth/mfa/disable", change, nil); status != http.StatusForbidden {
+		t.Fatalf("disable without MFA session: expected 403, got %d", status)
+	}
+	if status, _ := f.do(t, f.signIn(t, user, false), http.MethodPost, "/auth/mfa/recovery-codes", change, nil); status != http.StatusForbidden {
+		t.Fatalf("recovery codes without MFA session: expected 403, got %d", status)
+	}
+
+	// Wrong passwords count towards the login throttle
+	auth := f.signIn(t, user, true)
+	wrong := models.MFAChangeRequest{Password: "Wrong-Password-1", Code: "123456"}
+	for i := 0; i <= constants.AccountFreeAttempts; i++ {
+		if status, _ := f.do(t, auth, http.MethodPost, "/auth/mfa/disable", wrong, nil); status != http.StatusUnauthorized {
+			t.Fatalf("attempt %d with a wrong password: expected 401, got %d", i+1, status)
+		}
+	}
+	if status, _ := f.do(t, auth, http.MethodPost, "/auth/mfa/disable", change, nil); status != http.StatusTooManyRequests {
+		t.Fatalf("after repeated failures: expected 429, got %d", status)
+	}
+	if stored, _ := f.users.GetByID(context.Background(), user.ID); !stored.MFAEnabled {
+		t.Fatal("MFA was disabled despite the throttle")
+	}
+}
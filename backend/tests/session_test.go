@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mentori/internal/middleware"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const chromeOnWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func newTestSessionService() *services.SessionService {
+	return services.NewSessionService(newMemoryAuthSessionRepo(), services.NewRefreshTokenService(newMemoryRefreshTokenRepo()))
+}
+
+func TestSessionStartAndList(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestSessionService()
+	userID := uuid.New()
+
+	_, browser, err := svc.Start(ctx, userID, false, services.SessionInfo{UserAgent: chromeOnWindows, IPAddress: "203.0.113.7"})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if browser.DeviceName != "Chrome on Windows" || browser.IPAddress != "203.0.113.7" {
+		t.Fatalf("unexpected session: %+v", browser)
+	}
+
+	_, phone, err := svc.Start(ctx, userID, false, services.SessionInfo{DeviceName: "Aino's phone"})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if phone.DeviceName != "Aino's phone" {
+		t.Fatalf("client-supplied device name should be kept, got %q", phone.DeviceName)
+	}
+
+	sessions, err := svc.List(ctx, userID)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+}
+
+func TestSessionRevokeOthers(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestSessionService()
+	userID := uuid.New()
+
+	_, current, _ := svc.Start(ctx, userID, false, services.SessionInfo{})
+	otherRefresh, other, _ := svc.Start(ctx, userID, false, services.SessionInfo{})
+
+	// Users cannot revoke someone else's session
+	if err := svc.Revoke(ctx, uuid.New(), current.ID); !errors.Is(err, services.ErrSessionNotFound) {
+		t.Fatalf("expected not found for another user's session, got %v", err)
+	}
+
+	revoked, err := svc.RevokeOthers(ctx, userID, current.ID)
+	if err != nil || revoked != 1 {
+		t.Fatalf("RevokeOthers: revoked=%d err=%v", revoked, err)
+	}
+	if err := svc.Validate(ctx, other.ID); !errors.Is(err, services.ErrSessionRevoked) {
+		t.Fatalf("expected other session to be revoked, got %v", err)
+	}
+	if _, _, err := svc.Refresh(ctx, otherRefresh); err == nil {
+		t.Fatal("refresh token of a revoked session must not rotate")
+	}
+	if err := svc.Validate(ctx, current.ID); err != nil {
+		t.Fatalf("current session must stay active: %v", err)
+	}
+}
+
+func TestSessionRefreshTokenReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestSessionService()
+
+	first, session, _ := svc.Start(ctx, uuid.New(), false, services.SessionInfo{})
+	if _, _, err := svc.Refresh(ctx, first); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if _, _, err := svc.Refresh(ctx, first); !errors.Is(err, services.ErrRefreshTokenReused) {
+		t.Fatalf("expected reuse error, got %v", err)
+	}
+	if err := svc.Validate(ctx, session.ID); !errors.Is(err, services.ErrSessionRevoked) {
+		t.Fatalf("expected session to be revoked after reuse, got %v", err)
+	}
+}
+
+func TestJWTAuthRejectsRevokedSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	issuer := newTestTokenIssuer(t, services.TokenIssuerConfig{})
+	svc := newTestSessionService()
+	userID := uuid.New()
+
+	_, session, _ := svc.Start(ctx, userID, false, services.SessionInfo{})
+	token, err := issuer.Sign(jwt.MapClaims{
+		"user_id": userID.String(),
+		"email":   "mentee@example.com",
+		"role":    constants.RoleMentee,
+		"sid":     session.ID.String(),
+		"typ":     constants.TokenTypeAccess,
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/me", middleware.JWTAuth(issuer, svc, nil), func(c *gin.Context) { c.Status(http.StatusOK) })
+	request := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(constants.HeaderAuthorization, "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("expected 200 for an active session, got %d", code)
+	}
+	if err := svc.Revoke(ctx, userID, session.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if code := request(); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 after revocation, got %d", code)
+	}
+}
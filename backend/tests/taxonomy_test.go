@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mentori/internal/handlers"
+	"mentori/internal/models"
+	"mentori/internal/services"
+	"mentori/pkg/constants"
+	"mentori/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// newTestTaxonomyService serves a few terms, including a merged and a
+// deprecated one
+func newTestTaxonomyService() *services.TaxonomyService {
+	return services.NewTaxonomyService(newTestTaxonomyRepo())
+}
+
+// newTestTaxonomyRepo holds the terms of newTestTaxonomyService
+func newTestTaxonomyRepo() *memoryTaxonomyRepo {
+	retired := time.Now().Add(-24 * time.Hour)
+	merged := "finnish-language-learning"
+	return newMemoryTaxonomyRepo(
+		&models.TaxonomyTerm{Kind: constants.TaxonomyKindExpertise, Slug: "find-a-job", LabelEN: "Find a Job", LabelFI: "Työnhaku", SortOrder: 10},
+		&models.TaxonomyTerm{Kind: constants.TaxonomyKindExpertise, Slug: "finnish-language-learning", LabelEN: "Finnish Language Learning", LabelFI: "Suomen kielen opiskelu", SortOrder: 20},
+		&models.TaxonomyTerm{Kind: constants.TaxonomyKindExpertise, Slug: "yki-test-preparation", LabelEN: "YKI Test Preparation", LabelFI: "YKI-testiin valmistautuminen", SortOrder: 30, DeprecatedAt: &retired, MergedInto: &merged},
+		&models.TaxonomyTerm{Kind: constants.TaxonomyKindInterest, Slug: "coffee-culture", LabelEN: "Coffee Culture", LabelFI: "Kahvikulttuuri", SortOrder: 10},
+		&models.TaxonomyTerm{Kind: constants.TaxonomyKindInterest, Slug: "bars-nightlife", LabelEN: "Bars & Nightlife", LabelFI: "Baarit ja yöelämä", SortOrder: 20, DeprecatedAt: &retired},
+	)
+}
+
+func TestTaxonomyNormalize(t *testing.T) {
+	svc := newTestTaxonomyService()
+	ctx := context.Background()
+
+	slugs, err := svc.Normalize(ctx, constants.TaxonomyKindExpertise, []string{"find-a-job", "yki-test-preparation", "Finnish Language Learning"}, nil)
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if len(slugs) != 2 || slugs[0] != "find-a-job" || slugs[1] != "finnish-language-learning" {
+		t.Fatalf("expected merged and deduplicated slugs, got %v", slugs)
+	}
+
+	if _, err := svc.Normalize(ctx, constants.TaxonomyKindExpertise, []string{"coffee-culture"}, nil); !errors.Is(err, services.ErrTaxonomyUnknown) {
+		t.Fatalf("interest as expertise: expected ErrTaxonomyUnknown, got %v", err)
+	}
+	if _, err := svc.Normalize(ctx, constants.TaxonomyKindInterest, []string{"bars-nightlife"}, nil); !errors.Is(err, services.ErrTaxonomyDeprecated) {
+		t.Fatalf("new deprecated term: expected ErrTaxonomyDeprecated, got %v", err)
+	}
+	if slugs, err := svc.Normalize(ctx, constants.TaxonomyKindInterest, []string{"bars-nightlife", "coffee-culture"}, []string{"bars-nightlife"}); err != nil || len(slugs) != 2 {
+		t.Fatalf("keeping a deprecated term: got %v, %v", slugs, err)
+	}
+}
+
+func TestGetTaxonomyLocalized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/taxonomy", handlers.NewTaxonomyHandler(newTestTaxonomyService()).GetTaxonomy)
+
+	for header, want := range map[string]string{
+		"":                           constants.LanguageEnglish,
+		"fi-FI,fi;q=0.9,en;q=0.8":    constants.LanguageFinnish,
+		"en;q=0.5, fi;q=0.8":         constants.LanguageFinnish,
+		"de-DE,de;q=0.9,en-GB;q=0.5": constants.LanguageEnglish,
+		"sv":                         constants.LanguageEnglish,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/taxonomy", nil)
+		req.Header.Set("Accept-Language", header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d", header, w.Code)
+		}
+
+		var resp models.TaxonomyResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.Language != want || w.Header().Get("Content-Language") != want {
+			t.Fatalf("%q: expected %s, got %s", header, want, resp.Language)
+		}
+		if len(resp.Expertise) != 2 || len(resp.Interests) != 1 {
+			t.Fatalf("%q: deprecated terms should not be offered: %+v", header, resp)
+		}
+		wantLabel := map[string]string{constants.LanguageEnglish: "Find a Job", constants.LanguageFinnish: "Työnhaku"}[want]
+		if resp.Expertise[0].Slug != "find-a-job" || resp.Expertise[0].Label != wantLabel {
+			t.Fatalf("%q: unexpected first term %+v", header, resp.Expertise[0])
+		}
+	}
+}
+
+func TestUpdateProfileValidatesTaxonomy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := newMemoryUserRepo()
+	profiles := newMemoryProfileRepo()
+	user := &models.User{ID: uuid.New(), Email: "taxonomy@example.com", Role: constants.RoleMentor}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	store, err := storage.NewLocalStore(t.TempDir(), "http://localhost/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	privacy := services.NewProfilePrivacyService(newTestAuthorizationService(), newMemoryMentorshipRepo())
+	h := handlers.NewProfileHandler(profiles, users, privacy, services.NewAvatarService(profiles, store), newTestTaxonomyService())
+
+	router := gin.New()
+	router.PUT("/profiles", func(c *gin.Context) {
+		c.Set("user", jwt.MapClaims{"user_id": user.ID.String()})
+	}, h.UpdateProfile)
+
+	update := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/profiles", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := update(`{"expertise": ["Find a Job", "yki-test-preparation"], "interests": ["coffee-culture"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	profile, _ := profiles.GetByUserID(context.Background(), user.ID)
+	if string(profile.Expertise) != `["find-a-job","finnish-language-learning"]` || string(profile.Interests) != `["coffee-culture"]` {
+		t.Fatalf("expected slugs, got %s and %s", profile.Expertise, profile.Interests)
+	}
+
+	w = update(`{"interests": ["underwater-basket-weaving"]}`)
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusBadRequest || resp.Field != "interests" {
+		t.Fatalf("unknown interest: expected 400 on interests, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mentori/internal/services"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestTokenIssuer(t *testing.T, cfg services.TokenIssuerConfig) *services.TokenIssuer {
+	t.Helper()
+	if cfg.Issuer == "" {
+		cfg.Issuer = "mentori-api"
+	}
+	issuer, err := services.NewTokenIssuer(cfg)
+	if err != nil {
+		t.Fatalf("NewTokenIssuer: %v", err)
+	}
+	return issuer
+}
+
+func accessClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"user_id": "3f2504e0-4f89-11d3-9a0c-0305e82c3301",
+		"aud":     "mentori-messaging",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+}
+
+func TestTokenIssuerSignAndParse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	for name, cfg := range map[string]services.TokenIssuerConfig{
+		"EdDSA": {},
+		"RS256": {SigningKey: rsaKey},
+	} {
+		t.Run(name, func(t *testing.T) {
+			issuer := newTestTokenIssuer(t, cfg)
+			signed, err := issuer.Sign(accessClaims())
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+
+			header, _, err := jwt.NewParser().ParseUnverified(signed, jwt.MapClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified: %v", err)
+			}
+			if header.Method.Alg() != name || header.Header["kid"] != issuer.KeyID() {
+				t.Fatalf("unexpected header %v", header.Header)
+			}
+
+			claims, err := issuer.Parse(signed)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if claims["iss"] != "mentori-api" {
+				t.Fatalf("expected issuer claim, got %v", claims["iss"])
+			}
+		})
+	}
+}
+
+func TestTokenIssuerRejectsForeignTokens(t *testing.T) {
+	issuer := newTestTokenIssuer(t, services.TokenIssuerConfig{})
+	other := newTestTokenIssuer(t, services.TokenIssuerConfig{})
+
+	forged, _ := other.Sign(accessClaims())
+	if _, err := issuer.Parse(forged); !errors.Is(err, services.ErrUnknownKeyID) {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+
+	// An HS256 token keyed with the public key must not pass as the real key
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims())
+	hmac.Header["kid"] = issuer.KeyID()
+	signed, _ := hmac.SignedString([]byte("secret"))
+	if _, err := issuer.Parse(signed); err == nil {
+		t.Fatal("expected HS256 token to be rejected")
+	}
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	wrongIssuer := newTestTokenIssuer(t, services.TokenIssuerConfig{Issuer: "someone-else", SigningKey: key})
+	signed, _ = wrongIssuer.Sign(accessClaims())
+	if _, err := newTestTokenIssuer(t, services.TokenIssuerConfig{SigningKey: key}).Parse(signed); !errors.Is(err, jwt.ErrTokenInvalidIssuer) {
+		t.Fatal("expected unknown issuer to be rejected")
+	}
+}
+
+func TestTokenIssuerKeyRotation(t *testing.T) {
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	before := newTestTokenIssuer(t, services.TokenIssuerConfig{SigningKey: oldKey})
+	oldToken, err := before.Sign(accessClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	// After rotation the old key is kept for verification only
+	after := newTestTokenIssuer(t, services.TokenIssuerConfig{
+		SigningKey:       newKey,
+		VerificationKeys: []crypto.PublicKey{oldKey.Public()},
+	})
+	if after.KeyID() == before.KeyID() {
+		t.Fatal("rotated issuer must sign with the new key")
+	}
+	if _, err := after.Parse(oldToken); err != nil {
+		t.Fatalf("token signed with the previous key must still verify: %v", err)
+	}
+
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err := json.Unmarshal(after.JWKS(), &set); err != nil {
+		t.Fatalf("decode JWKS: %v", err)
+	}
+	if len(set.Keys) != 2 || set.Keys[0]["kid"] != after.KeyID() || set.Keys[1]["kid"] != before.KeyID() {
+		t.Fatalf("expected current and previous keys in JWKS, got %v", set.Keys)
+	}
+	if _, leaked := set.Keys[0]["d"]; leaked {
+		t.Fatal("JWKS must not contain private key material")
+	}
+}
+
+func TestTokenIssuerJWKSVerifiableByOtherServices(t *testing.T) {
+	issuer := newTestTokenIssuer(t, services.TokenIssuerConfig{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(issuer.JWKS())
+	}))
+	t.Cleanup(server.Close)
+
+	verifier := services.NewJWKSVerifier(services.JWKSVerifierConfig{
+		JWKSURL:   server.URL,
+		Issuers:   []string{"mentori-api"},
+		Audiences: []string{"mentori-messaging"},
+	})
+	signed, err := issuer.Sign(accessClaims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), signed, ""); err != nil {
+		t.Fatalf("Verify with published JWKS: %v", err)
+	}
+}
+
+func TestLoadTokenKeysFromPEM(t *testing.T) {
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwt-signing.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+
+	signer, err := services.LoadSigningKey(path)
+	if err != nil {
+		t.Fatalf("LoadSigningKey: %v", err)
+	}
+	public, err := services.LoadVerificationKey(path)
+	if err != nil {
+		t.Fatalf("LoadVerificationKey: %v", err)
+	}
+	if !key.Public().(ed25519.PublicKey).Equal(public) || !key.Equal(signer) {
+		t.Fatal("loaded keys do not match the generated key")
+	}
+}
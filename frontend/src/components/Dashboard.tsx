@@ -121,9 +121,9 @@ export default function Dashboard() {
       const results = await apiClient.searchProfiles(searchQuery);
       
       // Check if we have data before filtering
-      if (results.data && Array.isArray(results.data)) {
+      if (results.data && Array.isArray(results.data.items)) {
         // Filter out current user and calculate match scores
-        const mentors = results.data
+        const mentors = results.data.items
           .filter((m: any) => m.id !== userProfile.id)
           .map((mentor: any) => ({
             ...mentor,
@@ -161,9 +161,9 @@ class ApiClient {
     }
     
     if (filters.limit) queryParams.append('limit', filters.limit.toString());
-    if (filters.offset) queryParams.append('offset', filters.offset.toString());
+    if (filters.cursor) queryParams.append('cursor', filters.cursor);
 
-    return this.request<any[]>(`/profiles/public?${queryParams.toString()}`, {
+    return this.request<{ items: any[]; next_cursor?: string; estimated_total?: number }>(`/profiles/public?${queryParams.toString()}`, {
       method: 'GET',
       headers: {
         'Authorization': `Bearer ${typeof window !== 'undefined' ? localStorage.getItem('mentori_auth') : ''}`,